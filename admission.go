@@ -0,0 +1,181 @@
+package quic
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/logging"
+)
+
+const (
+	defaultAdmissionIPv4PrefixLen = 32
+	defaultAdmissionIPv6PrefixLen = 64
+	// maxAdmissionBuckets is the maximum number of source prefixes that are tracked for rate limiting.
+	maxAdmissionBuckets = 1 << 16
+)
+
+// AdmissionControl configures how the server handles connection attempts,
+// i.e. Initial packets that would create a new connection.
+// It protects the server from floods of Initial packets.
+type AdmissionControl struct {
+	// MaxConcurrentHandshakes is the maximum number of handshakes that may be in progress at the same time.
+	// Connection attempts beyond this limit are refused with a CONNECTION_REFUSED error.
+	// If zero, the number of handshakes is not limited.
+	MaxConcurrentHandshakes int
+	// RetryThreshold is the number of handshakes in progress from which on clients are required
+	// to validate their address using a Retry, unless they present a valid token.
+	// If zero, Retries are only sent as determined by Config.AcceptToken.
+	RetryThreshold int
+	// SourceRate is the number of connection attempts per second that are allowed from a source address prefix.
+	// Connection attempts exceeding the rate are dropped without sending a response.
+	// If zero, connection attempts are not rate limited.
+	SourceRate float64
+	// SourceBurst is the number of connection attempts that a source address prefix may make at once.
+	// If zero, it defaults to SourceRate, but at least 1.
+	SourceBurst int
+	// IPv4PrefixLen is the length of the prefix used to group IPv4 source addresses for rate limiting.
+	// If zero, it defaults to 32, i.e. every address is rate limited separately.
+	IPv4PrefixLen int
+	// IPv6PrefixLen is the length of the prefix used to group IPv6 source addresses for rate limiting.
+	// If zero, it defaults to 64.
+	IPv6PrefixLen int
+	// DrainWithRetry makes a draining server answer connection attempts with a Retry, instead of refusing them.
+	// This allows the client to connect to a different server, if the connection ID chosen by the ConnectionIDGenerator
+	// is routed to a server that is not draining, and if this server accepts the Retry token, i.e. uses the same TokenKeyRing.
+	// Connection attempts that already carry a Retry token are still refused.
+	DrainWithRetry bool
+}
+
+type tokenBucket struct {
+	tokens     float64
+	lastUpdate time.Time
+}
+
+// sourceRateLimiter limits the rate of connection attempts per source address prefix, using token buckets.
+type sourceRateLimiter struct {
+	rate       float64
+	burst      float64
+	ipv4Mask   net.IPMask
+	ipv6Mask   net.IPMask
+	maxBuckets int
+	mutex      sync.Mutex
+	buckets    map[string]*tokenBucket
+}
+
+func newSourceRateLimiter(conf *AdmissionControl) *sourceRateLimiter {
+	burst := float64(conf.SourceBurst)
+	if burst == 0 {
+		burst = conf.SourceRate
+		if burst < 1 {
+			burst = 1
+		}
+	}
+	ipv4PrefixLen := conf.IPv4PrefixLen
+	if ipv4PrefixLen == 0 {
+		ipv4PrefixLen = defaultAdmissionIPv4PrefixLen
+	}
+	ipv6PrefixLen := conf.IPv6PrefixLen
+	if ipv6PrefixLen == 0 {
+		ipv6PrefixLen = defaultAdmissionIPv6PrefixLen
+	}
+	return newSourceRateLimiterWithPrefixes(conf.SourceRate, burst, ipv4PrefixLen, ipv6PrefixLen)
+}
+
+func newSourceRateLimiterWithPrefixes(rate, burst float64, ipv4PrefixLen, ipv6PrefixLen int) *sourceRateLimiter {
+	return &sourceRateLimiter{
+		rate:       rate,
+		burst:      burst,
+		ipv4Mask:   net.CIDRMask(ipv4PrefixLen, 32),
+		ipv6Mask:   net.CIDRMask(ipv6PrefixLen, 128),
+		maxBuckets: maxAdmissionBuckets,
+		buckets:    make(map[string]*tokenBucket),
+	}
+}
+
+// prefix returns the source address prefix that addr belongs to.
+func (l *sourceRateLimiter) prefix(addr net.Addr) string {
+	udpAddr, ok := addr.(*net.UDPAddr)
+	if !ok {
+		return addr.String()
+	}
+	if ip := udpAddr.IP.To4(); ip != nil {
+		return string(ip.Mask(l.ipv4Mask))
+	}
+	return string(udpAddr.IP.Mask(l.ipv6Mask))
+}
+
+// Allow takes a token from the bucket of the source address prefix.
+// It returns false if the bucket is empty.
+func (l *sourceRateLimiter) Allow(addr net.Addr, now time.Time) bool {
+	prefix := l.prefix(addr)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	b, ok := l.buckets[prefix]
+	if !ok {
+		if len(l.buckets) >= l.maxBuckets {
+			l.removeFullBuckets(now)
+			// If we're still tracking too many prefixes, we're probably under attack.
+			// Err on the side of caution, and don't accept connection attempts from new prefixes.
+			if len(l.buckets) >= l.maxBuckets {
+				return false
+			}
+		}
+		b = &tokenBucket{tokens: l.burst, lastUpdate: now}
+		l.buckets[prefix] = b
+	}
+	return b.take(now, l.rate, l.burst)
+}
+
+// refill adds the tokens accumulated since the last update, up to the burst size.
+func (b *tokenBucket) refill(now time.Time, rate, burst float64) {
+	if elapsed := now.Sub(b.lastUpdate); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * rate
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+	}
+	b.lastUpdate = now
+}
+
+// take takes a token from the bucket.
+// It returns false if the bucket is empty.
+func (b *tokenBucket) take(now time.Time, rate, burst float64) bool {
+	b.refill(now, rate, burst)
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// removeFullBuckets removes the buckets that have been refilled completely.
+// These behave exactly like new buckets.
+func (l *sourceRateLimiter) removeFullBuckets(now time.Time) {
+	for prefix, b := range l.buckets {
+		b.refill(now, l.rate, l.burst)
+		if b.tokens >= l.burst {
+			delete(l.buckets, prefix)
+		}
+	}
+}
+
+// traceConnectionAttempt reports how a connection attempt was handled to the tracer.
+func (s *baseServer) traceConnectionAttempt(remoteAddr net.Addr, decision logging.AdmissionDecision) {
+	if t, ok := s.config.Tracer.(logging.AdmissionTracer); ok {
+		t.HandledConnectionAttempt(remoteAddr, decision)
+	}
+}
+
+// trackHandshake keeps track of the number of handshakes in progress.
+// It must be called for every session that was counted in handshakesInProgress.
+func (s *baseServer) trackHandshake(sess quicSession) {
+	select {
+	case <-sess.HandshakeComplete().Done():
+	case <-sess.Context().Done():
+	}
+	atomic.AddInt32(&s.handshakesInProgress, -1)
+}
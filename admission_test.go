@@ -0,0 +1,104 @@
+package quic
+
+import (
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Source Rate Limiter", func() {
+	var now time.Time
+
+	BeforeEach(func() {
+		now = time.Now()
+	})
+
+	udpAddr := func(ip string) net.Addr {
+		return &net.UDPAddr{IP: net.ParseIP(ip), Port: 1337}
+	}
+
+	It("allows a burst of connection attempts", func() {
+		l := newSourceRateLimiter(&AdmissionControl{SourceRate: 1, SourceBurst: 3})
+		addr := udpAddr("192.168.0.1")
+		for i := 0; i < 3; i++ {
+			Expect(l.Allow(addr, now)).To(BeTrue())
+		}
+		Expect(l.Allow(addr, now)).To(BeFalse())
+	})
+
+	It("defaults the burst to the rate", func() {
+		l := newSourceRateLimiter(&AdmissionControl{SourceRate: 2})
+		addr := udpAddr("192.168.0.1")
+		Expect(l.Allow(addr, now)).To(BeTrue())
+		Expect(l.Allow(addr, now)).To(BeTrue())
+		Expect(l.Allow(addr, now)).To(BeFalse())
+	})
+
+	It("uses a burst of at least 1", func() {
+		l := newSourceRateLimiter(&AdmissionControl{SourceRate: 0.5})
+		addr := udpAddr("192.168.0.1")
+		Expect(l.Allow(addr, now)).To(BeTrue())
+		Expect(l.Allow(addr, now)).To(BeFalse())
+	})
+
+	It("refills the bucket", func() {
+		l := newSourceRateLimiter(&AdmissionControl{SourceRate: 10})
+		addr := udpAddr("192.168.0.1")
+		for i := 0; i < 10; i++ {
+			Expect(l.Allow(addr, now)).To(BeTrue())
+		}
+		Expect(l.Allow(addr, now)).To(BeFalse())
+		Expect(l.Allow(addr, now.Add(50*time.Millisecond))).To(BeFalse())
+		Expect(l.Allow(addr, now.Add(100*time.Millisecond))).To(BeTrue())
+		Expect(l.Allow(addr, now.Add(100*time.Millisecond))).To(BeFalse())
+		// the bucket is never filled beyond the burst size
+		later := now.Add(time.Hour)
+		for i := 0; i < 10; i++ {
+			Expect(l.Allow(addr, later)).To(BeTrue())
+		}
+		Expect(l.Allow(addr, later)).To(BeFalse())
+	})
+
+	It("rate limits IPv4 addresses separately, by default", func() {
+		l := newSourceRateLimiter(&AdmissionControl{SourceRate: 1})
+		Expect(l.Allow(udpAddr("192.168.0.1"), now)).To(BeTrue())
+		Expect(l.Allow(udpAddr("192.168.0.1"), now)).To(BeFalse())
+		Expect(l.Allow(udpAddr("192.168.0.2"), now)).To(BeTrue())
+	})
+
+	It("groups IPv4 addresses by prefix", func() {
+		l := newSourceRateLimiter(&AdmissionControl{SourceRate: 1, IPv4PrefixLen: 24})
+		Expect(l.Allow(udpAddr("192.168.0.1"), now)).To(BeTrue())
+		Expect(l.Allow(udpAddr("192.168.0.2"), now)).To(BeFalse())
+		Expect(l.Allow(udpAddr("192.168.1.1"), now)).To(BeTrue())
+	})
+
+	It("groups IPv6 addresses by /64, by default", func() {
+		l := newSourceRateLimiter(&AdmissionControl{SourceRate: 1})
+		Expect(l.Allow(udpAddr("2001:db8::1"), now)).To(BeTrue())
+		Expect(l.Allow(udpAddr("2001:db8::2"), now)).To(BeFalse())
+		Expect(l.Allow(udpAddr("2001:db8:0:1::1"), now)).To(BeTrue())
+	})
+
+	It("groups IPv6 addresses by prefix", func() {
+		l := newSourceRateLimiter(&AdmissionControl{SourceRate: 1, IPv6PrefixLen: 48})
+		Expect(l.Allow(udpAddr("2001:db8::1"), now)).To(BeTrue())
+		Expect(l.Allow(udpAddr("2001:db8:0:1::1"), now)).To(BeFalse())
+		Expect(l.Allow(udpAddr("2001:db8:1::1"), now)).To(BeTrue())
+	})
+
+	It("removes full buckets when tracking too many prefixes", func() {
+		l := newSourceRateLimiter(&AdmissionControl{SourceRate: 1})
+		l.maxBuckets = 2
+		Expect(l.Allow(udpAddr("192.168.0.1"), now)).To(BeTrue())
+		Expect(l.Allow(udpAddr("192.168.0.2"), now)).To(BeTrue())
+		// all buckets are in use
+		Expect(l.Allow(udpAddr("192.168.0.3"), now)).To(BeFalse())
+		Expect(l.buckets).To(HaveLen(2))
+		// once the buckets are refilled, they can be removed
+		Expect(l.Allow(udpAddr("192.168.0.3"), now.Add(time.Second))).To(BeTrue())
+		Expect(l.buckets).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,125 @@
+package quic
+
+import (
+	"net"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+
+	"golang.org/x/net/ipv4"
+)
+
+// The maximum number of segments that can be sent in a single message using UDP GSO.
+// This is UDP_MAX_SEGMENTS in the Linux kernel.
+const maxGSOSegments = 64
+
+// The maximum size of a message sent using UDP GSO.
+// All segments (including their UDP and IP headers) have to fit into a single IP packet.
+const maxGSOMessageSize = 65535 - 8 - 40
+
+// batchPacketConn is implemented by both ipv4.PacketConn and ipv6.PacketConn.
+// On Linux, ReadBatch and WriteBatch use the recvmmsg and sendmmsg syscalls.
+type batchPacketConn interface {
+	ReadBatch(ms []ipv4.Message, flags int) (int, error)
+	WriteBatch(ms []ipv4.Message, flags int) (int, error)
+}
+
+// A batchConn reads and writes multiple packets using a single syscall.
+// If the kernel supports UDP GSO, packets of the same size are coalesced into a single message,
+// which is then split into individual datagrams by the kernel (or the NIC).
+// A batchConn must not be used from multiple goroutines concurrently.
+type batchConn struct {
+	conn batchPacketConn
+	gso  bool
+
+	msgs []ipv4.Message
+}
+
+func (c *batchConn) ReadBatch(ms []ipv4.Message) (int, error) {
+	return c.conn.ReadBatch(ms, 0)
+}
+
+// WriteTo sends all packets to addr.
+// If a message is rejected because it is too large, WriteTo continues with the next message,
+// and returns the message size error after all other packets were sent.
+func (c *batchConn) WriteTo(packets [][]byte, addr net.Addr) error {
+	msgs := c.msgs[:0]
+	if c.gso {
+		msgs = c.appendGSOMessages(msgs, packets, addr)
+	} else {
+		for _, p := range packets {
+			msgs = append(msgs, ipv4.Message{Buffers: [][]byte{p}, Addr: addr})
+		}
+	}
+	c.msgs = msgs
+
+	var msgSizeErr error
+	for len(msgs) > 0 {
+		n, err := c.conn.WriteBatch(msgs, 0)
+		if err == nil {
+			msgs = msgs[n:]
+			continue
+		}
+		if c.gso && isGSOErr(err) {
+			// The NIC doesn't support checksum offloading, which is required for GSO.
+			// Disable GSO, and send the remaining packets one by one.
+			c.gso = false
+			var remaining [][]byte
+			for _, m := range msgs {
+				remaining = append(remaining, m.Buffers...)
+			}
+			if err := c.WriteTo(remaining, addr); err != nil {
+				return err
+			}
+			return msgSizeErr
+		}
+		if !isMsgSizeErr(err) {
+			return err
+		}
+		// The first message that wasn't sent was too large. Skip it.
+		msgSizeErr = err
+		msgs = msgs[n+1:]
+	}
+	return msgSizeErr
+}
+
+// appendGSOMessages coalesces packets into as few messages as possible.
+// All packets in a message have the same size, except for the last one, which may be smaller.
+func (c *batchConn) appendGSOMessages(msgs []ipv4.Message, packets [][]byte, addr net.Addr) []ipv4.Message {
+	for len(packets) > 0 {
+		segmentSize := len(packets[0])
+		size := segmentSize
+		n := 1
+		for n < len(packets) && n < maxGSOSegments && size+len(packets[n]) <= maxGSOMessageSize {
+			l := len(packets[n])
+			if l > segmentSize {
+				break
+			}
+			size += l
+			n++
+			if l < segmentSize {
+				break
+			}
+		}
+		msg := ipv4.Message{Buffers: packets[:n:n], Addr: addr}
+		if n > 1 {
+			msg.OOB = appendUDPSegmentSizeMsg(nil, uint16(segmentSize))
+		}
+		msgs = append(msgs, msg)
+		packets = packets[n:]
+	}
+	return msgs
+}
+
+// newBatchConn creates a new batchConn.
+// It returns nil if batched I/O is not supported for this connection.
+func newBatchConn(c net.PacketConn) *batchConn {
+	conn, gso, ok := newBatchPacketConn(c)
+	if !ok {
+		return nil
+	}
+	return &batchConn{
+		conn: conn,
+		gso:  gso,
+		msgs: make([]ipv4.Message, 0, protocol.MaxBatchSize),
+	}
+}
@@ -0,0 +1,96 @@
+package quic
+
+import (
+	"bytes"
+	"net"
+
+	"golang.org/x/net/ipv4"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type mockBatchPacketConn struct {
+	written [][]ipv4.Message
+}
+
+var _ batchPacketConn = &mockBatchPacketConn{}
+
+func (c *mockBatchPacketConn) ReadBatch([]ipv4.Message, int) (int, error) { panic("not implemented") }
+
+func (c *mockBatchPacketConn) WriteBatch(ms []ipv4.Message, _ int) (int, error) {
+	msgs := make([]ipv4.Message, len(ms))
+	copy(msgs, ms)
+	c.written = append(c.written, msgs)
+	return len(ms), nil
+}
+
+var _ = Describe("Batch Connection", func() {
+	var (
+		conn       *batchConn
+		packetConn *mockBatchPacketConn
+		addr       = &net.UDPAddr{IP: net.IPv4(192, 168, 100, 200), Port: 1337}
+	)
+
+	BeforeEach(func() {
+		packetConn = &mockBatchPacketConn{}
+		conn = &batchConn{conn: packetConn}
+	})
+
+	packet := func(l int) []byte { return bytes.Repeat([]byte{'f'}, l) }
+
+	It("sends every packet in its own message, if GSO is not available", func() {
+		Expect(conn.WriteTo([][]byte{packet(100), packet(100), packet(50)}, addr)).To(Succeed())
+		Expect(packetConn.written).To(HaveLen(1))
+		msgs := packetConn.written[0]
+		Expect(msgs).To(HaveLen(3))
+		for _, m := range msgs {
+			Expect(m.Buffers).To(HaveLen(1))
+			Expect(m.OOB).To(BeEmpty())
+			Expect(m.Addr).To(Equal(addr))
+		}
+	})
+
+	Context("using GSO", func() {
+		BeforeEach(func() {
+			conn.gso = true
+		})
+
+		It("coalesces packets of the same size", func() {
+			Expect(conn.WriteTo([][]byte{packet(100), packet(100), packet(100)}, addr)).To(Succeed())
+			Expect(packetConn.written).To(HaveLen(1))
+			msgs := packetConn.written[0]
+			Expect(msgs).To(HaveLen(1))
+			Expect(msgs[0].Buffers).To(HaveLen(3))
+		})
+
+		It("ends a message with a smaller packet", func() {
+			Expect(conn.WriteTo([][]byte{packet(100), packet(100), packet(50), packet(100)}, addr)).To(Succeed())
+			msgs := packetConn.written[0]
+			Expect(msgs).To(HaveLen(2))
+			Expect(msgs[0].Buffers).To(HaveLen(3))
+			Expect(msgs[1].Buffers).To(HaveLen(1))
+			Expect(msgs[1].OOB).To(BeEmpty())
+		})
+
+		It("doesn't coalesce a larger packet", func() {
+			Expect(conn.WriteTo([][]byte{packet(100), packet(200), packet(200)}, addr)).To(Succeed())
+			msgs := packetConn.written[0]
+			Expect(msgs).To(HaveLen(2))
+			Expect(msgs[0].Buffers).To(HaveLen(1))
+			Expect(msgs[1].Buffers).To(HaveLen(2))
+		})
+
+		It("limits the size of a message", func() {
+			packets := make([][]byte, 10)
+			for i := range packets {
+				packets[i] = packet(9000)
+			}
+			Expect(conn.WriteTo(packets, addr)).To(Succeed())
+			msgs := packetConn.written[0]
+			Expect(msgs).To(HaveLen(2))
+			Expect(msgs[0].Buffers).To(HaveLen(7))
+			Expect(msgs[1].Buffers).To(HaveLen(3))
+		})
+	})
+})
@@ -13,6 +13,10 @@ type packetBuffer struct {
 	// It doesn't support concurrent use.
 	// It is > 1 when used for coalesced packet.
 	refCount int
+
+	// isMTUProbePacket is set for Path MTU Discovery probe packets.
+	// These packets are never coalesced with other packets when sending.
+	isMTUProbePacket bool
 }
 
 // Split increases the refCount.
@@ -56,7 +60,7 @@ func (b *packetBuffer) Len() protocol.ByteCount {
 }
 
 func (b *packetBuffer) putBack() {
-	if cap(b.Data) != int(protocol.MaxReceivePacketSize) {
+	if cap(b.Data) != int(protocol.MaxPacketBufferSize) {
 		panic("putPacketBuffer called with packet of wrong size!")
 	}
 	bufferPool.Put(b)
@@ -67,6 +71,7 @@ var bufferPool sync.Pool
 func getPacketBuffer() *packetBuffer {
 	buf := bufferPool.Get().(*packetBuffer)
 	buf.refCount = 1
+	buf.isMTUProbePacket = false
 	buf.Data = buf.Data[:0]
 	return buf
 }
@@ -74,7 +79,7 @@ func getPacketBuffer() *packetBuffer {
 func init() {
 	bufferPool.New = func() interface{} {
 		return &packetBuffer{
-			Data: make([]byte, 0, protocol.MaxReceivePacketSize),
+			Data: make([]byte, 0, protocol.MaxPacketBufferSize),
 		}
 	}
 }
@@ -221,7 +221,13 @@ func newClient(
 		}
 	}
 
-	srcConnID, err := generateConnectionID(config.ConnectionIDLength)
+	var srcConnID protocol.ConnectionID
+	var err error
+	if config.ConnectionIDGenerator != nil {
+		srcConnID, err = config.ConnectionIDGenerator.GenerateConnectionID()
+	} else {
+		srcConnID, err = generateConnectionID(config.ConnectionIDLength)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,142 @@
+package quic
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/lucas-clemente/quic-go/internal/handshake"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const (
+	typeClientHello uint8 = 1
+
+	extensionServerName uint16 = 0
+	extensionALPN       uint16 = 16
+)
+
+var errInvalidClientHello = errors.New("invalid ClientHello")
+
+// clientHelloInfo contains the values of a ClientHello that are used for routing sessions.
+type clientHelloInfo struct {
+	ServerName string
+	NextProtos []string
+}
+
+// peekClientHello decrypts the first Initial packet of a connection, and parses the ClientHello it contains.
+// It fails if the ClientHello is not completely contained in this packet.
+// The packet is not modified, so that it can be passed to the session afterwards.
+func peekClientHello(hdr *wire.Header, data []byte) (*clientHelloInfo, error) {
+	packetLen := hdr.ParsedLen() + hdr.Length
+	if protocol.ByteCount(len(data)) < packetLen {
+		return nil, fmt.Errorf("packet too small. Expected %d bytes, got %d", packetLen, len(data))
+	}
+	data = append([]byte(nil), data[:packetLen]...)
+
+	_, opener := handshake.NewInitialAEAD(hdr.DestConnectionID, protocol.PerspectiveServer, hdr.Version)
+	extHdr, err := unpackHeader(opener, hdr, data, hdr.Version)
+	if err != nil {
+		return nil, err
+	}
+	pn := protocol.DecodePacketNumber(extHdr.PacketNumberLen, 0, extHdr.PacketNumber)
+	extHdrLen := extHdr.ParsedLen()
+	payload, err := opener.Open(data[extHdrLen:extHdrLen], data[extHdrLen:], pn, data[:extHdrLen])
+	if err != nil {
+		return nil, err
+	}
+
+	// Clients might send the CRYPTO frames out of order.
+	var frames []*wire.CryptoFrame
+	parser := wire.NewFrameParser(hdr.Version)
+	r := bytes.NewReader(payload)
+	for {
+		frame, err := parser.ParseNext(r, protocol.EncryptionInitial)
+		if err != nil {
+			return nil, err
+		}
+		if frame == nil {
+			break
+		}
+		if f, ok := frame.(*wire.CryptoFrame); ok {
+			frames = append(frames, f)
+		}
+	}
+	sort.Slice(frames, func(i, j int) bool { return frames[i].Offset < frames[j].Offset })
+	var cryptoData []byte
+	for _, f := range frames {
+		offset := protocol.ByteCount(len(cryptoData))
+		if f.Offset > offset {
+			break
+		}
+		if f.Offset+protocol.ByteCount(len(f.Data)) > offset {
+			cryptoData = append(cryptoData, f.Data[offset-f.Offset:]...)
+		}
+	}
+	return parseClientHello(cryptoData)
+}
+
+// parseClientHello parses the server name (SNI) and the application protocols (ALPN) from a ClientHello message.
+func parseClientHello(data []byte) (*clientHelloInfo, error) {
+	s := cryptobyte.String(data)
+	var msgType uint8
+	var msg cryptobyte.String
+	if !s.ReadUint8(&msgType) || msgType != typeClientHello {
+		return nil, errors.New("not a ClientHello")
+	}
+	if !s.ReadUint24LengthPrefixed(&msg) {
+		return nil, errors.New("incomplete ClientHello")
+	}
+
+	var sessionID, cipherSuites, compressionMethods, extensions cryptobyte.String
+	if !msg.Skip(2+32) || // legacy_version and random
+		!msg.ReadUint8LengthPrefixed(&sessionID) ||
+		!msg.ReadUint16LengthPrefixed(&cipherSuites) ||
+		!msg.ReadUint8LengthPrefixed(&compressionMethods) ||
+		!msg.ReadUint16LengthPrefixed(&extensions) {
+		return nil, errInvalidClientHello
+	}
+
+	info := &clientHelloInfo{}
+	for !extensions.Empty() {
+		var extType uint16
+		var ext cryptobyte.String
+		if !extensions.ReadUint16(&extType) || !extensions.ReadUint16LengthPrefixed(&ext) {
+			return nil, errInvalidClientHello
+		}
+		switch extType {
+		case extensionServerName:
+			var names cryptobyte.String
+			if !ext.ReadUint16LengthPrefixed(&names) {
+				return nil, errInvalidClientHello
+			}
+			for !names.Empty() {
+				var nameType uint8
+				var name cryptobyte.String
+				if !names.ReadUint8(&nameType) || !names.ReadUint16LengthPrefixed(&name) {
+					return nil, errInvalidClientHello
+				}
+				if nameType == 0 { // host_name
+					info.ServerName = string(name)
+				}
+			}
+		case extensionALPN:
+			var protos cryptobyte.String
+			if !ext.ReadUint16LengthPrefixed(&protos) {
+				return nil, errInvalidClientHello
+			}
+			for !protos.Empty() {
+				var proto cryptobyte.String
+				if !protos.ReadUint8LengthPrefixed(&proto) || proto.Empty() {
+					return nil, errInvalidClientHello
+				}
+				info.NextProtos = append(info.NextProtos, string(proto))
+			}
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,165 @@
+package quic
+
+import (
+	"bytes"
+
+	"github.com/lucas-clemente/quic-go/internal/handshake"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+
+	"golang.org/x/crypto/cryptobyte"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientHello parsing", func() {
+	getClientHello := func(serverName string, nextProtos []string) []byte {
+		b := cryptobyte.NewBuilder(nil)
+		b.AddUint8(typeClientHello)
+		b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddUint16(0x0303)          // legacy_version
+			b.AddBytes(make([]byte, 32)) // random
+			b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {})
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddUint16(0x1301) })
+			b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) { b.AddUint8(0) })
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+				// an extension that is ignored
+				b.AddUint16(0x2b) // supported_versions
+				b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+					b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) { b.AddUint16(0x0304) })
+				})
+				if len(serverName) > 0 {
+					b.AddUint16(extensionServerName)
+					b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+						b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+							b.AddUint8(0) // host_name
+							b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes([]byte(serverName)) })
+						})
+					})
+				}
+				if len(nextProtos) > 0 {
+					b.AddUint16(extensionALPN)
+					b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+						b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+							for _, proto := range nextProtos {
+								proto := proto
+								b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes([]byte(proto)) })
+							}
+						})
+					})
+				}
+			})
+		})
+		return b.BytesOrPanic()
+	}
+
+	It("parses the server name and the application protocols", func() {
+		chi, err := parseClientHello(getClientHello("quic.clemente.io", []string{"h3-29", "hq-29"}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(chi.ServerName).To(Equal("quic.clemente.io"))
+		Expect(chi.NextProtos).To(Equal([]string{"h3-29", "hq-29"}))
+	})
+
+	It("parses a ClientHello without server name and application protocols", func() {
+		chi, err := parseClientHello(getClientHello("", nil))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(chi.ServerName).To(BeEmpty())
+		Expect(chi.NextProtos).To(BeEmpty())
+	})
+
+	It("errors on other handshake messages", func() {
+		data := getClientHello("quic.clemente.io", nil)
+		data[0] = 2 // ServerHello
+		_, err := parseClientHello(data)
+		Expect(err).To(MatchError("not a ClientHello"))
+	})
+
+	It("errors on incomplete ClientHellos", func() {
+		data := getClientHello("quic.clemente.io", []string{"h3-29"})
+		_, err := parseClientHello(data[:len(data)-1])
+		Expect(err).To(MatchError("incomplete ClientHello"))
+	})
+
+	It("errors on invalid extensions", func() {
+		data := getClientHello("", []string{"h3-29"})
+		// set the length of the first application protocol to 0
+		data[len(data)-len("h3-29")-1] = 0
+		_, err := parseClientHello(data)
+		Expect(err).To(MatchError(errInvalidClientHello))
+	})
+
+	Context("peeking into Initial packets", func() {
+		destConnID := protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8}
+
+		getInitial := func(frames ...*wire.CryptoFrame) []byte {
+			payload := &bytes.Buffer{}
+			for _, f := range frames {
+				Expect(f.Write(payload, protocol.VersionTLS)).To(Succeed())
+			}
+			payload.Write(make([]byte, 100)) // PADDING
+			buf := &bytes.Buffer{}
+			Expect((&wire.ExtendedHeader{
+				Header: wire.Header{
+					IsLongHeader:     true,
+					Type:             protocol.PacketTypeInitial,
+					SrcConnectionID:  protocol.ConnectionID{5, 4, 3, 2, 1},
+					DestConnectionID: destConnID,
+					Length:           2 + protocol.ByteCount(payload.Len()) + 16,
+					Version:          protocol.VersionTLS,
+				},
+				PacketNumber:    1,
+				PacketNumberLen: protocol.PacketNumberLen2,
+			}).Write(buf, protocol.VersionTLS)).To(Succeed())
+			hdrLen := buf.Len()
+			sealer, _ := handshake.NewInitialAEAD(destConnID, protocol.PerspectiveClient, protocol.VersionTLS)
+			data := sealer.Seal(buf.Bytes(), payload.Bytes(), 1, buf.Bytes())
+			sealer.EncryptHeader(data[hdrLen+2:hdrLen+2+16], &data[0], data[hdrLen-2:hdrLen])
+			return data
+		}
+
+		peek := func(data []byte) (*clientHelloInfo, error) {
+			hdr, _, _, err := wire.ParsePacket(data, 0)
+			Expect(err).ToNot(HaveOccurred())
+			return peekClientHello(hdr, data)
+		}
+
+		It("parses the ClientHello", func() {
+			ch := getClientHello("quic.clemente.io", []string{"h3-29"})
+			data := getInitial(&wire.CryptoFrame{Data: ch})
+			orig := make([]byte, len(data))
+			copy(orig, data)
+			chi, err := peek(data)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(chi.ServerName).To(Equal("quic.clemente.io"))
+			Expect(chi.NextProtos).To(Equal([]string{"h3-29"}))
+			// the packet is not modified
+			Expect(data).To(Equal(orig))
+		})
+
+		It("parses the ClientHello from CRYPTO frames sent out of order", func() {
+			ch := getClientHello("quic.clemente.io", []string{"h3-29"})
+			data := getInitial(
+				&wire.CryptoFrame{Offset: 20, Data: ch[20:]},
+				&wire.CryptoFrame{Data: ch[:25]},
+			)
+			chi, err := peek(data)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(chi.ServerName).To(Equal("quic.clemente.io"))
+		})
+
+		It("errors if the ClientHello doesn't fit into the packet", func() {
+			ch := getClientHello("quic.clemente.io", []string{"h3-29"})
+			_, err := peek(getInitial(&wire.CryptoFrame{Data: ch[:30]}))
+			Expect(err).To(MatchError("incomplete ClientHello"))
+		})
+
+		It("errors if the packet can't be decrypted", func() {
+			ch := getClientHello("quic.clemente.io", []string{"h3-29"})
+			data := getInitial(&wire.CryptoFrame{Data: ch})
+			data[len(data)-1] ^= 0xff // corrupt the AEAD tag
+			_, err := peek(data)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
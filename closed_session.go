@@ -10,6 +10,8 @@ import (
 // A closedLocalSession is a session that we closed locally.
 // When receiving packets for such a session, we need to retransmit the packet containing the CONNECTION_CLOSE frame,
 // with an exponential backoff.
+// To prevent amplification attacks, the CONNECTION_CLOSE is only retransmitted
+// as long as we don't send more than 3 times the data we received.
 type closedLocalSession struct {
 	conn            sendConn
 	connClosePacket []byte
@@ -19,6 +21,8 @@ type closedLocalSession struct {
 
 	receivedPackets chan *receivedPacket
 	counter         uint64 // number of packets received
+	bytesReceived   protocol.ByteCount
+	bytesSent       protocol.ByteCount
 
 	perspective protocol.Perspective
 
@@ -64,8 +68,9 @@ func (s *closedLocalSession) handlePacket(p *receivedPacket) {
 	}
 }
 
-func (s *closedLocalSession) handlePacketImpl(_ *receivedPacket) {
+func (s *closedLocalSession) handlePacketImpl(p *receivedPacket) {
 	s.counter++
+	s.bytesReceived += p.Size()
 	// exponential backoff
 	// only send a CONNECTION_CLOSE for the 1st, 2nd, 4th, 8th, 16th, ... packet arriving
 	for n := s.counter; n > 1; n = n / 2 {
@@ -73,7 +78,13 @@ func (s *closedLocalSession) handlePacketImpl(_ *receivedPacket) {
 			return
 		}
 	}
+	size := protocol.ByteCount(len(s.connClosePacket))
+	if s.bytesSent+size > protocol.AmplificationFactor*s.bytesReceived {
+		s.logger.Debugf("Received %d packets after sending CONNECTION_CLOSE. Not retransmitting, since this would exceed the amplification limit.", s.counter)
+		return
+	}
 	s.logger.Debugf("Received %d packets after sending CONNECTION_CLOSE. Retransmitting.", s.counter)
+	s.bytesSent += size
 	if err := s.conn.Write(s.connClosePacket); err != nil {
 		s.logger.Debugf("Error retransmitting CONNECTION_CLOSE: %s", err)
 	}
@@ -37,7 +37,7 @@ var _ = Describe("Closed local session", func() {
 		written := make(chan []byte)
 		mconn.EXPECT().Write(gomock.Any()).Do(func(p []byte) { written <- p }).AnyTimes()
 		for i := 1; i <= 20; i++ {
-			sess.handlePacket(&receivedPacket{})
+			sess.handlePacket(&receivedPacket{data: []byte("foobar")})
 			if i == 1 || i == 2 || i == 4 || i == 8 || i == 16 {
 				Eventually(written).Should(Receive(Equal([]byte("close")))) // receive the CONNECTION_CLOSE
 			} else {
@@ -48,6 +48,18 @@ var _ = Describe("Closed local session", func() {
 		sess.shutdown()
 	})
 
+	It("doesn't send more than 3 times the data it received", func() {
+		written := make(chan []byte)
+		mconn.EXPECT().Write(gomock.Any()).Do(func(p []byte) { written <- p }).AnyTimes()
+		// 3 times 1 byte is less than the size of the CONNECTION_CLOSE packet
+		sess.handlePacket(&receivedPacket{data: []byte("f")})
+		Consistently(written, 10*time.Millisecond).Should(HaveLen(0))
+		sess.handlePacket(&receivedPacket{data: []byte("f")})
+		Eventually(written).Should(Receive(Equal([]byte("close"))))
+		// stop the session
+		sess.shutdown()
+	})
+
 	It("destroys sessions", func() {
 		Expect(areClosedSessionsRunning()).To(BeTrue())
 		sess.destroy(errors.New("destroy"))
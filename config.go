@@ -2,6 +2,7 @@ package quic
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 )
@@ -22,6 +23,15 @@ func validateConfig(config *Config) error {
 	if config.MaxIncomingUniStreams > 1<<60 {
 		return errors.New("invalid value for Config.MaxIncomingUniStreams")
 	}
+	if config.ConnectionIDGenerator != nil {
+		l := config.ConnectionIDGenerator.ConnectionIDLen()
+		if l < 4 || l > protocol.MaxConnIDLen {
+			return fmt.Errorf("invalid connection ID length for Config.ConnectionIDGenerator: %d", l)
+		}
+		if config.ConnectionIDLength != 0 && config.ConnectionIDLength != l {
+			return errors.New("Config.ConnectionIDLength doesn't match the length of Config.ConnectionIDGenerator")
+		}
+	}
 	return nil
 }
 
@@ -78,6 +88,10 @@ func populateConfig(config *Config) *Config {
 	} else if maxIncomingStreams < 0 {
 		maxIncomingStreams = 0
 	}
+	connIDLen := config.ConnectionIDLength
+	if config.ConnectionIDGenerator != nil {
+		connIDLen = config.ConnectionIDGenerator.ConnectionIDLen()
+	}
 	maxIncomingUniStreams := config.MaxIncomingUniStreams
 	if maxIncomingUniStreams == 0 {
 		maxIncomingUniStreams = protocol.DefaultMaxIncomingUniStreams
@@ -91,13 +105,21 @@ func populateConfig(config *Config) *Config {
 		MaxIdleTimeout:                        idleTimeout,
 		AcceptToken:                           config.AcceptToken,
 		KeepAlive:                             config.KeepAlive,
+		DisablePathMTUDiscovery:               config.DisablePathMTUDiscovery,
+		EnableDatagrams:                       config.EnableDatagrams,
 		MaxReceiveStreamFlowControlWindow:     maxReceiveStreamFlowControlWindow,
 		MaxReceiveConnectionFlowControlWindow: maxReceiveConnectionFlowControlWindow,
+		MemoryBudget:                          config.MemoryBudget,
 		MaxIncomingStreams:                    maxIncomingStreams,
 		MaxIncomingUniStreams:                 maxIncomingUniStreams,
-		ConnectionIDLength:                    config.ConnectionIDLength,
+		ConnectionIDLength:                    connIDLen,
+		ConnectionIDGenerator:                 config.ConnectionIDGenerator,
 		StatelessResetKey:                     config.StatelessResetKey,
 		TokenStore:                            config.TokenStore,
+		TokenKeyRing:                          config.TokenKeyRing,
+		AdmissionControl:                      config.AdmissionControl,
+		KeyUpdateInterval:                     config.KeyUpdateInterval,
+		KeyUpdatePeriod:                       config.KeyUpdatePeriod,
 		QuicTracer:                            config.QuicTracer,
 		Tracer:                                config.Tracer,
 		// rQUIC {
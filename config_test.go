@@ -31,6 +31,16 @@ var _ = Describe("Config", func() {
 		It("errors on too large values for MaxIncomingUniStreams", func() {
 			Expect(validateConfig(&Config{MaxIncomingUniStreams: 1<<60 + 1})).To(MatchError("invalid value for Config.MaxIncomingUniStreams"))
 		})
+
+		It("errors on invalid ConnectionIDGenerator lengths", func() {
+			Expect(validateConfig(&Config{ConnectionIDGenerator: &countingConnIDGenerator{length: 3}})).To(MatchError("invalid connection ID length for Config.ConnectionIDGenerator: 3"))
+			Expect(validateConfig(&Config{ConnectionIDGenerator: &countingConnIDGenerator{length: 21}})).To(MatchError("invalid connection ID length for Config.ConnectionIDGenerator: 21"))
+		})
+
+		It("errors if the ConnectionIDLength doesn't match the ConnectionIDGenerator", func() {
+			Expect(validateConfig(&Config{ConnectionIDGenerator: &countingConnIDGenerator{length: 8}, ConnectionIDLength: 8})).To(Succeed())
+			Expect(validateConfig(&Config{ConnectionIDGenerator: &countingConnIDGenerator{length: 8}, ConnectionIDLength: 6})).To(MatchError("Config.ConnectionIDLength doesn't match the length of Config.ConnectionIDGenerator"))
+		})
 	})
 
 	configWithNonZeroNonFunctionFields := func() *Config {
@@ -52,16 +62,30 @@ var _ = Describe("Config", func() {
 				f.Set(reflect.ValueOf([]VersionNumber{1, 2, 3}))
 			case "ConnectionIDLength":
 				f.Set(reflect.ValueOf(8))
+			case "ConnectionIDGenerator":
+				f.Set(reflect.ValueOf(&countingConnIDGenerator{length: 8}))
 			case "HandshakeTimeout":
 				f.Set(reflect.ValueOf(time.Second))
 			case "MaxIdleTimeout":
 				f.Set(reflect.ValueOf(time.Hour))
 			case "TokenStore":
 				f.Set(reflect.ValueOf(NewLRUTokenStore(2, 3)))
+			case "TokenKeyRing":
+				keys, err := NewTokenKeyRing(TokenKey{Secret: make([]byte, TokenKeySize)})
+				Expect(err).ToNot(HaveOccurred())
+				f.Set(reflect.ValueOf(keys))
+			case "AdmissionControl":
+				f.Set(reflect.ValueOf(&AdmissionControl{MaxConcurrentHandshakes: 10, SourceRate: 5}))
+			case "KeyUpdateInterval":
+				f.Set(reflect.ValueOf(uint64(13)))
+			case "KeyUpdatePeriod":
+				f.Set(reflect.ValueOf(time.Minute))
 			case "MaxReceiveStreamFlowControlWindow":
 				f.Set(reflect.ValueOf(uint64(9)))
 			case "MaxReceiveConnectionFlowControlWindow":
 				f.Set(reflect.ValueOf(uint64(10)))
+			case "MemoryBudget":
+				f.Set(reflect.ValueOf(NewMemoryBudget(1 << 20)))
 			case "MaxIncomingStreams":
 				f.Set(reflect.ValueOf(int64(11)))
 			case "MaxIncomingUniStreams":
@@ -70,6 +94,8 @@ var _ = Describe("Config", func() {
 				f.Set(reflect.ValueOf([]byte{1, 2, 3, 4}))
 			case "KeepAlive":
 				f.Set(reflect.ValueOf(true))
+			case "DisablePathMTUDiscovery", "EnableDatagrams":
+				f.Set(reflect.ValueOf(true))
 			case "QuicTracer":
 				f.Set(reflect.ValueOf(quictrace.NewTracer()))
 			case "Tracer":
@@ -142,6 +168,11 @@ var _ = Describe("Config", func() {
 			Expect(c.AcceptToken).ToNot(BeNil())
 		})
 
+		It("uses the length of the ConnectionIDGenerator", func() {
+			c := populateServerConfig(&Config{ConnectionIDGenerator: &countingConnIDGenerator{length: 12}})
+			Expect(c.ConnectionIDLength).To(Equal(12))
+		})
+
 		It("sets a default connection ID length if we didn't create the conn, for the client", func() {
 			c := populateClientConfig(&Config{}, false)
 			Expect(c.ConnectionIDLength).To(Equal(protocol.DefaultConnectionIDLength))
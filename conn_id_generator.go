@@ -11,6 +11,7 @@ import (
 
 type connIDGenerator struct {
 	connIDLen  int
+	generator  ConnectionIDGenerator // nil if random connection IDs are used
 	highestSeq uint64
 
 	activeSrcConnIDs        map[uint64]protocol.ConnectionID
@@ -27,6 +28,7 @@ type connIDGenerator struct {
 func newConnIDGenerator(
 	initialConnectionID protocol.ConnectionID,
 	initialClientDestConnID protocol.ConnectionID, // nil for the client
+	generator ConnectionIDGenerator, // nil if random connection IDs are used
 	addConnectionID func(protocol.ConnectionID),
 	getStatelessResetToken func(protocol.ConnectionID) protocol.StatelessResetToken,
 	removeConnectionID func(protocol.ConnectionID),
@@ -36,6 +38,7 @@ func newConnIDGenerator(
 ) *connIDGenerator {
 	m := &connIDGenerator{
 		connIDLen:              initialConnectionID.Len(),
+		generator:              generator,
 		activeSrcConnIDs:       make(map[uint64]protocol.ConnectionID),
 		addConnectionID:        addConnectionID,
 		getStatelessResetToken: getStatelessResetToken,
@@ -92,7 +95,7 @@ func (m *connIDGenerator) issueNewConnID() error {
 	if RetireBugBackwardsCompatibilityMode {
 		return nil
 	}
-	connID, err := protocol.GenerateConnectionID(m.connIDLen)
+	connID, err := m.generateConnID()
 	if err != nil {
 		return err
 	}
@@ -107,6 +110,20 @@ func (m *connIDGenerator) issueNewConnID() error {
 	return nil
 }
 
+func (m *connIDGenerator) generateConnID() (protocol.ConnectionID, error) {
+	if m.generator == nil {
+		return protocol.GenerateConnectionID(m.connIDLen)
+	}
+	connID, err := m.generator.GenerateConnectionID()
+	if err != nil {
+		return nil, err
+	}
+	if connID.Len() != m.connIDLen {
+		return nil, fmt.Errorf("ConnectionIDGenerator generated a connection ID of invalid length: %d (expected %d)", connID.Len(), m.connIDLen)
+	}
+	return connID, nil
+}
+
 func (m *connIDGenerator) SetHandshakeComplete() {
 	if m.initialClientDestConnID != nil {
 		m.retireConnectionID(m.initialClientDestConnID)
@@ -5,6 +5,7 @@ import (
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/wire"
+	"github.com/lucas-clemente/quic-go/quiclb"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -35,6 +36,7 @@ var _ = Describe("Connection ID Generator", func() {
 		g = newConnIDGenerator(
 			initialConnID,
 			initialClientDestConnID,
+			nil,
 			func(c protocol.ConnectionID) { addedConnIDs = append(addedConnIDs, c) },
 			connIDToToken,
 			func(c protocol.ConnectionID) { removedConnIDs = append(removedConnIDs, c) },
@@ -62,6 +64,25 @@ var _ = Describe("Connection ID Generator", func() {
 		}
 	})
 
+	Context("using a ConnectionIDGenerator", func() {
+		BeforeEach(func() {
+			g.generator = &countingConnIDGenerator{length: 7}
+		})
+
+		It("issues connection IDs generated by the ConnectionIDGenerator", func() {
+			Expect(g.SetMaxActiveConnIDs(3)).To(Succeed())
+			Expect(addedConnIDs).To(Equal([]protocol.ConnectionID{
+				{0, 0, 0, 0, 0, 0, 1},
+				{0, 0, 0, 0, 0, 0, 2},
+			}))
+		})
+
+		It("errors if the ConnectionIDGenerator generates connection IDs of the wrong length", func() {
+			g.generator = &countingConnIDGenerator{length: 5}
+			Expect(g.SetMaxActiveConnIDs(3)).To(MatchError("ConnectionIDGenerator generated a connection ID of invalid length: 5 (expected 7)"))
+		})
+	})
+
 	It("doesn't issue new connection IDs in RetireBugBackwardsCompatibilityMode", func() {
 		RetireBugBackwardsCompatibilityMode = true
 		defer func() { RetireBugBackwardsCompatibilityMode = false }()
@@ -167,3 +188,20 @@ var _ = Describe("Connection ID Generator", func() {
 		}
 	})
 })
+
+var _ ConnectionIDGenerator = &quiclb.Generator{}
+
+// countingConnIDGenerator generates connection IDs containing a counter.
+type countingConnIDGenerator struct {
+	length  int
+	counter uint8
+}
+
+func (g *countingConnIDGenerator) GenerateConnectionID() (ConnectionID, error) {
+	g.counter++
+	c := make(ConnectionID, g.length)
+	c[g.length-1] = g.counter
+	return c, nil
+}
+
+func (g *countingConnIDGenerator) ConnectionIDLen() int { return g.length }
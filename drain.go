@@ -0,0 +1,79 @@
+package quic
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/lucas-clemente/quic-go/internal/wire"
+	"github.com/lucas-clemente/quic-go/logging"
+)
+
+// Drain stops accepting new connections, and closes the server once all sessions are closed.
+func (s *baseServer) Drain(ctx context.Context) error {
+	s.mutex.Lock()
+	closed := s.closed
+	s.mutex.Unlock()
+	if closed {
+		return nil
+	}
+
+	if atomic.CompareAndSwapInt32(&s.draining, 0, 1) {
+		s.logger.Debugf("Draining. Not accepting any new connections.")
+	}
+	for {
+		remaining := int(atomic.LoadInt32(&s.numSessions))
+		if t, ok := s.config.Tracer.(logging.DrainingTracer); ok {
+			t.Draining(remaining)
+		}
+		if remaining == 0 {
+			break
+		}
+		s.logger.Debugf("Draining. Waiting for %d sessions to close.", remaining)
+		select {
+		case <-s.sessionClosed:
+		case <-s.errorChan:
+			return s.serverError
+		case <-ctx.Done():
+			s.logger.Debugf("Draining. Closing %d remaining sessions.", remaining)
+			if err := s.Close(); err != nil {
+				return err
+			}
+			return ctx.Err()
+		}
+	}
+	return s.Close()
+}
+
+// trackSession keeps track of the number of sessions, which is needed for draining.
+// It must be called for every session that was counted in numSessions.
+func (s *baseServer) trackSession(sessCtx context.Context) {
+	<-sessCtx.Done()
+	atomic.AddInt32(&s.numSessions, -1)
+	select {
+	case s.sessionClosed <- struct{}{}:
+	default:
+	}
+}
+
+// rejectWhileDraining handles a connection attempt received while the server is draining.
+func (s *baseServer) rejectWhileDraining(p *receivedPacket, hdr *wire.Header, token *Token) {
+	if conf := s.config.AdmissionControl; conf != nil && conf.DrainWithRetry && (token == nil || !token.IsRetryToken) {
+		s.logger.Debugf("Draining. Sending a Retry to %s.", p.remoteAddr)
+		s.traceConnectionAttempt(p.remoteAddr, logging.AdmissionRetry)
+		go func() {
+			defer p.buffer.Release()
+			if err := s.sendRetry(p.remoteAddr, hdr); err != nil {
+				s.logger.Debugf("Error sending Retry: %s", err)
+			}
+		}()
+		return
+	}
+	s.logger.Debugf("Draining. Rejecting new connection from %s.", p.remoteAddr)
+	s.traceConnectionAttempt(p.remoteAddr, logging.AdmissionRefusedDraining)
+	go func() {
+		defer p.buffer.Release()
+		if err := s.sendConnectionRefused(p.remoteAddr, hdr); err != nil {
+			s.logger.Debugf("Error rejecting connection: %s", err)
+		}
+	}()
+}
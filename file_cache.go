@@ -0,0 +1,323 @@
+package quic
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/qtls"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+// A fileCache persists a JSON-encoded value to a file.
+// The file is read before every access, and replaced atomically on every modification,
+// so that the cache can be shared by multiple processes.
+// When multiple processes modify the file concurrently, modifications might be lost,
+// but the file is never corrupted.
+type fileCache struct {
+	mutex sync.Mutex
+	path  string
+}
+
+func newFileCache(path string, v interface{}) (*fileCache, error) {
+	c := &fileCache{path: path}
+	// make sure that the directory exists and that the file can be parsed
+	if _, err := os.Stat(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	if err := c.load(v); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// load reads the file. A missing file is treated as an empty cache.
+func (c *fileCache) load(v interface{}) error {
+	data, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// store writes the file to a temporary file in the same directory, and then renames it.
+// Concurrent readers therefore never see a partially written file.
+func (c *fileCache) store(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(c.path), filepath.Base(c.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), c.path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+type fileTokenStoreToken struct {
+	Data   []byte
+	Expiry time.Time
+}
+
+type fileTokenStoreEntry struct {
+	Tokens   []fileTokenStoreToken // ordered from oldest to newest
+	LastUsed time.Time
+}
+
+type fileTokenStore struct {
+	cache *fileCache
+
+	maxOrigins      int
+	tokensPerOrigin int
+	maxAge          time.Duration
+	logger          utils.Logger
+}
+
+var _ TokenStore = &fileTokenStore{}
+
+// NewFileTokenStore creates a TokenStore that persists the tokens received by the client to a file.
+// This allows short-lived processes to skip address validation on future connection attempts.
+// maxOrigins specifies how many origins this cache is saving tokens for.
+// tokensPerOrigin specifies the maximum number of tokens per origin.
+// Tokens are discarded after maxAge. If maxAge is 0, tokens are kept for 24 hours.
+func NewFileTokenStore(path string, maxOrigins, tokensPerOrigin int, maxAge time.Duration) (TokenStore, error) {
+	cache, err := newFileCache(path, &map[string]*fileTokenStoreEntry{})
+	if err != nil {
+		return nil, err
+	}
+	if maxAge == 0 {
+		maxAge = protocol.TokenValidity
+	}
+	return &fileTokenStore{
+		cache:           cache,
+		maxOrigins:      maxOrigins,
+		tokensPerOrigin: tokensPerOrigin,
+		maxAge:          maxAge,
+		logger:          utils.DefaultLogger.WithPrefix("token store"),
+	}, nil
+}
+
+func (s *fileTokenStore) load(now time.Time) map[string]*fileTokenStoreEntry {
+	entries := make(map[string]*fileTokenStoreEntry)
+	if err := s.cache.load(&entries); err != nil {
+		s.logger.Errorf("Failed to load tokens from %s: %s", s.cache.path, err)
+		return make(map[string]*fileTokenStoreEntry)
+	}
+	for key, entry := range entries {
+		tokens := entry.Tokens[:0]
+		for _, t := range entry.Tokens {
+			if t.Expiry.After(now) {
+				tokens = append(tokens, t)
+			}
+		}
+		entry.Tokens = tokens
+		if len(entry.Tokens) == 0 {
+			delete(entries, key)
+		}
+	}
+	return entries
+}
+
+func (s *fileTokenStore) store(entries map[string]*fileTokenStoreEntry) {
+	if err := s.cache.store(entries); err != nil {
+		s.logger.Errorf("Failed to store tokens to %s: %s", s.cache.path, err)
+	}
+}
+
+func (s *fileTokenStore) Put(key string, token *ClientToken) {
+	s.cache.mutex.Lock()
+	defer s.cache.mutex.Unlock()
+
+	now := time.Now()
+	entries := s.load(now)
+	entry, ok := entries[key]
+	if !ok {
+		if len(entries) >= s.maxOrigins {
+			removeLeastRecentlyUsedToken(entries)
+		}
+		entry = &fileTokenStoreEntry{}
+		entries[key] = entry
+	}
+	entry.Tokens = append(entry.Tokens, fileTokenStoreToken{Data: token.data, Expiry: now.Add(s.maxAge)})
+	if len(entry.Tokens) > s.tokensPerOrigin {
+		entry.Tokens = entry.Tokens[len(entry.Tokens)-s.tokensPerOrigin:]
+	}
+	entry.LastUsed = now
+	s.store(entries)
+}
+
+func (s *fileTokenStore) Pop(key string) *ClientToken {
+	s.cache.mutex.Lock()
+	defer s.cache.mutex.Unlock()
+
+	entries := s.load(time.Now())
+	entry, ok := entries[key]
+	if !ok {
+		return nil
+	}
+	token := entry.Tokens[len(entry.Tokens)-1]
+	entry.Tokens = entry.Tokens[:len(entry.Tokens)-1]
+	if len(entry.Tokens) == 0 {
+		delete(entries, key)
+	}
+	s.store(entries)
+	return &ClientToken{data: token.Data}
+}
+
+func removeLeastRecentlyUsedToken(entries map[string]*fileTokenStoreEntry) {
+	var (
+		found     bool
+		oldestKey string
+		oldest    time.Time
+	)
+	for key, entry := range entries {
+		if !found || entry.LastUsed.Before(oldest) {
+			found = true
+			oldestKey = key
+			oldest = entry.LastUsed
+		}
+	}
+	delete(entries, oldestKey)
+}
+
+type fileClientSessionCacheEntry struct {
+	State    []byte
+	Expiry   time.Time
+	LastUsed time.Time
+}
+
+type fileClientSessionCache struct {
+	cache *fileCache
+
+	capacity int
+	logger   utils.Logger
+}
+
+var _ tls.ClientSessionCache = &fileClientSessionCache{}
+
+// NewFileClientSessionCache creates a tls.ClientSessionCache that persists session tickets to a file.
+// Together with the session ticket, the transport parameters of the session are saved,
+// allowing short-lived processes to resume sessions and use 0-RTT.
+// capacity specifies how many session tickets this cache is saving.
+func NewFileClientSessionCache(path string, capacity int) (tls.ClientSessionCache, error) {
+	cache, err := newFileCache(path, &map[string]*fileClientSessionCacheEntry{})
+	if err != nil {
+		return nil, err
+	}
+	return &fileClientSessionCache{
+		cache:    cache,
+		capacity: capacity,
+		logger:   utils.DefaultLogger.WithPrefix("session cache"),
+	}, nil
+}
+
+// load reads the session tickets from the file. Expired session tickets are removed.
+// It returns if any session tickets were removed.
+func (c *fileClientSessionCache) load(now time.Time) (map[string]*fileClientSessionCacheEntry, bool) {
+	entries := make(map[string]*fileClientSessionCacheEntry)
+	if err := c.cache.load(&entries); err != nil {
+		c.logger.Errorf("Failed to load session tickets from %s: %s", c.cache.path, err)
+		return make(map[string]*fileClientSessionCacheEntry), false
+	}
+	var removed bool
+	for key, entry := range entries {
+		if !entry.Expiry.IsZero() && !entry.Expiry.After(now) {
+			delete(entries, key)
+			removed = true
+		}
+	}
+	return entries, removed
+}
+
+func (c *fileClientSessionCache) store(entries map[string]*fileClientSessionCacheEntry) {
+	if err := c.cache.store(entries); err != nil {
+		c.logger.Errorf("Failed to store session tickets to %s: %s", c.cache.path, err)
+	}
+}
+
+func (c *fileClientSessionCache) Get(sessionKey string) (*tls.ClientSessionState, bool) {
+	c.cache.mutex.Lock()
+	defer c.cache.mutex.Unlock()
+
+	entries, removed := c.load(time.Now())
+	if removed {
+		c.store(entries)
+	}
+	entry, ok := entries[sessionKey]
+	if !ok {
+		return nil, false
+	}
+	cs, err := qtls.UnmarshalClientSessionState(entry.State)
+	if err != nil {
+		c.logger.Debugf("Failed to parse session ticket: %s", err)
+		return nil, false
+	}
+	return cs, true
+}
+
+func (c *fileClientSessionCache) Put(sessionKey string, cs *tls.ClientSessionState) {
+	c.cache.mutex.Lock()
+	defer c.cache.mutex.Unlock()
+
+	now := time.Now()
+	entries, _ := c.load(now)
+	if cs == nil {
+		delete(entries, sessionKey)
+		c.store(entries)
+		return
+	}
+	state, err := qtls.MarshalClientSessionState(cs)
+	if err != nil {
+		c.logger.Errorf("Failed to serialize session ticket: %s", err)
+		return
+	}
+	if _, ok := entries[sessionKey]; !ok && len(entries) >= c.capacity {
+		removeLeastRecentlyUsedSessionState(entries)
+	}
+	entries[sessionKey] = &fileClientSessionCacheEntry{
+		State:    state,
+		Expiry:   qtls.ClientSessionStateExpiry(cs),
+		LastUsed: now,
+	}
+	c.store(entries)
+}
+
+func removeLeastRecentlyUsedSessionState(entries map[string]*fileClientSessionCacheEntry) {
+	var (
+		found     bool
+		oldestKey string
+		oldest    time.Time
+	)
+	for key, entry := range entries {
+		if !found || entry.LastUsed.Before(oldest) {
+			found = true
+			oldestKey = key
+			oldest = entry.LastUsed
+		}
+	}
+	delete(entries, oldestKey)
+}
@@ -0,0 +1,155 @@
+package quic
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("File Caches", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "quic-go-file-cache")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("errors when the directory doesn't exist", func() {
+		_, err := NewFileTokenStore(filepath.Join(dir, "foo", "tokens"), 1, 1, 0)
+		Expect(err).To(HaveOccurred())
+		_, err = NewFileClientSessionCache(filepath.Join(dir, "foo", "sessions"), 1)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("errors when the file can't be parsed", func() {
+		path := filepath.Join(dir, "cache")
+		Expect(ioutil.WriteFile(path, []byte("foobar"), 0600)).To(Succeed())
+		_, err := NewFileTokenStore(path, 1, 1, 0)
+		Expect(err).To(HaveOccurred())
+		_, err = NewFileClientSessionCache(path, 1)
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("tokens", func() {
+		var path string
+
+		BeforeEach(func() {
+			path = filepath.Join(dir, "tokens")
+		})
+
+		mockToken := func(num int) *ClientToken {
+			return &ClientToken{data: []byte(fmt.Sprintf("%d", num))}
+		}
+
+		newTokenStore := func(maxAge time.Duration) TokenStore {
+			s, err := NewFileTokenStore(path, 2, 3, maxAge)
+			Expect(err).ToNot(HaveOccurred())
+			return s
+		}
+
+		It("adds and gets tokens", func() {
+			s := newTokenStore(0)
+			s.Put("localhost", mockToken(1))
+			s.Put("localhost", mockToken(2))
+			Expect(s.Pop("localhost")).To(Equal(mockToken(2)))
+			Expect(s.Pop("localhost")).To(Equal(mockToken(1)))
+			Expect(s.Pop("localhost")).To(BeNil())
+		})
+
+		It("persists tokens", func() {
+			newTokenStore(0).Put("localhost", mockToken(1))
+			s := newTokenStore(0)
+			Expect(s.Pop("localhost")).To(Equal(mockToken(1)))
+			Expect(newTokenStore(0).Pop("localhost")).To(BeNil())
+		})
+
+		It("overwrites old tokens", func() {
+			s := newTokenStore(0)
+			for i := 1; i <= 4; i++ {
+				s.Put("localhost", mockToken(i))
+			}
+			Expect(s.Pop("localhost")).To(Equal(mockToken(4)))
+			Expect(s.Pop("localhost")).To(Equal(mockToken(3)))
+			Expect(s.Pop("localhost")).To(Equal(mockToken(2)))
+			Expect(s.Pop("localhost")).To(BeNil())
+		})
+
+		It("removes the least recently used origin", func() {
+			s := newTokenStore(0)
+			s.Put("origin1", mockToken(1))
+			time.Sleep(time.Millisecond)
+			s.Put("origin2", mockToken(2))
+			time.Sleep(time.Millisecond)
+			s.Put("origin1", mockToken(3))
+			time.Sleep(time.Millisecond)
+			s.Put("origin3", mockToken(4))
+			Expect(s.Pop("origin2")).To(BeNil())
+			Expect(s.Pop("origin1")).To(Equal(mockToken(3)))
+			Expect(s.Pop("origin3")).To(Equal(mockToken(4)))
+		})
+
+		It("discards expired tokens", func() {
+			s := newTokenStore(50 * time.Millisecond)
+			s.Put("localhost", mockToken(1))
+			time.Sleep(60 * time.Millisecond)
+			Expect(s.Pop("localhost")).To(BeNil())
+		})
+	})
+
+	Context("session tickets", func() {
+		var path string
+
+		BeforeEach(func() {
+			path = filepath.Join(dir, "sessions")
+		})
+
+		newSessionCache := func() tls.ClientSessionCache {
+			c, err := NewFileClientSessionCache(path, 2)
+			Expect(err).ToNot(HaveOccurred())
+			return c
+		}
+
+		It("persists session tickets", func() {
+			newSessionCache().Put("localhost", &tls.ClientSessionState{})
+			cs, ok := newSessionCache().Get("localhost")
+			Expect(ok).To(BeTrue())
+			Expect(cs).ToNot(BeNil())
+			_, ok = newSessionCache().Get("remotehost")
+			Expect(ok).To(BeFalse())
+		})
+
+		It("removes session tickets", func() {
+			c := newSessionCache()
+			c.Put("localhost", &tls.ClientSessionState{})
+			c.Put("localhost", nil)
+			_, ok := newSessionCache().Get("localhost")
+			Expect(ok).To(BeFalse())
+		})
+
+		It("removes the least recently added session ticket", func() {
+			c := newSessionCache()
+			c.Put("host1", &tls.ClientSessionState{})
+			time.Sleep(time.Millisecond)
+			c.Put("host2", &tls.ClientSessionState{})
+			time.Sleep(time.Millisecond)
+			c.Put("host3", &tls.ClientSessionState{})
+			_, ok := c.Get("host1")
+			Expect(ok).To(BeFalse())
+			_, ok = c.Get("host2")
+			Expect(ok).To(BeTrue())
+			_, ok = c.Get("host3")
+			Expect(ok).To(BeTrue())
+		})
+	})
+})
@@ -16,6 +16,8 @@ type framer interface {
 	AppendControlFrames([]ackhandler.Frame, protocol.ByteCount) ([]ackhandler.Frame, protocol.ByteCount)
 
 	AddActiveStream(protocol.StreamID)
+	SetStreamPriority(protocol.StreamID, protocol.StreamPriority)
+	RemoveStream(protocol.StreamID)
 	AppendStreamFrames([]ackhandler.Frame, protocol.ByteCount) ([]ackhandler.Frame, protocol.ByteCount)
 }
 
@@ -26,7 +28,11 @@ type framerI struct {
 	version      protocol.VersionNumber
 
 	activeStreams map[protocol.StreamID]struct{}
-	streamQueue   []protocol.StreamID
+	// The streamQueue is sorted by urgency.
+	// Streams of the same urgency are served in the order they were queued.
+	streamQueue []protocol.StreamID
+	// priorities only contains streams that don't use the default priority
+	priorities map[protocol.StreamID]protocol.StreamPriority
 
 	controlFrameMutex sync.Mutex
 	controlFrames     []wire.Frame
@@ -41,6 +47,7 @@ func newFramer(
 	return &framerI{
 		streamGetter:  streamGetter,
 		activeStreams: make(map[protocol.StreamID]struct{}),
+		priorities:    make(map[protocol.StreamID]protocol.StreamPriority),
 		version:       v,
 	}
 }
@@ -84,12 +91,69 @@ func (f *framerI) AppendControlFrames(frames []ackhandler.Frame, maxLen protocol
 func (f *framerI) AddActiveStream(id protocol.StreamID) {
 	f.mutex.Lock()
 	if _, ok := f.activeStreams[id]; !ok {
-		f.streamQueue = append(f.streamQueue, id)
+		f.queueStream(id)
 		f.activeStreams[id] = struct{}{}
 	}
 	f.mutex.Unlock()
 }
 
+func (f *framerI) SetStreamPriority(id protocol.StreamID, p protocol.StreamPriority) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if p == protocol.DefaultStreamPriority {
+		delete(f.priorities, id)
+	} else {
+		f.priorities[id] = p
+	}
+	if _, ok := f.activeStreams[id]; !ok {
+		return
+	}
+	// move the stream to its new position in the queue
+	for i, sid := range f.streamQueue {
+		if sid == id {
+			f.streamQueue = append(f.streamQueue[:i], f.streamQueue[i+1:]...)
+			break
+		}
+	}
+	f.queueStream(id)
+}
+
+func (f *framerI) RemoveStream(id protocol.StreamID) {
+	f.mutex.Lock()
+	delete(f.priorities, id)
+	f.mutex.Unlock()
+}
+
+func (f *framerI) getPriority(id protocol.StreamID) protocol.StreamPriority {
+	if p, ok := f.priorities[id]; ok {
+		return p
+	}
+	return protocol.DefaultStreamPriority
+}
+
+// queueStream inserts a stream into the streamQueue,
+// behind all streams that have the same or a lower urgency.
+// Must be called while holding the mutex.
+func (f *framerI) queueStream(id protocol.StreamID) {
+	urgency := f.getPriority(id).Urgency
+	i := len(f.streamQueue)
+	for i > 0 && f.getPriority(f.streamQueue[i-1]).Urgency > urgency {
+		i--
+	}
+	f.streamQueue = append(f.streamQueue, 0)
+	copy(f.streamQueue[i+1:], f.streamQueue[i:])
+	f.streamQueue[i] = id
+}
+
+// requeueStreamAtFront puts a stream back at the front of the streamQueue.
+// Must be called while holding the mutex.
+func (f *framerI) requeueStreamAtFront(id protocol.StreamID) {
+	f.streamQueue = append(f.streamQueue, 0)
+	copy(f.streamQueue[1:], f.streamQueue)
+	f.streamQueue[0] = id
+}
+
 func (f *framerI) AppendStreamFrames(frames []ackhandler.Frame, maxLen protocol.ByteCount) ([]ackhandler.Frame, protocol.ByteCount) {
 	var length protocol.ByteCount
 	var lastFrame *ackhandler.Frame
@@ -116,20 +180,31 @@ func (f *framerI) AppendStreamFrames(frames []ackhandler.Frame, maxLen protocol.
 		// the STREAM frame (which will always have the DataLen set).
 		remainingLen += utils.VarIntLen(uint64(remainingLen))
 		frame, hasMoreData := str.popStreamFrame(remainingLen)
-		if hasMoreData { // put the stream back in the queue (at the end)
-			f.streamQueue = append(f.streamQueue, id)
+		var sendNext bool
+		if hasMoreData {
+			if f.getPriority(id).Incremental {
+				// put the stream back in the queue (at the end of its urgency)
+				f.queueStream(id)
+			} else {
+				// Non-incremental streams are sent one after the other.
+				// This stream will be asked for data first when the next packet is packed.
+				f.requeueStreamAtFront(id)
+				sendNext = true
+			}
 		} else { // no more data to send. Stream is not active any more
 			delete(f.activeStreams, id)
 		}
 		// The frame can be nil
 		// * if the receiveStream was canceled after it said it had data
 		// * the remaining size doesn't allow us to add another STREAM frame
-		if frame == nil {
-			continue
+		if frame != nil {
+			frames = append(frames, *frame)
+			length += frame.Length(f.version)
+			lastFrame = frame
+		}
+		if sendNext {
+			break
 		}
-		frames = append(frames, *frame)
-		length += frame.Length(f.version)
-		lastFrame = frame
 	}
 	f.mutex.Unlock()
 	if lastFrame != nil {
@@ -0,0 +1,254 @@
+package http3
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultAltSvcMaxAge is the freshness lifetime of an alternative service if the ma parameter is omitted,
+// see RFC 7838, Section 3.1.
+const defaultAltSvcMaxAge = 24 * time.Hour
+
+// An altSvc is an alternative service, as advertised in the Alt-Svc header field.
+type altSvc struct {
+	protocol string
+	host     string // empty if the alternative service uses the same host as the origin
+	port     string
+	maxAge   time.Duration
+}
+
+// parseAltSvc parses the value of an Alt-Svc header field (see RFC 7838, Section 3).
+// It returns clear = true if the value is the special value "clear".
+func parseAltSvc(value string) (services []altSvc, clear bool, err error) {
+	value = strings.TrimSpace(value)
+	if value == "clear" {
+		return nil, true, nil
+	}
+	for _, elem := range splitQuoted(value, ',') {
+		elem = strings.TrimSpace(elem)
+		if elem == "" {
+			continue
+		}
+		params := splitQuoted(elem, ';')
+		svc, err := parseAltValue(params[0])
+		if err != nil {
+			return nil, false, err
+		}
+		for _, param := range params[1:] {
+			name, val, err := parseAltSvcParam(param)
+			if err != nil {
+				return nil, false, err
+			}
+			if name != "ma" {
+				continue
+			}
+			ma, err := strconv.ParseUint(val, 10, 32)
+			if err != nil {
+				return nil, false, fmt.Errorf("invalid ma parameter: %q", val)
+			}
+			svc.maxAge = time.Duration(ma) * time.Second
+		}
+		services = append(services, svc)
+	}
+	return services, false, nil
+}
+
+// parseAltValue parses a protocol-id "=" alt-authority pair.
+func parseAltValue(s string) (altSvc, error) {
+	name, val, err := parseAltSvcParam(s)
+	if err != nil {
+		return altSvc{}, err
+	}
+	protocol, err := url.PathUnescape(name)
+	if err != nil {
+		return altSvc{}, fmt.Errorf("invalid protocol-id: %q", name)
+	}
+	host, port, err := net.SplitHostPort(val)
+	if err != nil {
+		return altSvc{}, fmt.Errorf("invalid alt-authority: %q", val)
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return altSvc{}, fmt.Errorf("invalid alt-authority: %q", val)
+	}
+	return altSvc{protocol: protocol, host: host, port: port, maxAge: defaultAltSvcMaxAge}, nil
+}
+
+// parseAltSvcParam parses a name "=" value pair. The value may be a quoted string.
+func parseAltSvcParam(s string) (name, value string, err error) {
+	i := strings.IndexByte(s, '=')
+	if i <= 0 {
+		return "", "", fmt.Errorf("invalid Alt-Svc parameter: %q", s)
+	}
+	name = strings.TrimSpace(s[:i])
+	value = strings.TrimSpace(s[i+1:])
+	if !strings.HasPrefix(value, `"`) {
+		return name, value, nil
+	}
+	if len(value) < 2 || !strings.HasSuffix(value, `"`) {
+		return "", "", fmt.Errorf("invalid quoted string: %s", value)
+	}
+	var b strings.Builder
+	for i := 1; i < len(value)-1; i++ {
+		if value[i] == '\\' {
+			i++
+			if i == len(value)-1 {
+				return "", "", fmt.Errorf("invalid quoted string: %s", value)
+			}
+		}
+		b.WriteByte(value[i])
+	}
+	return name, b.String(), nil
+}
+
+// splitQuoted splits s at every occurrence of sep that's not inside a quoted string.
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	var quoted, escaped bool
+	var start int
+	for i := 0; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && s[i] == '\\':
+			escaped = true
+		case s[i] == '"':
+			quoted = !quoted
+		case !quoted && s[i] == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// An altSvcEntry is the cached state of an origin.
+type altSvcEntry struct {
+	addr    string // the address of the HTTP/3 alternative service, empty if the origin doesn't support HTTP/3
+	expires time.Time
+}
+
+// An altSvcCache caches the HTTP/3 alternative services of origins.
+// It also keeps track of origins for which QUIC doesn't work.
+type altSvcCache struct {
+	mutex   sync.Mutex
+	entries map[string]altSvcEntry // keyed by origin (host:port)
+	broken  map[string]time.Time   // origins for which QUIC is broken, and when to try QUIC again
+}
+
+// update processes the Alt-Svc header fields of a response received from the origin.
+// It returns an error if an Alt-Svc header field can't be parsed, in which case the cache isn't modified.
+// The alternative services advertised in a response replace all cached alternative services of the origin.
+// If the response doesn't contain an Alt-Svc header field, the cache is only updated if the origin is not known yet.
+func (c *altSvcCache) update(origin string, hdr http.Header, now time.Time) error {
+	values := hdr.Values("Alt-Svc")
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]altSvcEntry)
+	}
+	if len(values) == 0 {
+		if _, ok := c.entries[origin]; !ok {
+			c.entries[origin] = altSvcEntry{}
+		}
+		return nil
+	}
+	var services []altSvc
+	for _, v := range values {
+		svcs, clear, err := parseAltSvc(v)
+		if err != nil {
+			return err
+		}
+		if clear {
+			c.entries[origin] = altSvcEntry{}
+			return nil
+		}
+		services = append(services, svcs...)
+	}
+	for _, svc := range services {
+		if svc.protocol != NextProtoH3 && svc.protocol != NextProtoH3Draft29 {
+			continue
+		}
+		host := svc.host
+		if host == "" {
+			var err error
+			host, _, err = net.SplitHostPort(origin)
+			if err != nil {
+				return err
+			}
+			host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+		}
+		c.entries[origin] = altSvcEntry{
+			addr:    net.JoinHostPort(host, svc.port),
+			expires: now.Add(svc.maxAge),
+		}
+		return nil
+	}
+	c.entries[origin] = altSvcEntry{}
+	return nil
+}
+
+// confirm records that the origin itself supports HTTP/3,
+// unless a (fresh) alternative service is already cached for the origin.
+func (c *altSvcCache) confirm(origin string, now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if entry, ok := c.entries[origin]; ok && entry.addr != "" && now.Before(entry.expires) {
+		return
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]altSvcEntry)
+	}
+	c.entries[origin] = altSvcEntry{addr: origin, expires: now.Add(defaultAltSvcMaxAge)}
+}
+
+// lookup returns the address of the HTTP/3 alternative service of an origin.
+// known is false if no state is cached for the origin, or if the cached alternative service has expired.
+func (c *altSvcCache) lookup(origin string, now time.Time) (addr string, known bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[origin]
+	if !ok {
+		return "", false
+	}
+	if entry.addr != "" && !now.Before(entry.expires) {
+		delete(c.entries, origin)
+		return "", false
+	}
+	return entry.addr, true
+}
+
+// markBroken marks QUIC as broken for an origin, until the given time.
+func (c *altSvcCache) markBroken(origin string, until time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.broken == nil {
+		c.broken = make(map[string]time.Time)
+	}
+	c.broken[origin] = until
+}
+
+// isBroken says if QUIC is marked as broken for an origin.
+func (c *altSvcCache) isBroken(origin string, now time.Time) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	until, ok := c.broken[origin]
+	if !ok {
+		return false
+	}
+	if !now.Before(until) {
+		delete(c.broken, origin)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,170 @@
+package http3
+
+import (
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Alt-Svc", func() {
+	Context("parsing", func() {
+		It("parses a single alternative service", func() {
+			services, clear, err := parseAltSvc(`h3-29=":443"; ma=2592000`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(clear).To(BeFalse())
+			Expect(services).To(Equal([]altSvc{{protocol: "h3-29", port: "443", maxAge: 2592000 * time.Second}}))
+		})
+
+		It("uses the default max age", func() {
+			services, _, err := parseAltSvc(`h3-29=":443"`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(services).To(HaveLen(1))
+			Expect(services[0].maxAge).To(Equal(24 * time.Hour))
+		})
+
+		It("parses multiple alternative services", func() {
+			services, _, err := parseAltSvc(`h2="alt.example.org:8000"; persist=1, h3-29="[::1]:443";ma=60`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(services).To(Equal([]altSvc{
+				{protocol: "h2", host: "alt.example.org", port: "8000", maxAge: defaultAltSvcMaxAge},
+				{protocol: "h3-29", host: "::1", port: "443", maxAge: time.Minute},
+			}))
+		})
+
+		It("unescapes protocol IDs and quoted strings", func() {
+			services, _, err := parseAltSvc(`w%3Dx%3Ay="alt.example\.org:443"`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(services).To(Equal([]altSvc{{protocol: "w=x:y", host: "alt.example.org", port: "443", maxAge: defaultAltSvcMaxAge}}))
+		})
+
+		It("parses clear", func() {
+			services, clear, err := parseAltSvc(" clear ")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(clear).To(BeTrue())
+			Expect(services).To(BeEmpty())
+		})
+
+		It("errors on invalid values", func() {
+			for _, v := range []string{
+				`h3-29`,
+				`h3-29=":foo"`,
+				`h3-29="example.org"`,
+				`h3-29=":443`,
+				`h3-29=":443"; ma=-1`,
+				`h3-29=":443"; ma`,
+			} {
+				_, _, err := parseAltSvc(v)
+				Expect(err).To(HaveOccurred(), v)
+			}
+		})
+	})
+
+	Context("caching", func() {
+		const origin = "www.example.org:443"
+
+		var (
+			cache *altSvcCache
+			now   time.Time
+		)
+
+		BeforeEach(func() {
+			cache = &altSvcCache{}
+			now = time.Now()
+		})
+
+		It("doesn't know origins it never saw a response from", func() {
+			_, known := cache.lookup(origin, now)
+			Expect(known).To(BeFalse())
+		})
+
+		It("caches HTTP/3 alternative services", func() {
+			hdr := http.Header{"Alt-Svc": {`h2=":443", h3-29="alt.example.org:8443"; ma=60`}}
+			Expect(cache.update(origin, hdr, now)).To(Succeed())
+			addr, known := cache.lookup(origin, now)
+			Expect(known).To(BeTrue())
+			Expect(addr).To(Equal("alt.example.org:8443"))
+			_, known = cache.lookup(origin, now.Add(time.Minute))
+			Expect(known).To(BeFalse())
+		})
+
+		It("caches HTTP/3 over QUIC v1 and draft-29", func() {
+			Expect(cache.update(origin, http.Header{"Alt-Svc": {`h3="alt.example.org:443"`}}, now)).To(Succeed())
+			addr, known := cache.lookup(origin, now)
+			Expect(known).To(BeTrue())
+			Expect(addr).To(Equal("alt.example.org:443"))
+			Expect(cache.update(origin, http.Header{"Alt-Svc": {`h3-29="alt.example.org:8443"`}}, now)).To(Succeed())
+			addr, known = cache.lookup(origin, now)
+			Expect(known).To(BeTrue())
+			Expect(addr).To(Equal("alt.example.org:8443"))
+		})
+
+		It("uses the origin's host if the alternative service doesn't specify one", func() {
+			Expect(cache.update("[::1]:443", http.Header{"Alt-Svc": {`h3-29=":4433"`}}, now)).To(Succeed())
+			addr, known := cache.lookup("[::1]:443", now)
+			Expect(known).To(BeTrue())
+			Expect(addr).To(Equal("[::1]:4433"))
+		})
+
+		It("remembers origins that don't support HTTP/3", func() {
+			Expect(cache.update(origin, http.Header{}, now)).To(Succeed())
+			addr, known := cache.lookup(origin, now)
+			Expect(known).To(BeTrue())
+			Expect(addr).To(BeEmpty())
+		})
+
+		It("keeps the alternative service if a response doesn't contain an Alt-Svc header field", func() {
+			Expect(cache.update(origin, http.Header{"Alt-Svc": {`h3-29=":443"`}}, now)).To(Succeed())
+			Expect(cache.update(origin, http.Header{}, now)).To(Succeed())
+			addr, _ := cache.lookup(origin, now)
+			Expect(addr).To(Equal(origin))
+		})
+
+		It("replaces the alternative service", func() {
+			Expect(cache.update(origin, http.Header{"Alt-Svc": {`h3-29=":443"`}}, now)).To(Succeed())
+			Expect(cache.update(origin, http.Header{"Alt-Svc": {`h2=":443"`}}, now)).To(Succeed())
+			addr, known := cache.lookup(origin, now)
+			Expect(known).To(BeTrue())
+			Expect(addr).To(BeEmpty())
+		})
+
+		It("clears the alternative service", func() {
+			Expect(cache.update(origin, http.Header{"Alt-Svc": {`h3-29=":443"`}}, now)).To(Succeed())
+			Expect(cache.update(origin, http.Header{"Alt-Svc": {"clear"}}, now)).To(Succeed())
+			addr, known := cache.lookup(origin, now)
+			Expect(known).To(BeTrue())
+			Expect(addr).To(BeEmpty())
+		})
+
+		It("doesn't modify the cache if the Alt-Svc header field is invalid", func() {
+			Expect(cache.update(origin, http.Header{"Alt-Svc": {`h3-29=":443"`}}, now)).To(Succeed())
+			Expect(cache.update(origin, http.Header{"Alt-Svc": {`h3-29`}}, now)).ToNot(Succeed())
+			addr, _ := cache.lookup(origin, now)
+			Expect(addr).To(Equal(origin))
+		})
+
+		It("confirms that an origin supports HTTP/3", func() {
+			Expect(cache.update(origin, http.Header{}, now)).To(Succeed())
+			cache.confirm(origin, now)
+			addr, known := cache.lookup(origin, now)
+			Expect(known).To(BeTrue())
+			Expect(addr).To(Equal(origin))
+		})
+
+		It("doesn't replace a cached alternative service when confirming", func() {
+			Expect(cache.update(origin, http.Header{"Alt-Svc": {`h3-29="alt.example.org:443"`}}, now)).To(Succeed())
+			cache.confirm(origin, now)
+			addr, _ := cache.lookup(origin, now)
+			Expect(addr).To(Equal("alt.example.org:443"))
+		})
+
+		It("marks QUIC as broken", func() {
+			Expect(cache.isBroken(origin, now)).To(BeFalse())
+			cache.markBroken(origin, now.Add(time.Minute))
+			Expect(cache.isBroken(origin, now)).To(BeTrue())
+			Expect(cache.isBroken("other.example.org:443", now)).To(BeFalse())
+			Expect(cache.isBroken(origin, now.Add(time.Minute))).To(BeFalse())
+		})
+	})
+})
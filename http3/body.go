@@ -1,15 +1,19 @@
 package http3
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"net/http"
 
 	"github.com/lucas-clemente/quic-go"
 )
 
 // The body of a http.Request or http.Response.
 type body struct {
-	str quic.Stream
+	str     quic.ReceiveStream
+	ctx     context.Context // cancels decoding of the trailers
+	decoder *qpackDecoder   // used to decode the trailers
 
 	// only set for the http.Response
 	// The channel is closed when the user is done with this response:
@@ -19,21 +23,35 @@ type body struct {
 
 	onFrameError func()
 
+	// onPushPromise is called when a PUSH_PROMISE frame is received.
+	// It must read the field section of the frame. Only set for the http.Response.
+	onPushPromise func(*pushPromiseFrame) error
+
+	// onTrailers is called with the trailers, if the peer sent a HEADERS frame after the DATA frames.
+	onTrailers func(http.Header)
+	// maxTrailerBytes limits the size of that HEADERS frame. 0 means no limit.
+	maxTrailerBytes uint64
+
 	bytesRemainingInFrame uint64
+	readErr               error // sticky error, set once the trailers were read
 }
 
 var _ io.ReadCloser = &body{}
 
-func newRequestBody(str quic.Stream, onFrameError func()) *body {
+func newRequestBody(ctx context.Context, str quic.Stream, decoder *qpackDecoder, onFrameError func()) *body {
 	return &body{
 		str:          str,
+		ctx:          ctx,
+		decoder:      decoder,
 		onFrameError: onFrameError,
 	}
 }
 
-func newResponseBody(str quic.Stream, done chan<- struct{}, onFrameError func()) *body {
+func newResponseBody(ctx context.Context, str quic.ReceiveStream, decoder *qpackDecoder, done chan<- struct{}, onFrameError func()) *body {
 	return &body{
 		str:          str,
+		ctx:          ctx,
+		decoder:      decoder,
 		onFrameError: onFrameError,
 		reqDone:      done,
 	}
@@ -48,6 +66,9 @@ func (r *body) Read(b []byte) (int, error) {
 }
 
 func (r *body) readImpl(b []byte) (int, error) {
+	if r.readErr != nil {
+		return 0, r.readErr
+	}
 	if r.bytesRemainingInFrame == 0 {
 	parseLoop:
 		for {
@@ -57,11 +78,26 @@ func (r *body) readImpl(b []byte) (int, error) {
 			}
 			switch f := frame.(type) {
 			case *headersFrame:
-				// skip HEADERS frames
-				continue
+				// A HEADERS frame following the DATA frames contains the trailers.
+				// It is the last frame on the stream.
+				if err := r.readTrailers(f); err != nil {
+					r.readErr = err
+					return 0, err
+				}
+				r.readErr = io.EOF
+				return 0, io.EOF
 			case *dataFrame:
 				r.bytesRemainingInFrame = f.Length
 				break parseLoop
+			case *pushPromiseFrame:
+				if r.onPushPromise == nil {
+					r.onFrameError()
+					return 0, fmt.Errorf("peer sent an unexpected frame: %T", f)
+				}
+				if err := r.onPushPromise(f); err != nil {
+					r.readErr = err
+					return 0, err
+				}
 			default:
 				r.onFrameError()
 				// parseNextFrame skips over unknown frame types
@@ -82,6 +118,31 @@ func (r *body) readImpl(b []byte) (int, error) {
 	return n, err
 }
 
+func (r *body) readTrailers(f *headersFrame) error {
+	if r.maxTrailerBytes > 0 && f.Length > r.maxTrailerBytes {
+		return fmt.Errorf("HEADERS frame too large: %d bytes (max: %d)", f.Length, r.maxTrailerBytes)
+	}
+	headerBlock := make([]byte, f.Length)
+	if _, err := io.ReadFull(r.str, headerBlock); err != nil {
+		return err
+	}
+	hfs, err := r.decoder.decode(r.ctx, r.str.StreamID(), headerBlock)
+	if err != nil {
+		return err
+	}
+	trailer := make(http.Header, len(hfs))
+	for _, hf := range hfs {
+		if hf.IsPseudo() {
+			return fmt.Errorf("invalid pseudo header field in trailers: %s", hf.Name)
+		}
+		trailer.Add(hf.Name, hf.Value)
+	}
+	if r.onTrailers != nil {
+		r.onTrailers(trailer)
+	}
+	return nil
+}
+
 func (r *body) requestDone() {
 	if r.reqDoneClosed || r.reqDone == nil {
 		return
@@ -92,6 +153,10 @@ func (r *body) requestDone() {
 
 func (r *body) Close() error {
 	r.requestDone()
+	if r.readErr == nil {
+		// The trailers might still reference the dynamic table.
+		r.decoder.cancelStream(r.str.StreamID())
+	}
 	// If the EOF was read, CancelRead() is a no-op.
 	r.str.CancelRead(quic.ErrorCode(errorRequestCanceled))
 	return nil
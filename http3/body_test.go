@@ -2,12 +2,18 @@ package http3
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net/http"
 
 	"github.com/golang/mock/gomock"
 	"github.com/lucas-clemente/quic-go"
 	mockquic "github.com/lucas-clemente/quic-go/internal/mocks/quic"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/marten-seemann/qpack"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -62,13 +68,15 @@ var _ = Describe("Body", func() {
 				str.EXPECT().Read(gomock.Any()).DoAndReturn(func(b []byte) (int, error) {
 					return buf.Read(b)
 				}).AnyTimes()
+				str.EXPECT().StreamID().Return(quic.StreamID(4)).AnyTimes()
 
+				decoder := newQPACKDecoder(0, 0, nil, utils.DefaultLogger)
 				switch bodyType {
 				case bodyTypeRequest:
-					rb = newRequestBody(str, errorCb)
+					rb = newRequestBody(context.Background(), str, decoder, errorCb)
 				case bodyTypeResponse:
 					reqDone = make(chan struct{})
-					rb = newResponseBody(str, reqDone, errorCb)
+					rb = newResponseBody(context.Background(), str, decoder, reqDone, errorCb)
 				}
 			})
 
@@ -130,16 +138,60 @@ var _ = Describe("Body", func() {
 				Expect(b[:n]).To(Equal([]byte("bar")))
 			})
 
-			It("skips HEADERS frames", func() {
-				buf.Write(getDataFrame([]byte("foo")))
-				(&headersFrame{Length: 10}).Write(buf)
-				buf.Write(make([]byte, 10))
-				buf.Write(getDataFrame([]byte("bar")))
-				b := make([]byte, 6)
-				n, err := io.ReadFull(rb, b)
+			getTrailers := func(fields ...qpack.HeaderField) []byte {
+				headers := &bytes.Buffer{}
+				enc := qpack.NewEncoder(headers)
+				for _, f := range fields {
+					Expect(enc.WriteField(f)).To(Succeed())
+				}
+				b := &bytes.Buffer{}
+				(&headersFrame{Length: uint64(headers.Len())}).Write(b)
+				b.Write(headers.Bytes())
+				return b.Bytes()
+			}
+
+			It("reads trailers", func() {
+				var trailer http.Header
+				rb.onTrailers = func(t http.Header) { trailer = t }
+				buf.Write(getDataFrame([]byte("foobar")))
+				buf.Write(getTrailers(qpack.HeaderField{Name: "grpc-status", Value: "0"}))
+				data, err := ioutil.ReadAll(rb)
 				Expect(err).ToNot(HaveOccurred())
-				Expect(n).To(Equal(6))
-				Expect(b).To(Equal([]byte("foobar")))
+				Expect(data).To(Equal([]byte("foobar")))
+				Expect(trailer).To(Equal(http.Header{"Grpc-Status": []string{"0"}}))
+				// the trailers end the body
+				_, err = rb.Read([]byte{0})
+				Expect(err).To(Equal(io.EOF))
+			})
+
+			It("errors on pseudo header fields in the trailers", func() {
+				buf.Write(getTrailers(qpack.HeaderField{Name: ":status", Value: "200"}))
+				_, err := rb.Read([]byte{0})
+				Expect(err).To(MatchError("invalid pseudo header field in trailers: :status"))
+			})
+
+			It("stops waiting for blocked trailers when the context is canceled", func() {
+				decoder := newQPACKDecoder(defaultQPACKMaxTableCapacity, 1, nil, utils.DefaultLogger)
+				decoder.stream = &bytes.Buffer{}
+				rb.decoder = decoder
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				rb.ctx = ctx
+				// a field section referencing a dynamic table entry that was never inserted
+				headerBlock := appendQPACKInt(nil, 8, 0, 2)
+				headerBlock = append(headerBlock, 0)
+				headerBlock = appendQPACKInt(headerBlock, 6, 0x80, 0)
+				(&headersFrame{Length: uint64(len(headerBlock))}).Write(buf)
+				buf.Write(headerBlock)
+				_, err := rb.Read([]byte{0})
+				Expect(err).To(MatchError(context.Canceled))
+			})
+
+			It("errors when the trailers are too large", func() {
+				rb.maxTrailerBytes = 10
+				buf.Write(getTrailers(qpack.HeaderField{Name: "foo", Value: "this is a long value"}))
+				_, err := rb.Read([]byte{0})
+				Expect(err).To(MatchError(ContainSubstring("HEADERS frame too large")))
 			})
 
 			It("errors when it can't parse the frame", func() {
@@ -155,6 +207,34 @@ var _ = Describe("Body", func() {
 				Expect(errorCbCalled).To(BeTrue())
 			})
 
+			It("errors on PUSH_PROMISE frames, if server push is not enabled", func() {
+				(&pushPromiseFrame{PushID: 1}).Write(buf)
+				_, err := rb.Read([]byte{0})
+				Expect(err).To(MatchError("peer sent an unexpected frame: *http3.pushPromiseFrame"))
+				Expect(errorCbCalled).To(BeTrue())
+			})
+
+			It("passes PUSH_PROMISE frames to the callback", func() {
+				var promised *pushPromiseFrame
+				rb.onPushPromise = func(f *pushPromiseFrame) error {
+					promised = f
+					return nil
+				}
+				(&pushPromiseFrame{PushID: 3}).Write(buf)
+				buf.Write(getDataFrame([]byte("foobar")))
+				data, err := ioutil.ReadAll(rb)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).To(Equal([]byte("foobar")))
+				Expect(promised).To(Equal(&pushPromiseFrame{PushID: 3}))
+			})
+
+			It("errors when the PUSH_PROMISE callback errors", func() {
+				rb.onPushPromise = func(*pushPromiseFrame) error { return errors.New("invalid push ID") }
+				(&pushPromiseFrame{PushID: 3}).Write(buf)
+				_, err := rb.Read([]byte{0})
+				Expect(err).To(MatchError("invalid push ID"))
+			})
+
 			if bodyType == bodyTypeResponse {
 				It("closes the reqDone channel when Read errors", func() {
 					buf.Write([]byte("invalid"))
@@ -2,20 +2,22 @@ package http3
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"strconv"
 	"sync"
 
 	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
-	"github.com/marten-seemann/qpack"
 )
 
-// MethodGet0RTT allows a GET request to be sent using 0-RTT.
+// MethodGet0RTT allows a GET request to be sent using 0-RTT, even if RoundTripper.Disable0RTT is set.
 // Note that 0-RTT data doesn't provide replay protection.
 const MethodGet0RTT = "GET_0RTT"
 
@@ -29,9 +31,23 @@ var defaultQuicConfig = &quic.Config{
 
 var dialAddr = quic.DialAddrEarly
 
+// errRequestNotProcessed is returned by the client if the server didn't process a request,
+// either because it sent a GOAWAY frame, or because it rejected the request.
+// It is safe to retry such a request on a new connection.
+var errRequestNotProcessed = errors.New("http3: request was not processed by the server")
+
+// errHandshakeFailed is returned by the client if the session was closed before the handshake completed.
+var errHandshakeFailed = errors.New("http3: QUIC handshake failed")
+
 type roundTripperOpts struct {
 	DisableCompression bool
+	Disable0RTT        bool
+	EnableWebTransport bool
 	MaxHeaderBytes     int64
+	PushHandler        func(*http.Request, *http.Response)
+
+	MaxQPACKTableCapacity  int64
+	MaxQPACKBlockedStreams int64
 }
 
 // client is a HTTP3 client doing requests
@@ -46,10 +62,28 @@ type client struct {
 
 	requestWriter *requestWriter
 
-	decoder *qpack.Decoder
+	encoder *qpackEncoder
+	decoder *qpackDecoder
+
+	hostname   string
+	addr       string // the address that is dialed, if the origin is served by an alternative service
+	session    quic.EarlySession
+	controlStr quic.SendStream
+
+	mutex           sync.Mutex
+	activeRequests  int
+	draining        bool // no new requests are sent, and the session is closed once all active requests complete
+	dialFailed      bool
+	receivedGoAway  bool
+	goAwayID        quic.StreamID // requests on streams with IDs >= goAwayID were not processed by the server
+	maxPushID       uint64        // the maximum push ID the server is allowed to use, if push is enabled
+	pushes          map[uint64]*pendingPush
+	pushesDoneBelow uint64 // all pushes with lower IDs are done, and were removed from the pushes map
+
+	receivedSettings chan struct{} // closed once the server's SETTINGS frame was received
+	settings         *settingsFrame
 
-	hostname string
-	session  quic.EarlySession
+	webTransport *webTransportManager // nil if WebTransport is disabled
 
 	logger utils.Logger
 }
@@ -66,36 +100,68 @@ func newClient(
 	} else {
 		tlsConf = tlsConf.Clone()
 	}
-	// Replace existing ALPNs by H3
-	tlsConf.NextProtos = []string{nextProtoH3}
 	if quicConfig == nil {
 		quicConfig = defaultQuicConfig
 	}
-	quicConfig.MaxIncomingStreams = -1 // don't allow any bidirectional streams
+	// Replace existing ALPNs by H3, offering the application protocols of the QUIC versions the connection can use:
+	// the version we dial with, and the versions the server might switch to.
+	versions := protocol.SupportedVersions
+	if len(quicConfig.Versions) > 0 {
+		versions = quicConfig.Versions
+	}
+	tlsConf.NextProtos = nextProtosForVersion(quicConfig, versions[0])
+	if opts.EnableWebTransport {
+		// The server opens bidirectional streams for WebTransport sessions, and WebTransport uses datagrams.
+		quicConfig = quicConfig.Clone()
+		quicConfig.MaxIncomingStreams = 0
+		quicConfig.EnableDatagrams = true
+	} else {
+		quicConfig.MaxIncomingStreams = -1 // don't allow any bidirectional streams
+	}
 	logger := utils.DefaultLogger.WithPrefix("h3 client")
 
-	return &client{
-		hostname:      authorityAddr("https", hostname),
-		tlsConf:       tlsConf,
-		requestWriter: newRequestWriter(logger),
-		decoder:       qpack.NewDecoder(func(hf qpack.HeaderField) {}),
-		config:        quicConfig,
-		opts:          opts,
-		dialer:        dialer,
-		logger:        logger,
+	var maxPushID uint64
+	if opts.PushHandler != nil {
+		maxPushID = maxOutstandingPushes - 1
 	}
+	c := &client{
+		hostname:         authorityAddr("https", hostname),
+		addr:             authorityAddr("https", hostname),
+		tlsConf:          tlsConf,
+		config:           quicConfig,
+		opts:             opts,
+		dialer:           dialer,
+		receivedSettings: make(chan struct{}),
+		maxPushID:        maxPushID,
+		logger:           logger,
+	}
+	// The QPACK streams are opened once the first instruction is sent, after the session was dialed.
+	openUniStream := func() (quic.SendStream, error) { return c.session.OpenUniStream() }
+	capacity, blocked := qpackLimits(opts.MaxQPACKTableCapacity, opts.MaxQPACKBlockedStreams)
+	c.encoder = newQPACKEncoder(capacity, openUniStream, logger)
+	c.decoder = newQPACKDecoder(capacity, blocked, openUniStream, logger)
+	c.requestWriter = newRequestWriter(c.encoder, logger)
+	return c
 }
 
 func (c *client) dial() error {
+	var sess quic.EarlySession
 	var err error
 	if c.dialer != nil {
-		c.session, err = c.dialer("udp", c.hostname, c.tlsConf, c.config)
+		sess, err = c.dialer("udp", c.addr, c.tlsConf, c.config)
 	} else {
-		c.session, err = dialAddr(c.hostname, c.tlsConf, c.config)
+		sess, err = dialAddr(c.addr, c.tlsConf, c.config)
 	}
+	c.mutex.Lock()
+	c.session = sess
+	c.dialFailed = err != nil
+	c.mutex.Unlock()
 	if err != nil {
 		return err
 	}
+	if c.opts.EnableWebTransport {
+		c.webTransport = newWebTransportManager(c.session, c.logger)
+	}
 
 	// run the sesssion setup using 0-RTT data
 	go func() {
@@ -108,6 +174,36 @@ func (c *client) dial() error {
 	return nil
 }
 
+// useAlternativeService makes the client dial an alternative service (see RFC 7838) instead of the origin.
+// The TLS handshake is still performed for the origin's host name.
+func (c *client) useAlternativeService(addr string) {
+	c.addr = addr
+	if c.tlsConf.ServerName == "" {
+		if host, _, err := net.SplitHostPort(c.hostname); err == nil {
+			c.tlsConf.ServerName = host
+		}
+	}
+}
+
+// connect dials the session (if that didn't happen yet), and waits for the handshake to complete.
+// It doesn't send a request.
+func (c *client) connect(ctx context.Context) error {
+	c.dialOnce.Do(func() {
+		c.handshakeErr = c.dial()
+	})
+	if c.handshakeErr != nil {
+		return c.handshakeErr
+	}
+	select {
+	case <-c.session.HandshakeComplete().Done():
+		return nil
+	case <-c.session.Context().Done():
+		return errHandshakeFailed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (c *client) setupSession() error {
 	// open the control stream
 	str, err := c.session.OpenUniStream()
@@ -118,19 +214,225 @@ func (c *client) setupSession() error {
 	// write the type byte
 	buf.Write([]byte{0x0})
 	// send the SETTINGS frame
-	(&settingsFrame{}).Write(buf)
-	if _, err := str.Write(buf.Bytes()); err != nil {
+	settings := &settingsFrame{}
+	if c.opts.EnableWebTransport {
+		settings.settings = map[uint64]uint64{
+			settingDatagram:           1,
+			settingEnableWebTransport: 1,
+		}
+	}
+	addQPACKSettings(settings, c.decoder.maxCapacity, c.decoder.maxBlocked)
+	settings.Write(buf)
+	// allow the server to push
+	if c.opts.PushHandler != nil {
+		(&maxPushIDFrame{PushID: c.maxPushID}).Write(buf)
+	}
+	c.mutex.Lock()
+	_, err = str.Write(buf.Bytes())
+	c.controlStr = str
+	c.mutex.Unlock()
+	if err != nil {
 		return err
 	}
 
+	go c.handleUnidirectionalStreams()
+	if c.webTransport != nil {
+		go c.handleBidirectionalStreams()
+	}
+	return nil
+}
+
+// handleBidirectionalStreams accepts the bidirectional streams opened by the server.
+// HTTP/3 doesn't allow the server to open bidirectional streams, unless they belong to a WebTransport session.
+func (c *client) handleBidirectionalStreams() {
+	for {
+		str, err := c.session.AcceptStream(context.Background())
+		if err != nil {
+			c.logger.Debugf("Accepting bidirectional stream failed: %s", err)
+			return
+		}
+
+		go func(str quic.Stream) {
+			f, err := parseNextFrame(str)
+			if err != nil {
+				c.logger.Debugf("Reading the first frame on stream %d failed: %s", str.StreamID(), err)
+				return
+			}
+			wf, ok := f.(*webTransportFrame)
+			if !ok {
+				c.session.CloseWithError(quic.ErrorCode(errorStreamCreationError), "server opened a bidirectional stream")
+				return
+			}
+			c.webTransport.handleStream(wf.SessionID, str)
+		}(str)
+	}
+}
+
+func (c *client) handleUnidirectionalStreams() {
+	for {
+		str, err := c.session.AcceptUniStream(context.Background())
+		if err != nil {
+			c.logger.Debugf("Accepting unidirectional stream failed: %s", err)
+			// The session is closed. Unblock all streams waiting for QPACK encoder instructions.
+			c.decoder.close()
+			return
+		}
+
+		go func() {
+			streamType, err := utils.ReadVarInt(&byteReaderImpl{str})
+			if err != nil {
+				c.logger.Debugf("Reading stream type on stream %d failed: %s", str.StreamID(), err)
+				return
+			}
+			switch streamType {
+			case streamTypeControlStream:
+				c.handleControlStream(str)
+			case streamTypePushStream:
+				c.handlePushStream(str)
+			case streamTypeQPACKEncoderStream:
+				c.handleQPACKError(c.decoder.handleEncoderStream(str))
+			case streamTypeQPACKDecoderStream:
+				c.handleQPACKError(c.encoder.handleDecoderStream(str))
+			case streamTypeWebTransportStream:
+				if c.webTransport == nil {
+					str.CancelRead(quic.ErrorCode(errorStreamCreationError))
+					return
+				}
+				c.webTransport.handleUniStream(str)
+			default:
+				str.CancelRead(quic.ErrorCode(errorStreamCreationError))
+			}
+		}()
+	}
+}
+
+// handleQPACKError closes the session if the server sent an invalid instruction on a QPACK stream.
+func (c *client) handleQPACKError(err error) {
+	if qerr, ok := err.(*qpackError); ok {
+		c.session.CloseWithError(quic.ErrorCode(qerr.code), qerr.Error())
+	}
+}
+
+func (c *client) handleControlStream(str quic.ReceiveStream) {
+	f, err := parseNextFrame(str)
+	if err != nil {
+		c.session.CloseWithError(quic.ErrorCode(errorFrameError), "")
+		return
+	}
+	settings, ok := f.(*settingsFrame)
+	if !ok {
+		c.session.CloseWithError(quic.ErrorCode(errorMissingSettings), "")
+		return
+	}
+	c.settings = settings
+	c.encoder.setPeerSettings(settings.settings[settingQPACKMaxTableCapacity], settings.settings[settingQPACKBlockedStreams])
+	close(c.receivedSettings)
+	for {
+		f, err := parseNextFrame(str)
+		if err != nil {
+			c.logger.Debugf("Reading from the control stream failed: %s", err)
+			return
+		}
+		switch f := f.(type) {
+		case *goAwayFrame:
+			if err := c.handleGoAway(f.StreamID); err != nil {
+				c.session.CloseWithError(quic.ErrorCode(errorIDError), err.Error())
+				return
+			}
+		case *cancelPushFrame:
+			if err := c.handleCancelPush(f.PushID); err != nil {
+				c.session.CloseWithError(quic.ErrorCode(errorIDError), err.Error())
+				return
+			}
+		default:
+			c.session.CloseWithError(quic.ErrorCode(errorFrameUnexpected), "")
+			return
+		}
+	}
+}
+
+func (c *client) handleGoAway(id quic.StreamID) error {
+	if id%4 != 0 { // not a client-initiated bidirectional stream
+		return fmt.Errorf("invalid stream ID in GOAWAY frame: %d", id)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.receivedGoAway && id > c.goAwayID {
+		return fmt.Errorf("GOAWAY stream ID increased from %d to %d", c.goAwayID, id)
+	}
+	c.logger.Debugf("Received GOAWAY. Requests on streams >= %d will not be processed.", id)
+	c.receivedGoAway = true
+	c.goAwayID = id
+	c.draining = true
+	c.maybeCloseIdleSession()
 	return nil
 }
 
+func (c *client) startRequest() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.draining {
+		return false
+	}
+	c.activeRequests++
+	return true
+}
+
+func (c *client) requestDone() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.activeRequests--
+	c.maybeCloseIdleSession()
+}
+
+// maybeCloseIdleSession closes the session once all requests sent before the GOAWAY have completed.
+// It must be called with the mutex held.
+func (c *client) maybeCloseIdleSession() {
+	if c.draining && c.activeRequests == 0 {
+		c.session.CloseWithError(quic.ErrorCode(errorNoError), "")
+	}
+}
+
+// wasProcessed says if a request that failed with err might have been processed by the server.
+// If the server didn't process the request, we stop using this session for new requests.
+func (c *client) wasProcessed(str quic.Stream, err error) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if serr, ok := err.(quic.StreamError); ok && serr.Canceled() && serr.ErrorCode() == quic.ErrorCode(errorRequestRejected) {
+		c.draining = true
+		c.maybeCloseIdleSession()
+		return false
+	}
+	return !c.receivedGoAway || str.StreamID() < c.goAwayID
+}
+
+// canTakeNewRequest says if new requests can be sent using this client.
+// This is not the case if dialing failed, if the session was closed, or if the server sent a GOAWAY frame.
+func (c *client) canTakeNewRequest() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.dialFailed || c.draining {
+		return false
+	}
+	if c.session == nil { // not dialed yet
+		return true
+	}
+	select {
+	case <-c.session.Context().Done():
+		return false
+	default:
+		return true
+	}
+}
+
 func (c *client) Close() error {
-	if c.session == nil {
+	c.mutex.Lock()
+	sess := c.session
+	c.mutex.Unlock()
+	if sess == nil {
 		return nil
 	}
-	return c.session.CloseWithError(quic.ErrorCode(errorNoError), "")
+	return sess.CloseWithError(quic.ErrorCode(errorNoError), "")
 }
 
 func (c *client) maxHeaderBytes() uint64 {
@@ -149,29 +451,58 @@ func (c *client) RoundTrip(req *http.Request) (*http.Response, error) {
 		return nil, fmt.Errorf("http3 client BUG: RoundTrip called for the wrong client (expected %s, got %s)", c.hostname, req.Host)
 	}
 
-	c.dialOnce.Do(func() {
-		c.handshakeErr = c.dial()
-	})
-
-	if c.handshakeErr != nil {
-		return nil, c.handshakeErr
+	rsp, early, err := c.roundTrip(req, c.allowEarlyData(req))
+	if err != nil || !early || rsp.StatusCode != http.StatusTooEarly {
+		return rsp, err
+	}
+	// The server refused to process the request sent in 0-RTT.
+	// Retry it once the handshake has completed (see RFC 8470, Section 5.2).
+	rsp.Body.Close()
+	if req, err = rewindRequest(req); err != nil {
+		return nil, err
 	}
+	rsp, _, err = c.roundTrip(req, false)
+	return rsp, err
+}
 
-	// Immediately send out this request, if this is a 0-RTT request.
+// allowEarlyData says if a request may be sent in 0-RTT.
+// Since 0-RTT data can be replayed by an attacker, this is only the case for idempotent requests:
+// GET and HEAD requests, and requests carrying an Idempotency-Key header (like net/http).
+func (c *client) allowEarlyData(req *http.Request) bool {
 	if req.Method == MethodGet0RTT {
 		req.Method = http.MethodGet
-	} else {
-		// wait for the handshake to complete
-		select {
-		case <-c.session.HandshakeComplete().Done():
-		case <-req.Context().Done():
-			return nil, req.Context().Err()
-		}
+		return true
+	}
+	if c.opts.Disable0RTT {
+		return false
+	}
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead:
+		return true
+	}
+	if _, ok := req.Header["Idempotency-Key"]; ok {
+		return true
+	}
+	_, ok := req.Header["X-Idempotency-Key"]
+	return ok
+}
+
+// roundTrip sends a request and reads the response.
+// If allowEarly is set, the request is sent in 0-RTT if the handshake hasn't completed yet.
+// early says if the request was sent in 0-RTT.
+func (c *client) roundTrip(req *http.Request, allowEarly bool) (_ *http.Response, early bool, _ error) {
+	early, err := c.prepareRequest(req, allowEarly)
+	if err != nil {
+		return nil, false, err
 	}
 
 	str, err := c.session.OpenStreamSync(req.Context())
 	if err != nil {
-		return nil, err
+		c.requestDone()
+		return nil, early, err
+	}
+	if prio := req.Header.Get("Priority"); prio != "" {
+		str.SetPriority(parsePriority(prio))
 	}
 
 	// Request Cancellation:
@@ -185,6 +516,7 @@ func (c *client) RoundTrip(req *http.Request) (*http.Response, error) {
 			str.CancelRead(quic.ErrorCode(errorRequestCanceled))
 		case <-reqDone:
 		}
+		c.requestDone()
 	}()
 
 	rsp, rerr := c.doRequest(req, str, reqDone)
@@ -200,8 +532,116 @@ func (c *client) RoundTrip(req *http.Request) (*http.Response, error) {
 			}
 			c.session.CloseWithError(quic.ErrorCode(rerr.connErr), reason)
 		}
+		if !c.wasProcessed(str, rerr.err) {
+			return nil, early, errRequestNotProcessed
+		}
+	}
+	return rsp, early, rerr.err
+}
+
+// prepareRequest dials the session (if that didn't happen yet), and registers the request.
+// Unless the request may be sent in 0-RTT, it waits for the handshake to complete.
+// For Extended CONNECT requests, it also waits for the server's SETTINGS.
+func (c *client) prepareRequest(req *http.Request, allowEarly bool) (early bool, _ error) {
+	c.dialOnce.Do(func() {
+		c.handshakeErr = c.dial()
+	})
+
+	if c.handshakeErr != nil {
+		return false, c.handshakeErr
+	}
+
+	if !c.startRequest() {
+		return false, errRequestNotProcessed
+	}
+
+	if allowEarly {
+		select {
+		case <-c.session.HandshakeComplete().Done():
+			return false, nil
+		default:
+			// Immediately send out this request, using 0-RTT.
+			return true, nil
+		}
+	}
+	// wait for the handshake to complete
+	select {
+	case <-c.session.HandshakeComplete().Done():
+	case <-req.Context().Done():
+		c.requestDone()
+		return false, req.Context().Err()
+	}
+	if isExtendedConnect(req) {
+		if err := c.waitForExtendedConnect(req.Context()); err != nil {
+			c.requestDone()
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// waitForExtendedConnect waits for the server's SETTINGS,
+// and checks that the server supports Extended CONNECT.
+func (c *client) waitForExtendedConnect(ctx context.Context) error {
+	select {
+	case <-c.receivedSettings:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.session.Context().Done():
+		return errors.New("http3: session closed before receiving the server's SETTINGS")
+	}
+	if !c.settings.has(settingExtendedConnect) {
+		return errors.New("http3: server didn't enable Extended CONNECT")
+	}
+	return nil
+}
+
+// dialWebTransport sends an Extended CONNECT request, and establishes a WebTransport session if the server accepts it.
+func (c *client) dialWebTransport(req *http.Request) (*http.Response, *WebTransportSession, error) {
+	if !c.opts.EnableWebTransport {
+		return nil, nil, errors.New("http3: WebTransport not enabled")
+	}
+	if _, err := c.prepareRequest(req, false); err != nil {
+		return nil, nil, err
+	}
+	if !c.settings.has(settingEnableWebTransport) {
+		c.requestDone()
+		return nil, nil, errors.New("http3: server didn't enable WebTransport")
+	}
+
+	str, err := c.session.OpenStreamSync(req.Context())
+	if err != nil {
+		c.requestDone()
+		return nil, nil, err
+	}
+	if err := c.requestWriter.WriteRequest(str, req, false); err != nil {
+		str.CancelWrite(quic.ErrorCode(errorInternalError))
+		c.requestDone()
+		return nil, nil, err
+	}
+	res, rerr := c.readResponseHeaders(req.Context(), str, nil)
+	if rerr.err != nil {
+		if rerr.connErr != 0 {
+			c.session.CloseWithError(quic.ErrorCode(rerr.connErr), rerr.err.Error())
+		} else {
+			str.CancelWrite(quic.ErrorCode(rerr.streamErr))
+		}
+		c.requestDone()
+		return nil, nil, rerr.err
 	}
-	return rsp, rerr.err
+	res.Body = http.NoBody
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		str.CancelRead(quic.ErrorCode(errorNoError))
+		str.Close()
+		c.requestDone()
+		return res, nil, fmt.Errorf("http3: server rejected the WebTransport session with status %d", res.StatusCode)
+	}
+	sess := c.webTransport.addSession(str)
+	go func() {
+		<-sess.Context().Done()
+		c.requestDone()
+	}()
+	return res, sess, nil
 }
 
 func (c *client) doRequest(
@@ -217,9 +657,48 @@ func (c *client) doRequest(
 		return nil, newStreamError(errorInternalError, err)
 	}
 
-	frame, err := parseNextFrame(str)
-	if err != nil {
-		return nil, newStreamError(errorFrameError, err)
+	onPushPromise := func(f *pushPromiseFrame) error { return c.handlePushPromise(req.Context(), str, f) }
+	res, rerr := c.readResponseHeaders(req.Context(), str, onPushPromise)
+	if rerr.err != nil {
+		return nil, rerr
+	}
+	respBody := newResponseBody(req.Context(), str, c.decoder, reqDone, func() {
+		c.session.CloseWithError(quic.ErrorCode(errorFrameUnexpected), "")
+	})
+	respBody.onPushPromise = onPushPromise
+	respBody.maxTrailerBytes = c.maxHeaderBytes()
+	respBody.onTrailers = func(trailer http.Header) { setResponseTrailers(res, trailer) }
+	if requestGzip && res.Header.Get("Content-Encoding") == "gzip" {
+		res.Header.Del("Content-Encoding")
+		res.Header.Del("Content-Length")
+		res.ContentLength = -1
+		res.Body = newGzipReader(respBody)
+		res.Uncompressed = true
+	} else {
+		res.Body = respBody
+	}
+
+	return res, requestError{}
+}
+
+// readResponseHeaders reads the HEADERS frame of the response.
+// The body of the response is not set.
+// PUSH_PROMISE frames preceding the HEADERS frame are passed to onPushPromise, if set.
+func (c *client) readResponseHeaders(ctx context.Context, str quic.ReceiveStream, onPushPromise func(*pushPromiseFrame) error) (*http.Response, requestError) {
+	var frame frame
+	for {
+		var err error
+		frame, err = parseNextFrame(str)
+		if err != nil {
+			return nil, newStreamError(errorFrameError, err)
+		}
+		pf, ok := frame.(*pushPromiseFrame)
+		if !ok || onPushPromise == nil {
+			break
+		}
+		if err := onPushPromise(pf); err != nil {
+			return nil, newStreamError(errorFrameError, err)
+		}
 	}
 	hf, ok := frame.(*headersFrame)
 	if !ok {
@@ -232,10 +711,9 @@ func (c *client) doRequest(
 	if _, err := io.ReadFull(str, headerBlock); err != nil {
 		return nil, newStreamError(errorRequestIncomplete, err)
 	}
-	hfs, err := c.decoder.DecodeFull(headerBlock)
+	hfs, err := c.decoder.decode(ctx, str.StreamID(), headerBlock)
 	if err != nil {
-		// TODO: use the right error code
-		return nil, newConnError(errorGeneralProtocolError, err)
+		return nil, qpackRequestError(err)
 	}
 
 	res := &http.Response{
@@ -256,18 +734,6 @@ func (c *client) doRequest(
 			res.Header.Add(hf.Name, hf.Value)
 		}
 	}
-	respBody := newResponseBody(str, reqDone, func() {
-		c.session.CloseWithError(quic.ErrorCode(errorFrameUnexpected), "")
-	})
-	if requestGzip && res.Header.Get("Content-Encoding") == "gzip" {
-		res.Header.Del("Content-Encoding")
-		res.Header.Del("Content-Length")
-		res.ContentLength = -1
-		res.Body = newGzipReader(respBody)
-		res.Uncompressed = true
-	} else {
-		res.Body = respBody
-	}
-
+	res.Trailer = declaredTrailers(res.Header)
 	return res, requestError{}
 }
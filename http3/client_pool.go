@@ -0,0 +1,220 @@
+package http3
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// A pooledClient is a client in the RoundTripper's pool.
+// All fields are protected by the clientPool's mutex.
+type pooledClient struct {
+	roundTripCloser
+
+	activeRequests int
+	idleTimer      *time.Timer // set while the client is idle, if an idle timeout is configured
+	idleGen        uint64      // incremented every time the client becomes idle
+}
+
+// A clientPool holds the clients used by a RoundTripper, grouped by origin.
+// A new client is created for an origin once all existing clients have reached the maximum number of concurrent requests.
+type clientPool struct {
+	mutex   sync.Mutex
+	clients map[string][]*pooledClient
+
+	maxConcurrentStreams int
+	idleTimeout          time.Duration
+}
+
+// get returns a client for the origin that can take another request, and registers the request.
+// It returns nil if there's no such client.
+// Clients that can't take new requests any more are removed from the pool.
+// The caller must call release once the request has completed.
+func (p *clientPool) get(hostname string) *pooledClient {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.getLocked(hostname)
+}
+
+// getOrAdd is like get, but if there's no client that can take another request,
+// it creates a new client and adds it to the pool.
+// Looking up and adding the client happens atomically, such that concurrent requests
+// to the same origin don't create multiple clients (and connections).
+// newClient must not block, since it is called while holding the pool's mutex.
+func (p *clientPool) getOrAdd(hostname string, newClient func() roundTripCloser) *pooledClient {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if pc := p.getLocked(hostname); pc != nil {
+		return pc
+	}
+	if p.clients == nil {
+		p.clients = make(map[string][]*pooledClient)
+	}
+	pc := &pooledClient{roundTripCloser: newClient()}
+	p.clients[hostname] = append(p.clients[hostname], pc)
+	p.startRequestLocked(pc)
+	return pc
+}
+
+func (p *clientPool) getLocked(hostname string) *pooledClient {
+	p.removeUnusableLocked(hostname)
+	for _, pc := range p.clients[hostname] {
+		if p.maxConcurrentStreams <= 0 || pc.activeRequests < p.maxConcurrentStreams {
+			p.startRequestLocked(pc)
+			return pc
+		}
+	}
+	return nil
+}
+
+func (p *clientPool) startRequestLocked(pc *pooledClient) {
+	pc.activeRequests++
+	if pc.idleTimer != nil {
+		pc.idleTimer.Stop()
+		pc.idleTimer = nil
+	}
+}
+
+// release is called when a request has completed.
+// Once a client becomes idle, the idle timer is started.
+func (p *clientPool) release(hostname string, pc *pooledClient) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	pc.activeRequests--
+	if pc.activeRequests > 0 || !p.containsLocked(hostname, pc) {
+		return
+	}
+	if !pc.canTakeNewRequest() {
+		p.removeLocked(hostname, pc)
+		return
+	}
+	if p.idleTimeout > 0 {
+		pc.idleGen++
+		gen := pc.idleGen
+		pc.idleTimer = time.AfterFunc(p.idleTimeout, func() { p.closeIdle(hostname, pc, gen) })
+	}
+}
+
+// closeIdle closes a client when its idle timer fires.
+func (p *clientPool) closeIdle(hostname string, pc *pooledClient, gen uint64) {
+	p.mutex.Lock()
+	// The client might have been used again after the timer fired.
+	if pc.idleGen != gen || pc.activeRequests > 0 || !p.containsLocked(hostname, pc) {
+		p.mutex.Unlock()
+		return
+	}
+	p.removeLocked(hostname, pc)
+	p.mutex.Unlock()
+
+	pc.Close()
+}
+
+// remove removes a client from the pool, such that it isn't used for new requests.
+// It doesn't close the client.
+func (p *clientPool) remove(hostname string, pc *pooledClient) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.removeLocked(hostname, pc)
+}
+
+func (p *clientPool) removeUnusableLocked(hostname string) {
+	for _, pc := range p.clients[hostname] {
+		if !pc.canTakeNewRequest() {
+			// The client closes the session once its active requests have completed.
+			p.removeLocked(hostname, pc)
+		}
+	}
+}
+
+func (p *clientPool) containsLocked(hostname string, pc *pooledClient) bool {
+	for _, c := range p.clients[hostname] {
+		if c == pc {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *clientPool) removeLocked(hostname string, pc *pooledClient) {
+	if pc.idleTimer != nil {
+		pc.idleTimer.Stop()
+		pc.idleTimer = nil
+	}
+	clients := p.clients[hostname]
+	for i, c := range clients {
+		if c == pc {
+			clients = append(clients[:i:i], clients[i+1:]...)
+			break
+		}
+	}
+	if len(clients) == 0 {
+		delete(p.clients, hostname)
+	} else {
+		p.clients[hostname] = clients
+	}
+}
+
+// closeIdleClients closes all clients that don't have any active requests.
+func (p *clientPool) closeIdleClients() {
+	p.mutex.Lock()
+	var idle []*pooledClient
+	for hostname, clients := range p.clients {
+		for _, pc := range clients {
+			if pc.activeRequests == 0 {
+				idle = append(idle, pc)
+				p.removeLocked(hostname, pc)
+			}
+		}
+	}
+	p.mutex.Unlock()
+
+	for _, pc := range idle {
+		pc.Close()
+	}
+}
+
+// close closes all clients.
+func (p *clientPool) close() error {
+	p.mutex.Lock()
+	var clients []*pooledClient
+	for hostname, cls := range p.clients {
+		for _, pc := range cls {
+			clients = append(clients, pc)
+			p.removeLocked(hostname, pc)
+		}
+	}
+	p.mutex.Unlock()
+
+	var firstErr error
+	for _, pc := range clients {
+		if err := pc.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// A releasingBody releases the client once the response body was read or closed.
+type releasingBody struct {
+	io.ReadCloser
+
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.once.Do(b.release)
+	}
+	return n, err
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
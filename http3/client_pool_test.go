@@ -0,0 +1,174 @@
+package http3
+
+import (
+	"errors"
+	"io/ioutil"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// closeNotifyingClient closes a channel when Close is called, such that it can be closed from another go routine
+type closeNotifyingClient struct {
+	mockClient
+	closed chan struct{}
+}
+
+func (c *closeNotifyingClient) Close() error {
+	close(c.closed)
+	return nil
+}
+
+type mockReadCloser struct {
+	err    error
+	closed bool
+}
+
+func (m *mockReadCloser) Read([]byte) (int, error) { return 0, m.err }
+func (m *mockReadCloser) Close() error {
+	m.closed = true
+	return nil
+}
+
+var _ = Describe("Client Pool", func() {
+	var pool *clientPool
+
+	add := func(hostname string, cl roundTripCloser) *pooledClient {
+		return pool.getOrAdd(hostname, func() roundTripCloser { return cl })
+	}
+
+	BeforeEach(func() {
+		pool = &clientPool{}
+	})
+
+	It("returns nil if there are no clients", func() {
+		Expect(pool.get("foo.bar:443")).To(BeNil())
+	})
+
+	It("uses a single client per origin if there's no limit", func() {
+		cl := &mockClient{}
+		pc := add("foo.bar:443", cl)
+		for i := 0; i < 100; i++ {
+			Expect(pool.get("foo.bar:443")).To(Equal(pc))
+		}
+		Expect(pool.get("other.bar:443")).To(BeNil())
+	})
+
+	It("creates a single client for concurrent requests", func() {
+		var created int32
+		newClient := func() roundTripCloser {
+			atomic.AddInt32(&created, 1)
+			return &mockClient{}
+		}
+		var wg sync.WaitGroup
+		clients := make([]*pooledClient, 50)
+		for i := range clients {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				clients[i] = pool.getOrAdd("foo.bar:443", newClient)
+			}(i)
+		}
+		wg.Wait()
+		Expect(atomic.LoadInt32(&created)).To(BeEquivalentTo(1))
+		for _, pc := range clients {
+			Expect(pc).To(Equal(clients[0]))
+		}
+		Expect(clients[0].activeRequests).To(Equal(50))
+	})
+
+	It("limits the number of concurrent requests on a client", func() {
+		pool.maxConcurrentStreams = 2
+		pc := add("foo.bar:443", &mockClient{})
+		Expect(pool.get("foo.bar:443")).To(Equal(pc))
+		Expect(pool.get("foo.bar:443")).To(BeNil())
+		pc2 := add("foo.bar:443", &mockClient{})
+		Expect(pool.get("foo.bar:443")).To(Equal(pc2))
+		Expect(pool.get("foo.bar:443")).To(BeNil())
+		pool.release("foo.bar:443", pc)
+		Expect(pool.get("foo.bar:443")).To(Equal(pc))
+	})
+
+	It("removes clients that can't take new requests", func() {
+		cl := &mockClient{}
+		pc := add("foo.bar:443", cl)
+		cl.draining = true
+		Expect(pool.get("foo.bar:443")).To(BeNil())
+		Expect(pool.clients).To(BeEmpty())
+		// the client is not closed, it closes its connection once the active requests have completed
+		Expect(cl.closed).To(BeFalse())
+		pool.release("foo.bar:443", pc)
+		Expect(cl.closed).To(BeFalse())
+	})
+
+	It("removes clients that can't take new requests once they become idle", func() {
+		cl := &mockClient{}
+		pc := add("foo.bar:443", cl)
+		cl.draining = true
+		pool.release("foo.bar:443", pc)
+		Expect(pool.clients).To(BeEmpty())
+	})
+
+	It("closes idle clients after the idle timeout", func() {
+		pool.idleTimeout = 50 * time.Millisecond
+		cl := &closeNotifyingClient{closed: make(chan struct{})}
+		pc := add("foo.bar:443", cl)
+		pool.release("foo.bar:443", pc)
+		Eventually(cl.closed).Should(BeClosed())
+		pool.mutex.Lock()
+		defer pool.mutex.Unlock()
+		Expect(pool.clients).To(BeEmpty())
+	})
+
+	It("doesn't close clients that are used again before the idle timeout", func() {
+		pool.idleTimeout = 50 * time.Millisecond
+		cl := &mockClient{}
+		pc := add("foo.bar:443", cl)
+		pool.release("foo.bar:443", pc)
+		Expect(pool.get("foo.bar:443")).To(Equal(pc))
+		Consistently(func() bool {
+			pool.mutex.Lock()
+			defer pool.mutex.Unlock()
+			return len(pool.clients) == 1
+		}, 100*time.Millisecond).Should(BeTrue())
+		Expect(cl.closed).To(BeFalse())
+	})
+
+	It("closes all clients", func() {
+		cl1 := &mockClient{}
+		cl2 := &mockClient{}
+		add("foo.bar:443", cl1)
+		add("other.bar:443", cl2)
+		Expect(pool.close()).To(Succeed())
+		Expect(cl1.closed).To(BeTrue())
+		Expect(cl2.closed).To(BeTrue())
+		Expect(pool.clients).To(BeEmpty())
+	})
+
+	Context("response bodies", func() {
+		It("releases the client when the body is read completely", func() {
+			var released int
+			b := &releasingBody{
+				ReadCloser: ioutil.NopCloser(&mockReadCloser{err: errors.New("EOF")}),
+				release:    func() { released++ },
+			}
+			_, err := b.Read(make([]byte, 10))
+			Expect(err).To(MatchError("EOF"))
+			Expect(released).To(Equal(1))
+			Expect(b.Close()).To(Succeed())
+			Expect(released).To(Equal(1))
+		})
+
+		It("releases the client when the body is closed", func() {
+			var released int
+			rc := &mockReadCloser{}
+			b := &releasingBody{ReadCloser: rc, release: func() { released++ }}
+			Expect(b.Close()).To(Succeed())
+			Expect(rc.closed).To(BeTrue())
+			Expect(released).To(Equal(1))
+		})
+	})
+})
@@ -48,12 +48,29 @@ var _ = Describe("Client", func() {
 		dialAddr = origDialAddr
 	})
 
+	It("enables datagrams and incoming streams for WebTransport", func() {
+		quicConf := &quic.Config{MaxIdleTimeout: time.Nanosecond}
+		client = newClient("localhost:1337", nil, &roundTripperOpts{EnableWebTransport: true}, quicConf, nil)
+		Expect(client.config.EnableDatagrams).To(BeTrue())
+		Expect(client.config.MaxIncomingStreams).To(BeZero())
+		Expect(client.config.MaxIdleTimeout).To(Equal(time.Nanosecond))
+		// the original config is not modified
+		Expect(quicConf.EnableDatagrams).To(BeFalse())
+	})
+
+	It("refuses to dial WebTransport sessions if WebTransport is not enabled", func() {
+		req.Method = http.MethodConnect
+		req.Proto = protocolWebTransport
+		_, _, err := client.dialWebTransport(req)
+		Expect(err).To(MatchError("http3: WebTransport not enabled"))
+	})
+
 	It("uses the default QUIC and TLS config if none is give", func() {
 		client = newClient("localhost:1337", nil, &roundTripperOpts{}, nil, nil)
 		var dialAddrCalled bool
 		dialAddr = func(_ string, tlsConf *tls.Config, quicConf *quic.Config) (quic.EarlySession, error) {
 			Expect(quicConf).To(Equal(defaultQuicConfig))
-			Expect(tlsConf.NextProtos).To(Equal([]string{nextProtoH3}))
+			Expect(tlsConf.NextProtos).To(Equal([]string{NextProtoH3, NextProtoH3Draft29}))
 			dialAddrCalled = true
 			return nil, errors.New("test done")
 		}
@@ -90,7 +107,7 @@ var _ = Describe("Client", func() {
 		) (quic.EarlySession, error) {
 			Expect(hostname).To(Equal("localhost:1337"))
 			Expect(tlsConfP.ServerName).To(Equal(tlsConf.ServerName))
-			Expect(tlsConfP.NextProtos).To(Equal([]string{nextProtoH3}))
+			Expect(tlsConfP.NextProtos).To(Equal([]string{NextProtoH3, NextProtoH3Draft29}))
 			Expect(quicConfP.MaxIdleTimeout).To(Equal(quicConf.MaxIdleTimeout))
 			dialAddrCalled = true
 			return nil, errors.New("test done")
@@ -152,6 +169,101 @@ var _ = Describe("Client", func() {
 		Expect(err).ToNot(HaveOccurred())
 	})
 
+	Context("control stream", func() {
+		var (
+			sess       *mockquic.MockEarlySession
+			controlStr *mockquic.MockStream
+			buf        *bytes.Buffer
+		)
+
+		BeforeEach(func() {
+			sess = mockquic.NewMockEarlySession(mockCtrl)
+			client.session = sess
+			buf = &bytes.Buffer{}
+			controlStr = mockquic.NewMockStream(mockCtrl)
+			controlStr.EXPECT().Read(gomock.Any()).DoAndReturn(buf.Read).AnyTimes()
+		})
+
+		It("errors if the first frame is not a SETTINGS frame", func() {
+			(&goAwayFrame{}).Write(buf)
+			sess.EXPECT().CloseWithError(quic.ErrorCode(errorMissingSettings), gomock.Any())
+			client.handleControlStream(controlStr)
+		})
+
+		It("handles GOAWAY frames", func() {
+			(&settingsFrame{}).Write(buf)
+			(&goAwayFrame{StreamID: 8}).Write(buf)
+			(&goAwayFrame{StreamID: 4}).Write(buf)
+			client.activeRequests = 1
+			client.handleControlStream(controlStr)
+			Expect(client.receivedGoAway).To(BeTrue())
+			Expect(client.goAwayID).To(Equal(quic.StreamID(4)))
+			Expect(client.startRequest()).To(BeFalse())
+			// close the session once the last request completes
+			sess.EXPECT().CloseWithError(quic.ErrorCode(errorNoError), "")
+			client.requestDone()
+		})
+
+		It("closes the session right away if there are no active requests", func() {
+			(&settingsFrame{}).Write(buf)
+			(&goAwayFrame{StreamID: 8}).Write(buf)
+			sess.EXPECT().CloseWithError(quic.ErrorCode(errorNoError), "")
+			client.handleControlStream(controlStr)
+		})
+
+		It("errors if the stream ID in a GOAWAY frame increases", func() {
+			(&settingsFrame{}).Write(buf)
+			(&goAwayFrame{StreamID: 4}).Write(buf)
+			(&goAwayFrame{StreamID: 8}).Write(buf)
+			client.activeRequests = 1
+			sess.EXPECT().CloseWithError(quic.ErrorCode(errorIDError), "GOAWAY stream ID increased from 4 to 8")
+			client.handleControlStream(controlStr)
+		})
+
+		It("errors if the GOAWAY frame contains an invalid stream ID", func() {
+			(&settingsFrame{}).Write(buf)
+			(&goAwayFrame{StreamID: 5}).Write(buf)
+			sess.EXPECT().CloseWithError(quic.ErrorCode(errorIDError), "invalid stream ID in GOAWAY frame: 5")
+			client.handleControlStream(controlStr)
+		})
+
+		It("errors on unexpected frames", func() {
+			(&settingsFrame{}).Write(buf)
+			(&dataFrame{}).Write(buf)
+			sess.EXPECT().CloseWithError(quic.ErrorCode(errorFrameUnexpected), gomock.Any())
+			client.handleControlStream(controlStr)
+		})
+
+		It("stores the server's SETTINGS", func() {
+			(&settingsFrame{settings: map[uint64]uint64{settingExtendedConnect: 1}}).Write(buf)
+			client.handleControlStream(controlStr)
+			Expect(client.receivedSettings).To(BeClosed())
+			Expect(client.waitForExtendedConnect(context.Background())).To(Succeed())
+		})
+
+		It("refuses Extended CONNECT requests if the server didn't enable them", func() {
+			(&settingsFrame{}).Write(buf)
+			client.handleControlStream(controlStr)
+			Expect(client.waitForExtendedConnect(context.Background())).To(MatchError("http3: server didn't enable Extended CONNECT"))
+		})
+
+		It("waits for the server's SETTINGS before sending Extended CONNECT requests", func() {
+			sess.EXPECT().Context().Return(context.Background()).AnyTimes()
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			Expect(client.waitForExtendedConnect(ctx)).To(MatchError(context.DeadlineExceeded))
+		})
+
+		It("says if a request was processed", func() {
+			client.activeRequests = 1
+			str := mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().StreamID().Return(quic.StreamID(8)).AnyTimes()
+			Expect(client.wasProcessed(str, errors.New("foobar"))).To(BeTrue())
+			Expect(client.handleGoAway(8)).To(Succeed())
+			Expect(client.wasProcessed(str, errors.New("foobar"))).To(BeFalse())
+		})
+	})
+
 	Context("Doing requests", func() {
 		var (
 			request *http.Request
@@ -183,8 +295,10 @@ var _ = Describe("Client", func() {
 			controlStr.EXPECT().Write([]byte{0x0}).Return(1, nil).MaxTimes(1)
 			controlStr.EXPECT().Write(gomock.Any()).MaxTimes(1) // SETTINGS frame
 			str = mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().StreamID().AnyTimes()
 			sess = mockquic.NewMockEarlySession(mockCtrl)
 			sess.EXPECT().OpenUniStream().Return(controlStr, nil).MaxTimes(1)
+			sess.EXPECT().AcceptUniStream(gomock.Any()).Return(nil, errors.New("done")).MaxTimes(1)
 			dialAddr = func(hostname string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) {
 				return sess, nil
 			}
@@ -196,7 +310,8 @@ var _ = Describe("Client", func() {
 		It("performs a 0-RTT request", func() {
 			testErr := errors.New("stream open error")
 			request.Method = MethodGet0RTT
-			// don't EXPECT any calls to HandshakeComplete()
+			client.opts.Disable0RTT = true
+			sess.EXPECT().HandshakeComplete().Return(context.Background()) // the handshake hasn't completed yet
 			sess.EXPECT().OpenStreamSync(context.Background()).Return(str, nil)
 			buf := &bytes.Buffer{}
 			str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
@@ -212,9 +327,90 @@ var _ = Describe("Client", func() {
 			Expect(decodeHeader(buf)).To(HaveKeyWithValue(":method", "GET"))
 		})
 
+		Context("0-RTT", func() {
+			BeforeEach(func() {
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) { return len(p), nil }).AnyTimes()
+				str.EXPECT().Close().AnyTimes()
+			})
+
+			respond := func(str *mockquic.MockStream, status int) {
+				buf := &bytes.Buffer{}
+				rw := newResponseWriter(buf, 0, newQPACKEncoder(0, nil, utils.DefaultLogger), utils.DefaultLogger)
+				rw.WriteHeader(status)
+				rw.Flush()
+				str.EXPECT().Read(gomock.Any()).DoAndReturn(buf.Read).AnyTimes()
+			}
+
+			It("sends GET requests in 0-RTT", func() {
+				respond(str, 200)
+				gomock.InOrder(
+					sess.EXPECT().HandshakeComplete().Return(context.Background()), // the handshake hasn't completed yet
+					sess.EXPECT().OpenStreamSync(context.Background()).Return(str, nil),
+				)
+				rsp, err := client.RoundTrip(request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rsp.StatusCode).To(Equal(200))
+			})
+
+			It("sends requests with an Idempotency-Key in 0-RTT", func() {
+				respond(str, 200)
+				request.Method = http.MethodPost
+				request.Header.Set("Idempotency-Key", "foobar")
+				gomock.InOrder(
+					sess.EXPECT().HandshakeComplete().Return(context.Background()), // the handshake hasn't completed yet
+					sess.EXPECT().OpenStreamSync(context.Background()).Return(str, nil),
+				)
+				rsp, err := client.RoundTrip(request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rsp.StatusCode).To(Equal(200))
+			})
+
+			It("doesn't send other requests in 0-RTT", func() {
+				Expect(client.allowEarlyData(&http.Request{Method: http.MethodPost, Header: http.Header{}})).To(BeFalse())
+				Expect(client.allowEarlyData(&http.Request{Method: http.MethodPut, Header: http.Header{}})).To(BeFalse())
+				Expect(client.allowEarlyData(&http.Request{Method: http.MethodGet, Header: http.Header{}})).To(BeTrue())
+				Expect(client.allowEarlyData(&http.Request{Method: http.MethodHead, Header: http.Header{}})).To(BeTrue())
+			})
+
+			It("doesn't send requests in 0-RTT if 0-RTT is disabled", func() {
+				client.opts.Disable0RTT = true
+				Expect(client.allowEarlyData(&http.Request{Method: http.MethodGet, Header: http.Header{}})).To(BeFalse())
+			})
+
+			It("retries requests after the handshake completed if the server responds with 425", func() {
+				respond(str, http.StatusTooEarly)
+				str2 := mockquic.NewMockStream(mockCtrl)
+				str2.EXPECT().StreamID().AnyTimes()
+				str2.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) { return len(p), nil }).AnyTimes()
+				str2.EXPECT().Close()
+				respond(str2, 200)
+				gomock.InOrder(
+					sess.EXPECT().HandshakeComplete().Return(context.Background()), // the handshake hasn't completed yet
+					sess.EXPECT().OpenStreamSync(context.Background()).Return(str, nil),
+					sess.EXPECT().HandshakeComplete().Return(handshakeCtx),
+					sess.EXPECT().OpenStreamSync(context.Background()).Return(str2, nil),
+				)
+				str.EXPECT().CancelRead(gomock.Any()).AnyTimes() // when closing the body of the 425 response
+				rsp, err := client.RoundTrip(request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rsp.StatusCode).To(Equal(200))
+			})
+
+			It("doesn't retry requests that were not sent in 0-RTT", func() {
+				respond(str, http.StatusTooEarly)
+				gomock.InOrder(
+					sess.EXPECT().HandshakeComplete().Return(handshakeCtx),
+					sess.EXPECT().OpenStreamSync(context.Background()).Return(str, nil),
+				)
+				rsp, err := client.RoundTrip(request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rsp.StatusCode).To(Equal(http.StatusTooEarly))
+			})
+		})
+
 		It("returns a response", func() {
 			rspBuf := &bytes.Buffer{}
-			rw := newResponseWriter(rspBuf, utils.DefaultLogger)
+			rw := newResponseWriter(rspBuf, 0, newQPACKEncoder(0, nil, utils.DefaultLogger), utils.DefaultLogger)
 			rw.WriteHeader(418)
 			rw.Flush()
 
@@ -234,6 +430,36 @@ var _ = Describe("Client", func() {
 			Expect(rsp.StatusCode).To(Equal(418))
 		})
 
+		It("sets the trailers in the Trailer map of the response", func() {
+			rspBuf := &bytes.Buffer{}
+			rw := newResponseWriter(rspBuf, 0, newQPACKEncoder(0, nil, utils.DefaultLogger), utils.DefaultLogger)
+			rw.Header().Set("Trailer", "Grpc-Status")
+			rw.WriteHeader(http.StatusOK)
+			rw.Write([]byte("foobar"))
+			rw.Header().Set("Grpc-Status", "0")
+			rw.writeTrailers()
+			rw.Flush()
+
+			gomock.InOrder(
+				sess.EXPECT().HandshakeComplete().Return(handshakeCtx),
+				sess.EXPECT().OpenStreamSync(context.Background()).Return(str, nil),
+			)
+			str.EXPECT().Write(gomock.Any()).AnyTimes().DoAndReturn(func(p []byte) (int, error) { return len(p), nil })
+			str.EXPECT().Close()
+			str.EXPECT().Read(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+				return rspBuf.Read(p)
+			}).AnyTimes()
+			rsp, err := client.RoundTrip(request)
+			Expect(err).ToNot(HaveOccurred())
+			// the application may hold a reference to the map before reading the body
+			trailer := rsp.Trailer
+			Expect(trailer).To(Equal(http.Header{"Grpc-Status": nil}))
+			data, err := ioutil.ReadAll(rsp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal([]byte("foobar")))
+			Expect(trailer).To(Equal(http.Header{"Grpc-Status": []string{"0"}}))
+		})
+
 		Context("validating the address", func() {
 			It("refuses to do requests for the wrong host", func() {
 				req, err := http.NewRequest("https", "https://quic.clemente.io:1336/foobar.html", nil)
@@ -339,6 +565,7 @@ var _ = Describe("Client", func() {
 			It("cancels a request while waiting for the handshake to complete", func() {
 				ctx, cancel := context.WithCancel(context.Background())
 				req := request.WithContext(ctx)
+				req.Method = http.MethodPost // POST requests are not sent in 0-RTT
 				sess.EXPECT().HandshakeComplete().Return(context.Background())
 
 				errChan := make(chan error)
@@ -382,7 +609,7 @@ var _ = Describe("Client", func() {
 
 			It("cancels a request after the response arrived", func() {
 				rspBuf := &bytes.Buffer{}
-				rw := newResponseWriter(rspBuf, utils.DefaultLogger)
+				rw := newResponseWriter(rspBuf, 0, newQPACKEncoder(0, nil, utils.DefaultLogger), utils.DefaultLogger)
 				rw.WriteHeader(418)
 				rw.Flush()
 
@@ -452,7 +679,7 @@ var _ = Describe("Client", func() {
 			It("decompresses the response", func() {
 				sess.EXPECT().OpenStreamSync(context.Background()).Return(str, nil)
 				buf := &bytes.Buffer{}
-				rw := newResponseWriter(buf, utils.DefaultLogger)
+				rw := newResponseWriter(buf, 0, newQPACKEncoder(0, nil, utils.DefaultLogger), utils.DefaultLogger)
 				rw.Header().Set("Content-Encoding", "gzip")
 				gz := gzip.NewWriter(rw)
 				gz.Write([]byte("gzipped response"))
@@ -477,7 +704,7 @@ var _ = Describe("Client", func() {
 			It("only decompresses the response if the response contains the right content-encoding header", func() {
 				sess.EXPECT().OpenStreamSync(context.Background()).Return(str, nil)
 				buf := &bytes.Buffer{}
-				rw := newResponseWriter(buf, utils.DefaultLogger)
+				rw := newResponseWriter(buf, 0, newQPACKEncoder(0, nil, utils.DefaultLogger), utils.DefaultLogger)
 				rw.Write([]byte("not gzipped"))
 				rw.Flush()
 				str.EXPECT().Write(gomock.Any()).AnyTimes().DoAndReturn(func(p []byte) (int, error) { return len(p), nil })
@@ -23,8 +23,16 @@ const (
 	errorRequestRejected      errorCode = 0x10b
 	errorRequestCanceled      errorCode = 0x10c
 	errorRequestIncomplete    errorCode = 0x10d
+	errorMessageError         errorCode = 0x10e
 	errorConnectError         errorCode = 0x10f
 	errorVersionFallback      errorCode = 0x110
+	errorDatagramError        errorCode = 0x33
+
+	errorQPACKDecompressionFailed errorCode = 0x200
+	errorQPACKEncoderStreamError  errorCode = 0x201
+	errorQPACKDecoderStreamError  errorCode = 0x202
+
+	errorWebTransportBufferedStreamRejected errorCode = 0x3994bd84
 )
 
 func (e errorCode) String() string {
@@ -57,10 +65,22 @@ func (e errorCode) String() string {
 		return "H3_REQUEST_CANCELLED"
 	case errorRequestIncomplete:
 		return "H3_INCOMPLETE_REQUEST"
+	case errorMessageError:
+		return "H3_MESSAGE_ERROR"
 	case errorConnectError:
 		return "H3_CONNECT_ERROR"
 	case errorVersionFallback:
 		return "H3_VERSION_FALLBACK"
+	case errorDatagramError:
+		return "H3_DATAGRAM_ERROR"
+	case errorQPACKDecompressionFailed:
+		return "QPACK_DECOMPRESSION_FAILED"
+	case errorQPACKEncoderStreamError:
+		return "QPACK_ENCODER_STREAM_ERROR"
+	case errorQPACKDecoderStreamError:
+		return "QPACK_DECODER_STREAM_ERROR"
+	case errorWebTransportBufferedStreamRejected:
+		return "WEBTRANSPORT_BUFFERED_STREAM_REJECTED"
 	default:
 		return fmt.Sprintf("unknown error code: %#x", uint16(e))
 	}
@@ -2,14 +2,41 @@ package http3
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 
+	"github.com/lucas-clemente/quic-go"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 )
 
+// unidirectional stream types
+const (
+	streamTypeControlStream      = 0
+	streamTypePushStream         = 1
+	streamTypeQPACKEncoderStream = 2
+	streamTypeQPACKDecoderStream = 3
+	// streamTypeWebTransportStream is the type of unidirectional WebTransport streams.
+	// It is followed by the session ID.
+	streamTypeWebTransportStream = 0x54
+)
+
+// settings
+const (
+	// settingQPACKMaxTableCapacity is SETTINGS_QPACK_MAX_TABLE_CAPACITY, see RFC 9204, section 5.
+	settingQPACKMaxTableCapacity = 0x1
+	// settingQPACKBlockedStreams is SETTINGS_QPACK_BLOCKED_STREAMS, see RFC 9204, section 5.
+	settingQPACKBlockedStreams = 0x7
+	// settingExtendedConnect is SETTINGS_ENABLE_CONNECT_PROTOCOL, see RFC 8441, section 3.
+	settingExtendedConnect = 0x8
+	// settingDatagram is SETTINGS_H3_DATAGRAM, see RFC 9297, section 2.1.1.
+	settingDatagram = 0x33
+	// settingEnableWebTransport is SETTINGS_ENABLE_WEBTRANSPORT, see draft-ietf-webtrans-http3-02.
+	settingEnableWebTransport = 0x2b603742
+)
+
 type byteReader interface {
 	io.ByteReader
 	io.Reader
@@ -48,14 +75,26 @@ func parseNextFrame(b io.Reader) (frame, error) {
 		return &headersFrame{Length: l}, nil
 	case 0x4:
 		return parseSettingsFrame(br, l)
-	case 0x3: // CANCEL_PUSH
-		fallthrough
-	case 0x5: // PUSH_PROMISE
-		fallthrough
-	case 0x7: // GOAWAY
-		fallthrough
-	case 0xd: // MAX_PUSH_ID
-		fallthrough
+	case 0x7:
+		return parseGoAwayFrame(br, l)
+	case 0x3:
+		id, err := parseSingleVarIntFrame(br, l, "CANCEL_PUSH")
+		if err != nil {
+			return nil, err
+		}
+		return &cancelPushFrame{PushID: id}, nil
+	case 0x5:
+		return parsePushPromiseFrame(br, l)
+	case 0xd:
+		id, err := parseSingleVarIntFrame(br, l, "MAX_PUSH_ID")
+		if err != nil {
+			return nil, err
+		}
+		return &maxPushIDFrame{PushID: id}, nil
+	case 0x41:
+		// The signal value for bidirectional WebTransport streams isn't followed by a length,
+		// but by the session ID. All the following data belongs to the WebTransport stream.
+		return &webTransportFrame{SessionID: quic.StreamID(l)}, nil
 	case 0xe: // DUPLICATE_PUSH
 		fallthrough
 	default:
@@ -131,3 +170,100 @@ func (f *settingsFrame) Write(b *bytes.Buffer) {
 		utils.WriteVarInt(b, val)
 	}
 }
+
+// has checks if a setting was sent, and if it was set to 1.
+func (f *settingsFrame) has(id uint64) bool {
+	return f.settings[id] == 1
+}
+
+// A goAwayFrame is a GOAWAY frame.
+// When sent by the server, StreamID is the first (client-initiated bidirectional) stream that won't be processed.
+type goAwayFrame struct {
+	StreamID quic.StreamID
+}
+
+func parseGoAwayFrame(r byteReader, l uint64) (*goAwayFrame, error) {
+	id, err := parseSingleVarIntFrame(r, l, "GOAWAY")
+	if err != nil {
+		return nil, err
+	}
+	return &goAwayFrame{StreamID: quic.StreamID(id)}, nil
+}
+
+func (f *goAwayFrame) Write(b *bytes.Buffer) {
+	writeSingleVarIntFrame(b, 0x7, uint64(f.StreamID))
+}
+
+// parseSingleVarIntFrame parses the payload of a frame that consists of a single variable-length integer.
+func parseSingleVarIntFrame(r byteReader, l uint64, name string) (uint64, error) {
+	val, err := utils.ReadVarInt(r)
+	if err != nil {
+		return 0, err
+	}
+	if uint64(utils.VarIntLen(val)) != l {
+		return 0, fmt.Errorf("inconsistent length for %s frame", name)
+	}
+	return val, nil
+}
+
+func writeSingleVarIntFrame(b *bytes.Buffer, t, val uint64) {
+	utils.WriteVarInt(b, t)
+	utils.WriteVarInt(b, uint64(utils.VarIntLen(val)))
+	utils.WriteVarInt(b, val)
+}
+
+// A cancelPushFrame is a CANCEL_PUSH frame.
+type cancelPushFrame struct {
+	PushID uint64
+}
+
+func (f *cancelPushFrame) Write(b *bytes.Buffer) {
+	writeSingleVarIntFrame(b, 0x3, f.PushID)
+}
+
+// A maxPushIDFrame is a MAX_PUSH_ID frame.
+// PushID is the maximum push ID that the server can use.
+type maxPushIDFrame struct {
+	PushID uint64
+}
+
+func (f *maxPushIDFrame) Write(b *bytes.Buffer) {
+	writeSingleVarIntFrame(b, 0xd, f.PushID)
+}
+
+// A pushPromiseFrame is a PUSH_PROMISE frame.
+// Like for the headersFrame, only the length of the encoded field section is parsed.
+// The field section follows the frame header.
+type pushPromiseFrame struct {
+	PushID uint64
+	Length uint64 // the length of the encoded field section
+}
+
+func parsePushPromiseFrame(r byteReader, l uint64) (*pushPromiseFrame, error) {
+	id, err := utils.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	idLen := uint64(utils.VarIntLen(id))
+	if idLen > l {
+		return nil, errors.New("inconsistent length for PUSH_PROMISE frame")
+	}
+	return &pushPromiseFrame{PushID: id, Length: l - idLen}, nil
+}
+
+func (f *pushPromiseFrame) Write(b *bytes.Buffer) {
+	utils.WriteVarInt(b, 0x5)
+	utils.WriteVarInt(b, uint64(utils.VarIntLen(f.PushID))+f.Length)
+	utils.WriteVarInt(b, f.PushID)
+}
+
+// A webTransportFrame is the signal value at the beginning of a bidirectional WebTransport stream.
+// It is not a real frame: it is followed by the session ID and the application data.
+type webTransportFrame struct {
+	SessionID quic.StreamID
+}
+
+func (f *webTransportFrame) Write(b *bytes.Buffer) {
+	utils.WriteVarInt(b, 0x41)
+	utils.WriteVarInt(b, uint64(f.SessionID))
+}
@@ -113,6 +113,17 @@ var _ = Describe("Frames", func() {
 			Expect(frame).To(Equal(sf))
 		})
 
+		It("says if a setting is enabled", func() {
+			sf := &settingsFrame{settings: map[uint64]uint64{
+				settingExtendedConnect: 1,
+				settingDatagram:        0,
+			}}
+			Expect(sf.has(settingExtendedConnect)).To(BeTrue())
+			Expect(sf.has(settingDatagram)).To(BeFalse())
+			Expect(sf.has(settingEnableWebTransport)).To(BeFalse())
+			Expect((&settingsFrame{}).has(settingExtendedConnect)).To(BeFalse())
+		})
+
 		It("errors on EOF", func() {
 			sf := &settingsFrame{settings: map[uint64]uint64{
 				13:         37,
@@ -133,4 +144,119 @@ var _ = Describe("Frames", func() {
 			}
 		})
 	})
+
+	Context("GOAWAY frames", func() {
+		It("parses", func() {
+			data := appendVarInt(nil, 7) // type byte
+			data = appendVarInt(data, uint64(utils.VarIntLen(0x1337)))
+			data = appendVarInt(data, 0x1337)
+			frame, err := parseNextFrame(bytes.NewReader(data))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&goAwayFrame{StreamID: 0x1337}))
+		})
+
+		It("errors on inconsistent lengths", func() {
+			data := appendVarInt(nil, 7) // type byte
+			data = appendVarInt(data, 8)
+			data = appendVarInt(data, 0x1337)
+			_, err := parseNextFrame(bytes.NewReader(data))
+			Expect(err).To(MatchError("inconsistent length for GOAWAY frame"))
+		})
+
+		It("writes", func() {
+			buf := &bytes.Buffer{}
+			(&goAwayFrame{StreamID: 0xdeadbeef}).Write(buf)
+			frame, err := parseNextFrame(buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&goAwayFrame{StreamID: 0xdeadbeef}))
+		})
+	})
+
+	Context("CANCEL_PUSH frames", func() {
+		It("parses", func() {
+			data := appendVarInt(nil, 3) // type byte
+			data = appendVarInt(data, uint64(utils.VarIntLen(0x42)))
+			data = appendVarInt(data, 0x42)
+			frame, err := parseNextFrame(bytes.NewReader(data))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&cancelPushFrame{PushID: 0x42}))
+		})
+
+		It("errors on inconsistent lengths", func() {
+			data := appendVarInt(nil, 3) // type byte
+			data = appendVarInt(data, 4)
+			data = appendVarInt(data, 0x42)
+			_, err := parseNextFrame(bytes.NewReader(data))
+			Expect(err).To(MatchError("inconsistent length for CANCEL_PUSH frame"))
+		})
+
+		It("writes", func() {
+			buf := &bytes.Buffer{}
+			(&cancelPushFrame{PushID: 0x1337}).Write(buf)
+			frame, err := parseNextFrame(buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&cancelPushFrame{PushID: 0x1337}))
+		})
+	})
+
+	Context("MAX_PUSH_ID frames", func() {
+		It("parses", func() {
+			data := appendVarInt(nil, 0xd) // type byte
+			data = appendVarInt(data, uint64(utils.VarIntLen(0x1337)))
+			data = appendVarInt(data, 0x1337)
+			frame, err := parseNextFrame(bytes.NewReader(data))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&maxPushIDFrame{PushID: 0x1337}))
+		})
+
+		It("writes", func() {
+			buf := &bytes.Buffer{}
+			(&maxPushIDFrame{PushID: 100}).Write(buf)
+			frame, err := parseNextFrame(buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&maxPushIDFrame{PushID: 100}))
+		})
+	})
+
+	Context("PUSH_PROMISE frames", func() {
+		It("parses, and doesn't consume the field section", func() {
+			data := appendVarInt(nil, 5) // type byte
+			data = appendVarInt(data, uint64(utils.VarIntLen(0x1337))+6)
+			data = appendVarInt(data, 0x1337)
+			data = append(data, []byte("foobar")...)
+			r := bytes.NewReader(data)
+			frame, err := parseNextFrame(r)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&pushPromiseFrame{PushID: 0x1337, Length: 6}))
+			Expect(r.Len()).To(Equal(6))
+		})
+
+		It("errors on inconsistent lengths", func() {
+			data := appendVarInt(nil, 5) // type byte
+			data = appendVarInt(data, 1)
+			data = appendVarInt(data, 0x1337)
+			_, err := parseNextFrame(bytes.NewReader(data))
+			Expect(err).To(MatchError("inconsistent length for PUSH_PROMISE frame"))
+		})
+
+		It("writes", func() {
+			buf := &bytes.Buffer{}
+			(&pushPromiseFrame{PushID: 0xdead, Length: 0x42}).Write(buf)
+			frame, err := parseNextFrame(buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&pushPromiseFrame{PushID: 0xdead, Length: 0x42}))
+		})
+	})
+
+	Context("WebTransport stream signals", func() {
+		It("parses the session ID, and doesn't consume the stream data", func() {
+			buf := &bytes.Buffer{}
+			(&webTransportFrame{SessionID: 0x1337}).Write(buf)
+			buf.Write([]byte("foobar"))
+			frame, err := parseNextFrame(buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&webTransportFrame{SessionID: 0x1337}))
+			Expect(buf.String()).To(Equal("foobar"))
+		})
+	})
 })
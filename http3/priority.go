@@ -0,0 +1,45 @@
+package http3
+
+import (
+	"strconv"
+	"strings"
+
+	quic "github.com/lucas-clemente/quic-go"
+)
+
+// defaultUrgency is the urgency used by the Extensible Prioritization Scheme for HTTP
+// if the Priority header field doesn't contain an urgency.
+const defaultUrgency = 3
+
+// parsePriority parses the value of a Priority header field.
+// The value is a Structured Fields Dictionary, e.g. "u=1, i".
+// Unknown members and invalid values are ignored.
+func parsePriority(value string) quic.StreamPriority {
+	p := quic.StreamPriority{Urgency: defaultUrgency}
+	for _, member := range strings.Split(value, ",") {
+		// parameters of a dictionary member are irrelevant for us
+		if i := strings.IndexByte(member, ';'); i >= 0 {
+			member = member[:i]
+		}
+		key, val := strings.TrimSpace(member), ""
+		if i := strings.IndexByte(key, '='); i >= 0 {
+			key, val = key[:i], key[i+1:]
+		}
+		switch key {
+		case "u":
+			u, err := strconv.ParseUint(val, 10, 8)
+			if err == nil && u <= 7 {
+				p.Urgency = uint8(u)
+			}
+		case "i":
+			// a boolean member without a value is true
+			switch val {
+			case "", "?1":
+				p.Incremental = true
+			case "?0":
+				p.Incremental = false
+			}
+		}
+	}
+	return p
+}
@@ -0,0 +1,36 @@
+package http3
+
+import (
+	quic "github.com/lucas-clemente/quic-go"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Priority header", func() {
+	It("uses the defaults for an empty header", func() {
+		Expect(parsePriority("")).To(Equal(quic.StreamPriority{Urgency: 3}))
+	})
+
+	It("parses the urgency", func() {
+		Expect(parsePriority("u=1")).To(Equal(quic.StreamPriority{Urgency: 1}))
+		Expect(parsePriority(" u=7 ")).To(Equal(quic.StreamPriority{Urgency: 7}))
+	})
+
+	It("parses the incremental flag", func() {
+		Expect(parsePriority("i")).To(Equal(quic.StreamPriority{Urgency: 3, Incremental: true}))
+		Expect(parsePriority("i=?1")).To(Equal(quic.StreamPriority{Urgency: 3, Incremental: true}))
+		Expect(parsePriority("i=?0")).To(Equal(quic.StreamPriority{Urgency: 3}))
+	})
+
+	It("parses urgency and incremental flag", func() {
+		Expect(parsePriority("u=5, i")).To(Equal(quic.StreamPriority{Urgency: 5, Incremental: true}))
+		Expect(parsePriority("i,u=0")).To(Equal(quic.StreamPriority{Urgency: 0, Incremental: true}))
+	})
+
+	It("ignores invalid values, unknown members and parameters", func() {
+		Expect(parsePriority("u=8")).To(Equal(quic.StreamPriority{Urgency: 3}))
+		Expect(parsePriority("u=foo")).To(Equal(quic.StreamPriority{Urgency: 3}))
+		Expect(parsePriority("foo=bar, u=2;param=1")).To(Equal(quic.StreamPriority{Urgency: 2}))
+	})
+})
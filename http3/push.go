@@ -0,0 +1,382 @@
+package http3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/marten-seemann/qpack"
+	"golang.org/x/net/http/httpguts"
+)
+
+// maxOutstandingPushes is the number of pushes the client allows the server to initiate,
+// before the client handled the previous pushes.
+const maxOutstandingPushes = 16
+
+// pushPromiseHeaders returns the header fields of the request that is promised in a PUSH_PROMISE frame.
+// The target is resolved relative to the request that triggered the push.
+func pushPromiseHeaders(req *http.Request, target string, opts *http.PushOptions) ([]qpack.HeaderField, error) {
+	method := http.MethodGet
+	var header http.Header
+	if opts != nil {
+		if opts.Method != "" {
+			method = opts.Method
+		}
+		header = opts.Header
+	}
+	// Pushed requests must be safe and cacheable, see RFC 9114, section 4.6.
+	if method != http.MethodGet && method != http.MethodHead {
+		return nil, fmt.Errorf("http3: method %q is not allowed for pushed requests", method)
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" {
+		if !strings.HasPrefix(target, "/") {
+			return nil, fmt.Errorf("http3: target must be an absolute URL or an absolute path: %q", target)
+		}
+		u.Scheme = "https"
+		u.Host = req.Host
+	} else if u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("http3: invalid target for push: %q", target)
+	}
+
+	hfs := []qpack.HeaderField{
+		{Name: ":method", Value: method},
+		{Name: ":scheme", Value: u.Scheme},
+		{Name: ":authority", Value: u.Host},
+		{Name: ":path", Value: u.RequestURI()},
+	}
+	for k, vv := range header {
+		if !httpguts.ValidHeaderFieldName(k) {
+			return nil, fmt.Errorf("http3: invalid header name %q for pushed request", k)
+		}
+		for _, v := range vv {
+			if !httpguts.ValidHeaderFieldValue(v) {
+				return nil, fmt.Errorf("http3: invalid header value %q for header %q", v, k)
+			}
+			hfs = append(hfs, qpack.HeaderField{Name: strings.ToLower(k), Value: v})
+		}
+	}
+	return hfs, nil
+}
+
+// nextPushID returns the push ID for a new push.
+func (c *serverConn) nextPushID() (uint64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.pushEnabled || c.goingAway {
+		return 0, http.ErrNotSupported
+	}
+	if c.pushID > c.maxPushID {
+		return 0, errors.New("http3: push limit reached")
+	}
+	id := c.pushID
+	c.pushID++
+	return id, nil
+}
+
+// handleMaxPushID handles a MAX_PUSH_ID frame sent by the client.
+func (c *serverConn) handleMaxPushID(id uint64) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.pushEnabled && id < c.maxPushID {
+		return fmt.Errorf("MAX_PUSH_ID reduced from %d to %d", c.maxPushID, id)
+	}
+	c.pushEnabled = true
+	c.maxPushID = id
+	return nil
+}
+
+func (c *serverConn) addPushStream(id uint64, str quic.SendStream) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.pushStreams == nil {
+		c.pushStreams = make(map[uint64]quic.SendStream)
+	}
+	c.pushStreams[id] = str
+}
+
+func (c *serverConn) removePushStream(id uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.pushStreams, id)
+}
+
+// cancelPush handles a CANCEL_PUSH frame sent by the client.
+func (c *serverConn) cancelPush(id uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if str, ok := c.pushStreams[id]; ok {
+		str.CancelWrite(quic.ErrorCode(errorRequestCanceled))
+		delete(c.pushStreams, id)
+	}
+}
+
+// push sends a PUSH_PROMISE frame on the request stream, and serves the pushed request on a new push stream.
+func (s *Server) push(conn *serverConn, w *responseWriter, req *http.Request, target string, opts *http.PushOptions) error {
+	hfs, err := pushPromiseHeaders(req, target, opts)
+	if err != nil {
+		return err
+	}
+	pushedReq, err := requestFromHeaders(hfs)
+	if err != nil {
+		return err
+	}
+	id, err := conn.nextPushID()
+	if err != nil {
+		return err
+	}
+	str, err := conn.sess.OpenUniStream()
+	if err != nil {
+		return err
+	}
+	if err := w.writePushPromise(id, hfs); err != nil {
+		str.CancelWrite(quic.ErrorCode(errorRequestCanceled))
+		return err
+	}
+	buf := &bytes.Buffer{}
+	utils.WriteVarInt(buf, streamTypePushStream)
+	utils.WriteVarInt(buf, id)
+	if _, err := str.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	conn.addPushStream(id, str)
+
+	// The request that triggered the push is still running, so the WaitGroup can't be at zero.
+	conn.startPush()
+	go func() {
+		defer conn.requestDone()
+		defer conn.removePushStream(id)
+		s.handlePush(conn, str, pushedReq)
+	}()
+	return nil
+}
+
+// handlePush serves a pushed request, and writes the response to the push stream.
+func (s *Server) handlePush(conn *serverConn, str quic.SendStream, req *http.Request) {
+	req.RemoteAddr = conn.sess.RemoteAddr().String()
+	req.Body = http.NoBody
+	ctx := str.Context()
+	ctx = context.WithValue(ctx, ServerContextKey, s)
+	ctx = context.WithValue(ctx, http.LocalAddrContextKey, conn.sess.LocalAddr())
+	req = req.WithContext(ctx)
+
+	s.logger.Debugf("Pushing %s%s, on stream %d", req.Host, req.RequestURI, str.StreamID())
+
+	// Pushed responses can't trigger other pushes, so the push function is not set.
+	responseWriter := newResponseWriter(str, str.StreamID(), conn.encoder, s.logger)
+	responseWriter.conn = conn
+	if s.callHandler(responseWriter, req) {
+		responseWriter.WriteHeader(500)
+	} else {
+		responseWriter.WriteHeader(200)
+		responseWriter.writeTrailers()
+	}
+	responseWriter.Flush()
+	str.Close()
+}
+
+// A pendingPush is a push that the client received either the PUSH_PROMISE frame or the push stream for.
+type pendingPush struct {
+	req  *http.Request
+	str  quic.ReceiveStream
+	done bool // set when the push was handed to the application, or when it was cancelled
+}
+
+// handlePushPromise handles a PUSH_PROMISE frame received on a request stream.
+// It reads the field section from the stream.
+func (c *client) handlePushPromise(ctx context.Context, str quic.ReceiveStream, f *pushPromiseFrame) error {
+	c.mutex.Lock()
+	allowed := c.opts.PushHandler != nil && f.PushID <= c.maxPushID
+	c.mutex.Unlock()
+	if !allowed {
+		c.session.CloseWithError(quic.ErrorCode(errorIDError), "invalid push ID")
+		return fmt.Errorf("invalid push ID: %d", f.PushID)
+	}
+	if f.Length > c.maxHeaderBytes() {
+		return fmt.Errorf("PUSH_PROMISE frame too large: %d bytes (max: %d)", f.Length, c.maxHeaderBytes())
+	}
+	headerBlock := make([]byte, f.Length)
+	if _, err := io.ReadFull(str, headerBlock); err != nil {
+		return err
+	}
+	hfs, err := c.decoder.decode(ctx, str.StreamID(), headerBlock)
+	if err != nil {
+		if qerr, ok := err.(*qpackError); ok {
+			c.session.CloseWithError(quic.ErrorCode(qerr.code), qerr.Error())
+		}
+		return err
+	}
+	req, err := requestFromHeaders(hfs)
+	// We only accept pushes for safe requests, for which the server is authoritative.
+	if err != nil || (req.Method != http.MethodGet && req.Method != http.MethodHead) || authorityAddr("https", req.Host) != c.hostname {
+		c.logger.Debugf("Cancelling invalid push %d.", f.PushID)
+		c.cancelPush(f.PushID)
+		return nil
+	}
+	req.URL.Scheme = "https"
+	req.URL.Host = req.Host
+	req.RequestURI = ""
+	c.addPush(f.PushID, req, nil)
+	return nil
+}
+
+// handlePushStream handles a push stream. The stream type was already read.
+func (c *client) handlePushStream(str quic.ReceiveStream) {
+	id, err := utils.ReadVarInt(&byteReaderImpl{str})
+	if err != nil {
+		c.logger.Debugf("Reading the push ID on stream %d failed: %s", str.StreamID(), err)
+		return
+	}
+	c.mutex.Lock()
+	allowed := c.opts.PushHandler != nil && id <= c.maxPushID
+	c.mutex.Unlock()
+	if !allowed {
+		c.session.CloseWithError(quic.ErrorCode(errorIDError), "invalid push ID")
+		return
+	}
+	c.addPush(id, nil, str)
+}
+
+// addPush matches the promised request with the push stream.
+// Once both of them were received, the pushed response is passed to the PushHandler.
+func (c *client) addPush(id uint64, req *http.Request, str quic.ReceiveStream) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	p := c.getPushLocked(id)
+	if p.done {
+		if str != nil {
+			str.CancelRead(quic.ErrorCode(errorRequestCanceled))
+		}
+		return
+	}
+	// The server may send multiple PUSH_PROMISE frames for the same push.
+	if req != nil && p.req == nil {
+		p.req = req
+	}
+	if str != nil {
+		p.str = str
+	}
+	if p.req != nil && p.str != nil {
+		c.pushDoneLocked(p)
+		go c.deliverPush(p.req, p.str)
+	}
+}
+
+func (c *client) deliverPush(req *http.Request, str quic.ReceiveStream) {
+	rsp, rerr := c.readResponseHeaders(context.Background(), str, nil)
+	if rerr.err != nil {
+		c.logger.Debugf("Reading pushed response for %s failed: %s", req.URL, rerr.err)
+		if rerr.connErr != 0 {
+			c.session.CloseWithError(quic.ErrorCode(rerr.connErr), rerr.err.Error())
+			return
+		}
+		str.CancelRead(quic.ErrorCode(rerr.streamErr))
+		return
+	}
+	respBody := newResponseBody(context.Background(), str, c.decoder, nil, func() {
+		c.session.CloseWithError(quic.ErrorCode(errorFrameUnexpected), "")
+	})
+	respBody.maxTrailerBytes = c.maxHeaderBytes()
+	respBody.onTrailers = func(trailer http.Header) { setResponseTrailers(rsp, trailer) }
+	rsp.Body = respBody
+	rsp.Request = req
+	c.opts.PushHandler(req, rsp)
+}
+
+// cancelPush tells the server that we're not interested in a push.
+func (c *client) cancelPush(id uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	p := c.getPushLocked(id)
+	if p.done {
+		return
+	}
+	buf := &bytes.Buffer{}
+	(&cancelPushFrame{PushID: id}).Write(buf)
+	c.writeControlStreamLocked(buf.Bytes())
+	if p.str != nil {
+		p.str.CancelRead(quic.ErrorCode(errorRequestCanceled))
+	}
+	c.pushDoneLocked(p)
+}
+
+// handleCancelPush handles a CANCEL_PUSH frame sent by the server.
+func (c *client) handleCancelPush(id uint64) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.opts.PushHandler == nil || id > c.maxPushID {
+		return fmt.Errorf("invalid push ID in CANCEL_PUSH frame: %d", id)
+	}
+	p := c.getPushLocked(id)
+	if p.done {
+		return nil
+	}
+	if p.str != nil {
+		p.str.CancelRead(quic.ErrorCode(errorRequestCanceled))
+	}
+	c.pushDoneLocked(p)
+	return nil
+}
+
+// getPushLocked returns the state of a push, creating it if necessary.
+// It must be called with the mutex held.
+func (c *client) getPushLocked(id uint64) *pendingPush {
+	if id < c.pushesDoneBelow {
+		return &pendingPush{done: true}
+	}
+	if c.pushes == nil {
+		c.pushes = make(map[uint64]*pendingPush)
+	}
+	p, ok := c.pushes[id]
+	if !ok {
+		p = &pendingPush{}
+		c.pushes[id] = p
+	}
+	return p
+}
+
+// pushDoneLocked marks a push as done, and allows the server to initiate another push.
+// Pushes are only kept in the map until all pushes with lower IDs are done.
+// It must be called with the mutex held.
+func (c *client) pushDoneLocked(p *pendingPush) {
+	p.done = true
+	for {
+		next, ok := c.pushes[c.pushesDoneBelow]
+		if !ok || !next.done {
+			break
+		}
+		delete(c.pushes, c.pushesDoneBelow)
+		c.pushesDoneBelow++
+	}
+	c.maxPushID++
+	buf := &bytes.Buffer{}
+	(&maxPushIDFrame{PushID: c.maxPushID}).Write(buf)
+	c.writeControlStreamLocked(buf.Bytes())
+}
+
+// writeControlStreamLocked writes to the control stream.
+// It must be called with the mutex held.
+func (c *client) writeControlStreamLocked(b []byte) {
+	if c.controlStr == nil {
+		return
+	}
+	if _, err := c.controlStr.Write(b); err != nil {
+		c.logger.Debugf("Writing to the control stream failed: %s", err)
+	}
+}
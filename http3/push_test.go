@@ -0,0 +1,270 @@
+package http3
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lucas-clemente/quic-go"
+	mockquic "github.com/lucas-clemente/quic-go/internal/mocks/quic"
+	"github.com/marten-seemann/qpack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Server Push", func() {
+	Context("PUSH_PROMISE headers", func() {
+		var req *http.Request
+
+		BeforeEach(func() {
+			var err error
+			req, err = http.NewRequest(http.MethodGet, "https://quic.clemente.io/index.html", nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("resolves absolute paths", func() {
+			hfs, err := pushPromiseHeaders(req, "/style.css?v=1", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hfs).To(Equal([]qpack.HeaderField{
+				{Name: ":method", Value: "GET"},
+				{Name: ":scheme", Value: "https"},
+				{Name: ":authority", Value: "quic.clemente.io"},
+				{Name: ":path", Value: "/style.css?v=1"},
+			}))
+		})
+
+		It("uses absolute URLs", func() {
+			hfs, err := pushPromiseHeaders(req, "https://static.clemente.io/script.js", &http.PushOptions{
+				Method: http.MethodHead,
+				Header: http.Header{"Accept-Encoding": []string{"gzip"}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hfs).To(Equal([]qpack.HeaderField{
+				{Name: ":method", Value: "HEAD"},
+				{Name: ":scheme", Value: "https"},
+				{Name: ":authority", Value: "static.clemente.io"},
+				{Name: ":path", Value: "/script.js"},
+				{Name: "accept-encoding", Value: "gzip"},
+			}))
+		})
+
+		It("rejects unsafe methods", func() {
+			_, err := pushPromiseHeaders(req, "/foo", &http.PushOptions{Method: http.MethodPost})
+			Expect(err).To(MatchError("http3: method \"POST\" is not allowed for pushed requests"))
+		})
+
+		It("rejects relative paths", func() {
+			_, err := pushPromiseHeaders(req, "foo", nil)
+			Expect(err).To(MatchError("http3: target must be an absolute URL or an absolute path: \"foo\""))
+		})
+
+		It("rejects non-https URLs", func() {
+			_, err := pushPromiseHeaders(req, "http://quic.clemente.io/foo", nil)
+			Expect(err).To(MatchError("http3: invalid target for push: \"http://quic.clemente.io/foo\""))
+		})
+
+		It("rejects invalid header values", func() {
+			_, err := pushPromiseHeaders(req, "/foo", &http.PushOptions{Header: http.Header{"Foo": []string{"bar\r\n"}}})
+			Expect(err).To(MatchError(ContainSubstring("http3: invalid header value")))
+		})
+	})
+
+	Context("server side", func() {
+		var conn *serverConn
+
+		BeforeEach(func() {
+			conn = &serverConn{}
+		})
+
+		It("doesn't allow pushes before receiving a MAX_PUSH_ID frame", func() {
+			_, err := conn.nextPushID()
+			Expect(err).To(MatchError(http.ErrNotSupported))
+		})
+
+		It("allocates push IDs up to the maximum push ID", func() {
+			Expect(conn.handleMaxPushID(1)).To(Succeed())
+			id, err := conn.nextPushID()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(id).To(BeZero())
+			id, err = conn.nextPushID()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(id).To(BeEquivalentTo(1))
+			_, err = conn.nextPushID()
+			Expect(err).To(MatchError("http3: push limit reached"))
+			Expect(conn.handleMaxPushID(2)).To(Succeed())
+			id, err = conn.nextPushID()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(id).To(BeEquivalentTo(2))
+		})
+
+		It("errors when the maximum push ID is reduced", func() {
+			Expect(conn.handleMaxPushID(10)).To(Succeed())
+			Expect(conn.handleMaxPushID(5)).To(MatchError("MAX_PUSH_ID reduced from 10 to 5"))
+		})
+
+		It("doesn't push after sending a GOAWAY", func() {
+			Expect(conn.handleMaxPushID(10)).To(Succeed())
+			conn.goingAway = true
+			_, err := conn.nextPushID()
+			Expect(err).To(MatchError(http.ErrNotSupported))
+		})
+
+		It("cancels push streams", func() {
+			str := mockquic.NewMockStream(mockCtrl)
+			conn.addPushStream(3, str)
+			str.EXPECT().CancelWrite(quic.ErrorCode(errorRequestCanceled))
+			conn.cancelPush(3)
+			conn.cancelPush(3) // duplicate CANCEL_PUSH frames are ignored
+			Expect(conn.pushStreams).To(BeEmpty())
+		})
+	})
+
+	Context("client side", func() {
+		var (
+			cl         *client
+			sess       *mockquic.MockEarlySession
+			controlStr *bytes.Buffer
+			pushed     chan *http.Response
+		)
+
+		encodeHeaders := func(hfs ...qpack.HeaderField) []byte {
+			buf := &bytes.Buffer{}
+			enc := qpack.NewEncoder(buf)
+			for _, hf := range hfs {
+				Expect(enc.WriteField(hf)).To(Succeed())
+			}
+			return buf.Bytes()
+		}
+
+		// pushStream returns a push stream carrying a 200 response with body "foobar".
+		// The stream type and the push ID were already read.
+		pushStream := func() *mockquic.MockStream {
+			buf := &bytes.Buffer{}
+			headers := encodeHeaders(qpack.HeaderField{Name: ":status", Value: "200"})
+			(&headersFrame{Length: uint64(len(headers))}).Write(buf)
+			buf.Write(headers)
+			(&dataFrame{Length: 6}).Write(buf)
+			buf.Write([]byte("foobar"))
+			str := mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().Read(gomock.Any()).DoAndReturn(buf.Read).AnyTimes()
+			str.EXPECT().StreamID().AnyTimes()
+			return str
+		}
+
+		// promise returns the PUSH_PROMISE frame and a request stream carrying the encoded field section for a GET request
+		promise := func(id uint64, authority string) (*pushPromiseFrame, *mockquic.MockStream) {
+			headers := encodeHeaders(
+				qpack.HeaderField{Name: ":method", Value: "GET"},
+				qpack.HeaderField{Name: ":scheme", Value: "https"},
+				qpack.HeaderField{Name: ":authority", Value: authority},
+				qpack.HeaderField{Name: ":path", Value: "/style.css"},
+			)
+			str := mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().Read(gomock.Any()).DoAndReturn(bytes.NewReader(headers).Read).AnyTimes()
+			str.EXPECT().StreamID().AnyTimes()
+			return &pushPromiseFrame{PushID: id, Length: uint64(len(headers))}, str
+		}
+
+		BeforeEach(func() {
+			pushed = make(chan *http.Response, 1)
+			cl = newClient("quic.clemente.io", nil, &roundTripperOpts{
+				PushHandler: func(req *http.Request, rsp *http.Response) {
+					Expect(req.URL.String()).To(Equal("https://quic.clemente.io/style.css"))
+					pushed <- rsp
+				},
+			}, nil, nil)
+			sess = mockquic.NewMockEarlySession(mockCtrl)
+			cl.session = sess
+			controlStr = &bytes.Buffer{}
+			str := mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().Write(gomock.Any()).DoAndReturn(controlStr.Write).AnyTimes()
+			cl.controlStr = str
+		})
+
+		expectMaxPushID := func(id uint64) {
+			frame, err := parseNextFrame(controlStr)
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			ExpectWithOffset(1, frame).To(Equal(&maxPushIDFrame{PushID: id}))
+		}
+
+		It("allows the server to push", func() {
+			Expect(cl.maxPushID).To(BeEquivalentTo(maxOutstandingPushes - 1))
+		})
+
+		for _, o := range []string{"PUSH_PROMISE first", "push stream first"} {
+			order := o
+
+			It("delivers pushed responses, receiving the "+order, func() {
+				f, r := promise(3, "quic.clemente.io")
+				str := pushStream()
+				if order == "PUSH_PROMISE first" {
+					Expect(cl.handlePushPromise(context.Background(), r, f)).To(Succeed())
+					cl.addPush(3, nil, str)
+				} else {
+					cl.addPush(3, nil, str)
+					Expect(cl.handlePushPromise(context.Background(), r, f)).To(Succeed())
+				}
+				var rsp *http.Response
+				Eventually(pushed).Should(Receive(&rsp))
+				Expect(rsp.StatusCode).To(Equal(200))
+				data, err := ioutil.ReadAll(rsp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).To(Equal([]byte("foobar")))
+				expectMaxPushID(maxOutstandingPushes)
+			})
+		}
+
+		It("cancels pushes for other authorities", func() {
+			f, r := promise(2, "evil.com")
+			Expect(cl.handlePushPromise(context.Background(), r, f)).To(Succeed())
+			frame, err := parseNextFrame(controlStr)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&cancelPushFrame{PushID: 2}))
+			expectMaxPushID(maxOutstandingPushes)
+			// the push stream is rejected when it arrives
+			str := mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().CancelRead(quic.ErrorCode(errorRequestCanceled))
+			cl.addPush(2, nil, str)
+			Consistently(pushed).ShouldNot(Receive())
+		})
+
+		It("closes the connection when the push ID exceeds the maximum push ID", func() {
+			f, r := promise(maxOutstandingPushes, "quic.clemente.io")
+			sess.EXPECT().CloseWithError(quic.ErrorCode(errorIDError), gomock.Any())
+			Expect(cl.handlePushPromise(context.Background(), r, f)).To(MatchError("invalid push ID: 16"))
+		})
+
+		It("handles CANCEL_PUSH frames", func() {
+			str := mockquic.NewMockStream(mockCtrl)
+			cl.addPush(5, nil, str)
+			str.EXPECT().CancelRead(quic.ErrorCode(errorRequestCanceled))
+			Expect(cl.handleCancelPush(5)).To(Succeed())
+			expectMaxPushID(maxOutstandingPushes)
+			// a PUSH_PROMISE received later is ignored
+			f, r := promise(5, "quic.clemente.io")
+			Expect(cl.handlePushPromise(context.Background(), r, f)).To(Succeed())
+			Expect(controlStr.Len()).To(BeZero())
+		})
+
+		It("removes pushes once all pushes with lower IDs are done", func() {
+			Expect(cl.handleCancelPush(1)).To(Succeed())
+			expectMaxPushID(maxOutstandingPushes)
+			Expect(cl.pushes).To(HaveLen(1))
+			Expect(cl.handleCancelPush(0)).To(Succeed())
+			expectMaxPushID(maxOutstandingPushes + 1)
+			Expect(cl.pushes).To(BeEmpty())
+			// a push stream received later is rejected
+			str := mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().CancelRead(quic.ErrorCode(errorRequestCanceled))
+			cl.addPush(1, nil, str)
+			Expect(cl.pushes).To(BeEmpty())
+		})
+
+		It("rejects CANCEL_PUSH frames with invalid push IDs", func() {
+			Expect(cl.handleCancelPush(maxOutstandingPushes)).To(MatchError("invalid push ID in CANCEL_PUSH frame: 16"))
+		})
+	})
+})
@@ -0,0 +1,428 @@
+package http3
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/marten-seemann/qpack"
+)
+
+var errQPACKDecoderClosed = errors.New("qpack: decoder closed")
+
+// A qpackDecoder decodes the field sections received on one connection.
+// It processes the peer's encoder stream, and sends acknowledgements on the QPACK decoder stream.
+type qpackDecoder struct {
+	maxCapacity uint64 // the SETTINGS_QPACK_MAX_TABLE_CAPACITY we sent
+	maxBlocked  uint64 // the SETTINGS_QPACK_BLOCKED_STREAMS we sent
+
+	mutex    sync.Mutex
+	table    qpackDynamicTable
+	blocked  uint64        // the number of streams currently blocked
+	inserted chan struct{} // closed (and replaced) when entries are inserted, to unblock blocked streams
+	closed   bool
+
+	ackedInsertCount uint64 // the Known Received Count of the peer's encoder
+	stream           io.Writer
+
+	logger utils.Logger
+}
+
+func newQPACKDecoder(maxCapacity, maxBlocked uint64, openStream func() (quic.SendStream, error), logger utils.Logger) *qpackDecoder {
+	return &qpackDecoder{
+		maxCapacity: maxCapacity,
+		maxBlocked:  maxBlocked,
+		inserted:    make(chan struct{}),
+		stream:      newQPACKStream(streamTypeQPACKDecoderStream, openStream),
+		logger:      logger,
+	}
+}
+
+// close unblocks all streams that are waiting for encoder instructions.
+// It must be called when the connection is closed.
+func (d *qpackDecoder) close() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.closed {
+		return
+	}
+	d.closed = true
+	close(d.inserted)
+}
+
+// decode decodes a field section received on the stream with the given ID.
+// If the field section references dynamic table entries that weren't received yet,
+// it blocks until the respective encoder instructions are received, or the context is canceled.
+// A *qpackError is returned if the field section can't be decoded. This is a connection error.
+func (d *qpackDecoder) decode(ctx context.Context, id quic.StreamID, data []byte) ([]qpack.HeaderField, error) {
+	r := bytes.NewReader(data)
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	requiredInsertCount, base, err := d.readPrefixLocked(r)
+	if err != nil {
+		return nil, &qpackError{code: errorQPACKDecompressionFailed, err: err}
+	}
+	if requiredInsertCount > d.table.insertCount() {
+		if err := d.waitLocked(ctx, id, requiredInsertCount); err != nil {
+			return nil, err
+		}
+	}
+
+	var hfs []qpack.HeaderField
+	var maxRef uint64
+	var referencedDynamic bool
+	for r.Len() > 0 {
+		hf, abs, dynamic, err := d.readFieldLineLocked(r, base)
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, &qpackError{code: errorQPACKDecompressionFailed, err: err}
+		}
+		if dynamic {
+			if abs >= requiredInsertCount {
+				return nil, &qpackError{code: errorQPACKDecompressionFailed, err: fmt.Errorf("reference to dynamic table entry %d, but Required Insert Count is %d", abs, requiredInsertCount)}
+			}
+			if !referencedDynamic || abs > maxRef {
+				maxRef = abs
+			}
+			referencedDynamic = true
+		}
+		hfs = append(hfs, hf)
+	}
+	if requiredInsertCount > 0 {
+		if !referencedDynamic || maxRef+1 != requiredInsertCount {
+			return nil, &qpackError{code: errorQPACKDecompressionFailed, err: fmt.Errorf("invalid Required Insert Count %d", requiredInsertCount)}
+		}
+		// acknowledge the field section
+		if requiredInsertCount > d.ackedInsertCount {
+			d.ackedInsertCount = requiredInsertCount
+		}
+		d.writeLocked(appendQPACKInt(nil, 7, 0x80, uint64(id)))
+	}
+	return hfs, nil
+}
+
+// waitLocked waits until the dynamic table contains requiredInsertCount entries.
+func (d *qpackDecoder) waitLocked(ctx context.Context, id quic.StreamID, requiredInsertCount uint64) error {
+	if d.blocked >= d.maxBlocked {
+		return &qpackError{code: errorQPACKDecompressionFailed, err: fmt.Errorf("too many blocked streams (max: %d)", d.maxBlocked)}
+	}
+	d.blocked++
+	defer func() { d.blocked-- }()
+
+	for d.table.insertCount() < requiredInsertCount {
+		if d.closed {
+			return errQPACKDecoderClosed
+		}
+		inserted := d.inserted
+		d.mutex.Unlock()
+		select {
+		case <-inserted:
+			d.mutex.Lock()
+		case <-ctx.Done():
+			d.mutex.Lock()
+			// We won't process this stream any more. Tell the encoder that it doesn't need to wait for an acknowledgement.
+			d.writeLocked(appendQPACKInt(nil, 6, 0x40, uint64(id)))
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// readPrefixLocked reads the field section prefix, see RFC 9204, section 4.5.1.
+// It returns the Required Insert Count and the Base.
+func (d *qpackDecoder) readPrefixLocked(r *bytes.Reader) (uint64, uint64, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, io.ErrUnexpectedEOF
+	}
+	encodedInsertCount, err := readQPACKInt(r, b, 8)
+	if err != nil {
+		return 0, 0, err
+	}
+	var requiredInsertCount uint64
+	if encodedInsertCount != 0 {
+		maxEntries := qpackMaxEntries(d.maxCapacity)
+		fullRange := 2 * maxEntries
+		if encodedInsertCount > fullRange {
+			return 0, 0, errors.New("invalid Required Insert Count")
+		}
+		maxValue := d.table.insertCount() + maxEntries
+		maxWrapped := maxValue / fullRange * fullRange
+		requiredInsertCount = maxWrapped + encodedInsertCount - 1
+		if requiredInsertCount > maxValue {
+			if requiredInsertCount <= fullRange {
+				return 0, 0, errors.New("invalid Required Insert Count")
+			}
+			requiredInsertCount -= fullRange
+		}
+		if requiredInsertCount == 0 {
+			return 0, 0, errors.New("invalid Required Insert Count")
+		}
+	}
+	b, err = r.ReadByte()
+	if err != nil {
+		return 0, 0, io.ErrUnexpectedEOF
+	}
+	deltaBase, err := readQPACKInt(r, b, 7)
+	if err != nil {
+		return 0, 0, err
+	}
+	if b&0x80 == 0 {
+		return requiredInsertCount, requiredInsertCount + deltaBase, nil
+	}
+	if deltaBase >= requiredInsertCount {
+		return 0, 0, errors.New("invalid Base")
+	}
+	return requiredInsertCount, requiredInsertCount - deltaBase - 1, nil
+}
+
+// readFieldLineLocked reads a field line, see RFC 9204, section 4.5.
+// For references into the dynamic table, it also returns the absolute index of the entry.
+func (d *qpackDecoder) readFieldLineLocked(r *bytes.Reader, base uint64) (hf qpack.HeaderField, abs uint64, dynamic bool, err error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return
+	}
+	maxLen := uint64(r.Len())
+	switch {
+	case b&0x80 > 0: // Indexed Field Line
+		var idx uint64
+		idx, err = readQPACKInt(r, b, 6)
+		if err != nil {
+			return
+		}
+		if b&0x40 > 0 {
+			hf, err = qpackStaticEntry(idx)
+			return
+		}
+		abs, err = relativeToAbsolute(base, idx)
+		if err != nil {
+			return
+		}
+		hf, err = d.dynamicEntryLocked(abs)
+		return hf, abs, true, err
+	case b&0x40 > 0: // Literal Field Line with Name Reference
+		var idx uint64
+		idx, err = readQPACKInt(r, b, 4)
+		if err != nil {
+			return
+		}
+		if b&0x10 > 0 {
+			hf, err = qpackStaticEntry(idx)
+		} else {
+			dynamic = true
+			if abs, err = relativeToAbsolute(base, idx); err == nil {
+				hf, err = d.dynamicEntryLocked(abs)
+			}
+		}
+		if err != nil {
+			return
+		}
+		hf.Value, err = readQPACKValue(r, maxLen)
+		return
+	case b&0x20 > 0: // Literal Field Line with Literal Name
+		hf.Name, err = readQPACKString(r, b, 3, maxLen)
+		if err != nil {
+			return
+		}
+		hf.Value, err = readQPACKValue(r, maxLen)
+		return
+	case b&0x10 > 0: // Indexed Field Line with Post-Base Index
+		var idx uint64
+		idx, err = readQPACKInt(r, b, 4)
+		if err != nil {
+			return
+		}
+		abs = base + idx
+		hf, err = d.dynamicEntryLocked(abs)
+		return hf, abs, true, err
+	default: // Literal Field Line with Post-Base Name Reference
+		var idx uint64
+		idx, err = readQPACKInt(r, b, 3)
+		if err != nil {
+			return
+		}
+		abs = base + idx
+		if hf, err = d.dynamicEntryLocked(abs); err != nil {
+			return
+		}
+		hf.Value, err = readQPACKValue(r, maxLen)
+		return hf, abs, true, err
+	}
+}
+
+func qpackStaticEntry(idx uint64) (qpack.HeaderField, error) {
+	if idx >= uint64(len(qpackStaticTable)) {
+		return qpack.HeaderField{}, fmt.Errorf("invalid static table index %d", idx)
+	}
+	return qpackStaticTable[idx], nil
+}
+
+func (d *qpackDecoder) dynamicEntryLocked(abs uint64) (qpack.HeaderField, error) {
+	hf, ok := d.table.get(abs)
+	if !ok {
+		return qpack.HeaderField{}, fmt.Errorf("invalid dynamic table index %d", abs)
+	}
+	return hf, nil
+}
+
+func relativeToAbsolute(base, idx uint64) (uint64, error) {
+	if idx >= base {
+		return 0, fmt.Errorf("invalid relative index %d (base: %d)", idx, base)
+	}
+	return base - 1 - idx, nil
+}
+
+// readQPACKValue reads the string literal of a field value, which uses a 7-bit prefix.
+func readQPACKValue(r byteReader, maxLen uint64) (string, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	return readQPACKString(r, b, 7, maxLen)
+}
+
+// cancelStream tells the peer's encoder that the field sections on a stream won't be processed.
+// This is used when reading from a stream is aborted before all field sections were read.
+func (d *qpackDecoder) cancelStream(id quic.StreamID) {
+	if d.maxCapacity == 0 {
+		return
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	// If the encoder didn't insert any entries yet, there's nothing it could have referenced.
+	if d.table.insertCount() == 0 {
+		return
+	}
+	d.writeLocked(appendQPACKInt(nil, 6, 0x40, uint64(id)))
+}
+
+// writeLocked writes an instruction to the decoder stream.
+func (d *qpackDecoder) writeLocked(b []byte) {
+	if _, err := d.stream.Write(b); err != nil {
+		d.logger.Debugf("Writing to the QPACK decoder stream failed: %s", err)
+	}
+}
+
+// handleEncoderStream reads the instructions that the peer's encoder sends on the QPACK encoder stream.
+// It returns when reading from the stream fails, or when the peer sent an invalid instruction.
+func (d *qpackDecoder) handleEncoderStream(str io.Reader) error {
+	sr := &qpackStreamReader{str: str}
+	r := bufio.NewReader(sr)
+	for {
+		if err := d.readInstruction(r); err != nil {
+			return sr.streamError(err, errorQPACKEncoderStreamError)
+		}
+		// Acknowledge the insertions once we processed all the instructions that we received so far.
+		if r.Buffered() == 0 {
+			d.sendInsertCountIncrement()
+		}
+	}
+}
+
+func (d *qpackDecoder) readInstruction(r *bufio.Reader) error {
+	b, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	switch {
+	case b&0x80 > 0: // Insert with Name Reference
+		idx, err := readQPACKInt(r, b, 6)
+		if err != nil {
+			return err
+		}
+		value, err := readQPACKValue(r, d.maxCapacity)
+		if err != nil {
+			return err
+		}
+		d.mutex.Lock()
+		defer d.mutex.Unlock()
+		var hf qpack.HeaderField
+		if b&0x40 > 0 {
+			hf, err = qpackStaticEntry(idx)
+		} else {
+			var abs uint64
+			if abs, err = relativeToAbsolute(d.table.insertCount(), idx); err == nil {
+				hf, err = d.dynamicEntryLocked(abs)
+			}
+		}
+		if err != nil {
+			return &qpackError{code: errorQPACKEncoderStreamError, err: err}
+		}
+		hf.Value = value
+		return d.insertLocked(hf)
+	case b&0x40 > 0: // Insert with Literal Name
+		name, err := readQPACKString(r, b, 5, d.maxCapacity)
+		if err != nil {
+			return err
+		}
+		value, err := readQPACKValue(r, d.maxCapacity)
+		if err != nil {
+			return err
+		}
+		d.mutex.Lock()
+		defer d.mutex.Unlock()
+		return d.insertLocked(qpack.HeaderField{Name: name, Value: value})
+	case b&0x20 > 0: // Set Dynamic Table Capacity
+		capacity, err := readQPACKInt(r, b, 5)
+		if err != nil {
+			return err
+		}
+		if capacity > d.maxCapacity {
+			return &qpackError{code: errorQPACKEncoderStreamError, err: fmt.Errorf("dynamic table capacity %d exceeds the maximum (%d)", capacity, d.maxCapacity)}
+		}
+		d.mutex.Lock()
+		d.table.setCapacity(capacity)
+		d.mutex.Unlock()
+		return nil
+	default: // Duplicate
+		idx, err := readQPACKInt(r, b, 5)
+		if err != nil {
+			return err
+		}
+		d.mutex.Lock()
+		defer d.mutex.Unlock()
+		abs, err := relativeToAbsolute(d.table.insertCount(), idx)
+		if err != nil {
+			return &qpackError{code: errorQPACKEncoderStreamError, err: err}
+		}
+		hf, err := d.dynamicEntryLocked(abs)
+		if err != nil {
+			return &qpackError{code: errorQPACKEncoderStreamError, err: err}
+		}
+		return d.insertLocked(hf)
+	}
+}
+
+func (d *qpackDecoder) insertLocked(hf qpack.HeaderField) error {
+	if qpackEntrySize(hf) > d.table.capacity {
+		return &qpackError{code: errorQPACKEncoderStreamError, err: fmt.Errorf("entry too large for the dynamic table (capacity: %d)", d.table.capacity)}
+	}
+	d.table.insert(hf)
+	if d.blocked > 0 && !d.closed {
+		close(d.inserted)
+		d.inserted = make(chan struct{})
+	}
+	return nil
+}
+
+// sendInsertCountIncrement acknowledges insertions that weren't acknowledged by a Section Acknowledgement.
+func (d *qpackDecoder) sendInsertCountIncrement() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if inc := d.table.insertCount() - d.ackedInsertCount; inc > 0 {
+		d.ackedInsertCount += inc
+		d.writeLocked(appendQPACKInt(nil, 6, 0, inc))
+	}
+}
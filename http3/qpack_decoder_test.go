@@ -0,0 +1,154 @@
+package http3
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/marten-seemann/qpack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("QPACK decoder", func() {
+	var (
+		decoder       *qpackDecoder
+		decoderStream *bytes.Buffer
+	)
+
+	// insert returns the encoder instructions that set the capacity and insert a header field with a literal name
+	insert := func(capacity uint64, hf qpack.HeaderField) []byte {
+		b := appendQPACKInt(nil, 5, 0x20, capacity)
+		b = appendQPACKString(b, 5, 0x40, hf.Name)
+		return appendQPACKString(b, 7, 0, hf.Value)
+	}
+
+	// indexed returns a field section referencing the first entry of the dynamic table
+	indexed := func() []byte {
+		b := appendQPACKInt(nil, 8, 0, 2) // encoded Required Insert Count for a Required Insert Count of 1
+		b = append(b, 0)                  // Base = Required Insert Count
+		return appendQPACKInt(b, 6, 0x80, 0)
+	}
+
+	BeforeEach(func() {
+		decoder = newQPACKDecoder(defaultQPACKMaxTableCapacity, 1, nil, utils.DefaultLogger)
+		decoderStream = &bytes.Buffer{}
+		decoder.stream = decoderStream
+	})
+
+	It("decodes field sections that only use the static table", func() {
+		hfs := []qpack.HeaderField{
+			{Name: ":status", Value: "200"},
+			{Name: "content-type", Value: "text/html"},
+			{Name: "x-custom", Value: "foobar"},
+		}
+		buf := &bytes.Buffer{}
+		enc := qpack.NewEncoder(buf)
+		for _, hf := range hfs {
+			Expect(enc.WriteField(hf)).To(Succeed())
+		}
+		decoded, err := decoder.decode(context.Background(), 4, buf.Bytes())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal(hfs))
+		Expect(decoderStream.Len()).To(BeZero())
+	})
+
+	It("acknowledges insertions", func() {
+		err := decoder.handleEncoderStream(bytes.NewReader(insert(100, qpack.HeaderField{Name: "foo", Value: "bar"})))
+		Expect(err).To(MatchError("EOF"))
+		Expect(decoderStream.Bytes()).To(Equal([]byte{0x1})) // Insert Count Increment
+	})
+
+	It("acknowledges field sections that reference the dynamic table", func() {
+		Expect(decoder.handleEncoderStream(bytes.NewReader(insert(100, qpack.HeaderField{Name: "foo", Value: "bar"})))).To(MatchError("EOF"))
+		decoderStream.Reset()
+		decoded, err := decoder.decode(context.Background(), 4, indexed())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal([]qpack.HeaderField{{Name: "foo", Value: "bar"}}))
+		Expect(decoderStream.Bytes()).To(Equal([]byte{0x84})) // Section Acknowledgement for stream 4
+	})
+
+	It("blocks until the entries were inserted", func() {
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+			decoded, err := decoder.decode(context.Background(), 4, indexed())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decoded).To(Equal([]qpack.HeaderField{{Name: "foo", Value: "bar"}}))
+		}()
+		Consistently(done).ShouldNot(BeClosed())
+		Expect(decoder.handleEncoderStream(bytes.NewReader(insert(100, qpack.HeaderField{Name: "foo", Value: "bar"})))).To(MatchError("EOF"))
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("cancels blocked streams when the context is canceled", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := decoder.decode(ctx, 4, indexed())
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(decoderStream.Bytes()).To(Equal([]byte{0x44})) // Stream Cancellation for stream 4
+	})
+
+	It("unblocks streams when it is closed", func() {
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+			_, err := decoder.decode(context.Background(), 4, indexed())
+			Expect(err).To(MatchError(errQPACKDecoderClosed))
+		}()
+		Consistently(done).ShouldNot(BeClosed())
+		decoder.close()
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("limits the number of blocked streams", func() {
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+			_, err := decoder.decode(context.Background(), 4, indexed())
+			Expect(err).To(MatchError(errQPACKDecoderClosed))
+		}()
+		Consistently(done).ShouldNot(BeClosed())
+		_, err := decoder.decode(context.Background(), 8, indexed())
+		Expect(err).To(BeAssignableToTypeOf(&qpackError{}))
+		Expect(err.(*qpackError).code).To(Equal(errorQPACKDecompressionFailed))
+		decoder.close()
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("errors on references to entries that don't exist", func() {
+		Expect(decoder.handleEncoderStream(bytes.NewReader(insert(100, qpack.HeaderField{Name: "foo", Value: "bar"})))).To(MatchError("EOF"))
+		b := appendQPACKInt(nil, 8, 0, 2)
+		b = append(b, 0)
+		b = appendQPACKInt(b, 6, 0x80, 1) // relative index 1 doesn't exist
+		_, err := decoder.decode(context.Background(), 4, b)
+		Expect(err).To(BeAssignableToTypeOf(&qpackError{}))
+		Expect(err.(*qpackError).code).To(Equal(errorQPACKDecompressionFailed))
+	})
+
+	It("errors when the dynamic table capacity is too large", func() {
+		err := decoder.handleEncoderStream(bytes.NewReader(appendQPACKInt(nil, 5, 0x20, defaultQPACKMaxTableCapacity+1)))
+		Expect(err).To(BeAssignableToTypeOf(&qpackError{}))
+		Expect(err.(*qpackError).code).To(Equal(errorQPACKEncoderStreamError))
+	})
+
+	It("errors when an entry doesn't fit into the dynamic table", func() {
+		err := decoder.handleEncoderStream(bytes.NewReader(insert(10, qpack.HeaderField{Name: "foo", Value: "bar"})))
+		Expect(err).To(BeAssignableToTypeOf(&qpackError{}))
+		Expect(err.(*qpackError).code).To(Equal(errorQPACKEncoderStreamError))
+	})
+
+	It("sends Stream Cancellations", func() {
+		decoder.cancelStream(4)
+		Expect(decoderStream.Len()).To(BeZero()) // no entries were inserted yet
+		Expect(decoder.handleEncoderStream(bytes.NewReader(insert(100, qpack.HeaderField{Name: "foo", Value: "bar"})))).To(MatchError("EOF"))
+		decoderStream.Reset()
+		decoder.cancelStream(4)
+		Expect(decoderStream.Bytes()).To(Equal([]byte{0x44}))
+	})
+})
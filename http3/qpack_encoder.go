@@ -0,0 +1,341 @@
+package http3
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/marten-seemann/qpack"
+)
+
+// qpackNeverIndex are header fields that are never inserted into the dynamic table.
+// Some of them are sensitive, the others change with every request or response.
+var qpackNeverIndex = map[string]struct{}{
+	"authorization":       {},
+	"proxy-authorization": {},
+	"cookie":              {},
+	"set-cookie":          {},
+	"content-length":      {},
+	"date":                {},
+}
+
+// An unacknowledged field section, sent on a request or push stream.
+type qpackSection struct {
+	requiredInsertCount uint64
+	minRef              uint64 // the smallest absolute index referenced by the field section
+}
+
+// representations of field lines, before the Base of the field section is known
+const (
+	qpackStaticIndexed uint8 = iota
+	qpackDynamicIndexed
+	qpackStaticNameRef
+	qpackDynamicNameRef
+	qpackLiteralName
+)
+
+type qpackFieldLine struct {
+	kind  uint8
+	index uint64 // index into the static table, or absolute index into the dynamic table
+	hf    qpack.HeaderField
+}
+
+// A qpackEncoder encodes the field sections sent on one connection.
+// Once the peer's SETTINGS were received, it inserts header fields into the dynamic table,
+// and sends the encoder instructions on the QPACK encoder stream.
+type qpackEncoder struct {
+	mutex sync.Mutex
+
+	maxCapacity     uint64 // the maximum capacity we're willing to use
+	peerMaxCapacity uint64 // the SETTINGS_QPACK_MAX_TABLE_CAPACITY sent by the peer
+	maxBlocked      uint64 // the SETTINGS_QPACK_BLOCKED_STREAMS sent by the peer
+	sentCapacity    bool
+
+	table  qpackDynamicTable
+	fields map[qpack.HeaderField]uint64 // the absolute index of the newest entry for a header field
+	names  map[string]uint64            // the absolute index of the newest entry for a header name
+	stream io.Writer                    // the encoder stream
+	failed bool                         // set when writing to the encoder stream failed
+
+	knownReceivedCount uint64
+	sections           map[quic.StreamID][]qpackSection
+
+	logger utils.Logger
+}
+
+func newQPACKEncoder(maxCapacity uint64, openStream func() (quic.SendStream, error), logger utils.Logger) *qpackEncoder {
+	e := &qpackEncoder{
+		maxCapacity: maxCapacity,
+		stream:      newQPACKStream(streamTypeQPACKEncoderStream, openStream),
+		logger:      logger,
+	}
+	// The maps are only needed when the dynamic table is used.
+	if maxCapacity > 0 {
+		e.fields = make(map[qpack.HeaderField]uint64)
+		e.names = make(map[string]uint64)
+		e.sections = make(map[quic.StreamID][]qpackSection)
+	}
+	return e
+}
+
+// setPeerSettings enables the dynamic table, if the peer allows it.
+// It must be called when the peer's SETTINGS frame is received.
+// Until then, only the static table is used.
+func (e *qpackEncoder) setPeerSettings(maxCapacity, maxBlocked uint64) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.peerMaxCapacity = maxCapacity
+	e.maxBlocked = maxBlocked
+	capacity := maxCapacity
+	if e.maxCapacity < capacity {
+		capacity = e.maxCapacity
+	}
+	e.table.capacity = capacity
+}
+
+func (e *qpackEncoder) dynamicTableEnabled() bool {
+	return e.table.capacity > 0 && !e.failed
+}
+
+// encode encodes a field section that's sent on the stream with the given ID.
+func (e *qpackEncoder) encode(id quic.StreamID, hfs []qpack.HeaderField) []byte {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	section := qpackSection{minRef: ^uint64(0)}
+	canBlock := e.canBlockLocked(id)
+	lines := make([]qpackFieldLine, 0, len(hfs))
+	for _, hf := range hfs {
+		line := e.encodeFieldLocked(hf, &section, canBlock)
+		if line.kind == qpackDynamicIndexed || line.kind == qpackDynamicNameRef {
+			if line.index+1 > section.requiredInsertCount {
+				section.requiredInsertCount = line.index + 1
+			}
+			if line.index < section.minRef {
+				section.minRef = line.index
+			}
+		}
+		lines = append(lines, line)
+	}
+
+	// All dynamic table entries are referenced relative to the Base.
+	// We use the Required Insert Count as the Base.
+	base := section.requiredInsertCount
+	var b []byte
+	if base == 0 {
+		b = []byte{0, 0}
+	} else {
+		fullRange := 2 * qpackMaxEntries(e.peerMaxCapacity)
+		b = appendQPACKInt(nil, 8, 0, base%fullRange+1)
+		b = appendQPACKInt(b, 7, 0, 0)
+		e.sections[id] = append(e.sections[id], section)
+	}
+	for _, l := range lines {
+		switch l.kind {
+		case qpackStaticIndexed:
+			b = appendQPACKInt(b, 6, 0xc0, l.index)
+		case qpackDynamicIndexed:
+			b = appendQPACKInt(b, 6, 0x80, base-1-l.index)
+		case qpackStaticNameRef:
+			b = appendQPACKInt(b, 4, 0x50, l.index)
+			b = appendQPACKString(b, 7, 0, l.hf.Value)
+		case qpackDynamicNameRef:
+			b = appendQPACKInt(b, 4, 0x40, base-1-l.index)
+			b = appendQPACKString(b, 7, 0, l.hf.Value)
+		case qpackLiteralName:
+			b = appendQPACKString(b, 3, 0x20, l.hf.Name)
+			b = appendQPACKString(b, 7, 0, l.hf.Value)
+		}
+	}
+	return b
+}
+
+func (e *qpackEncoder) encodeFieldLocked(hf qpack.HeaderField, section *qpackSection, canBlock bool) qpackFieldLine {
+	if idx, ok := qpackStaticFields[hf]; ok {
+		return qpackFieldLine{kind: qpackStaticIndexed, index: idx}
+	}
+	staticName, hasStaticName := qpackStaticNames[hf.Name]
+	if e.dynamicTableEnabled() {
+		if idx, ok := e.fields[hf]; ok && e.canReferenceLocked(idx, canBlock) {
+			return qpackFieldLine{kind: qpackDynamicIndexed, index: idx}
+		}
+		if _, ok := qpackNeverIndex[hf.Name]; !ok {
+			if idx, ok := e.insertLocked(hf, staticName, hasStaticName, section); ok && e.canReferenceLocked(idx, canBlock) {
+				return qpackFieldLine{kind: qpackDynamicIndexed, index: idx}
+			}
+		}
+	}
+	if hasStaticName {
+		return qpackFieldLine{kind: qpackStaticNameRef, index: staticName, hf: hf}
+	}
+	if e.dynamicTableEnabled() {
+		if idx, ok := e.names[hf.Name]; ok && e.canReferenceLocked(idx, canBlock) {
+			return qpackFieldLine{kind: qpackDynamicNameRef, index: idx, hf: hf}
+		}
+	}
+	return qpackFieldLine{kind: qpackLiteralName, hf: hf}
+}
+
+// canReferenceLocked says if an entry of the dynamic table can be referenced.
+// Referencing an entry that the peer might not have received yet blocks the stream.
+func (e *qpackEncoder) canReferenceLocked(idx uint64, canBlock bool) bool {
+	if _, ok := e.table.get(idx); !ok {
+		return false
+	}
+	return idx < e.knownReceivedCount || canBlock
+}
+
+// canBlockLocked says if a field section sent on stream id may reference entries that the peer might not have received yet.
+func (e *qpackEncoder) canBlockLocked(id quic.StreamID) bool {
+	var blocked uint64
+	for strID, sections := range e.sections {
+		for _, s := range sections {
+			if s.requiredInsertCount > e.knownReceivedCount {
+				if strID == id { // this stream is already blocked
+					return true
+				}
+				blocked++
+				break
+			}
+		}
+	}
+	return blocked < e.maxBlocked
+}
+
+// insertLocked inserts a header field into the dynamic table, and sends the insert instruction on the encoder stream.
+// Entries referenced by unacknowledged field sections and by the field section that's currently encoded are not evicted,
+// nor are entries whose insertion wasn't acknowledged by the decoder yet.
+func (e *qpackEncoder) insertLocked(hf qpack.HeaderField, staticName uint64, hasStaticName bool, section *qpackSection) (uint64, bool) {
+	size := qpackEntrySize(hf)
+	if size > e.table.capacity/2 {
+		// Large entries would evict too many other entries.
+		return 0, false
+	}
+	if e.table.size+size > e.table.capacity {
+		limit := section.minRef
+		for _, sections := range e.sections {
+			for _, s := range sections {
+				if s.minRef < limit {
+					limit = s.minRef
+				}
+			}
+		}
+		if e.knownReceivedCount < limit {
+			limit = e.knownReceivedCount
+		}
+		if e.table.size-e.table.evictableSize(limit)+size > e.table.capacity {
+			return 0, false
+		}
+	}
+
+	var b []byte
+	if !e.sentCapacity {
+		b = appendQPACKInt(b, 5, 0x20, e.table.capacity)
+	}
+	if hasStaticName {
+		b = appendQPACKInt(b, 6, 0xc0, staticName)
+	} else {
+		b = appendQPACKString(b, 5, 0x40, hf.Name)
+	}
+	b = appendQPACKString(b, 7, 0, hf.Value)
+	if _, err := e.stream.Write(b); err != nil {
+		e.logger.Debugf("Writing to the QPACK encoder stream failed: %s. Disabling the dynamic table.", err)
+		e.failed = true
+		return 0, false
+	}
+	e.sentCapacity = true
+
+	for _, evicted := range e.table.insert(hf) {
+		e.forgetLocked(evicted)
+	}
+	idx := e.table.insertCount() - 1
+	e.fields[hf] = idx
+	e.names[hf.Name] = idx
+	return idx, true
+}
+
+// forgetLocked removes an evicted entry from the lookup maps, if it's not referenced by a newer entry.
+func (e *qpackEncoder) forgetLocked(hf qpack.HeaderField) {
+	if idx, ok := e.fields[hf]; ok && idx < e.table.dropped {
+		delete(e.fields, hf)
+	}
+	if idx, ok := e.names[hf.Name]; ok && idx < e.table.dropped {
+		delete(e.names, hf.Name)
+	}
+}
+
+// handleDecoderStream reads the instructions that the peer's decoder sends on the QPACK decoder stream.
+// It returns when reading from the stream fails, or when the peer sent an invalid instruction.
+func (e *qpackEncoder) handleDecoderStream(str io.Reader) error {
+	sr := &qpackStreamReader{str: str}
+	r := &byteReaderImpl{sr}
+	for {
+		if err := e.readInstruction(r); err != nil {
+			return sr.streamError(err, errorQPACKDecoderStreamError)
+		}
+	}
+}
+
+func (e *qpackEncoder) readInstruction(r io.ByteReader) error {
+	b, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	switch {
+	case b&0x80 > 0: // Section Acknowledgment
+		id, err := readQPACKInt(r, b, 7)
+		if err != nil {
+			return err
+		}
+		return e.handleSectionAck(quic.StreamID(id))
+	case b&0x40 > 0: // Stream Cancellation
+		id, err := readQPACKInt(r, b, 6)
+		if err != nil {
+			return err
+		}
+		e.mutex.Lock()
+		delete(e.sections, quic.StreamID(id))
+		e.mutex.Unlock()
+		return nil
+	default: // Insert Count Increment
+		inc, err := readQPACKInt(r, b, 6)
+		if err != nil {
+			return err
+		}
+		return e.handleInsertCountIncrement(inc)
+	}
+}
+
+func (e *qpackEncoder) handleSectionAck(id quic.StreamID) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	sections, ok := e.sections[id]
+	if !ok {
+		return &qpackError{code: errorQPACKDecoderStreamError, err: fmt.Errorf("received Section Acknowledgment for stream %d without outstanding field sections", id)}
+	}
+	if sections[0].requiredInsertCount > e.knownReceivedCount {
+		e.knownReceivedCount = sections[0].requiredInsertCount
+	}
+	if len(sections) == 1 {
+		delete(e.sections, id)
+	} else {
+		e.sections[id] = sections[1:]
+	}
+	return nil
+}
+
+func (e *qpackEncoder) handleInsertCountIncrement(inc uint64) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if inc == 0 || e.knownReceivedCount+inc > e.table.insertCount() {
+		return &qpackError{code: errorQPACKDecoderStreamError, err: errors.New("invalid Insert Count Increment")}
+	}
+	e.knownReceivedCount += inc
+	return nil
+}
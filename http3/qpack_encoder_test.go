@@ -0,0 +1,199 @@
+package http3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/marten-seemann/qpack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("QPACK encoder", func() {
+	var (
+		encoder       *qpackEncoder
+		decoder       *qpackDecoder
+		encoderStream *bytes.Buffer
+		decoderStream *bytes.Buffer
+	)
+
+	hfs := []qpack.HeaderField{
+		{Name: ":method", Value: "GET"},
+		{Name: ":authority", Value: "quic.clemente.io"},
+		{Name: "user-agent", Value: "quic-go HTTP/3"},
+		{Name: "x-custom", Value: "foobar"},
+	}
+
+	// processEncoderStream passes the encoder instructions to the decoder
+	processEncoderStream := func() {
+		ExpectWithOffset(1, decoder.handleEncoderStream(bytes.NewReader(encoderStream.Bytes()))).To(MatchError("EOF"))
+		encoderStream.Reset()
+	}
+
+	// processDecoderStream passes the decoder instructions to the encoder
+	processDecoderStream := func() {
+		ExpectWithOffset(1, encoder.handleDecoderStream(bytes.NewReader(decoderStream.Bytes()))).To(MatchError("EOF"))
+		decoderStream.Reset()
+	}
+
+	BeforeEach(func() {
+		encoder = newQPACKEncoder(defaultQPACKMaxTableCapacity, nil, utils.DefaultLogger)
+		encoderStream = &bytes.Buffer{}
+		encoder.stream = encoderStream
+		decoder = newQPACKDecoder(defaultQPACKMaxTableCapacity, defaultQPACKMaxBlockedStreams, nil, utils.DefaultLogger)
+		decoderStream = &bytes.Buffer{}
+		decoder.stream = decoderStream
+	})
+
+	It("only uses the static table before receiving the peer's SETTINGS", func() {
+		data := encoder.encode(4, hfs)
+		Expect(data[:2]).To(Equal([]byte{0, 0}))
+		Expect(encoderStream.Len()).To(BeZero())
+		decoded, err := qpack.NewDecoder(nil).DecodeFull(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal(hfs))
+	})
+
+	It("only uses the static table if the peer didn't enable the dynamic table", func() {
+		encoder.setPeerSettings(0, 0)
+		data := encoder.encode(4, hfs)
+		Expect(data[:2]).To(Equal([]byte{0, 0}))
+		Expect(encoderStream.Len()).To(BeZero())
+	})
+
+	It("inserts header fields into the dynamic table", func() {
+		encoder.setPeerSettings(defaultQPACKMaxTableCapacity, defaultQPACKMaxBlockedStreams)
+		data := encoder.encode(4, hfs)
+		Expect(encoderStream.Len()).ToNot(BeZero())
+		processEncoderStream()
+		decoded, err := decoder.decode(context.Background(), 4, data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal(hfs))
+		// the decoder acknowledges the field section
+		Expect(decoderStream.Len()).ToNot(BeZero())
+		processDecoderStream()
+		Expect(encoder.knownReceivedCount).To(BeEquivalentTo(3))
+		Expect(encoder.sections).To(BeEmpty())
+
+		// the second field section references the same entries
+		data2 := encoder.encode(8, hfs)
+		Expect(encoderStream.Len()).To(BeZero())
+		Expect(data2).To(Equal(data))
+		decoded, err = decoder.decode(context.Background(), 8, data2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal(hfs))
+	})
+
+	It("doesn't insert sensitive header fields", func() {
+		encoder.setPeerSettings(defaultQPACKMaxTableCapacity, defaultQPACKMaxBlockedStreams)
+		data := encoder.encode(4, []qpack.HeaderField{
+			{Name: "authorization", Value: "secret"},
+			{Name: "cookie", Value: "foo=bar"},
+		})
+		Expect(data[:2]).To(Equal([]byte{0, 0}))
+		Expect(encoderStream.Len()).To(BeZero())
+	})
+
+	It("doesn't insert header fields that are too large", func() {
+		encoder.setPeerSettings(100, defaultQPACKMaxBlockedStreams)
+		data := encoder.encode(4, []qpack.HeaderField{{Name: "x-large", Value: string(bytes.Repeat([]byte{'a'}, 50))}})
+		Expect(data[:2]).To(Equal([]byte{0, 0}))
+		Expect(encoderStream.Len()).To(BeZero())
+	})
+
+	It("doesn't evict entries before the decoder acknowledged their insertion", func() {
+		encoder.setPeerSettings(100, 0)
+		value := string(bytes.Repeat([]byte{'a'}, 10))
+		// every entry has a size of 45 bytes
+		encoder.encode(4, []qpack.HeaderField{{Name: "x-a", Value: value}, {Name: "x-b", Value: value}})
+		Expect(encoder.table.insertCount()).To(BeEquivalentTo(2))
+		encoder.encode(8, []qpack.HeaderField{{Name: "x-c", Value: value}})
+		Expect(encoder.table.insertCount()).To(BeEquivalentTo(2))
+		// the decoder acknowledges the insertions
+		processEncoderStream()
+		processDecoderStream()
+		Expect(encoder.knownReceivedCount).To(BeEquivalentTo(2))
+		encoder.encode(12, []qpack.HeaderField{{Name: "x-c", Value: value}})
+		Expect(encoder.table.insertCount()).To(BeEquivalentTo(3))
+	})
+
+	It("uses the smaller capacity", func() {
+		encoder = newQPACKEncoder(200, nil, utils.DefaultLogger)
+		encoder.stream = encoderStream
+		encoder.setPeerSettings(defaultQPACKMaxTableCapacity, defaultQPACKMaxBlockedStreams)
+		Expect(encoder.table.capacity).To(BeEquivalentTo(200))
+		encoder.encode(4, hfs)
+		b, err := encoderStream.ReadByte()
+		Expect(err).ToNot(HaveOccurred())
+		capacity, err := readQPACKInt(encoderStream, b, 5)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(capacity).To(BeEquivalentTo(200))
+	})
+
+	It("doesn't block streams if the peer doesn't allow it", func() {
+		encoder.setPeerSettings(defaultQPACKMaxTableCapacity, 0)
+		data := encoder.encode(4, hfs)
+		// The entries are inserted, but not referenced.
+		Expect(encoderStream.Len()).ToNot(BeZero())
+		Expect(data[:2]).To(Equal([]byte{0, 0}))
+		Expect(encoder.sections).To(BeEmpty())
+		// the decoder acknowledges the insertions
+		processEncoderStream()
+		processDecoderStream()
+		Expect(encoder.knownReceivedCount).To(BeEquivalentTo(3))
+		data = encoder.encode(8, hfs)
+		Expect(data[:2]).ToNot(Equal([]byte{0, 0}))
+		decoded, err := decoder.decode(context.Background(), 8, data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal(hfs))
+	})
+
+	It("limits the number of blocked streams", func() {
+		encoder.setPeerSettings(defaultQPACKMaxTableCapacity, 1)
+		data := encoder.encode(4, hfs)
+		Expect(data[:2]).ToNot(Equal([]byte{0, 0}))
+		// the same stream can reference unacknowledged entries
+		data = encoder.encode(4, []qpack.HeaderField{{Name: "x-custom", Value: "foobar"}})
+		Expect(data[:2]).ToNot(Equal([]byte{0, 0}))
+		// another stream can't
+		data = encoder.encode(8, []qpack.HeaderField{{Name: "x-custom", Value: "foobar"}})
+		Expect(data[:2]).To(Equal([]byte{0, 0}))
+	})
+
+	It("stops referencing entries once a stream is canceled", func() {
+		encoder.setPeerSettings(defaultQPACKMaxTableCapacity, 1)
+		encoder.encode(4, hfs)
+		Expect(encoder.sections).To(HaveKey(quic.StreamID(4)))
+		decoderStream.Write(appendQPACKInt(nil, 6, 0x40, 4))
+		processDecoderStream()
+		Expect(encoder.sections).To(BeEmpty())
+	})
+
+	It("disables the dynamic table when writing to the encoder stream fails", func() {
+		encoder = newQPACKEncoder(defaultQPACKMaxTableCapacity, func() (quic.SendStream, error) {
+			return nil, errors.New("test err")
+		}, utils.DefaultLogger)
+		encoder.setPeerSettings(defaultQPACKMaxTableCapacity, defaultQPACKMaxBlockedStreams)
+		data := encoder.encode(4, hfs)
+		Expect(data[:2]).To(Equal([]byte{0, 0}))
+		decoded, err := qpack.NewDecoder(nil).DecodeFull(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal(hfs))
+	})
+
+	It("errors on Section Acknowledgements for unknown streams", func() {
+		err := encoder.handleDecoderStream(bytes.NewReader(appendQPACKInt(nil, 7, 0x80, 4)))
+		Expect(err).To(BeAssignableToTypeOf(&qpackError{}))
+		Expect(err.(*qpackError).code).To(Equal(errorQPACKDecoderStreamError))
+	})
+
+	It("errors on invalid Insert Count Increments", func() {
+		err := encoder.handleDecoderStream(bytes.NewReader(appendQPACKInt(nil, 6, 0, 1)))
+		Expect(err).To(BeAssignableToTypeOf(&qpackError{}))
+		Expect(err.(*qpackError).code).To(Equal(errorQPACKDecoderStreamError))
+	})
+})
@@ -0,0 +1,276 @@
+package http3
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/marten-seemann/qpack"
+	"golang.org/x/net/http2/hpack"
+)
+
+const (
+	// defaultQPACKMaxTableCapacity is the default capacity of the QPACK dynamic table.
+	// It's the same value that's used for the HPACK dynamic table in HTTP/2.
+	defaultQPACKMaxTableCapacity = 4096
+	// defaultQPACKMaxBlockedStreams is the default number of streams that can be blocked on QPACK encoder instructions.
+	defaultQPACKMaxBlockedStreams = 16
+)
+
+// qpackEntryOverhead is the overhead of an entry in the dynamic table, see RFC 9204, section 3.2.1.
+const qpackEntryOverhead = 32
+
+// A qpackError is an error that occurred while processing the QPACK encoder or decoder stream, or a field section.
+// It is a connection error.
+type qpackError struct {
+	code errorCode
+	err  error
+}
+
+func (e *qpackError) Error() string {
+	return e.err.Error()
+}
+
+// qpackLimits returns the capacity of the dynamic table and the number of blocked streams,
+// as configured in the Server or in the RoundTripper.
+// Zero means that the default value is used, a negative value disables the feature.
+func qpackLimits(maxTableCapacity, maxBlockedStreams int64) (uint64, uint64) {
+	capacity := uint64(defaultQPACKMaxTableCapacity)
+	if maxTableCapacity < 0 {
+		capacity = 0
+	} else if maxTableCapacity > 0 {
+		capacity = uint64(maxTableCapacity)
+	}
+	blocked := uint64(defaultQPACKMaxBlockedStreams)
+	if maxBlockedStreams < 0 || capacity == 0 {
+		blocked = 0
+	} else if maxBlockedStreams > 0 {
+		blocked = uint64(maxBlockedStreams)
+	}
+	return capacity, blocked
+}
+
+// addQPACKSettings adds the QPACK settings to a SETTINGS frame.
+// Settings that have their default value of 0 are omitted.
+func addQPACKSettings(f *settingsFrame, capacity, blocked uint64) {
+	if capacity == 0 {
+		return
+	}
+	if f.settings == nil {
+		f.settings = make(map[uint64]uint64)
+	}
+	f.settings[settingQPACKMaxTableCapacity] = capacity
+	if blocked > 0 {
+		f.settings[settingQPACKBlockedStreams] = blocked
+	}
+}
+
+func qpackEntrySize(hf qpack.HeaderField) uint64 {
+	return uint64(len(hf.Name)+len(hf.Value)) + qpackEntryOverhead
+}
+
+// qpackMaxEntries is the maximum number of entries that the dynamic table can hold, see RFC 9204, section 3.2.2.
+func qpackMaxEntries(maxTableCapacity uint64) uint64 {
+	return maxTableCapacity / qpackEntryOverhead
+}
+
+// The qpackDynamicTable is the QPACK dynamic table, see RFC 9204, section 3.2.
+// Entries are referenced by their absolute index.
+type qpackDynamicTable struct {
+	capacity uint64
+	size     uint64
+	dropped  uint64 // the number of evicted entries, i.e. the absolute index of entries[0]
+	entries  []qpack.HeaderField
+}
+
+// insertCount is the total number of insertions into the table.
+func (t *qpackDynamicTable) insertCount() uint64 {
+	return t.dropped + uint64(len(t.entries))
+}
+
+func (t *qpackDynamicTable) get(abs uint64) (qpack.HeaderField, bool) {
+	if abs < t.dropped || abs >= t.insertCount() {
+		return qpack.HeaderField{}, false
+	}
+	return t.entries[abs-t.dropped], true
+}
+
+// evictableSize returns how much space can be freed by evicting all entries with an absolute index smaller than limit.
+func (t *qpackDynamicTable) evictableSize(limit uint64) uint64 {
+	var size uint64
+	for i := t.dropped; i < limit && i < t.insertCount(); i++ {
+		size += qpackEntrySize(t.entries[i-t.dropped])
+	}
+	return size
+}
+
+// evict evicts entries until the table size is at most size.
+// It returns the evicted entries.
+func (t *qpackDynamicTable) evict(size uint64) []qpack.HeaderField {
+	var evicted []qpack.HeaderField
+	for t.size > size {
+		hf := t.entries[0]
+		t.entries = t.entries[1:]
+		t.size -= qpackEntrySize(hf)
+		t.dropped++
+		evicted = append(evicted, hf)
+	}
+	return evicted
+}
+
+func (t *qpackDynamicTable) setCapacity(c uint64) {
+	t.evict(c)
+	t.capacity = c
+}
+
+// insert inserts an entry, evicting as many entries as necessary.
+// The caller must make sure that the entry fits into the table.
+func (t *qpackDynamicTable) insert(hf qpack.HeaderField) []qpack.HeaderField {
+	size := qpackEntrySize(hf)
+	evicted := t.evict(t.capacity - size)
+	t.entries = append(t.entries, hf)
+	t.size += size
+	return evicted
+}
+
+// A qpackStreamReader reads from the QPACK encoder or decoder stream, and remembers errors that occurred while reading.
+// This allows distinguishing errors reading from the stream from invalid instructions.
+type qpackStreamReader struct {
+	str io.Reader
+	err error
+}
+
+func (r *qpackStreamReader) Read(b []byte) (int, error) {
+	n, err := r.str.Read(b)
+	if err != nil {
+		r.err = err
+	}
+	return n, err
+}
+
+// streamError converts an error that occurred while processing the stream into a *qpackError,
+// unless it was caused by reading from the stream.
+func (r *qpackStreamReader) streamError(err error, code errorCode) error {
+	if _, ok := err.(*qpackError); ok || r.err != nil {
+		return err
+	}
+	return &qpackError{code: code, err: err}
+}
+
+// appendQPACKInt appends an integer using an n-bit prefix, see RFC 7541, section 5.1.
+// The bits of the first byte that are not used by the prefix are set to flags.
+func appendQPACKInt(b []byte, n uint8, flags byte, i uint64) []byte {
+	k := uint64(1)<<n - 1
+	if i < k {
+		return append(b, flags|byte(i))
+	}
+	b = append(b, flags|byte(k))
+	i -= k
+	for ; i >= 0x80; i >>= 7 {
+		b = append(b, byte(0x80|i&0x7f))
+	}
+	return append(b, byte(i))
+}
+
+// readQPACKInt reads an integer using an n-bit prefix.
+// first is the first byte of the integer, which was already read from r.
+func readQPACKInt(r io.ByteReader, first byte, n uint8) (uint64, error) {
+	k := uint64(1)<<n - 1
+	i := uint64(first) & k
+	if i < k {
+		return i, nil
+	}
+	for m := uint(0); ; m += 7 {
+		if m > 56 {
+			return 0, errors.New("qpack: integer overflow")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		i += uint64(b&0x7f) << m
+		if b&0x80 == 0 {
+			return i, nil
+		}
+	}
+}
+
+// appendQPACKString appends a string literal using an n-bit prefix for the length.
+// The Huffman encoding is used if it results in a shorter string.
+func appendQPACKString(b []byte, n uint8, flags byte, s string) []byte {
+	if l := hpack.HuffmanEncodeLength(s); l < uint64(len(s)) {
+		b = appendQPACKInt(b, n, flags|1<<n, l)
+		return hpack.AppendHuffmanString(b, s)
+	}
+	b = appendQPACKInt(b, n, flags, uint64(len(s)))
+	return append(b, s...)
+}
+
+// readQPACKString reads a string literal using an n-bit prefix for the length.
+// The bit preceding the prefix is the Huffman flag.
+// first is the first byte of the string literal, which was already read from r.
+func readQPACKString(r byteReader, first byte, n uint8, maxLen uint64) (string, error) {
+	l, err := readQPACKInt(r, first, n)
+	if err != nil {
+		return "", err
+	}
+	if l > maxLen {
+		return "", errors.New("qpack: string literal too long")
+	}
+	b := make([]byte, l)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	if first&(1<<n) == 0 {
+		return string(b), nil
+	}
+	return hpack.HuffmanDecodeToString(b)
+}
+
+// A qpackStream is the QPACK encoder or decoder stream.
+// The stream is only opened when the first instruction is sent on it.
+type qpackStream struct {
+	streamType uint64
+	open       func() (quic.SendStream, error)
+
+	mutex sync.Mutex
+	str   quic.SendStream
+	err   error
+}
+
+func newQPACKStream(streamType uint64, open func() (quic.SendStream, error)) *qpackStream {
+	return &qpackStream{streamType: streamType, open: open}
+}
+
+func (s *qpackStream) Write(b []byte) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.err != nil {
+		return 0, s.err
+	}
+	if s.str == nil {
+		str, err := s.open()
+		if err != nil {
+			s.err = err
+			return 0, err
+		}
+		s.str = str
+		buf := &bytes.Buffer{}
+		utils.WriteVarInt(buf, s.streamType)
+		buf.Write(b)
+		if _, err := s.str.Write(buf.Bytes()); err != nil {
+			s.err = err
+			return 0, err
+		}
+		return len(b), nil
+	}
+	n, err := s.str.Write(b)
+	if err != nil {
+		s.err = err
+	}
+	return n, err
+}
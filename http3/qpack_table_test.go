@@ -0,0 +1,155 @@
+package http3
+
+import (
+	"bytes"
+
+	"github.com/marten-seemann/qpack"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("QPACK tables", func() {
+	Context("limits", func() {
+		It("uses the default values", func() {
+			capacity, blocked := qpackLimits(0, 0)
+			Expect(capacity).To(BeEquivalentTo(defaultQPACKMaxTableCapacity))
+			Expect(blocked).To(BeEquivalentTo(defaultQPACKMaxBlockedStreams))
+		})
+
+		It("uses the configured values", func() {
+			capacity, blocked := qpackLimits(1000, 5)
+			Expect(capacity).To(BeEquivalentTo(1000))
+			Expect(blocked).To(BeEquivalentTo(5))
+		})
+
+		It("disables blocked streams", func() {
+			capacity, blocked := qpackLimits(0, -1)
+			Expect(capacity).To(BeEquivalentTo(defaultQPACKMaxTableCapacity))
+			Expect(blocked).To(BeZero())
+		})
+
+		It("disables the dynamic table", func() {
+			capacity, blocked := qpackLimits(-1, 10)
+			Expect(capacity).To(BeZero())
+			Expect(blocked).To(BeZero())
+		})
+
+		It("adds the settings to the SETTINGS frame", func() {
+			f := &settingsFrame{}
+			addQPACKSettings(f, 1000, 5)
+			Expect(f.settings).To(Equal(map[uint64]uint64{
+				settingQPACKMaxTableCapacity: 1000,
+				settingQPACKBlockedStreams:   5,
+			}))
+		})
+
+		It("omits settings that have the default value", func() {
+			f := &settingsFrame{}
+			addQPACKSettings(f, 1000, 0)
+			Expect(f.settings).To(Equal(map[uint64]uint64{settingQPACKMaxTableCapacity: 1000}))
+			f = &settingsFrame{}
+			addQPACKSettings(f, 0, 0)
+			Expect(f.settings).To(BeEmpty())
+		})
+	})
+
+	Context("integers", func() {
+		// examples from RFC 7541, appendix C.1
+		It("writes integers that fit into the prefix", func() {
+			Expect(appendQPACKInt(nil, 5, 0xe0, 10)).To(Equal([]byte{0xea}))
+		})
+
+		It("writes integers that don't fit into the prefix", func() {
+			Expect(appendQPACKInt(nil, 5, 0, 1337)).To(Equal([]byte{0x1f, 0x9a, 0x0a}))
+		})
+
+		It("writes integers starting at an octet boundary", func() {
+			Expect(appendQPACKInt(nil, 8, 0, 42)).To(Equal([]byte{0x2a}))
+		})
+
+		It("reads integers", func() {
+			for _, n := range []uint8{3, 5, 6, 7, 8} {
+				for _, i := range []uint64{0, 1, 6, 7, 8, 127, 128, 1337, 1 << 20, 1<<62 - 1} {
+					b := appendQPACKInt(nil, n, 0, i)
+					r := bytes.NewReader(b[1:])
+					val, err := readQPACKInt(r, b[0], n)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(val).To(Equal(i))
+					Expect(r.Len()).To(BeZero())
+				}
+			}
+		})
+
+		It("ignores the flags", func() {
+			b := appendQPACKInt(nil, 5, 0xe0, 1337)
+			val, err := readQPACKInt(bytes.NewReader(b[1:]), b[0], 5)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(val).To(BeEquivalentTo(1337))
+		})
+
+		It("errors on overflowing integers", func() {
+			b := append([]byte{0xff}, bytes.Repeat([]byte{0xff}, 10)...)
+			_, err := readQPACKInt(bytes.NewReader(b[1:]), b[0], 8)
+			Expect(err).To(MatchError("qpack: integer overflow"))
+		})
+	})
+
+	Context("strings", func() {
+		It("uses the Huffman encoding if it's shorter", func() {
+			b := appendQPACKString(nil, 7, 0, "www.example.com")
+			Expect(b[0] & 0x80).ToNot(BeZero())
+			Expect(len(b)).To(BeNumerically("<", 1+len("www.example.com")))
+			s, err := readQPACKString(bytes.NewReader(b[1:]), b[0], 7, 100)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s).To(Equal("www.example.com"))
+		})
+
+		It("doesn't use the Huffman encoding if it's longer", func() {
+			b := appendQPACKString(nil, 3, 0x20, "\x00\x01")
+			Expect(b).To(Equal([]byte{0x22, 0, 1}))
+			s, err := readQPACKString(bytes.NewReader(b[1:]), b[0], 3, 100)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s).To(Equal("\x00\x01"))
+		})
+
+		It("errors on strings that are too long", func() {
+			b := appendQPACKString(nil, 7, 0, "foobar")
+			_, err := readQPACKString(bytes.NewReader(b[1:]), b[0], 7, 3)
+			Expect(err).To(MatchError("qpack: string literal too long"))
+		})
+	})
+
+	Context("dynamic table", func() {
+		It("inserts and evicts entries", func() {
+			t := &qpackDynamicTable{}
+			t.setCapacity(100)
+			foo := qpack.HeaderField{Name: "foo", Value: "bar"} // 38 bytes
+			baz := qpack.HeaderField{Name: "baz", Value: "qux"} // 38 bytes
+			Expect(t.insert(foo)).To(BeEmpty())
+			Expect(t.insert(baz)).To(BeEmpty())
+			Expect(t.size).To(BeEquivalentTo(76))
+			Expect(t.insertCount()).To(BeEquivalentTo(2))
+			Expect(t.evictableSize(1)).To(BeEquivalentTo(38))
+			Expect(t.insert(qpack.HeaderField{Name: "new", Value: "val"})).To(Equal([]qpack.HeaderField{foo}))
+			_, ok := t.get(0)
+			Expect(ok).To(BeFalse())
+			hf, ok := t.get(1)
+			Expect(ok).To(BeTrue())
+			Expect(hf).To(Equal(baz))
+			_, ok = t.get(3)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("evicts entries when the capacity is reduced", func() {
+			t := &qpackDynamicTable{}
+			t.setCapacity(100)
+			t.insert(qpack.HeaderField{Name: "foo", Value: "bar"})
+			t.insert(qpack.HeaderField{Name: "baz", Value: "qux"})
+			t.setCapacity(40)
+			Expect(t.size).To(BeEquivalentTo(38))
+			Expect(t.dropped).To(BeEquivalentTo(1))
+			Expect(t.insertCount()).To(BeEquivalentTo(2))
+		})
+	})
+})
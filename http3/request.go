@@ -12,7 +12,7 @@ import (
 )
 
 func requestFromHeaders(headers []qpack.HeaderField) (*http.Request, error) {
-	var path, authority, method, contentLengthStr string
+	var path, authority, method, protocol, scheme, contentLengthStr string
 	httpHeaders := http.Header{}
 
 	for _, h := range headers {
@@ -23,6 +23,10 @@ func requestFromHeaders(headers []qpack.HeaderField) (*http.Request, error) {
 			method = h.Value
 		case ":authority":
 			authority = h.Value
+		case ":protocol":
+			protocol = h.Value
+		case ":scheme":
+			scheme = h.Value
 		case "content-length":
 			contentLengthStr = h.Value
 		default:
@@ -37,6 +41,18 @@ func requestFromHeaders(headers []qpack.HeaderField) (*http.Request, error) {
 		httpHeaders.Set("Cookie", strings.Join(httpHeaders["Cookie"], "; "))
 	}
 
+	// the trailers are populated once the request body was read
+	trailer := declaredTrailers(httpHeaders)
+	httpHeaders.Del("Trailer")
+
+	// An Extended CONNECT request carries a :protocol pseudo header, see RFC 9220, section 3.
+	isExtendedConnect := method == http.MethodConnect && len(protocol) > 0
+	if len(protocol) > 0 && !isExtendedConnect {
+		return nil, errors.New(":protocol must only be used with the CONNECT method")
+	}
+	if isExtendedConnect && len(scheme) == 0 {
+		return nil, errors.New("extended CONNECT: :scheme must not be empty")
+	}
 	if len(path) == 0 || len(authority) == 0 || len(method) == 0 {
 		return nil, errors.New(":path, :authority and :method must not be empty")
 	}
@@ -54,13 +70,19 @@ func requestFromHeaders(headers []qpack.HeaderField) (*http.Request, error) {
 		}
 	}
 
+	proto := "HTTP/3"
+	if isExtendedConnect {
+		proto = protocol
+	}
+
 	return &http.Request{
 		Method:        method,
 		URL:           u,
-		Proto:         "HTTP/3",
+		Proto:         proto,
 		ProtoMajor:    3,
 		ProtoMinor:    0,
 		Header:        httpHeaders,
+		Trailer:       trailer,
 		Body:          nil,
 		ContentLength: contentLength,
 		Host:          authority,
@@ -69,6 +91,12 @@ func requestFromHeaders(headers []qpack.HeaderField) (*http.Request, error) {
 	}, nil
 }
 
+// isExtendedConnect says if a request is an Extended CONNECT request.
+// The protocol is transported in the Proto field of the request.
+func isExtendedConnect(req *http.Request) bool {
+	return req.Method == http.MethodConnect && req.Proto != "" && req.Proto != "HTTP/1.1" && req.Proto != "HTTP/3"
+}
+
 func hostnameFromRequest(req *http.Request) string {
 	if req.URL != nil {
 		return req.URL.Host
@@ -81,6 +81,23 @@ var _ = Describe("Request", func() {
 		}))
 	})
 
+	It("populates the announced trailers", func() {
+		headers := []qpack.HeaderField{
+			{Name: ":path", Value: "/foo"},
+			{Name: ":authority", Value: "quic.clemente.io"},
+			{Name: ":method", Value: "POST"},
+			{Name: "trailer", Value: "grpc-status, grpc-message"},
+			{Name: "trailer", Value: "content-length"}, // not allowed in trailers
+		}
+		req, err := requestFromHeaders(headers)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(req.Header).ToNot(HaveKey("Trailer"))
+		Expect(req.Trailer).To(Equal(http.Header{
+			"Grpc-Status":  nil,
+			"Grpc-Message": nil,
+		}))
+	})
+
 	It("errors with missing path", func() {
 		headers := []qpack.HeaderField{
 			{Name: ":authority", Value: "quic.clemente.io"},
@@ -108,6 +125,58 @@ var _ = Describe("Request", func() {
 		Expect(err).To(MatchError(":path, :authority and :method must not be empty"))
 	})
 
+	Context("Extended CONNECT", func() {
+		It("populates the protocol", func() {
+			headers := []qpack.HeaderField{
+				{Name: ":path", Value: "/chat"},
+				{Name: ":authority", Value: "quic.clemente.io"},
+				{Name: ":method", Value: "CONNECT"},
+				{Name: ":scheme", Value: "https"},
+				{Name: ":protocol", Value: "websocket"},
+			}
+			req, err := requestFromHeaders(headers)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(req.Method).To(Equal(http.MethodConnect))
+			Expect(req.Proto).To(Equal("websocket"))
+			Expect(req.URL.Path).To(Equal("/chat"))
+			Expect(req.Header).To(BeEmpty())
+			Expect(isExtendedConnect(req)).To(BeTrue())
+		})
+
+		It("errors if the :scheme is missing", func() {
+			headers := []qpack.HeaderField{
+				{Name: ":path", Value: "/chat"},
+				{Name: ":authority", Value: "quic.clemente.io"},
+				{Name: ":method", Value: "CONNECT"},
+				{Name: ":protocol", Value: "websocket"},
+			}
+			_, err := requestFromHeaders(headers)
+			Expect(err).To(MatchError("extended CONNECT: :scheme must not be empty"))
+		})
+
+		It("errors if :protocol is used with a method other than CONNECT", func() {
+			headers := []qpack.HeaderField{
+				{Name: ":path", Value: "/chat"},
+				{Name: ":authority", Value: "quic.clemente.io"},
+				{Name: ":method", Value: "GET"},
+				{Name: ":scheme", Value: "https"},
+				{Name: ":protocol", Value: "websocket"},
+			}
+			_, err := requestFromHeaders(headers)
+			Expect(err).To(MatchError(":protocol must only be used with the CONNECT method"))
+		})
+
+		It("doesn't treat normal requests as Extended CONNECT requests", func() {
+			req, err := http.NewRequest(http.MethodConnect, "https://quic.clemente.io", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isExtendedConnect(req)).To(BeFalse())
+			req, err = http.NewRequest(http.MethodGet, "https://quic.clemente.io", nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.Proto = "websocket"
+			Expect(isExtendedConnect(req)).To(BeFalse())
+		})
+	})
+
 	Context("extracting the hostname from a request", func() {
 		var url *url.URL
 
@@ -8,7 +8,6 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
-	"sync"
 
 	"github.com/lucas-clemente/quic-go"
 	"github.com/lucas-clemente/quic-go/internal/utils"
@@ -21,34 +20,38 @@ import (
 const bodyCopyBufferSize = 8 * 1024
 
 type requestWriter struct {
-	mutex     sync.Mutex
-	encoder   *qpack.Encoder
-	headerBuf *bytes.Buffer
+	encoder *qpackEncoder
 
 	logger utils.Logger
 }
 
-func newRequestWriter(logger utils.Logger) *requestWriter {
-	headerBuf := &bytes.Buffer{}
-	encoder := qpack.NewEncoder(headerBuf)
+func newRequestWriter(encoder *qpackEncoder, logger utils.Logger) *requestWriter {
 	return &requestWriter{
-		encoder:   encoder,
-		headerBuf: headerBuf,
-		logger:    logger,
+		encoder: encoder,
+		logger:  logger,
 	}
 }
 
 func (w *requestWriter) WriteRequest(str quic.Stream, req *http.Request, gzip bool) error {
+	trailers, err := commaSeparatedTrailers(req)
+	if err != nil {
+		return err
+	}
 	buf := &bytes.Buffer{}
-	if err := w.writeHeaders(buf, req, gzip); err != nil {
+	if err := w.writeHeaders(buf, str.StreamID(), req, gzip, trailers); err != nil {
 		return err
 	}
 	if _, err := str.Write(buf.Bytes()); err != nil {
 		return err
 	}
-	// TODO: add support for trailers
 	if req.Body == nil {
-		str.Close()
+		if err := w.writeTrailers(str, str.StreamID(), req.Trailer); err != nil {
+			return err
+		}
+		// The stream of an Extended CONNECT request stays open, it is used by the protocol.
+		if !isExtendedConnect(req) {
+			str.Close()
+		}
 		return nil
 	}
 
@@ -84,57 +87,91 @@ func (w *requestWriter) WriteRequest(str quic.Stream, req *http.Request, gzip bo
 				return
 			}
 		}
+		// The trailer values are only available once the body was read.
+		if err := w.writeTrailers(str, str.StreamID(), req.Trailer); err != nil {
+			w.logger.Errorf("Error writing request trailers: %s", err)
+			str.CancelWrite(quic.ErrorCode(errorRequestCanceled))
+			return
+		}
 		str.Close()
 	}()
 
 	return nil
 }
 
-func (w *requestWriter) writeHeaders(wr io.Writer, req *http.Request, gzip bool) error {
-	w.mutex.Lock()
-	defer w.mutex.Unlock()
-	defer w.encoder.Close()
-
-	if err := w.encodeHeaders(req, gzip, "", actualContentLength(req)); err != nil {
+func (w *requestWriter) writeHeaders(wr io.Writer, id quic.StreamID, req *http.Request, gzip bool, trailers string) error {
+	hfs, err := w.encodeHeaders(req, gzip, trailers, actualContentLength(req))
+	if err != nil {
 		return err
 	}
+	return w.writeHeadersFrame(wr, id, hfs)
+}
+
+// writeTrailers writes a HEADERS frame containing the trailers.
+// Nothing is written if none of the trailers have a value.
+func (w *requestWriter) writeTrailers(wr io.Writer, id quic.StreamID, trailer http.Header) error {
+	var hasTrailers bool
+	for k, vv := range trailer {
+		if !httpguts.ValidHeaderFieldName(k) {
+			return fmt.Errorf("invalid HTTP trailer name %q", k)
+		}
+		for _, v := range vv {
+			if !httpguts.ValidHeaderFieldValue(v) {
+				return fmt.Errorf("invalid HTTP trailer value %q for trailer %q", v, k)
+			}
+			hasTrailers = true
+		}
+	}
+	if !hasTrailers {
+		return nil
+	}
+	var hfs []qpack.HeaderField
+	for k, vv := range trailer {
+		for _, v := range vv {
+			hfs = append(hfs, qpack.HeaderField{Name: strings.ToLower(k), Value: v})
+		}
+	}
+	return w.writeHeadersFrame(wr, id, hfs)
+}
 
+// writeHeadersFrame encodes the header fields, and writes a HEADERS frame containing them.
+func (w *requestWriter) writeHeadersFrame(wr io.Writer, id quic.StreamID, hfs []qpack.HeaderField) error {
+	headers := w.encoder.encode(id, hfs)
 	buf := &bytes.Buffer{}
-	hf := headersFrame{Length: uint64(w.headerBuf.Len())}
+	hf := headersFrame{Length: uint64(len(headers))}
 	hf.Write(buf)
 	if _, err := wr.Write(buf.Bytes()); err != nil {
 		return err
 	}
-	if _, err := wr.Write(w.headerBuf.Bytes()); err != nil {
-		return err
-	}
-	w.headerBuf.Reset()
-	return nil
+	_, err := wr.Write(headers)
+	return err
 }
 
 // copied from net/transport.go
 
-func (w *requestWriter) encodeHeaders(req *http.Request, addGzipHeader bool, trailers string, contentLength int64) error {
+func (w *requestWriter) encodeHeaders(req *http.Request, addGzipHeader bool, trailers string, contentLength int64) ([]qpack.HeaderField, error) {
 	host := req.Host
 	if host == "" {
 		host = req.URL.Host
 	}
 	host, err := httpguts.PunycodeHostPort(host)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	// Extended CONNECT requests carry a :path and a :scheme, see RFC 9220, section 3.
+	extendedConnect := isExtendedConnect(req)
 	var path string
-	if req.Method != "CONNECT" {
+	if req.Method != "CONNECT" || extendedConnect {
 		path = req.URL.RequestURI()
 		if !validPseudoPath(path) {
 			orig := path
 			path = strings.TrimPrefix(path, req.URL.Scheme+"://"+host)
 			if !validPseudoPath(path) {
 				if req.URL.Opaque != "" {
-					return fmt.Errorf("invalid request :path %q from URL.Opaque = %q", orig, req.URL.Opaque)
+					return nil, fmt.Errorf("invalid request :path %q from URL.Opaque = %q", orig, req.URL.Opaque)
 				} else {
-					return fmt.Errorf("invalid request :path %q", orig)
+					return nil, fmt.Errorf("invalid request :path %q", orig)
 				}
 			}
 		}
@@ -145,11 +182,11 @@ func (w *requestWriter) encodeHeaders(req *http.Request, addGzipHeader bool, tra
 	// continue to reuse the hpack encoder for future requests)
 	for k, vv := range req.Header {
 		if !httpguts.ValidHeaderFieldName(k) {
-			return fmt.Errorf("invalid HTTP header name %q", k)
+			return nil, fmt.Errorf("invalid HTTP header name %q", k)
 		}
 		for _, v := range vv {
 			if !httpguts.ValidHeaderFieldValue(v) {
-				return fmt.Errorf("invalid HTTP header value %q for header %q", v, k)
+				return nil, fmt.Errorf("invalid HTTP header value %q for header %q", v, k)
 			}
 		}
 	}
@@ -162,10 +199,13 @@ func (w *requestWriter) encodeHeaders(req *http.Request, addGzipHeader bool, tra
 		// [RFC3986]).
 		f(":authority", host)
 		f(":method", req.Method)
-		if req.Method != "CONNECT" {
+		if req.Method != "CONNECT" || extendedConnect {
 			f(":path", path)
 			f(":scheme", req.URL.Scheme)
 		}
+		if extendedConnect {
+			f(":protocol", req.Proto)
+		}
 		if trailers != "" {
 			f("trailer", trailers)
 		}
@@ -234,15 +274,16 @@ func (w *requestWriter) encodeHeaders(req *http.Request, addGzipHeader bool, tra
 	// traceHeaders := traceHasWroteHeaderField(trace)
 
 	// Header list size is ok. Write the headers.
+	var hfs []qpack.HeaderField
 	enumerateHeaders(func(name, value string) {
 		name = strings.ToLower(name)
-		w.encoder.WriteField(qpack.HeaderField{Name: name, Value: value})
+		hfs = append(hfs, qpack.HeaderField{Name: name, Value: value})
 		// if traceHeaders {
 		// 	traceWroteHeaderField(trace, name, value)
 		// }
 	})
 
-	return nil
+	return hfs, nil
 }
 
 // authorityAddr returns a given authority (a host/IP, or host:port / ip:port)
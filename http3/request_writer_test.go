@@ -48,12 +48,13 @@ var _ = Describe("Request Writer", func() {
 	}
 
 	BeforeEach(func() {
-		rw = newRequestWriter(utils.DefaultLogger)
+		rw = newRequestWriter(newQPACKEncoder(0, nil, utils.DefaultLogger), utils.DefaultLogger)
 		strBuf = &bytes.Buffer{}
 		str = mockquic.NewMockStream(mockCtrl)
 		str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
 			return strBuf.Write(p)
 		}).AnyTimes()
+		str.EXPECT().StreamID().AnyTimes()
 	})
 
 	It("writes a GET request", func() {
@@ -135,4 +136,56 @@ var _ = Describe("Request Writer", func() {
 		headerFields := decode(strBuf)
 		Expect(headerFields).To(HaveKeyWithValue("accept-encoding", "gzip"))
 	})
+
+	It("writes an Extended CONNECT request, and doesn't close the stream", func() {
+		req, err := http.NewRequest(http.MethodConnect, "https://quic.clemente.io/chat", nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.Proto = "websocket"
+		Expect(rw.WriteRequest(str, req, false)).To(Succeed())
+		headerFields := decode(strBuf)
+		Expect(headerFields).To(HaveKeyWithValue(":authority", "quic.clemente.io"))
+		Expect(headerFields).To(HaveKeyWithValue(":method", "CONNECT"))
+		Expect(headerFields).To(HaveKeyWithValue(":path", "/chat"))
+		Expect(headerFields).To(HaveKeyWithValue(":scheme", "https"))
+		Expect(headerFields).To(HaveKeyWithValue(":protocol", "websocket"))
+	})
+
+	Context("trailers", func() {
+		It("writes trailers after the request body", func() {
+			closed := make(chan struct{})
+			str.EXPECT().Close().Do(func() { close(closed) })
+			req, err := http.NewRequest("POST", "https://quic.clemente.io/upload.html", bytes.NewReader([]byte("foobar")))
+			Expect(err).ToNot(HaveOccurred())
+			req.Trailer = http.Header{"Foo": nil, "Grpc-Status": nil}
+			req.Trailer.Set("Grpc-Status", "0")
+			Expect(rw.WriteRequest(str, req, false)).To(Succeed())
+
+			Eventually(closed).Should(BeClosed())
+			headerFields := decode(strBuf)
+			Expect(headerFields).To(HaveKeyWithValue("trailer", "Foo,Grpc-Status"))
+			frame, err := parseNextFrame(strBuf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(BeAssignableToTypeOf(&dataFrame{}))
+			strBuf.Next(int(frame.(*dataFrame).Length))
+			Expect(decode(strBuf)).To(Equal(map[string]string{"grpc-status": "0"}))
+			Expect(strBuf.Len()).To(BeZero())
+		})
+
+		It("writes trailers for requests without a body", func() {
+			str.EXPECT().Close()
+			req, err := http.NewRequest("GET", "https://quic.clemente.io/", nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.Trailer = http.Header{"Foo": []string{"bar"}}
+			Expect(rw.WriteRequest(str, req, false)).To(Succeed())
+			Expect(decode(strBuf)).To(HaveKeyWithValue("trailer", "Foo"))
+			Expect(decode(strBuf)).To(Equal(map[string]string{"foo": "bar"}))
+		})
+
+		It("rejects invalid trailer keys", func() {
+			req, err := http.NewRequest("GET", "https://quic.clemente.io/", nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.Trailer = http.Header{"Content-Length": nil}
+			Expect(rw.WriteRequest(str, req, false)).To(MatchError(`invalid Trailer key "Content-Length"`))
+		})
+	})
 })
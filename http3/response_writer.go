@@ -8,28 +8,50 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/lucas-clemente/quic-go"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/marten-seemann/qpack"
+	"golang.org/x/net/http/httpguts"
 )
 
 type responseWriter struct {
-	stream *bufio.Writer
+	stream   *bufio.Writer
+	streamID quic.StreamID
+	encoder  *qpackEncoder
 
 	header        http.Header
 	status        int // status code passed to WriteHeader
 	headerWritten bool
+	trailers      []string // trailers announced in the Trailer header
+
+	// only set for responses written by the server
+	conn         *serverConn
+	str          quic.Stream
+	hijacked     bool // set when the stream was taken over for a WebTransport session
+	hasDeadlines bool // set if read or write deadlines were set on the stream
+	push         func(target string, opts *http.PushOptions) error
 
 	logger utils.Logger
 }
 
+// Hijacker allows an http.Handler to access the QUIC session that a request was received on.
+// The http.ResponseWriter passed to handlers by the Server implements this interface.
+type Hijacker interface {
+	Session() quic.Session
+}
+
 var _ http.ResponseWriter = &responseWriter{}
 var _ http.Flusher = &responseWriter{}
+var _ http.Pusher = &responseWriter{}
+var _ Hijacker = &responseWriter{}
 
-func newResponseWriter(stream io.Writer, logger utils.Logger) *responseWriter {
+func newResponseWriter(stream io.Writer, streamID quic.StreamID, encoder *qpackEncoder, logger utils.Logger) *responseWriter {
 	return &responseWriter{
-		header: http.Header{},
-		stream: bufio.NewWriter(stream),
-		logger: logger,
+		header:   http.Header{},
+		stream:   bufio.NewWriter(stream),
+		streamID: streamID,
+		encoder:  encoder,
+		logger:   logger,
 	}
 }
 
@@ -44,23 +66,28 @@ func (w *responseWriter) WriteHeader(status int) {
 	w.headerWritten = true
 	w.status = status
 
-	var headers bytes.Buffer
-	enc := qpack.NewEncoder(&headers)
-	enc.WriteField(qpack.HeaderField{Name: ":status", Value: strconv.Itoa(status)})
-
+	hfs := []qpack.HeaderField{{Name: ":status", Value: strconv.Itoa(status)}}
 	for k, v := range w.header {
+		if strings.HasPrefix(k, http.TrailerPrefix) {
+			// sent as a trailer
+			continue
+		}
 		for index := range v {
-			enc.WriteField(qpack.HeaderField{Name: strings.ToLower(k), Value: v[index]})
+			hfs = append(hfs, qpack.HeaderField{Name: strings.ToLower(k), Value: v[index]})
 		}
 	}
+	for k := range declaredTrailers(w.header) {
+		w.trailers = append(w.trailers, k)
+	}
+	headers := w.encoder.encode(w.streamID, hfs)
 
 	buf := &bytes.Buffer{}
-	(&headersFrame{Length: uint64(headers.Len())}).Write(buf)
+	(&headersFrame{Length: uint64(len(headers))}).Write(buf)
 	w.logger.Infof("Responding with %d", status)
 	if _, err := w.stream.Write(buf.Bytes()); err != nil {
 		w.logger.Errorf("could not write headers frame: %s", err.Error())
 	}
-	if _, err := w.stream.Write(headers.Bytes()); err != nil {
+	if _, err := w.stream.Write(headers); err != nil {
 		w.logger.Errorf("could not write header frame payload: %s", err.Error())
 	}
 }
@@ -81,6 +108,74 @@ func (w *responseWriter) Write(p []byte) (int, error) {
 	return w.stream.Write(p)
 }
 
+// writeTrailers writes a HEADERS frame containing the trailers, after the handler returned.
+// Trailers are either announced in the Trailer header before writing the response header,
+// or set using the http.TrailerPrefix.
+func (w *responseWriter) writeTrailers() {
+	trailer := make(http.Header)
+	for _, k := range w.trailers {
+		if vv, ok := w.header[k]; ok {
+			trailer[k] = vv
+		}
+	}
+	for k, vv := range w.header {
+		if strings.HasPrefix(k, http.TrailerPrefix) {
+			trailer[http.CanonicalHeaderKey(strings.TrimPrefix(k, http.TrailerPrefix))] = vv
+		}
+	}
+
+	var hfs []qpack.HeaderField
+	for k, vv := range trailer {
+		if !httpguts.ValidTrailerHeader(k) {
+			w.logger.Debugf("Ignoring invalid trailer %q", k)
+			continue
+		}
+		for _, v := range vv {
+			hfs = append(hfs, qpack.HeaderField{Name: strings.ToLower(k), Value: v})
+		}
+	}
+	if len(hfs) == 0 {
+		return
+	}
+	headers := w.encoder.encode(w.streamID, hfs)
+
+	buf := &bytes.Buffer{}
+	(&headersFrame{Length: uint64(len(headers))}).Write(buf)
+	if _, err := w.stream.Write(buf.Bytes()); err != nil {
+		w.logger.Errorf("could not write trailers frame: %s", err.Error())
+	}
+	if _, err := w.stream.Write(headers); err != nil {
+		w.logger.Errorf("could not write trailers frame payload: %s", err.Error())
+	}
+}
+
+// Push initiates an HTTP/3 server push.
+// It returns http.ErrNotSupported if the client didn't enable push.
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if w.push == nil {
+		return http.ErrNotSupported
+	}
+	return w.push(target, opts)
+}
+
+// writePushPromise writes a PUSH_PROMISE frame, announcing the pushed request.
+func (w *responseWriter) writePushPromise(pushID uint64, hfs []qpack.HeaderField) error {
+	headers := w.encoder.encode(w.streamID, hfs)
+	buf := &bytes.Buffer{}
+	(&pushPromiseFrame{PushID: pushID, Length: uint64(len(headers))}).Write(buf)
+	buf.Write(headers)
+	_, err := w.stream.Write(buf.Bytes())
+	return err
+}
+
+// Session returns the QUIC session that the request was received on.
+func (w *responseWriter) Session() quic.Session {
+	if w.conn == nil {
+		return nil
+	}
+	return w.conn.sess
+}
+
 func (w *responseWriter) Flush() {
 	if err := w.stream.Flush(); err != nil {
 		w.logger.Errorf("could not flush to stream: %s", err.Error())
@@ -20,7 +20,7 @@ var _ = Describe("Response Writer", func() {
 
 	BeforeEach(func() {
 		strBuf = &bytes.Buffer{}
-		rw = newResponseWriter(strBuf, utils.DefaultLogger)
+		rw = newResponseWriter(strBuf, 0, newQPACKEncoder(0, nil, utils.DefaultLogger), utils.DefaultLogger)
 	})
 
 	decodeHeader := func(str io.Reader) map[string][]string {
@@ -118,4 +118,70 @@ var _ = Describe("Response Writer", func() {
 		Expect(n).To(BeZero())
 		Expect(err).To(MatchError(http.ErrBodyNotAllowed))
 	})
+
+	Context("server push", func() {
+		It("doesn't push if push is not enabled", func() {
+			Expect(rw.Push("/foo", nil)).To(MatchError(http.ErrNotSupported))
+		})
+
+		It("writes PUSH_PROMISE frames before the response", func() {
+			rw.push = func(target string, _ *http.PushOptions) error {
+				return rw.writePushPromise(7, []qpack.HeaderField{{Name: ":path", Value: target}})
+			}
+			Expect(rw.Push("/foo", nil)).To(Succeed())
+			rw.WriteHeader(http.StatusOK)
+			rw.Flush()
+			frame, err := parseNextFrame(strBuf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(BeAssignableToTypeOf(&pushPromiseFrame{}))
+			f := frame.(*pushPromiseFrame)
+			Expect(f.PushID).To(BeEquivalentTo(7))
+			data := make([]byte, f.Length)
+			_, err = io.ReadFull(strBuf, data)
+			Expect(err).ToNot(HaveOccurred())
+			hfs, err := qpack.NewDecoder(nil).DecodeFull(data)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hfs).To(Equal([]qpack.HeaderField{{Name: ":path", Value: "/foo"}}))
+			Expect(decodeHeader(strBuf)).To(HaveKeyWithValue(":status", []string{"200"}))
+		})
+	})
+
+	Context("trailers", func() {
+		It("writes trailers announced in the Trailer header", func() {
+			rw.Header().Set("Trailer", "Grpc-Status")
+			rw.WriteHeader(http.StatusOK)
+			rw.Write([]byte("foobar"))
+			rw.Header().Set("Grpc-Status", "0")
+			rw.writeTrailers()
+			fields := decodeHeader(strBuf)
+			Expect(fields).To(HaveKeyWithValue("trailer", []string{"Grpc-Status"}))
+			Expect(getData(strBuf)).To(Equal([]byte("foobar")))
+			Expect(decodeHeader(strBuf)).To(Equal(map[string][]string{"grpc-status": {"0"}}))
+		})
+
+		It("writes trailers set using the TrailerPrefix", func() {
+			rw.WriteHeader(http.StatusOK)
+			rw.Header().Set(http.TrailerPrefix+"Foo", "bar")
+			rw.writeTrailers()
+			fields := decodeHeader(strBuf)
+			Expect(fields).ToNot(HaveKey("trailer:foo"))
+			Expect(decodeHeader(strBuf)).To(Equal(map[string][]string{"foo": {"bar"}}))
+		})
+
+		It("doesn't send trailers set using the TrailerPrefix as headers", func() {
+			rw.Header().Set(http.TrailerPrefix+"Foo", "bar")
+			rw.WriteHeader(http.StatusOK)
+			fields := decodeHeader(strBuf)
+			Expect(fields).To(HaveLen(1))
+			Expect(fields).To(HaveKey(":status"))
+		})
+
+		It("doesn't write a HEADERS frame if there are no trailers", func() {
+			rw.Header().Set("Trailer", "Foo")
+			rw.WriteHeader(http.StatusOK)
+			rw.writeTrailers()
+			decodeHeader(strBuf)
+			Expect(strBuf.Len()).To(BeZero())
+		})
+	})
 })
@@ -1,6 +1,7 @@
 package http3
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 
 	quic "github.com/lucas-clemente/quic-go"
 
@@ -17,6 +19,15 @@ import (
 type roundTripCloser interface {
 	http.RoundTripper
 	io.Closer
+	canTakeNewRequest() bool
+}
+
+type connecter interface {
+	connect(context.Context) error
+}
+
+type webTransportDialer interface {
+	dialWebTransport(*http.Request) (*http.Response, *WebTransportSession, error)
 }
 
 // RoundTripper implements the http.RoundTripper interface
@@ -51,7 +62,52 @@ type RoundTripper struct {
 	// Zero means to use a default limit.
 	MaxResponseHeaderBytes int64
 
-	clients map[string]roundTripCloser
+	// EnableWebTransport enables WebTransport sessions, see DialWebTransport.
+	// It enables datagram support on the QUIC layer, and allows the server to open bidirectional streams.
+	EnableWebTransport bool
+
+	// PushHandler enables HTTP/3 server push.
+	// It is called for every response pushed by the server, together with the request the server promised.
+	// It may be used to populate a cache. The handler is responsible for closing the response body.
+	// If nil, server push is disabled.
+	PushHandler func(req *http.Request, rsp *http.Response)
+
+	// MaxQPACKTableCapacity is the maximum capacity of the QPACK dynamic table, in bytes.
+	// It bounds the memory used for header compression, in each direction, on every connection.
+	// If zero, 4 KB are used. A negative value disables the dynamic table.
+	MaxQPACKTableCapacity int64
+	// MaxQPACKBlockedStreams is the maximum number of response streams that can be blocked
+	// waiting for QPACK encoder instructions.
+	// If zero, 16 streams can be blocked. A negative value doesn't allow any blocked streams.
+	MaxQPACKBlockedStreams int64
+
+	// Disable0RTT prevents requests from being sent in 0-RTT.
+	// By default, idempotent requests (GET and HEAD requests, and requests carrying an Idempotency-Key header)
+	// are sent in 0-RTT when resuming a session, before the handshake has completed.
+	// If the server responds with a 425 (Too Early), the request is retried once the handshake has completed.
+	// Unless 0-RTT is disabled, the RoundTripper uses a session cache and a token store,
+	// if none are set in TLSClientConfig and QuicConfig.
+	Disable0RTT bool
+
+	// MaxConcurrentStreams is the maximum number of requests that are sent concurrently on a single connection.
+	// Once all connections to an origin have reached this limit, a new connection is opened.
+	// If zero, a single connection is used for every origin.
+	MaxConcurrentStreams int
+
+	// IdleConnTimeout is the maximum amount of time a connection without any active requests
+	// is kept open, before it is closed.
+	// If zero, idle connections are kept open until CloseIdleConnections is called,
+	// or until the QUIC session times out.
+	IdleConnTimeout time.Duration
+
+	// alternativeService returns the address of the alternative service for an origin, if any.
+	// It is used by the Transport.
+	alternativeService func(hostname string) (addr string, ok bool)
+
+	poolOnce sync.Once
+	pool     clientPool
+	tlsConf  *tls.Config
+	quicConf *quic.Config
 }
 
 // RoundTripOpt are options for the Transport.RoundTripOpt method.
@@ -63,7 +119,8 @@ type RoundTripOpt struct {
 	OnlyCachedConn bool
 }
 
-var _ roundTripCloser = &RoundTripper{}
+var _ http.RoundTripper = &RoundTripper{}
+var _ io.Closer = &RoundTripper{}
 
 // ErrNoCachedConn is returned when RoundTripper.OnlyCachedConn is set
 var ErrNoCachedConn = errors.New("http3: no cached connection was available")
@@ -105,11 +162,51 @@ func (r *RoundTripper) RoundTripOpt(req *http.Request, opt RoundTripOpt) (*http.
 	}
 
 	hostname := authorityAddr("https", hostnameFromRequest(req))
-	cl, err := r.getClient(hostname, opt.OnlyCachedConn)
+	for i := 0; ; i++ {
+		cl, err := r.getClient(hostname, opt.OnlyCachedConn)
+		if err != nil {
+			return nil, err
+		}
+		rsp, err := cl.RoundTrip(req)
+		if err == nil && rsp.Body != nil {
+			// The request is active until the response body was read or closed.
+			rsp.Body = &releasingBody{
+				ReadCloser: rsp.Body,
+				release:    func() { r.pool.release(hostname, cl) },
+			}
+		} else {
+			r.pool.release(hostname, cl)
+		}
+		if err != errRequestNotProcessed || i >= maxRequestRetries {
+			return rsp, err
+		}
+		// The server didn't process the request, most likely because it is shutting down.
+		// Stop using this connection, and retry the request on a new connection.
+		r.pool.remove(hostname, cl)
+		if req, err = rewindRequest(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// maxRequestRetries is the number of times a request that wasn't processed by the server is retried.
+const maxRequestRetries = 3
+
+// rewindRequest prepares a request for being sent again.
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("http3: request was not processed by the server, and the request body can't be rewound")
+	}
+	body, err := req.GetBody()
 	if err != nil {
 		return nil, err
 	}
-	return cl.RoundTrip(req)
+	newReq := *req
+	newReq.Body = body
+	return &newReq, nil
 }
 
 // RoundTrip does a round trip.
@@ -117,45 +214,141 @@ func (r *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	return r.RoundTripOpt(req, RoundTripOpt{})
 }
 
-func (r *RoundTripper) getClient(hostname string, onlyCached bool) (http.RoundTripper, error) {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-
-	if r.clients == nil {
-		r.clients = make(map[string]roundTripCloser)
+// DialWebTransport establishes a WebTransport session with the server, using an Extended CONNECT request.
+// The server's response is returned even if it rejected the session.
+// The Body of the response is always empty.
+func (r *RoundTripper) DialWebTransport(ctx context.Context, url string, header http.Header) (*http.Response, *WebTransportSession, error) {
+	if !r.EnableWebTransport {
+		return nil, nil, errors.New("http3: WebTransport not enabled")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodConnect, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.URL.Scheme != "https" {
+		return nil, nil, fmt.Errorf("http3: unsupported protocol scheme: %s", req.URL.Scheme)
 	}
+	if header != nil {
+		req.Header = header.Clone()
+	}
+	req.Proto = protocolWebTransport
 
-	client, ok := r.clients[hostname]
+	hostname := authorityAddr("https", hostnameFromRequest(req))
+	cl, err := r.getClient(hostname, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	d, ok := cl.roundTripCloser.(webTransportDialer)
 	if !ok {
-		if onlyCached {
-			return nil, ErrNoCachedConn
+		r.pool.release(hostname, cl)
+		return nil, nil, errors.New("http3: client doesn't support WebTransport")
+	}
+	rsp, sess, err := d.dialWebTransport(req)
+	if err != nil {
+		r.pool.release(hostname, cl)
+		return rsp, sess, err
+	}
+	// The connection is in use as long as the WebTransport session is active.
+	go func() {
+		<-sess.Context().Done()
+		r.pool.release(hostname, cl)
+	}()
+	return rsp, sess, nil
+}
+
+// getClient returns a client that can take another request.
+// The caller must release the client once the request has completed.
+func (r *RoundTripper) getClient(hostname string, onlyCached bool) (*pooledClient, error) {
+	r.poolOnce.Do(func() {
+		r.pool.maxConcurrentStreams = r.MaxConcurrentStreams
+		r.pool.idleTimeout = r.IdleConnTimeout
+		r.tlsConf, r.quicConf = r.TLSClientConfig, r.QuicConfig
+		if !r.Disable0RTT {
+			r.enableResumption()
+		}
+	})
+
+	if onlyCached {
+		if cl := r.pool.get(hostname); cl != nil {
+			return cl, nil
 		}
-		client = newClient(
-			hostname,
-			r.TLSClientConfig,
-			&roundTripperOpts{
-				DisableCompression: r.DisableCompression,
-				MaxHeaderBytes:     r.MaxResponseHeaderBytes,
-			},
-			r.QuicConfig,
-			r.Dial,
-		)
-		r.clients[hostname] = client
+		return nil, ErrNoCachedConn
 	}
-	return client, nil
+	return r.pool.getOrAdd(hostname, func() roundTripCloser { return r.newClient(hostname) }), nil
 }
 
-// Close closes the QUIC connections that this RoundTripper has used
-func (r *RoundTripper) Close() error {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-	for _, client := range r.clients {
-		if err := client.Close(); err != nil {
-			return err
+// newClient creates a client for an origin.
+// It doesn't establish a connection, this happens when the first request is sent.
+func (r *RoundTripper) newClient(hostname string) roundTripCloser {
+	cl := newClient(
+		hostname,
+		r.tlsConf,
+		&roundTripperOpts{
+			DisableCompression:     r.DisableCompression,
+			Disable0RTT:            r.Disable0RTT,
+			EnableWebTransport:     r.EnableWebTransport,
+			MaxHeaderBytes:         r.MaxResponseHeaderBytes,
+			PushHandler:            r.PushHandler,
+			MaxQPACKTableCapacity:  r.MaxQPACKTableCapacity,
+			MaxQPACKBlockedStreams: r.MaxQPACKBlockedStreams,
+		},
+		r.quicConf,
+		r.Dial,
+	)
+	if r.alternativeService != nil {
+		if addr, ok := r.alternativeService(hostname); ok {
+			cl.useAlternativeService(addr)
+		}
+	}
+	return cl
+}
+
+// enableResumption sets a session cache and a token store, unless the application configured them.
+// Sessions can only be resumed (and requests sent in 0-RTT) if the session tickets issued by the server are stored.
+func (r *RoundTripper) enableResumption() {
+	if r.tlsConf == nil || r.tlsConf.ClientSessionCache == nil {
+		if r.tlsConf == nil {
+			r.tlsConf = &tls.Config{}
+		} else {
+			r.tlsConf = r.tlsConf.Clone()
 		}
+		r.tlsConf.ClientSessionCache = tls.NewLRUClientSessionCache(0)
 	}
-	r.clients = nil
-	return nil
+	if r.quicConf == nil || r.quicConf.TokenStore == nil {
+		if r.quicConf == nil {
+			r.quicConf = defaultQuicConfig.Clone()
+		} else {
+			r.quicConf = r.quicConf.Clone()
+		}
+		r.quicConf.TokenStore = quic.NewLRUTokenStore(100, 4)
+	}
+}
+
+// connect establishes a QUIC connection to an origin, without sending a request.
+// It returns once the handshake has completed.
+// The connection is kept in the pool and used for subsequent requests.
+func (r *RoundTripper) connect(ctx context.Context, hostname string) error {
+	cl, err := r.getClient(hostname, false)
+	if err != nil {
+		return err
+	}
+	defer r.pool.release(hostname, cl)
+	c, ok := cl.roundTripCloser.(connecter)
+	if !ok {
+		return errors.New("http3: client doesn't support connecting")
+	}
+	return c.connect(ctx)
+}
+
+// CloseIdleConnections closes all connections that don't have any active requests.
+// It doesn't interrupt any connections currently in use.
+func (r *RoundTripper) CloseIdleConnections() {
+	r.pool.closeIdleClients()
+}
+
+// Close closes the QUIC connections that this RoundTripper has used
+func (r *RoundTripper) Close() error {
+	return r.pool.close()
 }
 
 func closeRequestBody(req *http.Request) {
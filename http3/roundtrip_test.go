@@ -6,7 +6,10 @@ import (
 	"crypto/tls"
 	"errors"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang/mock/gomock"
@@ -17,16 +20,24 @@ import (
 )
 
 type mockClient struct {
-	closed bool
+	closed   bool
+	draining bool
+	err      error
 }
 
 func (m *mockClient) RoundTrip(req *http.Request) (*http.Response, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
 	return &http.Response{Request: req}, nil
 }
 func (m *mockClient) Close() error {
 	m.closed = true
 	return nil
 }
+func (m *mockClient) canTakeNewRequest() bool {
+	return !m.closed && !m.draining
+}
 
 var _ roundTripCloser = &mockClient{}
 
@@ -86,6 +97,7 @@ var _ = Describe("RoundTripper", func() {
 				// we don't want to test all the dial logic here, just that dialing happens at all
 				return session, nil
 			}
+			session.EXPECT().Context().Return(context.Background()).AnyTimes()
 		})
 
 		AfterEach(func() {
@@ -103,7 +115,7 @@ var _ = Describe("RoundTripper", func() {
 			session.EXPECT().CloseWithError(gomock.Any(), gomock.Any()).Do(func(quic.ErrorCode, string) { close(closed) })
 			_, err = rt.RoundTrip(req)
 			Expect(err).To(MatchError(testErr))
-			Expect(rt.clients).To(HaveLen(1))
+			Expect(rt.pool.clients).To(HaveKeyWithValue("quic.clemente.io:443", HaveLen(1)))
 			Eventually(closed).Should(BeClosed())
 		})
 
@@ -143,15 +155,50 @@ var _ = Describe("RoundTripper", func() {
 			Expect(err).ToNot(HaveOccurred())
 			_, err = rt.RoundTrip(req)
 			Expect(err).To(MatchError(testErr))
-			Expect(rt.clients).To(HaveLen(1))
+			Expect(rt.pool.clients).To(HaveKeyWithValue("quic.clemente.io:443", HaveLen(1)))
 			req2, err := http.NewRequest("GET", "https://quic.clemente.io/file2.html", nil)
 			Expect(err).ToNot(HaveOccurred())
 			_, err = rt.RoundTrip(req2)
 			Expect(err).To(MatchError(testErr))
-			Expect(rt.clients).To(HaveLen(1))
+			Expect(rt.pool.clients).To(HaveKeyWithValue("quic.clemente.io:443", HaveLen(1)))
 			Eventually(closed).Should(BeClosed())
 		})
 
+		It("dials a single connection for concurrent first requests", func() {
+			const num = 20
+			var dials int32
+			unblock := make(chan struct{})
+			rt.Dial = func(_, _ string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) {
+				atomic.AddInt32(&dials, 1)
+				<-unblock
+				return nil, errors.New("handshake error")
+			}
+			var wg sync.WaitGroup
+			for i := 0; i < num; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					req, err := http.NewRequest("GET", "https://quic.clemente.io/foobar.html", nil)
+					Expect(err).ToNot(HaveOccurred())
+					_, err = rt.RoundTrip(req)
+					Expect(err).To(MatchError("handshake error"))
+				}()
+			}
+			// wait until all requests have been assigned to a client
+			Eventually(func() (n int) {
+				rt.pool.mutex.Lock()
+				defer rt.pool.mutex.Unlock()
+				for _, pc := range rt.pool.clients["quic.clemente.io:443"] {
+					n += pc.activeRequests
+				}
+				return n
+			}).Should(Equal(num))
+			close(unblock)
+			wg.Wait()
+			Expect(atomic.LoadInt32(&dials)).To(BeEquivalentTo(1))
+		})
+
 		It("doesn't create new clients if RoundTripOpt.OnlyCachedConn is set", func() {
 			req, err := http.NewRequest("GET", "https://quic.clemente.io/foobar.html", nil)
 			Expect(err).ToNot(HaveOccurred())
@@ -222,22 +269,69 @@ var _ = Describe("RoundTripper", func() {
 		})
 	})
 
+	Context("retrying requests", func() {
+		It("retries requests that were not processed on a new connection", func() {
+			rejecting := &mockClient{err: errRequestNotProcessed}
+			rt.pool.getOrAdd("www.example.org:443", func() roundTripCloser { return rejecting }).activeRequests = 0
+			origDialAddr := dialAddr
+			defer func() { dialAddr = origDialAddr }()
+			testErr := errors.New("dial error")
+			dialAddr = func(string, *tls.Config, *quic.Config) (quic.EarlySession, error) {
+				return nil, testErr
+			}
+			_, err := rt.RoundTrip(req1)
+			Expect(err).To(MatchError(testErr))
+			Expect(rt.pool.clients).To(BeEmpty())
+		})
+
+		It("rewinds the request body", func() {
+			req, err := http.NewRequest("POST", "https://www.example.org/upload", bytes.NewReader([]byte("foobar")))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = ioutil.ReadAll(req.Body)
+			Expect(err).ToNot(HaveOccurred())
+			newReq, err := rewindRequest(req)
+			Expect(err).ToNot(HaveOccurred())
+			data, err := ioutil.ReadAll(newReq.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal([]byte("foobar")))
+		})
+
+		It("errors if the request body can't be rewound", func() {
+			req, err := http.NewRequest("POST", "https://www.example.org/upload", &mockBody{})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = rewindRequest(req)
+			Expect(err).To(MatchError("http3: request was not processed by the server, and the request body can't be rewound"))
+		})
+	})
+
 	Context("closing", func() {
 		It("closes", func() {
-			rt.clients = make(map[string]roundTripCloser)
 			cl := &mockClient{}
-			rt.clients["foo.bar"] = cl
+			rt.pool.getOrAdd("foo.bar", func() roundTripCloser { return cl })
 			err := rt.Close()
 			Expect(err).ToNot(HaveOccurred())
-			Expect(len(rt.clients)).To(BeZero())
+			Expect(rt.pool.clients).To(BeEmpty())
 			Expect(cl.closed).To(BeTrue())
 		})
 
 		It("closes a RoundTripper that has never been used", func() {
-			Expect(len(rt.clients)).To(BeZero())
+			Expect(rt.pool.clients).To(BeEmpty())
 			err := rt.Close()
 			Expect(err).ToNot(HaveOccurred())
-			Expect(len(rt.clients)).To(BeZero())
+			Expect(rt.pool.clients).To(BeEmpty())
+		})
+
+		It("closes idle connections", func() {
+			// allow a single request per client, such that the second client is added to the pool
+			rt.pool.maxConcurrentStreams = 1
+			active := &mockClient{}
+			rt.pool.getOrAdd("foo.bar", func() roundTripCloser { return active })
+			idle := &mockClient{}
+			rt.pool.release("foo.bar", rt.pool.getOrAdd("foo.bar", func() roundTripCloser { return idle }))
+			rt.CloseIdleConnections()
+			Expect(idle.closed).To(BeTrue())
+			Expect(active.closed).To(BeFalse())
+			Expect(rt.pool.clients).To(HaveKeyWithValue("foo.bar", HaveLen(1)))
 		})
 	})
 })
@@ -10,13 +10,15 @@ import (
 	"net"
 	"net/http"
 	"runtime"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/handshake"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
-	"github.com/marten-seemann/qpack"
 )
 
 // allows mocking of quic.Listen and quic.ListenAddr
@@ -25,7 +27,63 @@ var (
 	quicListenAddr = quic.ListenAddrEarly
 )
 
-const nextProtoH3 = "h3-29"
+const (
+	// NextProtoH3 is the application protocol (ALPN) of HTTP/3 over QUIC version 1 (RFC 9114).
+	NextProtoH3 = "h3"
+	// NextProtoH3Draft29 is the application protocol (ALPN) of HTTP/3 over QUIC draft-29.
+	NextProtoH3Draft29 = "h3-29"
+)
+
+// versionToALPN returns the HTTP/3 application protocol used with a QUIC version.
+func versionToALPN(v protocol.VersionNumber) string {
+	switch v {
+	case protocol.Version1:
+		return NextProtoH3
+	case protocol.VersionDraft29:
+		return NextProtoH3Draft29
+	default:
+		return ""
+	}
+}
+
+// nextProtos returns the HTTP/3 application protocols for the QUIC versions of a quic.Config,
+// in the order of preference of the versions.
+func nextProtos(conf *quic.Config) []string {
+	versions := protocol.SupportedVersions
+	if conf != nil && len(conf.Versions) > 0 {
+		versions = conf.Versions
+	}
+	protos := make([]string, 0, len(versions))
+	for _, v := range versions {
+		if alpn := versionToALPN(v); alpn != "" {
+			protos = append(protos, alpn)
+		}
+	}
+	return protos
+}
+
+// nextProtosForVersion returns the HTTP/3 application protocols that can be used on a connection
+// established with QUIC version v.
+// A server switches to its most preferred version that is compatible with v and supported by the client,
+// see protocol.AreCompatibleVersions. The application protocols of these versions are returned in the
+// order of preference of the versions, such that the application protocol chosen from the client's offer
+// matches the QUIC version that is negotiated.
+func nextProtosForVersion(conf *quic.Config, v protocol.VersionNumber) []string {
+	versions := protocol.SupportedVersions
+	if conf != nil && len(conf.Versions) > 0 {
+		versions = conf.Versions
+	}
+	var protos []string
+	for _, ver := range versions {
+		if !protocol.AreCompatibleVersions(v, ver) {
+			continue
+		}
+		if alpn := versionToALPN(ver); alpn != "" {
+			protos = append(protos, alpn)
+		}
+	}
+	return protos
+}
 
 // contextKey is a value for use with context.WithValue. It's used as
 // a pointer so it fits in an interface{} without allocation.
@@ -57,7 +115,16 @@ func newConnError(code errorCode, err error) requestError {
 	return requestError{err: err, connErr: code}
 }
 
+// errHijacked is returned by handleRequest if the stream was taken over by a WebTransport session.
+// The stream must not be closed in that case.
+var errHijacked = errors.New("hijacked")
+
 // Server is a HTTP2 server listening for QUIC connections.
+//
+// The timeouts of the http.Server are applied to every request stream:
+// ReadHeaderTimeout (or ReadTimeout, if zero) limits the time to receive the request header, starting when the stream is opened.
+// Once the header was received, ReadTimeout limits the time to receive the request body, and WriteTimeout the time to send the response.
+// A connection without any requests in flight is closed after IdleTimeout (or ReadTimeout, if zero).
 type Server struct {
 	*http.Server
 
@@ -65,10 +132,34 @@ type Server struct {
 	// If nil, it uses reasonable default values.
 	QuicConfig *quic.Config
 
+	// EnableExtendedConnect enables Extended CONNECT requests (RFC 9220), which carry a :protocol pseudo header.
+	// Such requests can for example be used to run WebSockets over HTTP/3.
+	EnableExtendedConnect bool
+
+	// EnableWebTransport enables WebTransport sessions, see UpgradeWebTransport.
+	// It implies EnableExtendedConnect, and enables datagram support on the QUIC layer.
+	EnableWebTransport bool
+
+	// MaxQPACKTableCapacity is the maximum capacity of the QPACK dynamic table, in bytes.
+	// It bounds the memory used for header compression, in each direction, on every connection.
+	// If zero, 4 KB are used. A negative value disables the dynamic table.
+	MaxQPACKTableCapacity int64
+	// MaxQPACKBlockedStreams is the maximum number of request streams that can be blocked
+	// waiting for QPACK encoder instructions.
+	// If zero, 16 streams can be blocked. A negative value doesn't allow any blocked streams.
+	MaxQPACKBlockedStreams int64
+
+	// MaxConcurrentRequests is the maximum number of requests a client may send concurrently on a connection.
+	// It is enforced using the QUIC stream limit, so clients wait before sending more requests,
+	// instead of having their requests rejected. Streams of WebTransport sessions count towards the limit.
+	// If zero, QuicConfig.MaxIncomingStreams is used.
+	MaxConcurrentRequests int
+
 	port uint32 // used atomically
 
 	mutex     sync.Mutex
 	listeners map[*quic.EarlyListener]struct{}
+	conns     map[*serverConn]struct{}
 	closed    utils.AtomicBool
 
 	loggerOnce sync.Once
@@ -122,33 +213,85 @@ func (s *Server) serveImpl(tlsConf *tls.Config, conn net.PacketConn) error {
 	} else {
 		tlsConf = tlsConf.Clone()
 	}
-	// Replace existing ALPNs by H3
-	tlsConf.NextProtos = []string{nextProtoH3}
-	if tlsConf.GetConfigForClient != nil {
-		getConfigForClient := tlsConf.GetConfigForClient
-		tlsConf.GetConfigForClient = func(ch *tls.ClientHelloInfo) (*tls.Config, error) {
-			conf, err := getConfigForClient(ch)
-			if err != nil || conf == nil {
-				return conf, err
+	// Replace existing ALPNs by H3.
+	// The application protocol is chosen based on the QUIC version of the connection.
+	tlsConf.NextProtos = nextProtos(s.QuicConfig)
+	baseConf := tlsConf.Clone()
+	getConfigForClient := tlsConf.GetConfigForClient
+	baseConf.GetConfigForClient = nil
+	tlsConf.GetConfigForClient = func(ch *tls.ClientHelloInfo) (*tls.Config, error) {
+		conf := baseConf
+		if getConfigForClient != nil {
+			c, err := getConfigForClient(ch)
+			if err != nil {
+				return nil, err
+			}
+			if c != nil {
+				conf = c
 			}
-			conf = conf.Clone()
-			conf.NextProtos = []string{nextProtoH3}
-			return conf, nil
+		}
+		conf = conf.Clone()
+		conf.NextProtos = nextProtos(s.QuicConfig)
+		if qconn, ok := ch.Conn.(handshake.ConnWithVersion); ok {
+			conf.NextProtos = nextProtosForVersion(s.QuicConfig, qconn.GetQUICVersion())
+		}
+		return conf, nil
+	}
+
+	quicConf := s.QuicConfig
+	if s.EnableWebTransport || s.MaxConcurrentRequests > 0 {
+		if quicConf == nil {
+			quicConf = &quic.Config{}
+		} else {
+			quicConf = quicConf.Clone()
+		}
+		if s.EnableWebTransport {
+			quicConf.EnableDatagrams = true
+		}
+		if s.MaxConcurrentRequests > 0 {
+			quicConf.MaxIncomingStreams = int64(s.MaxConcurrentRequests)
 		}
 	}
 
 	var ln quic.EarlyListener
 	var err error
 	if conn == nil {
-		ln, err = quicListenAddr(s.Addr, tlsConf, s.QuicConfig)
+		ln, err = quicListenAddr(s.Addr, tlsConf, quicConf)
 	} else {
-		ln, err = quicListen(conn, tlsConf, s.QuicConfig)
+		ln, err = quicListen(conn, tlsConf, quicConf)
 	}
 	if err != nil {
 		return err
 	}
+	return s.serveListener(ln)
+}
+
+// ServeListener serves HTTP/3 requests on the sessions accepted by an existing listener,
+// e.g. the listener of a quic.Route.
+// The listener must negotiate one of the HTTP/3 application protocols, see NextProtoH3 and NextProtoH3Draft29.
+// Since the listener was already configured, the server's TLSConfig, QuicConfig,
+// EnableWebTransport and MaxConcurrentRequests don't affect the sessions' configuration.
+func (s *Server) ServeListener(ln quic.EarlyListener) error {
+	if s.closed.Get() {
+		return http.ErrServerClosed
+	}
+	if s.Server == nil {
+		return errors.New("use of http3.Server without http.Server")
+	}
+	s.loggerOnce.Do(func() {
+		s.logger = utils.DefaultLogger.WithPrefix("server")
+	})
+	return s.serveListener(ln)
+}
+
+func (s *Server) serveListener(ln quic.EarlyListener) error {
 	s.addListener(&ln)
 	defer s.removeListener(&ln)
+	// The server might have been closed while we were creating the listener.
+	if s.closed.Get() {
+		ln.Close()
+		return http.ErrServerClosed
+	}
 
 	for {
 		sess, err := ln.Accept(context.Background())
@@ -177,10 +320,185 @@ func (s *Server) removeListener(l *quic.EarlyListener) {
 	s.mutex.Unlock()
 }
 
-func (s *Server) handleConn(sess quic.EarlySession) {
-	// TODO: accept control streams
-	decoder := qpack.NewDecoder(nil)
+// A serverConn is a connection handled by the server.
+// It keeps track of the requests in flight, such that the connection can be shut down gracefully.
+type serverConn struct {
+	sess       quic.EarlySession
+	controlStr quic.SendStream
 
+	mutex        sync.Mutex
+	goingAway    bool
+	nextStreamID quic.StreamID // the ID of the next request stream, used in the GOAWAY frame
+
+	pushEnabled bool   // set once the client sent a MAX_PUSH_ID frame
+	maxPushID   uint64 // the maximum push ID the client allows
+	pushID      uint64 // the ID of the next push
+	pushStreams map[uint64]quic.SendStream
+
+	requests       sync.WaitGroup
+	activeRequests int
+	idleTimeout    time.Duration // if zero, the connection is never closed for being idle
+	idleTimer      *time.Timer   // set while the connection is idle, if an idle timeout is configured
+	idleGen        uint64        // incremented every time the connection becomes idle
+
+	receivedSettings chan struct{} // closed once the client's SETTINGS frame was received
+	settings         *settingsFrame
+
+	encoder *qpackEncoder
+	decoder *qpackDecoder
+
+	webTransport *webTransportManager // nil if WebTransport is disabled
+}
+
+// startRequest registers the request on stream id.
+// It returns false if the request must be rejected, because we already sent a GOAWAY frame.
+func (c *serverConn) startRequest(id quic.StreamID) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Streams are accepted in order.
+	// Any stream accepted after sending the GOAWAY frame has a stream ID that's
+	// equal to or larger than the stream ID announced in the GOAWAY frame.
+	if c.goingAway {
+		return false
+	}
+	c.nextStreamID = id + 4
+	c.startRequestLocked()
+	return true
+}
+
+// startPush registers a push. Pushes are handled like requests.
+func (c *serverConn) startPush() {
+	c.mutex.Lock()
+	c.startRequestLocked()
+	c.mutex.Unlock()
+}
+
+func (c *serverConn) startRequestLocked() {
+	c.requests.Add(1)
+	c.activeRequests++
+	if c.idleTimer != nil {
+		c.idleTimer.Stop()
+		c.idleTimer = nil
+	}
+}
+
+// requestDone is called when a request (or a push) has completed.
+func (c *serverConn) requestDone() {
+	c.mutex.Lock()
+	c.activeRequests--
+	c.startIdleTimerLocked()
+	c.mutex.Unlock()
+	c.requests.Done()
+}
+
+// startIdleTimer starts the idle timer, if the connection is idle.
+func (c *serverConn) startIdleTimer() {
+	c.mutex.Lock()
+	c.startIdleTimerLocked()
+	c.mutex.Unlock()
+}
+
+func (c *serverConn) startIdleTimerLocked() {
+	if c.idleTimeout <= 0 || c.activeRequests > 0 || c.idleTimer != nil {
+		return
+	}
+	c.idleGen++
+	gen := c.idleGen
+	c.idleTimer = time.AfterFunc(c.idleTimeout, func() { c.closeIdle(gen) })
+}
+
+// closeIdle closes the connection when the idle timer fires.
+func (c *serverConn) closeIdle(gen uint64) {
+	c.mutex.Lock()
+	// A request might have been started after the timer fired.
+	if c.idleGen != gen || c.activeRequests > 0 || c.goingAway {
+		c.mutex.Unlock()
+		return
+	}
+	c.idleTimer = nil
+	// WebTransport sessions outlive the CONNECT request that established them.
+	if c.webTransport != nil && c.webTransport.numSessions() > 0 {
+		c.startIdleTimerLocked()
+		c.mutex.Unlock()
+		return
+	}
+	// Reject all requests from now on.
+	c.goingAway = true
+	id := c.nextStreamID
+	c.mutex.Unlock()
+
+	if err := c.sendGoAway(id); err != nil {
+		return
+	}
+	c.sess.CloseWithError(quic.ErrorCode(errorNoError), "")
+}
+
+// goAway sends a GOAWAY frame, telling the client which requests will be processed.
+// Requests on streams that are accepted after that are rejected.
+func (c *serverConn) goAway() error {
+	c.mutex.Lock()
+	if c.goingAway {
+		c.mutex.Unlock()
+		return nil
+	}
+	c.goingAway = true
+	id := c.nextStreamID
+	c.mutex.Unlock()
+
+	return c.sendGoAway(id)
+}
+
+func (c *serverConn) sendGoAway(id quic.StreamID) error {
+	buf := &bytes.Buffer{}
+	(&goAwayFrame{StreamID: id}).Write(buf)
+	_, err := c.controlStr.Write(buf.Bytes())
+	return err
+}
+
+func (s *Server) addConn(c *serverConn) {
+	s.mutex.Lock()
+	if s.conns == nil {
+		s.conns = make(map[*serverConn]struct{})
+	}
+	s.conns[c] = struct{}{}
+	s.mutex.Unlock()
+
+	// The server might have started shutting down while we were accepting this connection.
+	if s.closed.Get() {
+		if err := c.goAway(); err != nil {
+			s.logger.Debugf("Sending GOAWAY failed: %s", err)
+		}
+	}
+}
+
+func (s *Server) removeConn(c *serverConn) {
+	s.mutex.Lock()
+	delete(s.conns, c)
+	s.mutex.Unlock()
+}
+
+func (s *Server) extendedConnectEnabled() bool {
+	return s.EnableExtendedConnect || s.EnableWebTransport
+}
+
+func (s *Server) newServerConn(sess quic.EarlySession, controlStr quic.SendStream) *serverConn {
+	capacity, blocked := qpackLimits(s.MaxQPACKTableCapacity, s.MaxQPACKBlockedStreams)
+	conn := &serverConn{
+		sess:             sess,
+		controlStr:       controlStr,
+		receivedSettings: make(chan struct{}),
+		idleTimeout:      s.idleTimeout(),
+		encoder:          newQPACKEncoder(capacity, sess.OpenUniStream, s.logger),
+		decoder:          newQPACKDecoder(capacity, blocked, sess.OpenUniStream, s.logger),
+	}
+	if s.EnableWebTransport {
+		conn.webTransport = newWebTransportManager(sess, s.logger)
+	}
+	return conn
+}
+
+func (s *Server) handleConn(sess quic.EarlySession) {
 	// send a SETTINGS frame
 	str, err := sess.OpenUniStream()
 	if err != nil {
@@ -188,21 +506,54 @@ func (s *Server) handleConn(sess quic.EarlySession) {
 		return
 	}
 	buf := bytes.NewBuffer([]byte{0})
-	(&settingsFrame{}).Write(buf)
+	settings := &settingsFrame{settings: make(map[uint64]uint64)}
+	if s.extendedConnectEnabled() {
+		settings.settings[settingExtendedConnect] = 1
+	}
+	if s.EnableWebTransport {
+		settings.settings[settingDatagram] = 1
+		settings.settings[settingEnableWebTransport] = 1
+	}
+	conn := s.newServerConn(sess, str)
+	addQPACKSettings(settings, conn.decoder.maxCapacity, conn.decoder.maxBlocked)
+	settings.Write(buf)
 	str.Write(buf.Bytes())
 
+	s.addConn(conn)
+	defer conn.decoder.close()
+	defer s.removeConn(conn)
+
+	go s.handleUnidirectionalStreams(conn)
+	conn.startIdleTimer()
+
 	// Process all requests immediately.
 	// It's the client's responsibility to decide which requests are eligible for 0-RTT.
+	// Requests received in 0-RTT are marked using the Early-Data header field,
+	// such that the handler can reject them with a 425 (Too Early).
+	// This depends on when the request was received, not when the stream is accepted:
+	// The handshake might complete before a request received in 0-RTT is accepted.
 	for {
 		str, err := sess.AcceptStream(context.Background())
 		if err != nil {
 			s.logger.Debugf("Accepting stream failed: %s", err)
 			return
 		}
+		if !conn.startRequest(str.StreamID()) {
+			// We already sent a GOAWAY frame that tells the client that this request won't be processed.
+			// The client can safely retry it on a new connection.
+			s.logger.Debugf("Rejecting request on stream %d, since the connection is going away.", str.StreamID())
+			str.CancelRead(quic.ErrorCode(errorRequestRejected))
+			str.CancelWrite(quic.ErrorCode(errorRequestRejected))
+			continue
+		}
 		go func() {
-			rerr := s.handleRequest(sess, str, decoder, func() {
+			defer conn.requestDone()
+			rerr := s.handleRequest(conn, str, func() {
 				sess.CloseWithError(quic.ErrorCode(errorFrameUnexpected), "")
 			})
+			if rerr.err == errHijacked {
+				return
+			}
 			if rerr.err != nil || rerr.streamErr != 0 || rerr.connErr != 0 {
 				s.logger.Debugf("Handling request failed: %s", err)
 				if rerr.streamErr != 0 {
@@ -222,6 +573,95 @@ func (s *Server) handleConn(sess quic.EarlySession) {
 	}
 }
 
+func (s *Server) handleUnidirectionalStreams(conn *serverConn) {
+	for {
+		str, err := conn.sess.AcceptUniStream(context.Background())
+		if err != nil {
+			s.logger.Debugf("Accepting unidirectional stream failed: %s", err)
+			return
+		}
+
+		go func(str quic.ReceiveStream) {
+			streamType, err := utils.ReadVarInt(&byteReaderImpl{str})
+			if err != nil {
+				s.logger.Debugf("Reading stream type on stream %d failed: %s", str.StreamID(), err)
+				return
+			}
+			switch streamType {
+			case streamTypeControlStream:
+				conn.handleControlStream(str)
+			case streamTypeQPACKEncoderStream:
+				conn.handleQPACKError(conn.decoder.handleEncoderStream(str))
+			case streamTypeQPACKDecoderStream:
+				conn.handleQPACKError(conn.encoder.handleDecoderStream(str))
+			case streamTypeWebTransportStream:
+				if conn.webTransport == nil {
+					str.CancelRead(quic.ErrorCode(errorStreamCreationError))
+					return
+				}
+				conn.webTransport.handleUniStream(str)
+			default:
+				str.CancelRead(quic.ErrorCode(errorStreamCreationError))
+			}
+		}(str)
+	}
+}
+
+// handleControlStream reads the client's control stream.
+// The first frame must be a SETTINGS frame.
+func (c *serverConn) handleControlStream(str quic.ReceiveStream) {
+	f, err := parseNextFrame(str)
+	if err != nil {
+		c.sess.CloseWithError(quic.ErrorCode(errorFrameError), "")
+		return
+	}
+	settings, ok := f.(*settingsFrame)
+	if !ok {
+		c.sess.CloseWithError(quic.ErrorCode(errorMissingSettings), "")
+		return
+	}
+	c.settings = settings
+	c.encoder.setPeerSettings(settings.settings[settingQPACKMaxTableCapacity], settings.settings[settingQPACKBlockedStreams])
+	close(c.receivedSettings)
+	for {
+		f, err := parseNextFrame(str)
+		if err != nil {
+			return
+		}
+		switch f := f.(type) {
+		case *goAwayFrame:
+			// The client doesn't want to receive any more pushes.
+			// We stop pushing once we send a GOAWAY frame ourselves, so there's nothing to do.
+		case *maxPushIDFrame:
+			if err := c.handleMaxPushID(f.PushID); err != nil {
+				c.sess.CloseWithError(quic.ErrorCode(errorIDError), err.Error())
+				return
+			}
+		case *cancelPushFrame:
+			c.cancelPush(f.PushID)
+		default:
+			c.sess.CloseWithError(quic.ErrorCode(errorFrameUnexpected), "")
+			return
+		}
+	}
+}
+
+// handleQPACKError closes the connection if the peer sent an invalid instruction on a QPACK stream.
+// The QPACK streams are critical streams, errors reading from them are handled when the connection is closed.
+func (c *serverConn) handleQPACKError(err error) {
+	if qerr, ok := err.(*qpackError); ok {
+		c.sess.CloseWithError(quic.ErrorCode(qerr.code), qerr.Error())
+	}
+}
+
+// qpackRequestError converts an error returned when decoding a field section into a requestError.
+func qpackRequestError(err error) requestError {
+	if qerr, ok := err.(*qpackError); ok {
+		return newConnError(qerr.code, qerr.err)
+	}
+	return newStreamError(errorRequestCanceled, err)
+}
+
 func (s *Server) maxHeaderBytes() uint64 {
 	if s.Server.MaxHeaderBytes <= 0 {
 		return http.DefaultMaxHeaderBytes
@@ -229,11 +669,23 @@ func (s *Server) maxHeaderBytes() uint64 {
 	return uint64(s.Server.MaxHeaderBytes)
 }
 
-func (s *Server) handleRequest(sess quic.Session, str quic.Stream, decoder *qpack.Decoder, onFrameError func()) requestError {
+// handleRequest handles a request.
+func (s *Server) handleRequest(conn *serverConn, str quic.Stream, onFrameError func()) requestError {
+	sess := conn.sess
+	if d := s.readHeaderTimeout(); d > 0 {
+		str.SetReadDeadline(time.Now().Add(d))
+	}
 	frame, err := parseNextFrame(str)
 	if err != nil {
 		return newStreamError(errorRequestIncomplete, err)
 	}
+	if f, ok := frame.(*webTransportFrame); ok && conn.webTransport != nil {
+		if s.readHeaderTimeout() > 0 {
+			str.SetReadDeadline(time.Time{})
+		}
+		conn.webTransport.handleStream(f.SessionID, str)
+		return requestError{err: errHijacked}
+	}
 	hf, ok := frame.(*headersFrame)
 	if !ok {
 		return newConnError(errorFrameUnexpected, errors.New("expected first frame to be a HEADERS frame"))
@@ -245,19 +697,42 @@ func (s *Server) handleRequest(sess quic.Session, str quic.Stream, decoder *qpac
 	if _, err := io.ReadFull(str, headerBlock); err != nil {
 		return newStreamError(errorRequestIncomplete, err)
 	}
-	hfs, err := decoder.DecodeFull(headerBlock)
+	ctx := str.Context()
+	hfs, err := conn.decoder.decode(ctx, str.StreamID(), headerBlock)
 	if err != nil {
-		// TODO: use the right error code
-		return newConnError(errorGeneralProtocolError, err)
+		return qpackRequestError(err)
 	}
 	req, err := requestFromHeaders(hfs)
 	if err != nil {
 		// TODO: use the right error code
 		return newStreamError(errorGeneralProtocolError, err)
 	}
+	if isExtendedConnect(req) && !s.extendedConnectEnabled() {
+		return newStreamError(errorMessageError, errors.New("received an Extended CONNECT request, but Extended CONNECT is disabled"))
+	}
+	// The request header was received. Apply the timeouts for the remainder of the request.
+	hasDeadlines := s.setRequestDeadlines(str)
 
+	// Only the server can tell if a request was received in 0-RTT (see RFC 8470, Section 5.1).
+	req.Header.Del("Early-Data")
+	if str.ReceivedEarlyData() {
+		req.Header.Set("Early-Data", "1")
+	}
 	req.RemoteAddr = sess.RemoteAddr().String()
-	req.Body = newRequestBody(str, onFrameError)
+	body := newRequestBody(ctx, str, conn.decoder, onFrameError)
+	body.maxTrailerBytes = s.maxHeaderBytes()
+	body.onTrailers = func(trailer http.Header) {
+		// Only accept trailers that were announced in the Trailer header.
+		for k, vv := range trailer {
+			if _, ok := req.Trailer[k]; ok {
+				req.Trailer[k] = vv
+			}
+		}
+	}
+	req.Body = body
+	if prio := req.Header.Get("Priority"); prio != "" {
+		str.SetPriority(parsePriority(prio))
+	}
 
 	if s.logger.Debug() {
 		s.logger.Infof("%s %s%s, on stream %d", req.Method, req.Host, req.RequestURI, str.StreamID())
@@ -265,36 +740,27 @@ func (s *Server) handleRequest(sess quic.Session, str quic.Stream, decoder *qpac
 		s.logger.Infof("%s %s%s", req.Method, req.Host, req.RequestURI)
 	}
 
-	ctx := str.Context()
 	ctx = context.WithValue(ctx, ServerContextKey, s)
 	ctx = context.WithValue(ctx, http.LocalAddrContextKey, sess.LocalAddr())
 	req = req.WithContext(ctx)
-	responseWriter := newResponseWriter(str, s.logger)
-	defer responseWriter.Flush()
-	handler := s.Handler
-	if handler == nil {
-		handler = http.DefaultServeMux
+	responseWriter := newResponseWriter(str, str.StreamID(), conn.encoder, s.logger)
+	responseWriter.conn = conn
+	responseWriter.str = str
+	responseWriter.hasDeadlines = hasDeadlines
+	responseWriter.push = func(target string, opts *http.PushOptions) error {
+		return s.push(conn, responseWriter, req, target, opts)
 	}
+	defer responseWriter.Flush()
 
-	var panicked bool
-	func() {
-		defer func() {
-			if p := recover(); p != nil {
-				// Copied from net/http/server.go
-				const size = 64 << 10
-				buf := make([]byte, size)
-				buf = buf[:runtime.Stack(buf, false)]
-				s.logger.Errorf("http: panic serving: %v\n%s", p, buf)
-				panicked = true
-			}
-		}()
-		handler.ServeHTTP(responseWriter, req)
-	}()
-
+	panicked := s.callHandler(responseWriter, req)
+	if responseWriter.hijacked {
+		return requestError{err: errHijacked}
+	}
 	if panicked {
 		responseWriter.WriteHeader(500)
 	} else {
 		responseWriter.WriteHeader(200)
+		responseWriter.writeTrailers()
 	}
 
 	// If the EOF was read by the handler, CancelRead() is a no-op.
@@ -302,6 +768,58 @@ func (s *Server) handleRequest(sess quic.Session, str quic.Stream, decoder *qpac
 	return requestError{}
 }
 
+// setRequestDeadlines sets the deadlines for reading the request body and writing the response.
+// It returns true if any deadline was set on the stream.
+func (s *Server) setRequestDeadlines(str quic.Stream) bool {
+	var hasDeadlines bool
+	now := time.Now()
+	if s.ReadTimeout > 0 {
+		str.SetReadDeadline(now.Add(s.ReadTimeout))
+		hasDeadlines = true
+	} else if s.readHeaderTimeout() > 0 {
+		str.SetReadDeadline(time.Time{})
+	}
+	if s.WriteTimeout > 0 {
+		str.SetWriteDeadline(now.Add(s.WriteTimeout))
+		hasDeadlines = true
+	}
+	return hasDeadlines
+}
+
+func (s *Server) readHeaderTimeout() time.Duration {
+	if s.ReadHeaderTimeout > 0 {
+		return s.ReadHeaderTimeout
+	}
+	return s.ReadTimeout
+}
+
+func (s *Server) idleTimeout() time.Duration {
+	if s.IdleTimeout > 0 {
+		return s.IdleTimeout
+	}
+	return s.ReadTimeout
+}
+
+// callHandler calls the handler. It returns true if the handler panicked.
+func (s *Server) callHandler(w http.ResponseWriter, req *http.Request) (panicked bool) {
+	handler := s.Handler
+	if handler == nil {
+		handler = http.DefaultServeMux
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			// Copied from net/http/server.go
+			const size = 64 << 10
+			buf := make([]byte, size)
+			buf = buf[:runtime.Stack(buf, false)]
+			s.logger.Errorf("http: panic serving: %v\n%s", p, buf)
+			panicked = true
+		}
+	}()
+	handler.ServeHTTP(w, req)
+	return false
+}
+
 // Close the server immediately, aborting requests and sending CONNECTION_CLOSE frames to connected clients.
 // Close in combination with ListenAndServe() (instead of Serve()) may race if it is called before a UDP socket is established.
 func (s *Server) Close() error {
@@ -320,15 +838,69 @@ func (s *Server) Close() error {
 }
 
 // CloseGracefully shuts down the server gracefully. The server sends a GOAWAY frame first, then waits for either timeout to trigger, or for all running requests to complete.
+// Once all requests have completed, the server waits for the clients to close their connections,
+// so that the responses are fully delivered. When the timeout triggers, all remaining connections are closed.
+// New requests that are received after sending the GOAWAY frame are rejected, such that the client can retry them on a new connection.
 // CloseGracefully in combination with ListenAndServe() (instead of Serve()) may race if it is called before a UDP socket is established.
 func (s *Server) CloseGracefully(timeout time.Duration) error {
-	// TODO: implement
-	return nil
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := s.closeGracefully(ctx)
+	if err == context.DeadlineExceeded {
+		return errors.New("http3: timeout while waiting for requests to complete")
+	}
+	return err
+}
+
+// closeGracefully shuts down the server gracefully, see CloseGracefully.
+// When the context is canceled, all remaining connections are closed, and the context's error is returned.
+func (s *Server) closeGracefully(ctx context.Context) error {
+	s.closed.Set(true)
+
+	s.mutex.Lock()
+	conns := make([]*serverConn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mutex.Unlock()
+
+	for _, c := range conns {
+		if err := c.goAway(); err != nil {
+			s.logger.Debugf("Sending GOAWAY failed: %s", err)
+		}
+	}
+
+	var err error
+	if len(conns) > 0 {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for _, c := range conns {
+				c.requests.Wait()
+			}
+			for _, c := range conns {
+				<-c.sess.Context().Done()
+			}
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		for _, c := range conns {
+			c.sess.CloseWithError(quic.ErrorCode(errorNoError), "")
+		}
+	}
+	if cerr := s.Close(); err == nil {
+		err = cerr
+	}
+	return err
 }
 
 // SetQuicHeaders can be used to set the proper headers that announce that this server supports QUIC.
 // The values that are set depend on the port information from s.Server.Addr, and currently look like this (if Addr has port 443):
-//  Alt-Svc: quic=":443"; ma=2592000; v="33,32,31,30"
+//  Alt-Svc: h3=":443"; ma=2592000,h3-29=":443"; ma=2592000
 func (s *Server) SetQuicHeaders(hdr http.Header) error {
 	port := atomic.LoadUint32(&s.port)
 
@@ -346,7 +918,12 @@ func (s *Server) SetQuicHeaders(hdr http.Header) error {
 		atomic.StoreUint32(&s.port, port)
 	}
 
-	hdr.Add("Alt-Svc", fmt.Sprintf(`%s=":%d"; ma=2592000`, nextProtoH3, port))
+	protos := nextProtos(s.QuicConfig)
+	services := make([]string, 0, len(protos))
+	for _, proto := range protos {
+		services = append(services, fmt.Sprintf(`%s=":%d"; ma=2592000`, proto, port))
+	}
+	hdr.Add("Alt-Svc", strings.Join(services, ","))
 
 	return nil
 }
@@ -368,77 +945,15 @@ func ListenAndServeQUIC(addr, certFile, keyFile string, handler http.Handler) er
 // connetions in parallel. It returns if one of the two returns an error.
 // http.DefaultServeMux is used when handler is nil.
 // The correct Alt-Svc headers for QUIC are set.
+// Use a UnifiedServer for more control over the lifecycle of the servers.
 func ListenAndServe(addr, certFile, keyFile string, handler http.Handler) error {
-	// Load certs
-	var err error
-	certs := make([]tls.Certificate, 1)
-	certs[0], err = tls.LoadX509KeyPair(certFile, keyFile)
-	if err != nil {
-		return err
-	}
-	// We currently only use the cert-related stuff from tls.Config,
-	// so we don't need to make a full copy.
-	config := &tls.Config{
-		Certificates: certs,
-	}
-
-	// Open the listeners
-	udpAddr, err := net.ResolveUDPAddr("udp", addr)
-	if err != nil {
-		return err
-	}
-	udpConn, err := net.ListenUDP("udp", udpAddr)
-	if err != nil {
-		return err
-	}
-	defer udpConn.Close()
-
-	tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
-	if err != nil {
-		return err
-	}
-	tcpConn, err := net.ListenTCP("tcp", tcpAddr)
-	if err != nil {
-		return err
-	}
-	defer tcpConn.Close()
-
-	tlsConn := tls.NewListener(tcpConn, config)
-	defer tlsConn.Close()
-
-	// Start the servers
-	httpServer := &http.Server{
-		Addr:      addr,
-		TLSConfig: config,
-	}
-
-	quicServer := &Server{
-		Server: httpServer,
-	}
-
-	if handler == nil {
-		handler = http.DefaultServeMux
-	}
-	httpServer.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		quicServer.SetQuicHeaders(w.Header())
-		handler.ServeHTTP(w, r)
-	})
-
-	hErr := make(chan error)
-	qErr := make(chan error)
-	go func() {
-		hErr <- httpServer.Serve(tlsConn)
-	}()
-	go func() {
-		qErr <- quicServer.Serve(udpConn)
-	}()
-
-	select {
-	case err := <-hErr:
-		quicServer.Close()
-		return err
-	case err := <-qErr:
-		// Cannot close the HTTP server or wait for requests to complete properly :/
-		return err
+	server := &UnifiedServer{
+		Server: &Server{
+			Server: &http.Server{
+				Addr:    addr,
+				Handler: handler,
+			},
+		},
 	}
+	return server.ListenAndServeTLS(certFile, keyFile)
 }
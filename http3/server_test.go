@@ -14,6 +14,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/lucas-clemente/quic-go"
 	mockquic "github.com/lucas-clemente/quic-go/internal/mocks/quic"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/testdata"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/marten-seemann/qpack"
@@ -22,6 +23,13 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+type connWithVersion struct {
+	net.Conn
+	version protocol.VersionNumber
+}
+
+func (c *connWithVersion) GetQUICVersion() protocol.VersionNumber { return c.version }
+
 var _ = Describe("Server", func() {
 	var (
 		s                  *Server
@@ -44,8 +52,8 @@ var _ = Describe("Server", func() {
 
 	Context("handling requests", func() {
 		var (
-			qpackDecoder       *qpack.Decoder
 			str                *mockquic.MockStream
+			receivedEarlyData  bool
 			sess               *mockquic.MockEarlySession
 			exampleGetRequest  *http.Request
 			examplePostRequest *http.Request
@@ -77,9 +85,10 @@ var _ = Describe("Server", func() {
 			str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
 				return buf.Write(p)
 			}).AnyTimes()
+			str.EXPECT().StreamID().AnyTimes()
 			closed := make(chan struct{})
 			str.EXPECT().Close().Do(func() { close(closed) })
-			rw := newRequestWriter(utils.DefaultLogger)
+			rw := newRequestWriter(newQPACKEncoder(0, nil, utils.DefaultLogger), utils.DefaultLogger)
 			Expect(rw.WriteRequest(str, req, false)).To(Succeed())
 			Eventually(closed).Should(BeClosed())
 			return buf.Bytes()
@@ -102,8 +111,10 @@ var _ = Describe("Server", func() {
 			examplePostRequest, err = http.NewRequest("POST", "https://www.example.com", bytes.NewReader([]byte("foobar")))
 			Expect(err).ToNot(HaveOccurred())
 
-			qpackDecoder = qpack.NewDecoder(nil)
 			str = mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().StreamID().Return(quic.StreamID(4)).AnyTimes()
+			receivedEarlyData = false
+			str.EXPECT().ReceivedEarlyData().DoAndReturn(func() bool { return receivedEarlyData }).AnyTimes()
 
 			sess = mockquic.NewMockEarlySession(mockCtrl)
 			addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1337}
@@ -124,7 +135,7 @@ var _ = Describe("Server", func() {
 			}).AnyTimes()
 			str.EXPECT().CancelRead(gomock.Any())
 
-			Expect(s.handleRequest(sess, str, qpackDecoder, nil)).To(Equal(requestError{}))
+			Expect(s.handleRequest(s.newServerConn(sess, nil), str, nil)).To(Equal(requestError{}))
 			var req *http.Request
 			Eventually(requestChan).Should(Receive(&req))
 			Expect(req.Host).To(Equal("www.example.com"))
@@ -132,6 +143,101 @@ var _ = Describe("Server", func() {
 			Expect(req.Context().Value(ServerContextKey)).To(Equal(s))
 		})
 
+		It("marks requests received in 0-RTT", func() {
+			requestChan := make(chan *http.Request, 1)
+			s.Handler = http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				requestChan <- r
+			})
+
+			setRequest(encodeRequest(exampleGetRequest))
+			str.EXPECT().Context().Return(reqContext)
+			str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+				return len(p), nil
+			}).AnyTimes()
+			str.EXPECT().CancelRead(gomock.Any())
+
+			receivedEarlyData = true
+			Expect(s.handleRequest(s.newServerConn(sess, nil), str, nil)).To(Equal(requestError{}))
+			var req *http.Request
+			Eventually(requestChan).Should(Receive(&req))
+			Expect(req.Header.Get("Early-Data")).To(Equal("1"))
+		})
+
+		It("applies the read header timeout", func() {
+			s.ReadHeaderTimeout = time.Minute
+			s.Handler = http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {})
+
+			setRequest(encodeRequest(exampleGetRequest))
+			str.EXPECT().Context().Return(reqContext)
+			str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+				return len(p), nil
+			}).AnyTimes()
+			str.EXPECT().CancelRead(gomock.Any())
+			start := time.Now()
+			gomock.InOrder(
+				str.EXPECT().SetReadDeadline(gomock.Any()).Do(func(t time.Time) {
+					Expect(t).To(BeTemporally("~", start.Add(time.Minute), time.Second))
+				}),
+				// the deadline is removed once the header was received
+				str.EXPECT().SetReadDeadline(time.Time{}),
+			)
+
+			Expect(s.handleRequest(s.newServerConn(sess, nil), str, nil)).To(Equal(requestError{}))
+		})
+
+		It("applies the read and write timeouts", func() {
+			s.ReadTimeout = time.Minute
+			s.WriteTimeout = time.Hour
+			s.Handler = http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {})
+
+			setRequest(encodeRequest(exampleGetRequest))
+			str.EXPECT().Context().Return(reqContext)
+			str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+				return len(p), nil
+			}).AnyTimes()
+			str.EXPECT().CancelRead(gomock.Any())
+			start := time.Now()
+			// the ReadTimeout is used for reading the header, and the whole request
+			str.EXPECT().SetReadDeadline(gomock.Any()).Do(func(t time.Time) {
+				Expect(t).To(BeTemporally("~", start.Add(time.Minute), time.Second))
+			}).Times(2)
+			str.EXPECT().SetWriteDeadline(gomock.Any()).Do(func(t time.Time) {
+				Expect(t).To(BeTemporally("~", start.Add(time.Hour), time.Second))
+			})
+
+			Expect(s.handleRequest(s.newServerConn(sess, nil), str, nil)).To(Equal(requestError{}))
+		})
+
+		It("cancels the request if the header isn't received in time", func() {
+			s.ReadHeaderTimeout = time.Minute
+			testErr := errors.New("deadline exceeded")
+			str.EXPECT().SetReadDeadline(gomock.Any())
+			str.EXPECT().Read(gomock.Any()).Return(0, testErr)
+			rerr := s.handleRequest(s.newServerConn(sess, nil), str, nil)
+			Expect(rerr.err).To(MatchError(testErr))
+			Expect(rerr.streamErr).To(Equal(errorRequestIncomplete))
+		})
+
+		It("removes the Early-Data header field from requests that were not received in 0-RTT", func() {
+			requestChan := make(chan *http.Request, 1)
+			s.Handler = http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				requestChan <- r
+			})
+
+			exampleGetRequest.Header.Set("Early-Data", "1")
+			setRequest(encodeRequest(exampleGetRequest))
+			str.EXPECT().Context().Return(reqContext)
+			str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+				return len(p), nil
+			}).AnyTimes()
+			str.EXPECT().CancelRead(gomock.Any())
+
+			Expect(s.handleRequest(s.newServerConn(sess, nil), str, nil)).To(Equal(requestError{}))
+			var req *http.Request
+			Eventually(requestChan).Should(Receive(&req))
+			Expect(req.Header).ToNot(HaveKey("Early-Data"))
+		})
+
 		It("returns 200 with an empty handler", func() {
 			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
 
@@ -143,7 +249,7 @@ var _ = Describe("Server", func() {
 			}).AnyTimes()
 			str.EXPECT().CancelRead(gomock.Any())
 
-			serr := s.handleRequest(sess, str, qpackDecoder, nil)
+			serr := s.handleRequest(s.newServerConn(sess, nil), str, nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			hfs := decodeHeader(responseBuf)
 			Expect(hfs).To(HaveKeyWithValue(":status", []string{"200"}))
@@ -162,7 +268,7 @@ var _ = Describe("Server", func() {
 			}).AnyTimes()
 			str.EXPECT().CancelRead(gomock.Any())
 
-			serr := s.handleRequest(sess, str, qpackDecoder, nil)
+			serr := s.handleRequest(s.newServerConn(sess, nil), str, nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			hfs := decodeHeader(responseBuf)
 			Expect(hfs).To(HaveKeyWithValue(":status", []string{"500"}))
@@ -179,6 +285,7 @@ var _ = Describe("Server", func() {
 				sess.EXPECT().OpenUniStream().Return(controlStr, nil)
 				sess.EXPECT().AcceptStream(gomock.Any()).Return(str, nil)
 				sess.EXPECT().AcceptStream(gomock.Any()).Return(nil, errors.New("done"))
+				sess.EXPECT().AcceptUniStream(gomock.Any()).Return(nil, errors.New("done")).AnyTimes()
 				sess.EXPECT().RemoteAddr().Return(addr).AnyTimes()
 				sess.EXPECT().LocalAddr().AnyTimes()
 			})
@@ -209,6 +316,33 @@ var _ = Describe("Server", func() {
 				Expect(hfs).To(HaveKeyWithValue(":status", []string{"200"}))
 			})
 
+			It("marks requests received in 0-RTT, even if the handshake completed before the stream was accepted", func() {
+				// the request was received in 0-RTT, but the handshake is already complete
+				handshakeCtx, cancel := context.WithCancel(context.Background())
+				cancel()
+				sess.EXPECT().HandshakeComplete().Return(handshakeCtx).AnyTimes()
+				receivedEarlyData = true
+
+				requestChan := make(chan *http.Request, 1)
+				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					requestChan <- r
+				})
+				setRequest(encodeRequest(exampleGetRequest))
+				done := make(chan struct{})
+				str.EXPECT().Context().Return(reqContext)
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+					return len(p), nil
+				}).AnyTimes()
+				str.EXPECT().CancelRead(gomock.Any())
+				str.EXPECT().Close().Do(func() { close(done) })
+
+				s.handleConn(sess)
+				Eventually(done).Should(BeClosed())
+				var req *http.Request
+				Eventually(requestChan).Should(Receive(&req))
+				Expect(req.Header.Get("Early-Data")).To(Equal("1"))
+			})
+
 			It("errors when the client sends a too large header frame", func() {
 				s.Server.MaxHeaderBytes = 20
 				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -309,7 +443,7 @@ var _ = Describe("Server", func() {
 			}).AnyTimes()
 			str.EXPECT().CancelRead(quic.ErrorCode(errorNoError))
 
-			serr := s.handleRequest(sess, str, qpackDecoder, nil)
+			serr := s.handleRequest(s.newServerConn(sess, nil), str, nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			Eventually(handlerCalled).Should(BeClosed())
 		})
@@ -332,10 +466,140 @@ var _ = Describe("Server", func() {
 			}).AnyTimes()
 			str.EXPECT().CancelRead(quic.ErrorCode(errorNoError))
 
-			serr := s.handleRequest(sess, str, qpackDecoder, nil)
+			serr := s.handleRequest(s.newServerConn(sess, nil), str, nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			Eventually(handlerCalled).Should(BeClosed())
 		})
+
+		Context("Extended CONNECT", func() {
+			var extendedConnectRequest *http.Request
+
+			BeforeEach(func() {
+				var err error
+				extendedConnectRequest, err = http.NewRequest(http.MethodConnect, "https://www.example.com/chat", http.NoBody)
+				Expect(err).ToNot(HaveOccurred())
+				extendedConnectRequest.Proto = "websocket"
+			})
+
+			It("rejects Extended CONNECT requests if it is disabled", func() {
+				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					Fail("Handler should not be called.")
+				})
+				setRequest(encodeRequest(extendedConnectRequest))
+				str.EXPECT().Context().Return(reqContext)
+				serr := s.handleRequest(s.newServerConn(sess, nil), str, nil)
+				Expect(serr.streamErr).To(Equal(errorMessageError))
+			})
+
+			It("passes Extended CONNECT requests to the handler", func() {
+				s.EnableExtendedConnect = true
+				requestChan := make(chan *http.Request, 1)
+				var hijacker Hijacker
+				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					hijacker = w.(Hijacker)
+					requestChan <- r
+				})
+				setRequest(encodeRequest(extendedConnectRequest))
+				str.EXPECT().Context().Return(reqContext)
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+					return len(p), nil
+				}).AnyTimes()
+				str.EXPECT().CancelRead(gomock.Any())
+
+				Expect(s.handleRequest(s.newServerConn(sess, nil), str, nil)).To(Equal(requestError{}))
+				var req *http.Request
+				Eventually(requestChan).Should(Receive(&req))
+				Expect(req.Method).To(Equal(http.MethodConnect))
+				Expect(req.Proto).To(Equal("websocket"))
+				Expect(req.URL.Path).To(Equal("/chat"))
+				Expect(hijacker.Session()).To(Equal(sess))
+			})
+		})
+
+		Context("WebTransport", func() {
+			var (
+				conn                *serverConn
+				webTransportRequest *http.Request
+			)
+
+			BeforeEach(func() {
+				s.EnableWebTransport = true
+				conn = s.newServerConn(sess, nil)
+				var err error
+				webTransportRequest, err = http.NewRequest(http.MethodConnect, "https://www.example.com/wt", http.NoBody)
+				Expect(err).ToNot(HaveOccurred())
+				webTransportRequest.Proto = protocolWebTransport
+			})
+
+			It("upgrades a request to a WebTransport session", func() {
+				conn.settings = &settingsFrame{settings: map[uint64]uint64{settingEnableWebTransport: 1}}
+				close(conn.receivedSettings)
+				sessChan := make(chan *WebTransportSession, 1)
+				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					defer GinkgoRecover()
+					wsess, err := UpgradeWebTransport(w, r)
+					Expect(err).ToNot(HaveOccurred())
+					sessChan <- wsess
+				})
+				setRequest(encodeRequest(webTransportRequest))
+				responseBuf := &bytes.Buffer{}
+				str.EXPECT().Context().Return(reqContext)
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
+				sess.EXPECT().ReceiveMessage().Return(nil, errors.New("datagrams disabled")).AnyTimes()
+
+				// the stream is neither closed nor reset
+				Expect(s.handleRequest(conn, str, nil).err).To(Equal(errHijacked))
+				var wsess *WebTransportSession
+				Eventually(sessChan).Should(Receive(&wsess))
+				Expect(wsess.SessionID()).To(Equal(quic.StreamID(4)))
+				hfs := decodeHeader(responseBuf)
+				Expect(hfs).To(HaveKeyWithValue(":status", []string{"200"}))
+				// The client closed the CONNECT stream (we returned io.EOF), so the session is closed.
+				Eventually(wsess.Context().Done()).Should(BeClosed())
+			})
+
+			It("doesn't upgrade if the client didn't enable WebTransport", func() {
+				conn.settings = &settingsFrame{}
+				close(conn.receivedSettings)
+				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					defer GinkgoRecover()
+					_, err := UpgradeWebTransport(w, r)
+					Expect(err).To(MatchError("http3: client didn't enable WebTransport"))
+				})
+				setRequest(encodeRequest(webTransportRequest))
+				str.EXPECT().Context().Return(reqContext)
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+					return len(p), nil
+				}).AnyTimes()
+				str.EXPECT().CancelRead(gomock.Any())
+				Expect(s.handleRequest(conn, str, nil)).To(Equal(requestError{}))
+			})
+
+			It("doesn't upgrade requests that are not WebTransport requests", func() {
+				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					defer GinkgoRecover()
+					_, err := UpgradeWebTransport(w, r)
+					Expect(err).To(MatchError("http3: not a WebTransport request"))
+				})
+				setRequest(encodeRequest(exampleGetRequest))
+				str.EXPECT().Context().Return(reqContext)
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+					return len(p), nil
+				}).AnyTimes()
+				str.EXPECT().CancelRead(gomock.Any())
+				Expect(s.handleRequest(conn, str, nil)).To(Equal(requestError{}))
+			})
+
+			It("passes WebTransport streams to the session", func() {
+				buf := &bytes.Buffer{}
+				(&webTransportFrame{SessionID: 4}).Write(buf)
+				buf.Write([]byte("foobar"))
+				setRequest(buf.Bytes())
+				Expect(s.handleRequest(conn, str, nil).err).To(Equal(errHijacked))
+				Expect(conn.webTransport.buffered).To(HaveLen(1))
+				Expect(conn.webTransport.buffered[0].sessionID).To(Equal(quic.StreamID(4)))
+			})
+		})
 	})
 
 	Context("setting http headers", func() {
@@ -343,12 +607,12 @@ var _ = Describe("Server", func() {
 
 		getExpectedHeader := func() http.Header {
 			return http.Header{
-				"Alt-Svc": {fmt.Sprintf(`%s=":443"; ma=2592000`, nextProtoH3)},
+				"Alt-Svc": {fmt.Sprintf(`%s=":443"; ma=2592000,%s=":443"; ma=2592000`, NextProtoH3, NextProtoH3Draft29)},
 			}
 		}
 
 		BeforeEach(func() {
-			Expect(getExpectedHeader()).To(Equal(http.Header{"Alt-Svc": {nextProtoH3 + `=":443"; ma=2592000`}}))
+			Expect(getExpectedHeader()).To(Equal(http.Header{"Alt-Svc": {`h3=":443"; ma=2592000,h3-29=":443"; ma=2592000`}}))
 			expected = getExpectedHeader()
 		})
 
@@ -485,6 +749,34 @@ var _ = Describe("Server", func() {
 			Eventually(done1).Should(BeClosed())
 			Eventually(done2).Should(BeClosed())
 		})
+
+		It("serves a listener", func() {
+			ln := mockquic.NewMockEarlyListener(mockCtrl)
+			s := &Server{Server: &http.Server{}}
+
+			stopAccept := make(chan struct{})
+			ln.EXPECT().Accept(gomock.Any()).DoAndReturn(func(context.Context) (quic.Session, error) {
+				<-stopAccept
+				return nil, errors.New("closed")
+			})
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				s.ServeListener(ln)
+			}()
+
+			Consistently(done).ShouldNot(BeClosed())
+			ln.EXPECT().Close().Do(func() { close(stopAccept) })
+			Expect(s.Close()).To(Succeed())
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("errors when ServeListener is called after Close", func() {
+			s := &Server{Server: &http.Server{}}
+			Expect(s.Close()).To(Succeed())
+			Expect(s.ServeListener(mockquic.NewMockEarlyListener(mockCtrl))).To(MatchError(http.ErrServerClosed))
+		})
 	})
 
 	Context("ListenAndServe", func() {
@@ -517,7 +809,7 @@ var _ = Describe("Server", func() {
 			}
 			s.TLSConfig = tlsConf
 			Expect(s.ListenAndServe()).To(HaveOccurred())
-			Expect(receivedConf.NextProtos).To(Equal([]string{nextProtoH3}))
+			Expect(receivedConf.NextProtos).To(Equal([]string{NextProtoH3, NextProtoH3Draft29}))
 			// make sure the original tls.Config was not modified
 			Expect(tlsConf.NextProtos).To(Equal([]string{"foo", "bar"}))
 		})
@@ -529,7 +821,46 @@ var _ = Describe("Server", func() {
 				return nil, errors.New("listen err")
 			}
 			Expect(s.ListenAndServe()).To(HaveOccurred())
-			Expect(receivedConf.NextProtos).To(Equal([]string{nextProtoH3}))
+			Expect(receivedConf.NextProtos).To(Equal([]string{NextProtoH3, NextProtoH3Draft29}))
+		})
+
+		It("orders the ALPN tokens by the preference of the QUIC versions", func() {
+			var receivedConf *tls.Config
+			quicListenAddr = func(addr string, tlsConf *tls.Config, _ *quic.Config) (quic.EarlyListener, error) {
+				receivedConf = tlsConf
+				return nil, errors.New("listen err")
+			}
+			s.QuicConfig = &quic.Config{Versions: []quic.VersionNumber{protocol.VersionDraft29, protocol.Version1}}
+			Expect(s.ListenAndServe()).To(HaveOccurred())
+			Expect(receivedConf.NextProtos).To(Equal([]string{NextProtoH3Draft29, NextProtoH3}))
+		})
+
+		It("only uses the ALPN tokens of the configured QUIC versions", func() {
+			var receivedConf *tls.Config
+			quicListenAddr = func(addr string, tlsConf *tls.Config, _ *quic.Config) (quic.EarlyListener, error) {
+				receivedConf = tlsConf
+				return nil, errors.New("listen err")
+			}
+			s.QuicConfig = &quic.Config{Versions: []quic.VersionNumber{protocol.Version1}}
+			Expect(s.ListenAndServe()).To(HaveOccurred())
+			Expect(receivedConf.NextProtos).To(Equal([]string{NextProtoH3}))
+		})
+
+		It("chooses the ALPN based on the QUIC version of the connection", func() {
+			var receivedConf *tls.Config
+			quicListenAddr = func(addr string, conf *tls.Config, _ *quic.Config) (quic.EarlyListener, error) {
+				receivedConf = conf
+				return nil, errors.New("listen err")
+			}
+			s.QuicConfig = &quic.Config{Versions: []quic.VersionNumber{protocol.VersionDraft29, protocol.Version1}}
+			Expect(s.ListenAndServe()).To(HaveOccurred())
+			conf, err := receivedConf.GetConfigForClient(&tls.ClientHelloInfo{Conn: &connWithVersion{version: protocol.Version1}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(conf.NextProtos).To(Equal([]string{NextProtoH3Draft29, NextProtoH3}))
+			// no application protocol is defined for versions that can't be switched to draft-29 or v1
+			conf, err = receivedConf.GetConfigForClient(&tls.ClientHelloInfo{Conn: &connWithVersion{version: 0x1234}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(conf.NextProtos).To(BeEmpty())
 		})
 
 		It("sets the ALPN for tls.Configs returned by the tls.GetConfigForClient", func() {
@@ -549,7 +880,7 @@ var _ = Describe("Server", func() {
 			// check that the config used by QUIC uses the h3 ALPN
 			conf, err := receivedConf.GetConfigForClient(&tls.ClientHelloInfo{})
 			Expect(err).ToNot(HaveOccurred())
-			Expect(conf.NextProtos).To(Equal([]string{nextProtoH3}))
+			Expect(conf.NextProtos).To(Equal([]string{NextProtoH3, NextProtoH3Draft29}))
 			// check that the original config was not modified
 			conf, err = tlsConf.GetConfigForClient(&tls.ClientHelloInfo{})
 			Expect(err).ToNot(HaveOccurred())
@@ -574,7 +905,7 @@ var _ = Describe("Server", func() {
 			// check that the config used by QUIC uses the h3 ALPN
 			conf, err := receivedConf.GetConfigForClient(&tls.ClientHelloInfo{})
 			Expect(err).ToNot(HaveOccurred())
-			Expect(conf.NextProtos).To(Equal([]string{nextProtoH3}))
+			Expect(conf.NextProtos).To(Equal([]string{NextProtoH3, NextProtoH3Draft29}))
 			// check that the original config was not modified
 			conf, err = tlsConf.GetConfigForClient(&tls.ClientHelloInfo{})
 			Expect(err).ToNot(HaveOccurred())
@@ -586,6 +917,212 @@ var _ = Describe("Server", func() {
 		Expect(s.CloseGracefully(0)).To(Succeed())
 	})
 
+	Context("closing idle connections", func() {
+		var (
+			sess       *mockquic.MockEarlySession
+			controlStr *mockquic.MockStream
+			conn       *serverConn
+		)
+
+		BeforeEach(func() {
+			s.IdleTimeout = 50 * time.Millisecond
+			sess = mockquic.NewMockEarlySession(mockCtrl)
+			controlStr = mockquic.NewMockStream(mockCtrl)
+			conn = s.newServerConn(sess, controlStr)
+		})
+
+		It("uses the read timeout if no idle timeout is set", func() {
+			s.IdleTimeout = 0
+			s.ReadTimeout = time.Minute
+			Expect(s.newServerConn(sess, controlStr).idleTimeout).To(Equal(time.Minute))
+		})
+
+		It("closes idle connections", func() {
+			var data []byte
+			closed := make(chan struct{})
+			gomock.InOrder(
+				controlStr.EXPECT().Write(gomock.Any()).DoAndReturn(func(b []byte) (int, error) {
+					data = b
+					return len(b), nil
+				}),
+				sess.EXPECT().CloseWithError(quic.ErrorCode(errorNoError), "").Do(func(quic.ErrorCode, string) { close(closed) }),
+			)
+			conn.startIdleTimer()
+			Eventually(closed).Should(BeClosed())
+			frame, err := parseNextFrame(bytes.NewReader(data))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&goAwayFrame{StreamID: 0}))
+			// requests received after that are rejected
+			Expect(conn.startRequest(0)).To(BeFalse())
+		})
+
+		It("doesn't close connections while requests are active", func() {
+			conn.startIdleTimer()
+			Expect(conn.startRequest(0)).To(BeTrue())
+			time.Sleep(100 * time.Millisecond) // wait longer than the idle timeout
+			closed := make(chan struct{})
+			controlStr.EXPECT().Write(gomock.Any())
+			sess.EXPECT().CloseWithError(quic.ErrorCode(errorNoError), "").Do(func(quic.ErrorCode, string) { close(closed) })
+			start := time.Now()
+			conn.requestDone()
+			Eventually(closed).Should(BeClosed())
+			Expect(time.Since(start)).To(BeNumerically(">=", 50*time.Millisecond))
+		})
+
+		It("doesn't close connections if no idle timeout is set", func() {
+			s.IdleTimeout = 0
+			conn = s.newServerConn(sess, controlStr)
+			conn.startIdleTimer()
+			Expect(conn.idleTimer).To(BeNil())
+		})
+	})
+
+	Context("going away", func() {
+		var (
+			sess       *mockquic.MockEarlySession
+			controlStr *mockquic.MockStream
+			conn       *serverConn
+		)
+
+		parseGoAway := func(data []byte) *goAwayFrame {
+			frame, err := parseNextFrame(bytes.NewReader(data))
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			ExpectWithOffset(1, frame).To(BeAssignableToTypeOf(&goAwayFrame{}))
+			return frame.(*goAwayFrame)
+		}
+
+		BeforeEach(func() {
+			sess = mockquic.NewMockEarlySession(mockCtrl)
+			controlStr = mockquic.NewMockStream(mockCtrl)
+			conn = s.newServerConn(sess, controlStr)
+		})
+
+		It("sends a GOAWAY frame with the ID of the next request stream", func() {
+			Expect(conn.startRequest(0)).To(BeTrue())
+			Expect(conn.startRequest(4)).To(BeTrue())
+			var data []byte
+			controlStr.EXPECT().Write(gomock.Any()).DoAndReturn(func(b []byte) (int, error) {
+				data = b
+				return len(b), nil
+			})
+			Expect(conn.goAway()).To(Succeed())
+			Expect(parseGoAway(data).StreamID).To(Equal(quic.StreamID(8)))
+			// only send a single GOAWAY frame
+			Expect(conn.goAway()).To(Succeed())
+		})
+
+		It("sends a GOAWAY frame with stream ID 0 if no requests were received", func() {
+			var data []byte
+			controlStr.EXPECT().Write(gomock.Any()).DoAndReturn(func(b []byte) (int, error) {
+				data = b
+				return len(b), nil
+			})
+			Expect(conn.goAway()).To(Succeed())
+			Expect(parseGoAway(data).StreamID).To(BeZero())
+		})
+
+		It("rejects requests after sending the GOAWAY frame", func() {
+			Expect(conn.startRequest(0)).To(BeTrue())
+			controlStr.EXPECT().Write(gomock.Any())
+			Expect(conn.goAway()).To(Succeed())
+			Expect(conn.startRequest(4)).To(BeFalse())
+		})
+
+		It("sends a GOAWAY frame when a connection is added after the server started shutting down", func() {
+			s.closed.Set(true)
+			controlStr.EXPECT().Write(gomock.Any())
+			s.addConn(conn)
+			Expect(conn.startRequest(0)).To(BeFalse())
+		})
+
+		It("waits for requests to complete and for the client to close the connection", func() {
+			s.addConn(conn)
+			Expect(conn.startRequest(0)).To(BeTrue())
+			ctx, cancel := context.WithCancel(context.Background())
+			sess.EXPECT().Context().Return(ctx).AnyTimes()
+			controlStr.EXPECT().Write(gomock.Any())
+			sess.EXPECT().CloseWithError(quic.ErrorCode(errorNoError), "")
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				Expect(s.CloseGracefully(time.Hour)).To(Succeed())
+			}()
+			Eventually(func() bool {
+				conn.mutex.Lock()
+				defer conn.mutex.Unlock()
+				return conn.goingAway
+			}).Should(BeTrue())
+			conn.requests.Done()
+			Consistently(done).ShouldNot(BeClosed())
+			cancel()
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("closes connections when the timeout triggers", func() {
+			s.addConn(conn)
+			Expect(conn.startRequest(0)).To(BeTrue())
+			sess.EXPECT().Context().Return(context.Background()).AnyTimes()
+			controlStr.EXPECT().Write(gomock.Any())
+			sess.EXPECT().CloseWithError(quic.ErrorCode(errorNoError), "")
+			Expect(s.CloseGracefully(50 * time.Millisecond)).To(MatchError("http3: timeout while waiting for requests to complete"))
+		})
+	})
+
+	Context("control stream", func() {
+		var (
+			sess *mockquic.MockEarlySession
+			conn *serverConn
+			buf  *bytes.Buffer
+			str  *mockquic.MockStream
+		)
+
+		BeforeEach(func() {
+			sess = mockquic.NewMockEarlySession(mockCtrl)
+			conn = s.newServerConn(sess, nil)
+			buf = &bytes.Buffer{}
+			str = mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().Read(gomock.Any()).DoAndReturn(buf.Read).AnyTimes()
+		})
+
+		It("reads the client's SETTINGS", func() {
+			(&settingsFrame{settings: map[uint64]uint64{settingEnableWebTransport: 1}}).Write(buf)
+			conn.handleControlStream(str)
+			Expect(conn.receivedSettings).To(BeClosed())
+			Expect(conn.settings.has(settingEnableWebTransport)).To(BeTrue())
+		})
+
+		It("errors if the first frame is not a SETTINGS frame", func() {
+			(&goAwayFrame{}).Write(buf)
+			sess.EXPECT().CloseWithError(quic.ErrorCode(errorMissingSettings), gomock.Any())
+			conn.handleControlStream(str)
+			Expect(conn.receivedSettings).ToNot(BeClosed())
+		})
+
+		It("errors on unexpected frames", func() {
+			(&settingsFrame{}).Write(buf)
+			(&headersFrame{}).Write(buf)
+			sess.EXPECT().CloseWithError(quic.ErrorCode(errorFrameUnexpected), gomock.Any())
+			conn.handleControlStream(str)
+		})
+
+		It("enables server push when receiving a MAX_PUSH_ID frame", func() {
+			(&settingsFrame{}).Write(buf)
+			(&maxPushIDFrame{PushID: 10}).Write(buf)
+			conn.handleControlStream(str)
+			Expect(conn.pushEnabled).To(BeTrue())
+			Expect(conn.maxPushID).To(BeEquivalentTo(10))
+		})
+
+		It("errors when the MAX_PUSH_ID is reduced", func() {
+			(&settingsFrame{}).Write(buf)
+			(&maxPushIDFrame{PushID: 10}).Write(buf)
+			(&maxPushIDFrame{PushID: 9}).Write(buf)
+			sess.EXPECT().CloseWithError(quic.ErrorCode(errorIDError), gomock.Any())
+			conn.handleControlStream(str)
+		})
+	})
+
 	It("errors when listening fails", func() {
 		testErr := errors.New("listen error")
 		quicListenAddr = func(addr string, tlsConf *tls.Config, config *quic.Config) (quic.EarlyListener, error) {
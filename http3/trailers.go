@@ -0,0 +1,61 @@
+package http3
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// declaredTrailers returns the trailers announced in the Trailer header.
+// The returned map contains a nil value for every key.
+// It returns nil if no trailers were announced.
+func declaredTrailers(hdr http.Header) http.Header {
+	var trailer http.Header
+	for _, v := range hdr["Trailer"] {
+		for _, key := range strings.Split(v, ",") {
+			key = http.CanonicalHeaderKey(textproto.TrimString(key))
+			if key == "" || !httpguts.ValidTrailerHeader(key) {
+				continue
+			}
+			if trailer == nil {
+				trailer = make(http.Header)
+			}
+			trailer[key] = nil
+		}
+	}
+	return trailer
+}
+
+// setResponseTrailers copies the trailers received after the body into the Trailer map of the response.
+// Applications may hold a reference to that map, so it's only created if the response didn't announce any trailers.
+func setResponseTrailers(rsp *http.Response, trailer http.Header) {
+	if rsp.Trailer == nil {
+		rsp.Trailer = make(http.Header, len(trailer))
+	}
+	for k, vv := range trailer {
+		rsp.Trailer[k] = vv
+	}
+}
+
+// copied from net/http2/transport.go
+
+// commaSeparatedTrailers returns the value of the Trailer header for a request.
+func commaSeparatedTrailers(req *http.Request) (string, error) {
+	keys := make([]string, 0, len(req.Trailer))
+	for k := range req.Trailer {
+		k = http.CanonicalHeaderKey(k)
+		if !httpguts.ValidTrailerHeader(k) {
+			return "", fmt.Errorf("invalid Trailer key %q", k)
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) > 0 {
+		sort.Strings(keys)
+		return strings.Join(keys, ","), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,150 @@
+package http3
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFallbackDelay = 300 * time.Millisecond
+	defaultBrokenTimeout = 5 * time.Minute
+)
+
+// Transport is a http.RoundTripper that uses HTTP/3 for origins that support it, and TCP for all other origins.
+// Origins announce HTTP/3 support using the Alt-Svc header field (see RFC 7838), e.g. set by Server.SetQuicHeaders.
+// The Transport caches the alternative services it learns about.
+//
+// Before a request is sent using HTTP/3, a QUIC handshake with the origin (or its alternative service) is raced
+// against TCP: If the handshake doesn't complete within the FallbackDelay, the request is sent using TCP instead.
+// The handshake continues in the background, and the QUIC connection is used for subsequent requests.
+// A request is never sent twice.
+// If the QUIC handshake fails, QUIC is marked as broken for the origin, and TCP is used for a while.
+// This way, requests still succeed in networks that block UDP.
+type Transport struct {
+	// QUIC is the RoundTripper used for HTTP/3 requests.
+	// If nil, a RoundTripper with default values is used.
+	QUIC *RoundTripper
+
+	// TCP is the RoundTripper used for all requests that are not sent using HTTP/3.
+	// If nil, http.DefaultTransport is used.
+	TCP http.RoundTripper
+
+	// FallbackDelay is the time to wait for the QUIC handshake to complete before falling back to TCP.
+	// If zero, 300ms is used.
+	FallbackDelay time.Duration
+
+	// BrokenTimeout is the duration for which QUIC isn't used for an origin after a QUIC handshake failed.
+	// If zero, 5 minutes are used.
+	BrokenTimeout time.Duration
+
+	initOnce sync.Once
+	altSvc   altSvcCache
+}
+
+var _ http.RoundTripper = &Transport{}
+
+func (t *Transport) init() {
+	t.initOnce.Do(func() {
+		if t.QUIC == nil {
+			t.QUIC = &RoundTripper{}
+		}
+		if t.TCP == nil {
+			t.TCP = http.DefaultTransport
+		}
+		t.QUIC.alternativeService = func(origin string) (string, bool) {
+			addr, known := t.altSvc.lookup(origin, time.Now())
+			return addr, known && addr != "" && addr != origin
+		}
+	})
+}
+
+// RoundTrip sends a request using HTTP/3 if the origin supports it, and using TCP otherwise.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.init()
+	if req.URL == nil || req.URL.Scheme != "https" || req.URL.Host == "" {
+		return t.TCP.RoundTrip(req)
+	}
+
+	origin := authorityAddr("https", hostnameFromRequest(req))
+	rt := t.TCP
+	if t.raceQUIC(req.Context(), origin) {
+		rt = t.QUIC
+	}
+	rsp, err := rt.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	// Ignore invalid Alt-Svc header fields.
+	_ = t.altSvc.update(origin, rsp.Header, time.Now())
+	return rsp, nil
+}
+
+// raceQUIC starts a QUIC handshake with the origin, unless it's known that the origin doesn't support HTTP/3.
+// It returns true if the handshake completed within the fallback delay.
+func (t *Transport) raceQUIC(ctx context.Context, origin string) bool {
+	now := time.Now()
+	if t.altSvc.isBroken(origin, now) {
+		return false
+	}
+	if addr, known := t.altSvc.lookup(origin, now); known && addr == "" {
+		return false
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		// Don't abort the handshake when falling back to TCP,
+		// the connection can be used for subsequent requests.
+		err := t.QUIC.connect(context.Background(), origin)
+		if err != nil {
+			t.altSvc.markBroken(origin, time.Now().Add(t.brokenTimeout()))
+		} else {
+			t.altSvc.confirm(origin, time.Now())
+		}
+		result <- err
+	}()
+
+	timer := time.NewTimer(t.fallbackDelay())
+	defer timer.Stop()
+	select {
+	case err := <-result:
+		return err == nil
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (t *Transport) fallbackDelay() time.Duration {
+	if t.FallbackDelay == 0 {
+		return defaultFallbackDelay
+	}
+	return t.FallbackDelay
+}
+
+func (t *Transport) brokenTimeout() time.Duration {
+	if t.BrokenTimeout == 0 {
+		return defaultBrokenTimeout
+	}
+	return t.BrokenTimeout
+}
+
+// CloseIdleConnections closes all idle connections, both QUIC and TCP.
+func (t *Transport) CloseIdleConnections() {
+	t.init()
+	t.QUIC.CloseIdleConnections()
+	if c, ok := t.TCP.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+// Close closes all QUIC connections, and all idle TCP connections.
+func (t *Transport) Close() error {
+	t.init()
+	if c, ok := t.TCP.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+	return t.QUIC.Close()
+}
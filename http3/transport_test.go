@@ -0,0 +1,133 @@
+package http3
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"time"
+
+	quic "github.com/lucas-clemente/quic-go"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type mockRoundTripper func(*http.Request) (*http.Response, error)
+
+func (m mockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) { return m(req) }
+
+var _ = Describe("Transport", func() {
+	const origin = "www.example.org:443"
+
+	var (
+		t            *Transport
+		tcpRequests  chan *http.Request
+		tcpHeader    http.Header
+		origDialAddr func(string, *tls.Config, *quic.Config) (quic.EarlySession, error)
+	)
+
+	BeforeEach(func() {
+		tcpRequests = make(chan *http.Request, 10)
+		tcpHeader = http.Header{}
+		t = &Transport{
+			TCP: mockRoundTripper(func(req *http.Request) (*http.Response, error) {
+				tcpRequests <- req
+				return &http.Response{Request: req, Header: tcpHeader}, nil
+			}),
+		}
+		origDialAddr = dialAddr
+	})
+
+	AfterEach(func() {
+		dialAddr = origDialAddr
+	})
+
+	newRequest := func(url string) *http.Request {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+		return req
+	}
+
+	It("uses TCP for plain HTTP requests", func() {
+		dialAddr = func(string, *tls.Config, *quic.Config) (quic.EarlySession, error) {
+			Fail("didn't expect any QUIC connection attempt")
+			return nil, nil
+		}
+		_, err := t.RoundTrip(newRequest("http://www.example.org/"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tcpRequests).To(Receive())
+	})
+
+	It("falls back to TCP if the QUIC handshake fails, and marks QUIC as broken", func() {
+		dialed := make(chan string, 10)
+		dialAddr = func(addr string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) {
+			dialed <- addr
+			return nil, errors.New("handshake failed")
+		}
+		_, err := t.RoundTrip(newRequest("https://www.example.org/"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tcpRequests).To(Receive())
+		Expect(dialed).To(Receive(Equal(origin)))
+		Expect(t.altSvc.isBroken(origin, time.Now())).To(BeTrue())
+		// QUIC is not used for subsequent requests
+		_, err = t.RoundTrip(newRequest("https://www.example.org/"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tcpRequests).To(Receive())
+		Consistently(dialed).ShouldNot(Receive())
+	})
+
+	It("falls back to TCP if the QUIC handshake doesn't complete within the fallback delay", func() {
+		t.FallbackDelay = 10 * time.Millisecond
+		dialing := make(chan struct{})
+		done := make(chan struct{})
+		dialAddr = func(string, *tls.Config, *quic.Config) (quic.EarlySession, error) {
+			defer close(done)
+			<-dialing
+			return nil, errors.New("handshake failed")
+		}
+		_, err := t.RoundTrip(newRequest("https://www.example.org/"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tcpRequests).To(Receive())
+		close(dialing)
+		Eventually(done).Should(BeClosed())
+		Eventually(func() bool { return t.altSvc.isBroken(origin, time.Now()) }).Should(BeTrue())
+	})
+
+	It("doesn't use QUIC for origins that don't support HTTP/3", func() {
+		dialAddr = func(string, *tls.Config, *quic.Config) (quic.EarlySession, error) {
+			Fail("didn't expect any QUIC connection attempt")
+			return nil, nil
+		}
+		Expect(t.altSvc.update(origin, http.Header{}, time.Now())).To(Succeed())
+		_, err := t.RoundTrip(newRequest("https://www.example.org/"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tcpRequests).To(Receive())
+	})
+
+	It("caches the alternative services announced in responses", func() {
+		Expect(t.altSvc.update(origin, http.Header{}, time.Now())).To(Succeed())
+		tcpHeader.Set("Alt-Svc", NextProtoH3+`="alt.example.org:8443"; ma=60`)
+		_, err := t.RoundTrip(newRequest("https://www.example.org/"))
+		Expect(err).ToNot(HaveOccurred())
+		addr, known := t.altSvc.lookup(origin, time.Now())
+		Expect(known).To(BeTrue())
+		Expect(addr).To(Equal("alt.example.org:8443"))
+	})
+
+	It("dials the alternative service", func() {
+		Expect(t.altSvc.update(origin, http.Header{"Alt-Svc": {NextProtoH3 + `="alt.example.org:8443"`}}, time.Now())).To(Succeed())
+		type dialParams struct {
+			addr       string
+			serverName string
+		}
+		dialed := make(chan dialParams, 1)
+		dialAddr = func(addr string, tlsConf *tls.Config, _ *quic.Config) (quic.EarlySession, error) {
+			dialed <- dialParams{addr: addr, serverName: tlsConf.ServerName}
+			return nil, errors.New("handshake failed")
+		}
+		_, err := t.RoundTrip(newRequest("https://www.example.org/"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tcpRequests).To(Receive())
+		Expect(dialed).To(Receive(Equal(dialParams{addr: "alt.example.org:8443", serverName: "www.example.org"})))
+	})
+})
@@ -0,0 +1,252 @@
+package http3
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// A UnifiedServer serves HTTP/3 on a UDP address, and HTTP/1.1 and HTTP/2 (using TLS) on the TCP address with the same port.
+// Responses sent over TCP announce the HTTP/3 server using the Alt-Svc header field (see Server.SetQuicHeaders).
+//
+// Both servers share a lifecycle: If one of them fails, the other one is stopped as well.
+// Close and Shutdown stop both servers.
+//
+// The configuration of the embedded Server (and its http.Server) applies to both servers,
+// with the exception of the HTTP/3 specific options.
+// The configuration must not be modified after calling one of the Serve methods.
+type UnifiedServer struct {
+	*Server
+
+	mutex       sync.Mutex
+	closed      bool
+	tcpServer   *http.Server
+	tcpListener net.Listener
+	udpConn     net.PacketConn
+	ownsUDPConn bool // true if the UDP conn was opened by the UnifiedServer, and needs to be closed when shutting down
+}
+
+// ListenAndServe listens on the UDP and TCP address s.Addr, and serves HTTP/3 and HTTP/1.1 and HTTP/2, respectively.
+// The certificates are taken from s.TLSConfig.
+// It always returns a non-nil error. After Close or Shutdown, the returned error is http.ErrServerClosed.
+func (s *UnifiedServer) ListenAndServe() error {
+	return s.listenAndServe(s.TLSConfig)
+}
+
+// ListenAndServeTLS listens on the UDP and TCP address s.Addr, and serves HTTP/3 and HTTP/1.1 and HTTP/2, respectively.
+// It always returns a non-nil error. After Close or Shutdown, the returned error is http.ErrServerClosed.
+func (s *UnifiedServer) ListenAndServeTLS(certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	var tlsConf *tls.Config
+	if s.TLSConfig == nil {
+		tlsConf = &tls.Config{}
+	} else {
+		tlsConf = s.TLSConfig.Clone()
+	}
+	tlsConf.Certificates = []tls.Certificate{cert}
+	return s.listenAndServe(tlsConf)
+}
+
+func (s *UnifiedServer) listenAndServe(tlsConf *tls.Config) error {
+	if s.Server == nil || s.Server.Server == nil {
+		return errors.New("use of http3.UnifiedServer without http3.Server")
+	}
+	addr := s.Addr
+	if addr == "" {
+		addr = ":https"
+	}
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return err
+	}
+	udpConn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return err
+	}
+	tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
+	if err != nil {
+		udpConn.Close()
+		return err
+	}
+	// If the UDP port was chosen by the OS, use the same port for TCP.
+	if tcpAddr.Port == 0 {
+		tcpAddr.Port = udpConn.LocalAddr().(*net.UDPAddr).Port
+	}
+	tcpListener, err := net.ListenTCP("tcp", tcpAddr)
+	if err != nil {
+		udpConn.Close()
+		return err
+	}
+	return s.serve(tlsConf, udpConn, tcpListener, true)
+}
+
+// Serve serves HTTP/3 on udpConn, and HTTP/1.1 and HTTP/2 on tcpListener.
+// TLS connections are accepted on tcpListener, using the certificates from s.TLSConfig.
+// It always returns a non-nil error. After Close or Shutdown, the returned error is http.ErrServerClosed.
+// Closing the server closes tcpListener, but not udpConn.
+func (s *UnifiedServer) Serve(udpConn net.PacketConn, tcpListener net.Listener) error {
+	if s.Server == nil || s.Server.Server == nil {
+		return errors.New("use of http3.UnifiedServer without http3.Server")
+	}
+	return s.serve(s.TLSConfig, udpConn, tcpListener, false)
+}
+
+func (s *UnifiedServer) serve(tlsConf *tls.Config, udpConn net.PacketConn, tcpListener net.Listener, ownsUDPConn bool) error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		tcpListener.Close()
+		if ownsUDPConn {
+			udpConn.Close()
+		}
+		return http.ErrServerClosed
+	}
+	// Announce the port that we're actually listening on.
+	if addr, ok := udpConn.LocalAddr().(*net.UDPAddr); ok {
+		atomic.StoreUint32(&s.Server.port, uint32(addr.Port))
+	}
+	s.udpConn = udpConn
+	s.ownsUDPConn = ownsUDPConn
+	s.tcpListener = tcpListener
+	s.tcpServer = s.newTCPServer(tlsConf)
+	tcpServer := s.tcpServer
+	s.mutex.Unlock()
+
+	errChan := make(chan error, 2)
+	go func() {
+		// The certificates are taken from the tls.Config.
+		errChan <- tcpServer.ServeTLS(tcpListener, "", "")
+	}()
+	go func() {
+		errChan <- s.Server.serveImpl(tlsConf, udpConn)
+	}()
+
+	err := <-errChan
+	s.mutex.Lock()
+	closed := s.closed
+	s.mutex.Unlock()
+	// If the server is shutting down, Close would abort the graceful shutdown.
+	if !closed {
+		s.Close()
+	}
+	<-errChan
+	if closed {
+		return http.ErrServerClosed
+	}
+	return err
+}
+
+// newTCPServer creates the http.Server that serves HTTP/1.1 and HTTP/2.
+// It uses the configuration of s.Server.Server, and adds the Alt-Svc header field to all responses.
+func (s *UnifiedServer) newTCPServer(tlsConf *tls.Config) *http.Server {
+	handler := s.Handler
+	if handler == nil {
+		handler = http.DefaultServeMux
+	}
+	return &http.Server{
+		Addr: s.Addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.SetQuicHeaders(w.Header())
+			handler.ServeHTTP(w, r)
+		}),
+		TLSConfig:         tlsConf,
+		ReadTimeout:       s.ReadTimeout,
+		ReadHeaderTimeout: s.ReadHeaderTimeout,
+		WriteTimeout:      s.WriteTimeout,
+		IdleTimeout:       s.IdleTimeout,
+		MaxHeaderBytes:    s.MaxHeaderBytes,
+		TLSNextProto:      s.TLSNextProto,
+		ConnState:         s.ConnState,
+		ErrorLog:          s.ErrorLog,
+		BaseContext:       s.BaseContext,
+		ConnContext:       s.ConnContext,
+	}
+}
+
+// UDPAddr returns the address that HTTP/3 is served on.
+// It returns nil if the server is not serving.
+func (s *UnifiedServer) UDPAddr() net.Addr {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.udpConn == nil {
+		return nil
+	}
+	return s.udpConn.LocalAddr()
+}
+
+// TCPAddr returns the address that HTTP/1.1 and HTTP/2 are served on.
+// It returns nil if the server is not serving.
+func (s *UnifiedServer) TCPAddr() net.Addr {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.tcpListener == nil {
+		return nil
+	}
+	return s.tcpListener.Addr()
+}
+
+// Close closes both servers immediately.
+// Requests that are in flight are aborted.
+func (s *UnifiedServer) Close() error {
+	tcpServer := s.setClosed()
+	var err error
+	if tcpServer != nil {
+		err = tcpServer.Close()
+	}
+	if cerr := s.Server.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := s.closeUDPConn(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Shutdown shuts down both servers gracefully, see http.Server.Shutdown and Server.CloseGracefully.
+// It waits until all requests have completed, or until the context is canceled.
+// In that case, all remaining connections are closed, and the context's error is returned.
+func (s *UnifiedServer) Shutdown(ctx context.Context) error {
+	tcpServer := s.setClosed()
+	tcpErr := make(chan error, 1)
+	if tcpServer != nil {
+		go func() { tcpErr <- tcpServer.Shutdown(ctx) }()
+	} else {
+		tcpErr <- nil
+	}
+	err := s.Server.closeGracefully(ctx)
+	if terr := <-tcpErr; err == nil {
+		err = terr
+	}
+	if tcpServer != nil && ctx.Err() != nil {
+		// The HTTP/1.1 and HTTP/2 server doesn't close active connections when the context is canceled.
+		tcpServer.Close()
+	}
+	if cerr := s.closeUDPConn(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (s *UnifiedServer) setClosed() *http.Server {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.closed = true
+	return s.tcpServer
+}
+
+func (s *UnifiedServer) closeUDPConn() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.ownsUDPConn {
+		return nil
+	}
+	s.ownsUDPConn = false
+	return s.udpConn.Close()
+}
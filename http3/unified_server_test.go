@@ -0,0 +1,184 @@
+package http3
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	quic "github.com/lucas-clemente/quic-go"
+	mockquic "github.com/lucas-clemente/quic-go/internal/mocks/quic"
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UnifiedServer", func() {
+	var (
+		s              *UnifiedServer
+		ln             *mockquic.MockEarlyListener
+		stopAccept     chan struct{}
+		udpConn        *net.UDPConn
+		tcpListener    net.Listener
+		origQuicListen = quicListen
+	)
+
+	BeforeEach(func() {
+		s = &UnifiedServer{
+			Server: &Server{
+				Server: &http.Server{
+					TLSConfig: testdata.GetTLSConfig(),
+					Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						w.Write([]byte("foobar"))
+					}),
+				},
+			},
+		}
+		ln = mockquic.NewMockEarlyListener(mockCtrl)
+		stopAccept = make(chan struct{})
+		origQuicListen = quicListen
+		quicListen = func(net.PacketConn, *tls.Config, *quic.Config) (quic.EarlyListener, error) { return ln, nil }
+
+		var err error
+		udpConn, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		tcpListener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		quicListen = origQuicListen
+		udpConn.Close()
+		tcpListener.Close()
+	})
+
+	serve := func() <-chan error {
+		ln.EXPECT().Accept(gomock.Any()).DoAndReturn(func(context.Context) (quic.EarlySession, error) {
+			<-stopAccept
+			return nil, errors.New("closed")
+		})
+		errChan := make(chan error, 1)
+		go func() {
+			defer GinkgoRecover()
+			errChan <- s.Serve(udpConn, tcpListener)
+		}()
+		Eventually(func() net.Addr { return s.TCPAddr() }).ShouldNot(BeNil())
+		return errChan
+	}
+
+	get := func() (*http.Response, error) {
+		tr := &http.Transport{TLSClientConfig: &tls.Config{RootCAs: testdata.GetRootCA(), ServerName: "localhost"}}
+		defer tr.CloseIdleConnections()
+		return (&http.Client{Transport: tr}).Get(fmt.Sprintf("https://%s/", s.TCPAddr()))
+	}
+
+	It("errors when Serve is called with s.Server nil", func() {
+		Expect((&UnifiedServer{}).Serve(udpConn, tcpListener)).To(MatchError("use of http3.UnifiedServer without http3.Server"))
+	})
+
+	It("reports the addresses", func() {
+		Expect(s.UDPAddr()).To(BeNil())
+		Expect(s.TCPAddr()).To(BeNil())
+		errChan := serve()
+		Expect(s.UDPAddr()).To(Equal(udpConn.LocalAddr()))
+		Expect(s.TCPAddr()).To(Equal(tcpListener.Addr()))
+		ln.EXPECT().Close().Do(func() { close(stopAccept) })
+		Expect(s.Close()).To(Succeed())
+		Eventually(errChan).Should(Receive(Equal(http.ErrServerClosed)))
+	})
+
+	It("announces HTTP/3 on TCP responses", func() {
+		errChan := serve()
+		rsp, err := get()
+		Expect(err).ToNot(HaveOccurred())
+		defer rsp.Body.Close()
+		Expect(rsp.StatusCode).To(Equal(200))
+		port := udpConn.LocalAddr().(*net.UDPAddr).Port
+		Expect(rsp.Header.Get("Alt-Svc")).To(Equal(fmt.Sprintf(`%s=":%d"; ma=2592000,%s=":%d"; ma=2592000`, NextProtoH3, port, NextProtoH3Draft29, port)))
+		body, err := ioutil.ReadAll(rsp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(body).To(Equal([]byte("foobar")))
+
+		ln.EXPECT().Close().Do(func() { close(stopAccept) })
+		Expect(s.Close()).To(Succeed())
+		Eventually(errChan).Should(Receive(Equal(http.ErrServerClosed)))
+	})
+
+	It("stops the HTTP/3 server when the TCP server fails", func() {
+		errChan := serve()
+		ln.EXPECT().Close().Do(func() { close(stopAccept) })
+		Expect(tcpListener.Close()).To(Succeed())
+		var err error
+		Eventually(errChan).Should(Receive(&err))
+		Expect(err).To(HaveOccurred())
+		Expect(err).ToNot(Equal(http.ErrServerClosed))
+	})
+
+	It("stops the TCP server when the HTTP/3 server fails", func() {
+		errChan := serve()
+		close(stopAccept)
+		Eventually(errChan).Should(Receive(MatchError("closed")))
+		_, err := net.Dial("tcp", tcpListener.Addr().String())
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("shuts down both servers gracefully", func() {
+		requestStarted := make(chan struct{})
+		finishRequest := make(chan struct{})
+		s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(requestStarted)
+			<-finishRequest
+			w.Write([]byte("foobar"))
+		})
+		errChan := serve()
+		rspChan := make(chan *http.Response, 1)
+		go func() {
+			defer GinkgoRecover()
+			rsp, err := get()
+			Expect(err).ToNot(HaveOccurred())
+			rspChan <- rsp
+		}()
+		Eventually(requestStarted).Should(BeClosed())
+
+		ln.EXPECT().Close().Do(func() { close(stopAccept) })
+		shutdownErr := make(chan error, 1)
+		go func() { shutdownErr <- s.Shutdown(context.Background()) }()
+		Eventually(errChan).Should(Receive(Equal(http.ErrServerClosed)))
+		Consistently(shutdownErr).ShouldNot(Receive())
+		close(finishRequest)
+		var rsp *http.Response
+		Eventually(rspChan).Should(Receive(&rsp))
+		Expect(rsp.StatusCode).To(Equal(200))
+		rsp.Body.Close()
+		Eventually(shutdownErr, 2*time.Second).Should(Receive(BeNil()))
+	})
+
+	It("returns the context's error when the graceful shutdown is canceled", func() {
+		requestStarted := make(chan struct{})
+		finishRequest := make(chan struct{})
+		defer close(finishRequest)
+		s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(requestStarted)
+			<-finishRequest
+		})
+		serve()
+		go get()
+		Eventually(requestStarted).Should(BeClosed())
+
+		ln.EXPECT().Close().Do(func() { close(stopAccept) })
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		Expect(s.Shutdown(ctx)).To(MatchError(context.DeadlineExceeded))
+	})
+
+	It("doesn't serve after Close", func() {
+		Expect(s.Close()).To(Succeed())
+		Expect(s.Serve(udpConn, tcpListener)).To(MatchError(http.ErrServerClosed))
+	})
+})
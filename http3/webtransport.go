@@ -0,0 +1,407 @@
+package http3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+// protocolWebTransport is the value of the :protocol pseudo header field used to establish a WebTransport session.
+const protocolWebTransport = "webtransport"
+
+// maxBufferedWebTransportStreams is the maximum number of streams that are buffered
+// for WebTransport sessions that haven't been established yet.
+const maxBufferedWebTransportStreams = 16
+
+// webTransportAcceptQueueLen is the number of streams that are queued per WebTransport session,
+// before the application accepts them.
+const webTransportAcceptQueueLen = 32
+
+// webTransportDatagramQueueLen is the number of datagrams that are queued per WebTransport session,
+// before the application reads them. Datagrams that don't fit into the queue are dropped.
+const webTransportDatagramQueueLen = 32
+
+var errWebTransportSessionClosed = errors.New("http3: WebTransport session closed")
+
+// A WebTransportSession is a WebTransport session, established by an Extended CONNECT request.
+// Streams and datagrams of the session are sent on the QUIC session that carried the request,
+// and are associated with the WebTransport session by its session ID.
+type WebTransportSession struct {
+	id      quic.StreamID
+	sess    quic.Session
+	str     quic.Stream // the stream of the CONNECT request
+	manager *webTransportManager
+
+	acceptQueue    chan quic.Stream
+	acceptUniQueue chan quic.ReceiveStream
+	datagrams      chan []byte
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+}
+
+func newWebTransportSession(id quic.StreamID, sess quic.Session, str quic.Stream, manager *webTransportManager) *WebTransportSession {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WebTransportSession{
+		id:             id,
+		sess:           sess,
+		str:            str,
+		manager:        manager,
+		acceptQueue:    make(chan quic.Stream, webTransportAcceptQueueLen),
+		acceptUniQueue: make(chan quic.ReceiveStream, webTransportAcceptQueueLen),
+		datagrams:      make(chan []byte, webTransportDatagramQueueLen),
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+}
+
+// SessionID returns the session ID, which is the stream ID of the CONNECT request.
+func (s *WebTransportSession) SessionID() quic.StreamID {
+	return s.id
+}
+
+// run reads from the CONNECT stream, until the peer closes it.
+func (s *WebTransportSession) run() {
+	// We don't support any capsules yet.
+	io.Copy(ioutil.Discard, s.str)
+	s.close()
+}
+
+// OpenStream opens a new bidirectional stream for this session.
+func (s *WebTransportSession) OpenStream() (quic.Stream, error) {
+	str, err := s.sess.OpenStream()
+	if err != nil {
+		return nil, err
+	}
+	return s.initStream(str)
+}
+
+// OpenStreamSync opens a new bidirectional stream for this session.
+// It blocks until a new stream can be opened.
+func (s *WebTransportSession) OpenStreamSync(ctx context.Context) (quic.Stream, error) {
+	str, err := s.sess.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.initStream(str)
+}
+
+func (s *WebTransportSession) initStream(str quic.Stream) (quic.Stream, error) {
+	if s.ctx.Err() != nil {
+		str.CancelWrite(quic.ErrorCode(errorRequestCanceled))
+		str.CancelRead(quic.ErrorCode(errorRequestCanceled))
+		return nil, errWebTransportSessionClosed
+	}
+	buf := &bytes.Buffer{}
+	(&webTransportFrame{SessionID: s.id}).Write(buf)
+	if _, err := str.Write(buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return str, nil
+}
+
+// OpenUniStream opens a new unidirectional stream for this session.
+func (s *WebTransportSession) OpenUniStream() (quic.SendStream, error) {
+	str, err := s.sess.OpenUniStream()
+	if err != nil {
+		return nil, err
+	}
+	return s.initUniStream(str)
+}
+
+// OpenUniStreamSync opens a new unidirectional stream for this session.
+// It blocks until a new stream can be opened.
+func (s *WebTransportSession) OpenUniStreamSync(ctx context.Context) (quic.SendStream, error) {
+	str, err := s.sess.OpenUniStreamSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.initUniStream(str)
+}
+
+func (s *WebTransportSession) initUniStream(str quic.SendStream) (quic.SendStream, error) {
+	if s.ctx.Err() != nil {
+		str.CancelWrite(quic.ErrorCode(errorRequestCanceled))
+		return nil, errWebTransportSessionClosed
+	}
+	buf := &bytes.Buffer{}
+	utils.WriteVarInt(buf, streamTypeWebTransportStream)
+	utils.WriteVarInt(buf, uint64(s.id))
+	if _, err := str.Write(buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return str, nil
+}
+
+// AcceptStream returns the next bidirectional stream opened by the peer for this session.
+func (s *WebTransportSession) AcceptStream(ctx context.Context) (quic.Stream, error) {
+	select {
+	case str := <-s.acceptQueue:
+		return str, nil
+	case <-s.ctx.Done():
+		return nil, errWebTransportSessionClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// AcceptUniStream returns the next unidirectional stream opened by the peer for this session.
+func (s *WebTransportSession) AcceptUniStream(ctx context.Context) (quic.ReceiveStream, error) {
+	select {
+	case str := <-s.acceptUniQueue:
+		return str, nil
+	case <-s.ctx.Done():
+		return nil, errWebTransportSessionClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// SendMessage sends a datagram for this session.
+// Datagrams are only available if the QUIC session was established with datagram support.
+func (s *WebTransportSession) SendMessage(b []byte) error {
+	if s.ctx.Err() != nil {
+		return errWebTransportSessionClosed
+	}
+	buf := &bytes.Buffer{}
+	// The datagram is prefixed with the quarter stream ID, see RFC 9297, section 2.1.
+	utils.WriteVarInt(buf, uint64(s.id/4))
+	buf.Write(b)
+	return s.sess.SendMessage(buf.Bytes())
+}
+
+// ReceiveMessage returns the next datagram received for this session.
+func (s *WebTransportSession) ReceiveMessage(ctx context.Context) ([]byte, error) {
+	select {
+	case b := <-s.datagrams:
+		return b, nil
+	case <-s.ctx.Done():
+		return nil, errWebTransportSessionClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *WebTransportSession) handleDatagram(b []byte) {
+	select {
+	case s.datagrams <- b:
+	default:
+		s.manager.logger.Debugf("Discarding datagram for WebTransport session %d, since the queue is full.", s.id)
+	}
+}
+
+func (s *WebTransportSession) enqueueStream(str interface{}) {
+	switch str := str.(type) {
+	case quic.Stream:
+		select {
+		case s.acceptQueue <- str:
+			return
+		default:
+		}
+	case quic.ReceiveStream:
+		select {
+		case s.acceptUniQueue <- str:
+			return
+		default:
+		}
+	}
+	s.manager.logger.Debugf("Rejecting stream for WebTransport session %d, since the accept queue is full.", s.id)
+	rejectWebTransportStream(str)
+}
+
+// Close closes the session, by closing the stream of the CONNECT request.
+func (s *WebTransportSession) Close() error {
+	s.close()
+	s.str.CancelRead(quic.ErrorCode(errorNoError))
+	return s.str.Close()
+}
+
+// Context returns a context that is cancelled when the session is closed.
+func (s *WebTransportSession) Context() context.Context {
+	return s.ctx
+}
+
+func (s *WebTransportSession) close() {
+	s.closeOnce.Do(func() {
+		s.cancel()
+		s.manager.removeSession(s.id)
+	})
+}
+
+// A bufferedWebTransportStream is a stream for a WebTransport session that hasn't been established yet.
+type bufferedWebTransportStream struct {
+	sessionID quic.StreamID
+	str       interface{} // either a quic.Stream or a quic.ReceiveStream
+}
+
+// The webTransportManager demultiplexes the streams and datagrams of a QUIC session
+// to the WebTransport sessions established on that QUIC session.
+type webTransportManager struct {
+	sess   quic.Session
+	logger utils.Logger
+
+	mutex            sync.Mutex
+	sessions         map[quic.StreamID]*WebTransportSession
+	buffered         []bufferedWebTransportStream
+	datagramsStarted bool
+}
+
+func newWebTransportManager(sess quic.Session, logger utils.Logger) *webTransportManager {
+	return &webTransportManager{
+		sess:     sess,
+		logger:   logger,
+		sessions: make(map[quic.StreamID]*WebTransportSession),
+	}
+}
+
+// addSession establishes a WebTransport session on the stream of a CONNECT request.
+// Streams that were received for this session before are passed to the session.
+func (m *webTransportManager) addSession(str quic.Stream) *WebTransportSession {
+	id := str.StreamID()
+	s := newWebTransportSession(id, m.sess, str, m)
+
+	m.mutex.Lock()
+	m.sessions[id] = s
+	buffered := m.buffered[:0]
+	for _, b := range m.buffered {
+		if b.sessionID == id {
+			s.enqueueStream(b.str)
+		} else {
+			buffered = append(buffered, b)
+		}
+	}
+	m.buffered = buffered
+	if !m.datagramsStarted {
+		m.datagramsStarted = true
+		go m.handleDatagrams()
+	}
+	m.mutex.Unlock()
+
+	go s.run()
+	return s
+}
+
+// numSessions returns the number of active WebTransport sessions.
+func (m *webTransportManager) numSessions() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.sessions)
+}
+
+func (m *webTransportManager) removeSession(id quic.StreamID) {
+	m.mutex.Lock()
+	delete(m.sessions, id)
+	m.mutex.Unlock()
+}
+
+// handleStream handles a bidirectional stream that started with the WebTransport signal value.
+func (m *webTransportManager) handleStream(id quic.StreamID, str quic.Stream) {
+	m.addStream(id, str)
+}
+
+// handleUniStream handles a unidirectional WebTransport stream.
+// The stream type was already read.
+func (m *webTransportManager) handleUniStream(str quic.ReceiveStream) {
+	id, err := utils.ReadVarInt(&byteReaderImpl{str})
+	if err != nil {
+		m.logger.Debugf("Reading the session ID on stream %d failed: %s", str.StreamID(), err)
+		return
+	}
+	m.addStream(quic.StreamID(id), str)
+}
+
+func (m *webTransportManager) addStream(id quic.StreamID, str interface{}) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if s, ok := m.sessions[id]; ok {
+		s.enqueueStream(str)
+		return
+	}
+	if len(m.buffered) >= maxBufferedWebTransportStreams {
+		m.logger.Debugf("Rejecting stream for unknown WebTransport session %d.", id)
+		rejectWebTransportStream(str)
+		return
+	}
+	m.buffered = append(m.buffered, bufferedWebTransportStream{sessionID: id, str: str})
+}
+
+func rejectWebTransportStream(str interface{}) {
+	switch str := str.(type) {
+	case quic.Stream:
+		str.CancelRead(quic.ErrorCode(errorWebTransportBufferedStreamRejected))
+		str.CancelWrite(quic.ErrorCode(errorWebTransportBufferedStreamRejected))
+	case quic.ReceiveStream:
+		str.CancelRead(quic.ErrorCode(errorWebTransportBufferedStreamRejected))
+	}
+}
+
+// handleDatagrams passes received datagrams to the WebTransport session they belong to.
+// Datagrams for unknown sessions are dropped.
+func (m *webTransportManager) handleDatagrams() {
+	for {
+		data, err := m.sess.ReceiveMessage()
+		if err != nil {
+			m.logger.Debugf("Receiving datagrams failed: %s", err)
+			return
+		}
+		r := bytes.NewReader(data)
+		quarterStreamID, err := utils.ReadVarInt(r)
+		if err != nil {
+			m.sess.CloseWithError(quic.ErrorCode(errorDatagramError), "invalid quarter stream ID")
+			return
+		}
+		m.mutex.Lock()
+		s, ok := m.sessions[quic.StreamID(quarterStreamID*4)]
+		m.mutex.Unlock()
+		if !ok {
+			m.logger.Debugf("Dropping datagram for unknown WebTransport session %d.", quarterStreamID*4)
+			continue
+		}
+		s.handleDatagram(data[len(data)-r.Len():])
+	}
+}
+
+// UpgradeWebTransport establishes a WebTransport session for an Extended CONNECT request using the "webtransport" protocol.
+// It must be called from the http.Handler, before the response header is written, and it responds with status 200.
+// The server must have EnableWebTransport set.
+// The session stays open after the handler returns, until it is closed by either peer.
+func UpgradeWebTransport(w http.ResponseWriter, r *http.Request) (*WebTransportSession, error) {
+	if r.Method != http.MethodConnect || r.Proto != protocolWebTransport {
+		return nil, errors.New("http3: not a WebTransport request")
+	}
+	rw, ok := w.(*responseWriter)
+	if !ok || rw.conn == nil || rw.conn.webTransport == nil {
+		return nil, errors.New("http3: WebTransport not enabled")
+	}
+	if rw.headerWritten {
+		return nil, errors.New("http3: response header already written")
+	}
+	// We can only establish the session if the client enabled WebTransport in its SETTINGS.
+	select {
+	case <-rw.conn.receivedSettings:
+	case <-r.Context().Done():
+		return nil, r.Context().Err()
+	}
+	if !rw.conn.settings.has(settingEnableWebTransport) {
+		return nil, errors.New("http3: client didn't enable WebTransport")
+	}
+	rw.WriteHeader(http.StatusOK)
+	rw.Flush()
+	rw.hijacked = true
+	// The session outlives the CONNECT request. The server's timeouts don't apply.
+	if rw.hasDeadlines {
+		rw.str.SetReadDeadline(time.Time{})
+		rw.str.SetWriteDeadline(time.Time{})
+	}
+	return rw.conn.webTransport.addSession(rw.str), nil
+}
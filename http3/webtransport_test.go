@@ -0,0 +1,170 @@
+package http3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lucas-clemente/quic-go"
+	mockquic "github.com/lucas-clemente/quic-go/internal/mocks/quic"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WebTransport", func() {
+	var (
+		sess       *mockquic.MockEarlySession
+		connectStr *mockquic.MockStream
+		manager    *webTransportManager
+		closeRead  chan struct{} // closing this channel makes the CONNECT stream return io.EOF
+	)
+
+	// newUniStream returns a stream that only implements the quic.ReceiveStream.
+	newUniStream := func(data []byte) quic.ReceiveStream {
+		str := mockquic.NewMockStream(mockCtrl)
+		str.EXPECT().Read(gomock.Any()).DoAndReturn(bytes.NewReader(data).Read).AnyTimes()
+		str.EXPECT().StreamID().AnyTimes()
+		return struct{ quic.ReceiveStream }{str}
+	}
+
+	BeforeEach(func() {
+		sess = mockquic.NewMockEarlySession(mockCtrl)
+		sess.EXPECT().ReceiveMessage().Return(nil, errors.New("datagrams disabled")).AnyTimes()
+		connectStr = mockquic.NewMockStream(mockCtrl)
+		connectStr.EXPECT().StreamID().Return(quic.StreamID(8)).AnyTimes()
+		closeRead = make(chan struct{})
+		connectStr.EXPECT().Read(gomock.Any()).DoAndReturn(func([]byte) (int, error) {
+			<-closeRead
+			return 0, io.EOF
+		}).AnyTimes()
+		manager = newWebTransportManager(sess, utils.DefaultLogger)
+	})
+
+	AfterEach(func() {
+		select {
+		case <-closeRead:
+		default:
+			close(closeRead)
+		}
+	})
+
+	It("prefixes bidirectional streams with the session ID", func() {
+		wsess := manager.addSession(connectStr)
+		buf := &bytes.Buffer{}
+		str := mockquic.NewMockStream(mockCtrl)
+		str.EXPECT().Write(gomock.Any()).DoAndReturn(buf.Write)
+		sess.EXPECT().OpenStream().Return(str, nil)
+		s, err := wsess.OpenStream()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s).To(Equal(str))
+		frame, err := parseNextFrame(buf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(frame).To(Equal(&webTransportFrame{SessionID: 8}))
+	})
+
+	It("prefixes unidirectional streams with the stream type and the session ID", func() {
+		wsess := manager.addSession(connectStr)
+		buf := &bytes.Buffer{}
+		str := mockquic.NewMockStream(mockCtrl)
+		str.EXPECT().Write(gomock.Any()).DoAndReturn(buf.Write)
+		sess.EXPECT().OpenUniStream().Return(str, nil)
+		_, err := wsess.OpenUniStream()
+		Expect(err).ToNot(HaveOccurred())
+		streamType, err := utils.ReadVarInt(buf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(streamType).To(BeEquivalentTo(streamTypeWebTransportStream))
+		id, err := utils.ReadVarInt(buf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(id).To(BeEquivalentTo(8))
+	})
+
+	It("passes streams to the session", func() {
+		wsess := manager.addSession(connectStr)
+		str := mockquic.NewMockStream(mockCtrl)
+		manager.handleStream(8, str)
+		s, err := wsess.AcceptStream(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s).To(Equal(str))
+
+		buf := &bytes.Buffer{}
+		utils.WriteVarInt(buf, 8)
+		uniStr := newUniStream(buf.Bytes())
+		manager.handleUniStream(uniStr)
+		us, err := wsess.AcceptUniStream(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(us).To(Equal(uniStr))
+	})
+
+	It("buffers streams that arrive before the session is established", func() {
+		str := mockquic.NewMockStream(mockCtrl)
+		manager.handleStream(8, str)
+		Expect(manager.buffered).To(HaveLen(1))
+		wsess := manager.addSession(connectStr)
+		Expect(manager.buffered).To(BeEmpty())
+		s, err := wsess.AcceptStream(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s).To(Equal(str))
+	})
+
+	It("rejects streams if too many streams are buffered", func() {
+		for i := 0; i < maxBufferedWebTransportStreams; i++ {
+			manager.handleStream(4, mockquic.NewMockStream(mockCtrl))
+		}
+		str := mockquic.NewMockStream(mockCtrl)
+		str.EXPECT().CancelRead(quic.ErrorCode(errorWebTransportBufferedStreamRejected))
+		str.EXPECT().CancelWrite(quic.ErrorCode(errorWebTransportBufferedStreamRejected))
+		manager.handleStream(4, str)
+		Expect(manager.buffered).To(HaveLen(maxBufferedWebTransportStreams))
+	})
+
+	It("prefixes datagrams with the quarter stream ID", func() {
+		wsess := manager.addSession(connectStr)
+		sess.EXPECT().SendMessage(gomock.Any()).DoAndReturn(func(b []byte) error {
+			defer GinkgoRecover()
+			Expect(b).To(Equal(append([]byte{2}, []byte("foobar")...)))
+			return nil
+		})
+		Expect(wsess.SendMessage([]byte("foobar"))).To(Succeed())
+	})
+
+	It("passes datagrams to the session", func() {
+		wsess := manager.addSession(connectStr)
+		wsess.handleDatagram([]byte("foobar"))
+		data, err := wsess.ReceiveMessage(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal([]byte("foobar")))
+	})
+
+	It("closes the session when the CONNECT stream is closed", func() {
+		wsess := manager.addSession(connectStr)
+		close(closeRead)
+		Eventually(wsess.Context().Done()).Should(BeClosed())
+		_, err := wsess.AcceptStream(context.Background())
+		Expect(err).To(MatchError(errWebTransportSessionClosed))
+		Expect(wsess.SendMessage([]byte("foobar"))).To(MatchError(errWebTransportSessionClosed))
+		manager.mutex.Lock()
+		Expect(manager.sessions).To(BeEmpty())
+		manager.mutex.Unlock()
+	})
+
+	It("closes the CONNECT stream when the session is closed", func() {
+		wsess := manager.addSession(connectStr)
+		connectStr.EXPECT().CancelRead(quic.ErrorCode(errorNoError))
+		connectStr.EXPECT().Close()
+		Expect(wsess.Close()).To(Succeed())
+		Expect(wsess.Context().Done()).To(BeClosed())
+	})
+
+	It("refuses to upgrade if WebTransport is not enabled", func() {
+		req, err := http.NewRequest(http.MethodConnect, "https://quic.clemente.io/wt", nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.Proto = protocolWebTransport
+		_, err = UpgradeWebTransport(newResponseWriter(&bytes.Buffer{}, 0, newQPACKEncoder(0, nil, utils.DefaultLogger), utils.DefaultLogger), req)
+		Expect(err).To(MatchError("http3: WebTransport not enabled"))
+	})
+})
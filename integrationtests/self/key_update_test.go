@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net"
-	"os"
 
 	quic "github.com/lucas-clemente/quic-go"
 	. "github.com/onsi/ginkgo"
@@ -18,7 +17,8 @@ var _ = Describe("Key Update tests", func() {
 	runServer := func() {
 		var err error
 		// start the server
-		server, err = quic.ListenAddr("localhost:0", getTLSConfig(), nil)
+		// update keys as frequently as possible
+		server, err = quic.ListenAddr("localhost:0", getTLSConfig(), getQuicConfig(&quic.Config{KeyUpdateInterval: 1}))
 		Expect(err).ToNot(HaveOccurred())
 
 		go func() {
@@ -34,8 +34,6 @@ var _ = Describe("Key Update tests", func() {
 	}
 
 	BeforeEach(func() {
-		// update keys as frequently as possible
-		os.Setenv("QUIC_GO_KEY_UPDATE_INTERVAL", "1")
 		runServer()
 	})
 
@@ -43,7 +41,7 @@ var _ = Describe("Key Update tests", func() {
 		sess, err := quic.DialAddr(
 			fmt.Sprintf("localhost:%d", server.Addr().(*net.UDPAddr).Port),
 			getTLSClientConfig(),
-			nil,
+			getQuicConfig(&quic.Config{KeyUpdateInterval: 1}),
 		)
 		Expect(err).ToNot(HaveOccurred())
 		defer sess.CloseWithError(0, "")
@@ -8,6 +8,7 @@ import (
 
 	"github.com/lucas-clemente/quic-go/logging"
 
+	"github.com/lucas-clemente/quic-go/internal/flowcontrol"
 	"github.com/lucas-clemente/quic-go/internal/handshake"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/quictrace"
@@ -29,6 +30,10 @@ var RetireBugBackwardsCompatibilityMode bool
 // The StreamID is the ID of a QUIC stream.
 type StreamID = protocol.StreamID
 
+// A StreamPriority is the scheduling priority of a stream.
+// Streams that didn't set a priority have an urgency of 3, and are served round-robin.
+type StreamPriority = protocol.StreamPriority
+
 // A VersionNumber is a QUIC version number.
 type VersionNumber = protocol.VersionNumber
 
@@ -59,6 +64,47 @@ type TokenStore interface {
 	Put(key string, token *ClientToken)
 }
 
+// A ConnectionID is a QUIC Connection ID, as defined in RFC 9000.
+type ConnectionID = protocol.ConnectionID
+
+// A ConnectionIDGenerator generates the connection IDs used by the endpoint.
+// It can be used to encode routing information into connection IDs, e.g. for QUIC-LB (see package quiclb).
+// All connection IDs generated must be unique and have the same length.
+type ConnectionIDGenerator interface {
+	// GenerateConnectionID generates a new connection ID.
+	GenerateConnectionID() (ConnectionID, error)
+	// ConnectionIDLen returns the length of the connection IDs.
+	// It must be between 4 and 20 bytes, and must not change.
+	ConnectionIDLen() int
+}
+
+// A TokenKey is a key used to protect address validation tokens, see TokenKeyRing.
+type TokenKey = handshake.TokenKey
+
+// A TokenKeyRing holds the keys used by a server to protect address validation tokens,
+// i.e. the tokens sent in Retry packets and in NEW_TOKEN frames.
+// Every token carries the ID of the key that was used to protect it.
+// Sharing the key ring between servers (and across restarts) allows clients to use their tokens with all servers.
+type TokenKeyRing = handshake.TokenKeyRing
+
+// NewTokenKeyRing creates a new key ring for address validation tokens.
+// At least one key is required. Secrets must be TokenKeySize bytes long.
+func NewTokenKeyRing(keys ...TokenKey) (*TokenKeyRing, error) {
+	return handshake.NewTokenKeyRing(keys...)
+}
+
+// TokenKeySize is the size of the secret of a TokenKey.
+const TokenKeySize = handshake.TokenKeySize
+
+// A MemoryBudget limits the memory committed to flow control receive windows.
+// A single budget can be shared by multiple Configs, to limit the memory used by all connections of the process.
+type MemoryBudget = flowcontrol.MemoryBudget
+
+// NewMemoryBudget creates a new memory budget of limit bytes.
+func NewMemoryBudget(limit uint64) *MemoryBudget {
+	return flowcontrol.NewMemoryBudget(protocol.ByteCount(limit))
+}
+
 // An ErrorCode is an application-defined error code.
 // Valid values range between 0 and MAX_UINT62.
 type ErrorCode = protocol.ApplicationErrorCode
@@ -90,6 +136,10 @@ type ReceiveStream interface {
 	// Read will unblock immediately, and future Read calls will fail.
 	// When called multiple times or after reading the io.EOF it is a no-op.
 	CancelRead(ErrorCode)
+	// ReceivedEarlyData says if data on this stream was received in 0-RTT packets, before the handshake completed.
+	// 0-RTT data can be replayed by an attacker.
+	// Data is only returned by Read once the stream has been marked, so it is safe to call this after reading.
+	ReceivedEarlyData() bool
 	// SetReadDeadline sets the deadline for future Read calls and
 	// any currently-blocked Read call.
 	// A zero value for t means Read will not time out.
@@ -130,6 +180,12 @@ type SendStream interface {
 	// some of the data was successfully written.
 	// A zero value for t means Write will not time out.
 	SetWriteDeadline(t time.Time) error
+	// SetPriority sets the scheduling priority of the stream.
+	// Data from streams with a lower urgency is sent before data from streams with a higher urgency.
+	// Urgencies larger than 7 are treated as 7.
+	SetPriority(StreamPriority)
+	// Priority returns the scheduling priority of the stream.
+	Priority() StreamPriority
 }
 
 // StreamError is returned by Read and Write when the peer cancels the stream.
@@ -187,6 +243,20 @@ type Session interface {
 	// It blocks until the handshake completes.
 	// Warning: This API should not be considered stable and might change soon.
 	ConnectionState() ConnectionState
+	// InitiateKeyUpdate requests an update of the 1-RTT keys.
+	// The keys are updated when the next packet is sent, as soon as a key update is permitted,
+	// i.e. after the handshake was confirmed and a packet sent with the current keys was acknowledged.
+	InitiateKeyUpdate()
+	// Stats returns a snapshot of the connection statistics.
+	// It can also be called after the session was closed.
+	Stats() ConnectionStats
+
+	// SendMessage sends a message as a datagram (RFC 9221).
+	// It errors if the DATAGRAM extension wasn't negotiated, or if the message is too large.
+	SendMessage([]byte) error
+	// ReceiveMessage gets a message received in a datagram (RFC 9221).
+	// It blocks until a datagram is received, or the session is closed.
+	ReceiveMessage() ([]byte, error)
 }
 
 // An EarlySession is a session that is handshaking.
@@ -215,6 +285,11 @@ type Config struct {
 	// If used for a server, or dialing on a packet conn, a 4 byte connection ID will be used.
 	// When dialing on a packet conn, the ConnectionIDLength value must be the same for every Dial call.
 	ConnectionIDLength int
+	// ConnectionIDGenerator generates the connection IDs used by this endpoint.
+	// If set, the length of the generated connection IDs is used as ConnectionIDLength,
+	// and ConnectionIDLength must either be zero, or match that length.
+	// If not set, random connection IDs are used.
+	ConnectionIDGenerator ConnectionIDGenerator
 	// HandshakeTimeout is the maximum duration that the cryptographic handshake may take.
 	// If the timeout is exceeded, the connection is closed.
 	// If this value is zero, the timeout is set to 10 seconds.
@@ -233,6 +308,25 @@ type Config struct {
 	//   * else, that it was issued within the last 24 hours.
 	// This option is only valid for the server.
 	AcceptToken func(clientAddr net.Addr, token *Token) bool
+	// TokenKeyRing holds the keys used to protect address validation tokens (sent in Retry packets and NEW_TOKEN frames).
+	// When using the same key ring on multiple servers, tokens issued by one server are accepted by all servers.
+	// Keys are rotated by adding new keys to the key ring, see TokenKeyRing.
+	// If nil, a random key is generated when the server is started.
+	// This option is only valid for the server.
+	TokenKeyRing *TokenKeyRing
+	// AdmissionControl configures how the server handles connection attempts when it is under load.
+	// It allows limiting the number of concurrent handshakes, rate limiting connection attempts per source address prefix,
+	// and requiring address validation using a Retry once a certain number of handshakes is in progress.
+	// If nil, the number of connection attempts is only limited by the accept queue.
+	// This option is only valid for the server.
+	AdmissionControl *AdmissionControl
+	// KeyUpdateInterval is the number of packets sent or received with the current 1-RTT keys after which a key update is initiated.
+	// It is capped at the confidentiality limit of the negotiated cipher suite.
+	// If zero, the keys are updated every 100,000 packets.
+	KeyUpdateInterval uint64
+	// KeyUpdatePeriod is the time after which the 1-RTT keys are updated.
+	// If zero, the keys are not updated based on time.
+	KeyUpdatePeriod time.Duration
 	// The TokenStore stores tokens received from the server.
 	// Tokens are used to skip address validation on future connection attempts.
 	// The key used to store tokens is the ServerName from the tls.Config, if set
@@ -244,6 +338,11 @@ type Config struct {
 	// MaxReceiveConnectionFlowControlWindow is the connection-level flow control window for receiving data.
 	// If this value is zero, it will default to 1.5 MB for the server and 15 MB for the client.
 	MaxReceiveConnectionFlowControlWindow uint64
+	// MemoryBudget limits the memory committed to receive windows.
+	// Every connection reserves its connection-level receive window from the budget.
+	// When the budget is nearly exhausted, receive windows are reduced instead of being increased by auto-tuning.
+	// If nil, windows are only limited by MaxReceiveStreamFlowControlWindow and MaxReceiveConnectionFlowControlWindow.
+	MemoryBudget *MemoryBudget
 	// MaxIncomingStreams is the maximum number of concurrent bidirectional streams that a peer is allowed to open.
 	// Values above 2^60 are invalid.
 	// If not set, it will default to 100.
@@ -256,9 +355,21 @@ type Config struct {
 	MaxIncomingUniStreams int64
 	// The StatelessResetKey is used to generate stateless reset tokens.
 	// If no key is configured, sending of stateless resets is disabled.
+	// The tokens are derived from the key and the connection ID only.
+	// When the same key (and connection ID length) is used by all servers of a fleet, and across restarts,
+	// any of them can reset connections that it doesn't have any state for.
+	// The rate at which stateless resets are sent is limited.
 	StatelessResetKey []byte
 	// KeepAlive defines whether this peer will periodically send a packet to keep the connection alive.
 	KeepAlive bool
+	// DisablePathMTUDiscovery disables Path MTU Discovery (RFC 8899).
+	// Packets will then be at most 1252 (IPv4) / 1232 (IPv6) bytes in size.
+	// Path MTU Discovery is only available on platforms where the DF bit can be set (currently Linux).
+	DisablePathMTUDiscovery bool
+	// EnableDatagrams enables the QUIC DATAGRAM extension (RFC 9221).
+	// Datagrams can then be sent and received using Session.SendMessage and Session.ReceiveMessage,
+	// if the peer also enabled the extension.
+	EnableDatagrams bool
 	// QUIC Event Tracer.
 	// Warning: Experimental. This API should not be considered stable and will change soon.
 	QuicTracer quictrace.Tracer
@@ -276,6 +387,11 @@ type Listener interface {
 	Addr() net.Addr
 	// Accept returns new sessions. It should be called in a loop.
 	Accept(context.Context) (Session, error)
+	// Drain stops accepting new connections, and closes the server once all sessions are closed.
+	// Connection attempts are refused (or redirected, see AdmissionControl.DrainWithRetry),
+	// but established sessions are served until they are closed, or until the context is canceled.
+	// When the context is canceled, the server and all remaining sessions are closed, and the context's error is returned.
+	Drain(context.Context) error
 }
 
 // An EarlyListener listens for incoming QUIC connections,
@@ -287,4 +403,6 @@ type EarlyListener interface {
 	Addr() net.Addr
 	// Accept returns new early sessions. It should be called in a loop.
 	Accept(context.Context) (EarlySession, error)
+	// Drain stops accepting new connections, and closes the server once all sessions are closed, see Listener.Drain.
+	Drain(context.Context) error
 }
@@ -0,0 +1,26 @@
+package ackhandler
+
+import "github.com/lucas-clemente/quic-go/internal/protocol"
+
+// The number of ACKs we'd like to receive per congestion window,
+// when the peer supports the ACK frequency extension.
+const acksPerCongestionWindow = 4
+
+// ReorderingThreshold is the reordering threshold we request from the peer.
+// Using the packet threshold used for loss detection, the peer will send an ACK
+// as soon as we'd declare a packet lost.
+const ReorderingThreshold = packetThreshold
+
+// AckElicitingThreshold calculates the ack-eliciting threshold we request from the peer.
+// With a large congestion window, acknowledging every other packet is wasteful,
+// especially on asymmetric links. Instead, we ask the peer to send a few ACKs per congestion window.
+func AckElicitingThreshold(cwnd, maxDatagramSize protocol.ByteCount) uint64 {
+	threshold := uint64(cwnd/maxDatagramSize) / acksPerCongestionWindow
+	if threshold < 1 {
+		return 1
+	}
+	if threshold > protocol.MaxAckElicitingThreshold {
+		return protocol.MaxAckElicitingThreshold
+	}
+	return threshold
+}
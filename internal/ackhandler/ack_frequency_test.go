@@ -0,0 +1,24 @@
+package ackhandler
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ACK frequency", func() {
+	It("requests an ACK for every other packet for small congestion windows", func() {
+		Expect(AckElicitingThreshold(2*1000, 1000)).To(BeEquivalentTo(1))
+		Expect(AckElicitingThreshold(5*1000, 1000)).To(BeEquivalentTo(1))
+	})
+
+	It("requests a few ACKs per congestion window", func() {
+		Expect(AckElicitingThreshold(32*1000, 1000)).To(BeEquivalentTo(8))
+		Expect(AckElicitingThreshold(100*1000, 1000)).To(BeEquivalentTo(25))
+	})
+
+	It("limits the threshold", func() {
+		Expect(AckElicitingThreshold(10000*1000, 1000)).To(BeEquivalentTo(protocol.MaxAckElicitingThreshold))
+	})
+})
@@ -16,10 +16,24 @@ type Packet struct {
 	Length          protocol.ByteCount
 	EncryptionLevel protocol.EncryptionLevel
 	SendTime        time.Time
+	// IsPathMTUProbePacket is set for packets sent by Path MTU Discovery.
+	// Losing these packets is expected, and doesn't indicate congestion.
+	IsPathMTUProbePacket bool
 
 	includedInBytesInFlight bool
 }
 
+// SentPacketStats contains statistics about the packets sent.
+type SentPacketStats struct {
+	BytesInFlight    protocol.ByteCount
+	CongestionWindow protocol.ByteCount
+	PacketsSent      uint64
+	PacketsLost      uint64
+	// PacketsRetransmitted is the number of packets whose frames were queued for retransmission,
+	// because the packet was declared lost, to send a probe packet, or after receiving a Retry.
+	PacketsRetransmitted uint64
+}
+
 // SentPacketHandler handles ACKs received for outgoing packets
 type SentPacketHandler interface {
 	// SentPacket may modify the packet
@@ -29,6 +43,8 @@ type SentPacketHandler interface {
 	DropPackets(protocol.EncryptionLevel)
 	ResetForRetry() error
 	SetHandshakeConfirmed()
+	// SetMaxDatagramSize is called when Path MTU Discovery increases the packet size.
+	SetMaxDatagramSize(protocol.ByteCount)
 
 	// The SendMode determines if and what kind of packets can be sent.
 	SendMode() SendMode
@@ -50,6 +66,7 @@ type SentPacketHandler interface {
 
 	// report some congestion statistics. For tracing only.
 	GetStats() *quictrace.TransportState
+	GetSentPacketStats() SentPacketStats
 	// rQUIC {
 
 	CodingEnabled()
@@ -74,4 +91,8 @@ type ReceivedPacketHandler interface {
 
 	GetAlarmTimeout() time.Time
 	GetAckFrame(encLevel protocol.EncryptionLevel, onlyIfQueued bool) *wire.AckFrame
+
+	// ACK frequency extension
+	SetAckFrequency(*wire.AckFrequencyFrame)
+	QueueImmediateAck()
 }
@@ -116,6 +116,17 @@ func (h *receivedPacketHandler) GetAckFrame(encLevel protocol.EncryptionLevel, o
 	return ack
 }
 
+// SetAckFrequency applies the values requested in an ACK_FREQUENCY frame.
+// ACK_FREQUENCY frames are only sent in 0-RTT and 1-RTT packets, so this only applies to the application data packet number space.
+func (h *receivedPacketHandler) SetAckFrequency(f *wire.AckFrequencyFrame) {
+	h.appDataPackets.SetAckFrequency(f)
+}
+
+// QueueImmediateAck is called when an IMMEDIATE_ACK frame is received.
+func (h *receivedPacketHandler) QueueImmediateAck() {
+	h.appDataPackets.QueueImmediateAck()
+}
+
 func (h *receivedPacketHandler) IsPotentiallyDuplicate(pn protocol.PacketNumber, encLevel protocol.EncryptionLevel) bool {
 	switch encLevel {
 	case protocol.EncryptionInitial:
@@ -130,6 +130,23 @@ func (h *receivedPacketHistory) GetHighestAckRange() wire.AckRange {
 	return ackRange
 }
 
+// SmallestMissingAbove returns the smallest packet number larger than p that was not received yet,
+// but is smaller than the largest packet number received so far.
+// It returns protocol.InvalidPacketNumber if there's no such packet number.
+func (h *receivedPacketHistory) SmallestMissingAbove(p protocol.PacketNumber) protocol.PacketNumber {
+	candidate := p + 1
+	for el := h.ranges.Front(); el != nil; el = el.Next() {
+		if el.Value.End < candidate {
+			continue
+		}
+		if el.Value.Start > candidate {
+			return candidate
+		}
+		candidate = el.Value.End + 1
+	}
+	return protocol.InvalidPacketNumber
+}
+
 func (h *receivedPacketHistory) IsPotentiallyDuplicate(p protocol.PacketNumber) bool {
 	if p < h.deletedBelow {
 		return true
@@ -248,6 +248,31 @@ var _ = Describe("receivedPacketHistory", func() {
 		})
 	})
 
+	Context("finding missing packets", func() {
+		It("returns an invalid packet number if there are no ranges", func() {
+			Expect(hist.SmallestMissingAbove(0)).To(Equal(protocol.InvalidPacketNumber))
+		})
+
+		It("returns an invalid packet number if no packet is missing", func() {
+			Expect(hist.ReceivedPacket(3)).To(BeTrue())
+			Expect(hist.ReceivedPacket(4)).To(BeTrue())
+			Expect(hist.SmallestMissingAbove(2)).To(Equal(protocol.InvalidPacketNumber))
+			Expect(hist.SmallestMissingAbove(4)).To(Equal(protocol.InvalidPacketNumber))
+		})
+
+		It("finds the smallest missing packet", func() {
+			Expect(hist.ReceivedPacket(1)).To(BeTrue())
+			Expect(hist.ReceivedPacket(2)).To(BeTrue())
+			Expect(hist.ReceivedPacket(5)).To(BeTrue())
+			Expect(hist.ReceivedPacket(8)).To(BeTrue())
+			Expect(hist.SmallestMissingAbove(0)).To(Equal(protocol.PacketNumber(3)))
+			Expect(hist.SmallestMissingAbove(1)).To(Equal(protocol.PacketNumber(3)))
+			Expect(hist.SmallestMissingAbove(3)).To(Equal(protocol.PacketNumber(4)))
+			Expect(hist.SmallestMissingAbove(4)).To(Equal(protocol.PacketNumber(6)))
+			Expect(hist.SmallestMissingAbove(7)).To(Equal(protocol.InvalidPacketNumber))
+		})
+	})
+
 	Context("duplicate detection", func() {
 		It("doesn't declare the first packet a duplicate", func() {
 			Expect(hist.IsPotentiallyDuplicate(5)).To(BeFalse())
@@ -9,8 +9,13 @@ import (
 )
 
 // number of ack-eliciting packets received before sending an ack.
+// The peer can change this value using an ACK_FREQUENCY frame.
 const packetsBeforeAck = 2
 
+// By default, an ACK is sent immediately when a packet is received out of order.
+// The peer can change this value using an ACK_FREQUENCY frame.
+const defaultReorderingThreshold = 1
+
 type receivedPacketTracker struct {
 	largestObserved             protocol.PacketNumber
 	ignoreBelow                 protocol.PacketNumber
@@ -21,6 +26,12 @@ type receivedPacketTracker struct {
 	maxAckDelay time.Duration
 	rttStats    *utils.RTTStats
 
+	// values requested by the peer using ACK_FREQUENCY frames
+	packetsBeforeAck          int
+	reorderingThreshold       protocol.PacketNumber
+	receivedAckFrequencyFrame bool
+	highestAckFrequencySeqNum uint64
+
 	hasNewAck bool // true as soon as we received an ack-eliciting new packet
 	ackQueued bool // true once we received more than 2 (or later in the connection 10) ack-eliciting packets
 
@@ -39,11 +50,13 @@ func newReceivedPacketTracker(
 	version protocol.VersionNumber,
 ) *receivedPacketTracker {
 	return &receivedPacketTracker{
-		packetHistory: newReceivedPacketHistory(),
-		maxAckDelay:   protocol.MaxAckDelay,
-		rttStats:      rttStats,
-		logger:        logger,
-		version:       version,
+		packetHistory:       newReceivedPacketHistory(),
+		maxAckDelay:         protocol.MaxAckDelay,
+		rttStats:            rttStats,
+		packetsBeforeAck:    packetsBeforeAck,
+		reorderingThreshold: defaultReorderingThreshold,
+		logger:              logger,
+		version:             version,
 	}
 }
 
@@ -79,6 +92,36 @@ func (h *receivedPacketTracker) IgnoreBelow(p protocol.PacketNumber) {
 	}
 }
 
+// SetAckFrequency applies the values requested by the peer in an ACK_FREQUENCY frame.
+// Frames with a sequence number lower than that of a previously received frame are ignored.
+func (h *receivedPacketTracker) SetAckFrequency(f *wire.AckFrequencyFrame) {
+	if h.receivedAckFrequencyFrame && f.SequenceNumber <= h.highestAckFrequencySeqNum {
+		return
+	}
+	h.receivedAckFrequencyFrame = true
+	h.highestAckFrequencySeqNum = f.SequenceNumber
+	h.maxAckDelay = f.RequestMaxAckDelay
+	if f.AckElicitingThreshold >= protocol.MaxAckElicitingThreshold {
+		h.packetsBeforeAck = protocol.MaxAckElicitingThreshold + 1
+	} else {
+		h.packetsBeforeAck = int(f.AckElicitingThreshold) + 1
+	}
+	h.reorderingThreshold = protocol.PacketNumber(f.ReorderingThreshold)
+	if h.logger.Debug() {
+		h.logger.Debugf("\tUpdating ACK frequency: %d packets before an ACK, max ack delay %s, reordering threshold %d", h.packetsBeforeAck, h.maxAckDelay, h.reorderingThreshold)
+	}
+}
+
+// QueueImmediateAck makes sure that an ACK is sent immediately.
+// It is used when receiving an IMMEDIATE_ACK frame.
+func (h *receivedPacketTracker) QueueImmediateAck() {
+	if !h.ackQueued {
+		h.logger.Debugf("\tQueueing ACK because an IMMEDIATE_ACK frame was received.")
+	}
+	h.ackQueued = true
+	h.ackAlarm = time.Time{}
+}
+
 // isMissing says if a packet was reported missing in the last ACK.
 func (h *receivedPacketTracker) isMissing(p protocol.PacketNumber) bool {
 	if h.lastAck == nil || p < h.ignoreBelow {
@@ -95,6 +138,16 @@ func (h *receivedPacketTracker) hasNewMissingPackets() bool {
 	return highestRange.Smallest > h.lastAck.LargestAcked()+1 && highestRange.Len() == 1
 }
 
+// hasReorderedPacketsBeyondThreshold says if a packet that wasn't reported missing yet
+// is missing for more than the reordering threshold.
+func (h *receivedPacketTracker) hasReorderedPacketsBeyondThreshold() bool {
+	if h.lastAck == nil {
+		return false
+	}
+	missing := h.packetHistory.SmallestMissingAbove(h.lastAck.LargestAcked())
+	return missing != protocol.InvalidPacketNumber && h.largestObserved-missing >= h.reorderingThreshold
+}
+
 // maybeQueueAck queues an ACK, if necessary.
 func (h *receivedPacketTracker) maybeQueueAck(pn protocol.PacketNumber, rcvTime time.Time, wasMissing bool) {
 	// always acknowledge the first packet
@@ -115,17 +168,17 @@ func (h *receivedPacketTracker) maybeQueueAck(pn protocol.PacketNumber, rcvTime
 	// Send an ACK if this packet was reported missing in an ACK sent before.
 	// Ack decimation with reordering relies on the timer to send an ACK, but if
 	// missing packets we reported in the previous ack, send an ACK immediately.
-	if wasMissing {
+	if wasMissing && h.reorderingThreshold > 0 {
 		if h.logger.Debug() {
 			h.logger.Debugf("\tQueueing ACK because packet %d was missing before.", pn)
 		}
 		h.ackQueued = true
 	}
 
-	// send an ACK every 2 ack-eliciting packets
-	if h.ackElicitingPacketsReceivedSinceLastAck >= packetsBeforeAck {
+	// send an ACK every 2 ack-eliciting packets (or as requested by the peer)
+	if h.ackElicitingPacketsReceivedSinceLastAck >= h.packetsBeforeAck {
 		if h.logger.Debug() {
-			h.logger.Debugf("\tQueueing ACK because packet %d packets were received after the last ACK (using initial threshold: %d).", h.ackElicitingPacketsReceivedSinceLastAck, packetsBeforeAck)
+			h.logger.Debugf("\tQueueing ACK because packet %d packets were received after the last ACK (using threshold: %d).", h.ackElicitingPacketsReceivedSinceLastAck, h.packetsBeforeAck)
 		}
 		h.ackQueued = true
 	} else if h.ackAlarm.IsZero() {
@@ -136,9 +189,18 @@ func (h *receivedPacketTracker) maybeQueueAck(pn protocol.PacketNumber, rcvTime
 	}
 
 	// Queue an ACK if there are new missing packets to report.
-	if h.hasNewMissingPackets() {
-		h.logger.Debugf("\tQueuing ACK because there's a new missing packet to report.")
-		h.ackQueued = true
+	switch {
+	case h.reorderingThreshold == 0: // the peer doesn't want to be notified about reordering
+	case h.reorderingThreshold == 1:
+		if h.hasNewMissingPackets() {
+			h.logger.Debugf("\tQueuing ACK because there's a new missing packet to report.")
+			h.ackQueued = true
+		}
+	default:
+		if h.hasReorderedPacketsBeyondThreshold() {
+			h.logger.Debugf("\tQueuing ACK because a missing packet exceeds the reordering threshold.")
+			h.ackQueued = true
+		}
 	}
 
 	if h.ackQueued {
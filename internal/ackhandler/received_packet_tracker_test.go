@@ -172,6 +172,74 @@ var _ = Describe("Received Packet Tracker", func() {
 				tracker.ReceivedPacket(11, time.Now(), true)
 				Expect(tracker.GetAckFrame(true)).To(BeNil())
 			})
+
+			Context("using ACK_FREQUENCY frames", func() {
+				It("uses the ack-eliciting threshold and max ack delay requested by the peer", func() {
+					receiveAndAck10Packets()
+					tracker.SetAckFrequency(&wire.AckFrequencyFrame{
+						SequenceNumber:        1,
+						AckElicitingThreshold: 4,
+						RequestMaxAckDelay:    100 * time.Millisecond,
+						ReorderingThreshold:   1,
+					})
+					rcvTime := time.Now()
+					for i := 11; i < 15; i++ {
+						tracker.ReceivedPacket(protocol.PacketNumber(i), rcvTime, true)
+						Expect(tracker.ackQueued).To(BeFalse())
+						Expect(tracker.GetAlarmTimeout()).To(Equal(rcvTime.Add(100 * time.Millisecond)))
+					}
+					tracker.ReceivedPacket(15, rcvTime, true)
+					Expect(tracker.ackQueued).To(BeTrue())
+				})
+
+				It("ignores ACK_FREQUENCY frames with old sequence numbers", func() {
+					tracker.SetAckFrequency(&wire.AckFrequencyFrame{SequenceNumber: 2, AckElicitingThreshold: 4, RequestMaxAckDelay: time.Millisecond})
+					tracker.SetAckFrequency(&wire.AckFrequencyFrame{SequenceNumber: 1, AckElicitingThreshold: 8, RequestMaxAckDelay: time.Second})
+					Expect(tracker.packetsBeforeAck).To(Equal(5))
+					Expect(tracker.maxAckDelay).To(Equal(time.Millisecond))
+					tracker.SetAckFrequency(&wire.AckFrequencyFrame{SequenceNumber: 2, AckElicitingThreshold: 8, RequestMaxAckDelay: time.Second})
+					Expect(tracker.packetsBeforeAck).To(Equal(5))
+				})
+
+				It("limits the ack-eliciting threshold", func() {
+					tracker.SetAckFrequency(&wire.AckFrequencyFrame{AckElicitingThreshold: 1 << 40})
+					Expect(tracker.packetsBeforeAck).To(Equal(protocol.MaxAckElicitingThreshold + 1))
+				})
+
+				It("doesn't queue an ACK for reordered packets if the reordering threshold is 0", func() {
+					receiveAndAck10Packets()
+					tracker.SetAckFrequency(&wire.AckFrequencyFrame{AckElicitingThreshold: 10, RequestMaxAckDelay: time.Second})
+					tracker.ReceivedPacket(13, time.Now(), true)
+					tracker.ReceivedPacket(12, time.Now(), true)
+					Expect(tracker.GetAckFrame(true)).To(BeNil())
+				})
+
+				It("queues an ACK when a missing packet exceeds the reordering threshold", func() {
+					receiveAndAck10Packets()
+					tracker.SetAckFrequency(&wire.AckFrequencyFrame{
+						AckElicitingThreshold: 10,
+						RequestMaxAckDelay:    time.Second,
+						ReorderingThreshold:   3,
+					})
+					// 11 is missing
+					tracker.ReceivedPacket(12, time.Now(), true)
+					tracker.ReceivedPacket(13, time.Now(), true)
+					Expect(tracker.ackQueued).To(BeFalse())
+					tracker.ReceivedPacket(14, time.Now(), true)
+					Expect(tracker.ackQueued).To(BeTrue())
+					ack := tracker.GetAckFrame(true)
+					Expect(ack).ToNot(BeNil())
+					Expect(ack.AckRanges).To(Equal([]wire.AckRange{{Smallest: 12, Largest: 14}, {Smallest: 1, Largest: 10}}))
+				})
+
+				It("queues an ACK when receiving an IMMEDIATE_ACK frame", func() {
+					receiveAndAck10Packets()
+					tracker.SetAckFrequency(&wire.AckFrequencyFrame{AckElicitingThreshold: 10, RequestMaxAckDelay: time.Second})
+					tracker.QueueImmediateAck()
+					tracker.ReceivedPacket(11, time.Now(), true)
+					Expect(tracker.GetAckFrame(true)).ToNot(BeNil())
+				})
+			})
 		})
 
 		Context("ACK generation", func() {
@@ -68,6 +68,10 @@ type sentPacketHandler struct {
 
 	bytesInFlight protocol.ByteCount
 
+	numPacketsSent          uint64
+	numPacketsLost          uint64
+	numPacketsRetransmitted uint64
+
 	congestion congestion.SendAlgorithmWithDebugInfos
 	rttStats   *utils.RTTStats
 
@@ -226,6 +230,7 @@ func (h *sentPacketHandler) packetsInFlight() int {
 
 func (h *sentPacketHandler) SentPacket(packet *Packet) {
 	h.bytesSent += packet.Length
+	h.numPacketsSent++
 	// For the client, drop the Initial packet number space when the first Handshake packet is sent.
 	if h.perspective == protocol.PerspectiveClient && packet.EncryptionLevel == protocol.EncryptionHandshake && h.initialPackets != nil {
 		h.dropPackets(protocol.EncryptionInitial)
@@ -343,6 +348,9 @@ func (h *sentPacketHandler) ReceivedAck(ack *wire.AckFrame, encLevel protocol.En
 		return err
 	}
 	for _, p := range lostPackets {
+		if p.IsPathMTUProbePacket {
+			continue
+		}
 		h.congestion.OnPacketLost(p.PacketNumber, p.Length, priorInFlight)
 	}
 	for _, p := range ackedPackets {
@@ -588,7 +596,12 @@ func (h *sentPacketHandler) detectAndRemoveLostPackets(now time.Time, encLevel p
 	}
 	// rQUIC {
 	if h.coding && encLevel == protocol.Encryption1RTT {
-		h.lastLosses += len(lostPackets)
+		for _, p := range lostPackets {
+			// The loss of a Path MTU Discovery probe doesn't say anything about the residual loss rate.
+			if !p.IsPathMTUProbePacket {
+				h.lastLosses++
+			}
+		}
 	}
 	// } rQUIC
 
@@ -600,6 +613,7 @@ func (h *sentPacketHandler) detectAndRemoveLostPackets(now time.Time, encLevel p
 		h.logger.Debugf("\tlost packets (%d): %d", len(pns), pns)
 	}
 
+	h.numPacketsLost += uint64(len(lostPackets))
 	for _, p := range lostPackets {
 		h.queueFramesForRetransmission(p)
 		// the bytes in flight need to be reduced no matter if this packet will be retransmitted
@@ -658,6 +672,9 @@ func (h *sentPacketHandler) onVerifiedLossDetectionTimeout() error {
 			return err
 		}
 		for _, p := range lostPackets {
+			if p.IsPathMTUProbePacket {
+				continue
+			}
 			h.congestion.OnPacketLost(p.PacketNumber, p.Length, priorInFlight)
 		}
 		return nil
@@ -803,6 +820,7 @@ func (h *sentPacketHandler) QueueProbePacket(encLevel protocol.EncryptionLevel)
 }
 
 func (h *sentPacketHandler) queueFramesForRetransmission(p *Packet) {
+	h.numPacketsRetransmitted++
 	for _, f := range p.Frames {
 		f.OnLost(f.Frame)
 	}
@@ -858,6 +876,10 @@ func (h *sentPacketHandler) SetHandshakeConfirmed() {
 	h.setLossDetectionTimer()
 }
 
+func (h *sentPacketHandler) SetMaxDatagramSize(s protocol.ByteCount) {
+	h.congestion.SetMaxDatagramSize(s)
+}
+
 func (h *sentPacketHandler) GetStats() *quictrace.TransportState {
 	return &quictrace.TransportState{
 		MinRTT:           h.rttStats.MinRTT(),
@@ -869,3 +891,13 @@ func (h *sentPacketHandler) GetStats() *quictrace.TransportState {
 		InRecovery:       h.congestion.InRecovery(),
 	}
 }
+
+func (h *sentPacketHandler) GetSentPacketStats() SentPacketStats {
+	return SentPacketStats{
+		BytesInFlight:        h.bytesInFlight,
+		CongestionWindow:     h.congestion.GetCongestionWindow(),
+		PacketsSent:          h.numPacketsSent,
+		PacketsLost:          h.numPacketsLost,
+		PacketsRetransmitted: h.numPacketsRetransmitted,
+	}
+}
@@ -467,6 +467,28 @@ var _ = Describe("SentPacketHandler", func() {
 			Expect(handler.ReceivedAck(ack, protocol.Encryption1RTT, time.Now())).To(Succeed())
 		})
 
+		It("doesn't call OnPacketLost when a Path MTU probe packet is lost", func() {
+			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+			var mtuPacketLost bool
+			handler.SentPacket(&Packet{
+				PacketNumber:         1,
+				Length:               1,
+				EncryptionLevel:      protocol.Encryption1RTT,
+				SendTime:             time.Now().Add(-time.Hour),
+				IsPathMTUProbePacket: true,
+				Frames:               []Frame{{Frame: &wire.PingFrame{}, OnLost: func(wire.Frame) { mtuPacketLost = true }}},
+			})
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2}))
+			// lose packet 1, but don't EXPECT any calls to OnPacketLost
+			gomock.InOrder(
+				cong.EXPECT().MaybeExitSlowStart(),
+				cong.EXPECT().OnPacketAcked(protocol.PacketNumber(2), protocol.ByteCount(1), protocol.ByteCount(2), gomock.Any()),
+			)
+			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 2, Largest: 2}}}
+			Expect(handler.ReceivedAck(ack, protocol.Encryption1RTT, time.Now())).To(Succeed())
+			Expect(mtuPacketLost).To(BeTrue())
+		})
+
 		It("calls OnPacketAcked and OnPacketLost with the right bytes_in_flight value", func() {
 			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(4)
 			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 1, SendTime: time.Now().Add(-time.Hour)}))
@@ -491,6 +513,31 @@ var _ = Describe("SentPacketHandler", func() {
 			Expect(handler.ReceivedAck(ack, protocol.Encryption1RTT, time.Now())).To(Succeed())
 		})
 
+		It("counts sent, lost and retransmitted packets", func() {
+			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 1, SendTime: time.Now().Add(-time.Hour)}))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2, SendTime: time.Now().Add(-time.Hour)}))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 3, SendTime: time.Now()}))
+			cong.EXPECT().GetCongestionWindow().Return(protocol.ByteCount(1337))
+			stats := handler.GetSentPacketStats()
+			Expect(stats.PacketsSent).To(BeEquivalentTo(3))
+			Expect(stats.BytesInFlight).To(Equal(protocol.ByteCount(3)))
+			Expect(stats.CongestionWindow).To(Equal(protocol.ByteCount(1337)))
+			Expect(stats.PacketsLost).To(BeZero())
+			// lose packets 1 and 2
+			cong.EXPECT().MaybeExitSlowStart()
+			cong.EXPECT().OnPacketLost(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+			cong.EXPECT().OnPacketAcked(protocol.PacketNumber(3), gomock.Any(), gomock.Any(), gomock.Any())
+			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 3, Largest: 3}}}
+			Expect(handler.ReceivedAck(ack, protocol.Encryption1RTT, time.Now())).To(Succeed())
+			cong.EXPECT().GetCongestionWindow()
+			stats = handler.GetSentPacketStats()
+			Expect(stats.PacketsSent).To(BeEquivalentTo(3))
+			Expect(stats.PacketsLost).To(BeEquivalentTo(2))
+			Expect(stats.PacketsRetransmitted).To(BeEquivalentTo(2))
+			Expect(stats.BytesInFlight).To(BeZero())
+		})
+
 		It("passes the bytes in flight to the congestion controller", func() {
 			handler.ReceivedPacket(protocol.EncryptionHandshake)
 			cong.EXPECT().OnPacketSent(gomock.Any(), protocol.ByteCount(42), gomock.Any(), protocol.ByteCount(42), true)
@@ -1,6 +1,7 @@
 package congestion
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
@@ -15,8 +16,8 @@ import (
 const (
 	// maxDatagramSize is the default maximum packet size used in the Linux TCP implementation.
 	// Used in QUIC for congestion window computations in bytes.
+	// It is used until Path MTU Discovery increases the packet size.
 	maxDatagramSize         = protocol.ByteCount(protocol.MaxPacketSizeIPv4)
-	maxBurstBytes           = 3 * maxDatagramSize
 	renoBeta                = 0.7 // Reno backoff factor.
 	maxCongestionWindow     = protocol.MaxCongestionWindowPackets * maxDatagramSize
 	minCongestionWindow     = 2 * maxDatagramSize
@@ -32,6 +33,9 @@ type cubicSender struct {
 
 	reno bool
 
+	// The maximum size of the packets sent.
+	maxDatagramSize protocol.ByteCount
+
 	// Track the largest packet that has been sent.
 	largestSentPacketNumber protocol.PacketNumber
 
@@ -90,6 +94,7 @@ func newCubicSender(clock Clock, rttStats *utils.RTTStats, reno bool, initialCon
 		cubic:                      NewCubic(clock),
 		clock:                      clock,
 		reno:                       reno,
+		maxDatagramSize:            maxDatagramSize,
 		tracer:                     tracer,
 	}
 	c.pacer = newPacer(c.BandwidthEstimate)
@@ -106,7 +111,7 @@ func (c *cubicSender) TimeUntilSend(_ protocol.ByteCount) time.Time {
 }
 
 func (c *cubicSender) HasPacingBudget() bool {
-	return c.pacer.Budget(c.clock.Now()) >= maxDatagramSize
+	return c.pacer.Budget(c.clock.Now()) >= c.maxDatagramSize
 }
 
 func (c *cubicSender) OnPacketSent(
@@ -141,7 +146,7 @@ func (c *cubicSender) GetCongestionWindow() protocol.ByteCount {
 }
 
 func (c *cubicSender) MaybeExitSlowStart() {
-	if c.InSlowStart() && c.hybridSlowStart.ShouldExitSlowStart(c.rttStats.LatestRTT(), c.rttStats.MinRTT(), c.GetCongestionWindow()/maxDatagramSize) {
+	if c.InSlowStart() && c.hybridSlowStart.ShouldExitSlowStart(c.rttStats.LatestRTT(), c.rttStats.MinRTT(), c.GetCongestionWindow()/c.maxDatagramSize) {
 		// exit slow start
 		c.slowStartThreshold = c.congestionWindow
 		c.maybeTraceStateChange(logging.CongestionStateCongestionAvoidance)
@@ -232,7 +237,7 @@ func (c *cubicSender) maybeIncreaseCwnd(
 	// } rQUIC
 	if c.InSlowStart() {
 		// TCP slow start, exponential growth, increase by one for each ACK.
-		c.congestionWindow += maxDatagramSize
+		c.congestionWindow += c.maxDatagramSize
 		c.maybeTraceStateChange(logging.CongestionStateSlowStart)
 		return
 	}
@@ -241,8 +246,8 @@ func (c *cubicSender) maybeIncreaseCwnd(
 	if c.reno {
 		// Classic Reno congestion avoidance.
 		c.numAckedPackets++
-		if c.numAckedPackets >= uint64(c.congestionWindow/maxDatagramSize) {
-			c.congestionWindow += maxDatagramSize
+		if c.numAckedPackets >= uint64(c.congestionWindow/c.maxDatagramSize) {
+			c.congestionWindow += c.maxDatagramSize
 			c.numAckedPackets = 0
 		}
 	} else {
@@ -257,7 +262,7 @@ func (c *cubicSender) isCwndLimited(bytesInFlight protocol.ByteCount) bool {
 	}
 	availableBytes := congestionWindow - bytesInFlight
 	slowStartLimited := c.InSlowStart() && bytesInFlight > congestionWindow/2
-	return slowStartLimited || availableBytes <= maxBurstBytes
+	return slowStartLimited || availableBytes <= 3*c.maxDatagramSize
 }
 
 // BandwidthEstimate returns the current bandwidth estimate
@@ -316,6 +321,21 @@ func (c *cubicSender) OnConnectionMigration() {
 	c.maxCongestionWindow = c.initialMaxCongestionWindow
 }
 
+// SetMaxDatagramSize sets the maximum size of the packets sent.
+// It is called when Path MTU Discovery increases the packet size.
+func (c *cubicSender) SetMaxDatagramSize(s protocol.ByteCount) {
+	if s < c.maxDatagramSize {
+		panic(fmt.Sprintf("congestion BUG: decreased max datagram size from %d to %d", c.maxDatagramSize, s))
+	}
+	cwndIsMinCwnd := c.congestionWindow == c.minCongestionWindow
+	c.maxDatagramSize = s
+	c.minCongestionWindow = 2 * s
+	if cwndIsMinCwnd {
+		c.congestionWindow = c.minCongestionWindow
+	}
+	c.pacer.SetMaxDatagramSize(s)
+}
+
 func (c *cubicSender) maybeTraceStateChange(new logging.CongestionState) {
 	if c.tracer == nil || new == c.lastState {
 		return
@@ -494,4 +494,27 @@ var _ = Describe("Cubic Sender", func() {
 		AckNPackets(2)
 		Expect(sender.GetCongestionWindow()).To(Equal(savedCwnd + maxDatagramSize))
 	})
+
+	It("uses the maximum datagram size", func() {
+		sender.SetMaxDatagramSize(3 * maxDatagramSize)
+		Expect(sender.GetCongestionWindow()).To(Equal(defaultWindowTCP))
+		// in slow start, the congestion window grows by one packet per ACK
+		SendAvailableSendWindowLen(3 * maxDatagramSize)
+		sender.OnPacketAcked(1, 3*maxDatagramSize, bytesInFlight, clock.Now())
+		Expect(sender.GetCongestionWindow()).To(Equal(defaultWindowTCP + 3*maxDatagramSize))
+		// the minimum congestion window is 2 packets
+		sender.OnRetransmissionTimeout(true)
+		Expect(sender.GetCongestionWindow()).To(Equal(6 * maxDatagramSize))
+	})
+
+	It("raises the congestion window if it is at the minimum when the maximum datagram size increases", func() {
+		sender.OnRetransmissionTimeout(true)
+		Expect(sender.GetCongestionWindow()).To(Equal(minCongestionWindow))
+		sender.SetMaxDatagramSize(3 * maxDatagramSize)
+		Expect(sender.GetCongestionWindow()).To(Equal(6 * maxDatagramSize))
+	})
+
+	It("doesn't allow reducing the maximum datagram size", func() {
+		Expect(func() { sender.SetMaxDatagramSize(maxDatagramSize - 1) }).To(Panic())
+	})
 })
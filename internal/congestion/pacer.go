@@ -8,25 +8,27 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/utils"
 )
 
-const maxBurstSize = 10 * maxDatagramSize
-
 // The pacer implements a token bucket pacing algorithm.
 type pacer struct {
 	budgetAtLastSent     protocol.ByteCount
 	lastSentTime         time.Time
+	maxDatagramSize      protocol.ByteCount
 	getAdjustedBandwidth func() uint64 // in bytes/s
 }
 
 func newPacer(getBandwidth func() Bandwidth) *pacer {
-	p := &pacer{getAdjustedBandwidth: func() uint64 {
-		// Bandwidth is in bits/s. We need the value in bytes/s.
-		bw := uint64(getBandwidth() / BytesPerSecond)
-		// Use a slightly higher value than the actual measured bandwidth.
-		// RTT variations then won't result in under-utilization of the congestion window.
-		// Ultimately, this will  result in sending packets as acknowledgments are received rather than when timers fire,
-		// provided the congestion window is fully utilized and acknowledgments arrive at regular intervals.
-		return bw * 5 / 4
-	}}
+	p := &pacer{
+		maxDatagramSize: maxDatagramSize,
+		getAdjustedBandwidth: func() uint64 {
+			// Bandwidth is in bits/s. We need the value in bytes/s.
+			bw := uint64(getBandwidth() / BytesPerSecond)
+			// Use a slightly higher value than the actual measured bandwidth.
+			// RTT variations then won't result in under-utilization of the congestion window.
+			// Ultimately, this will  result in sending packets as acknowledgments are received rather than when timers fire,
+			// provided the congestion window is fully utilized and acknowledgments arrive at regular intervals.
+			return bw * 5 / 4
+		},
+	}
 	p.budgetAtLastSent = p.maxBurstSize()
 	return p
 }
@@ -52,17 +54,22 @@ func (p *pacer) Budget(now time.Time) protocol.ByteCount {
 func (p *pacer) maxBurstSize() protocol.ByteCount {
 	return utils.MaxByteCount(
 		protocol.ByteCount(uint64((protocol.MinPacingDelay+protocol.TimerGranularity).Nanoseconds())*p.getAdjustedBandwidth())/1e9,
-		maxBurstSize,
+		10*p.maxDatagramSize,
 	)
 }
 
+// SetMaxDatagramSize sets the maximum size of the packets sent.
+func (p *pacer) SetMaxDatagramSize(s protocol.ByteCount) {
+	p.maxDatagramSize = s
+}
+
 // TimeUntilSend returns when the next packet should be sent.
 func (p *pacer) TimeUntilSend() time.Time {
-	if p.budgetAtLastSent >= maxDatagramSize {
+	if p.budgetAtLastSent >= p.maxDatagramSize {
 		return time.Time{}
 	}
 	return p.lastSentTime.Add(utils.MaxDuration(
 		protocol.MinPacingDelay,
-		time.Duration(math.Ceil(float64(maxDatagramSize-p.budgetAtLastSent)*1e9/float64(p.getAdjustedBandwidth())))*time.Nanosecond,
+		time.Duration(math.Ceil(float64(p.maxDatagramSize-p.budgetAtLastSent)*1e9/float64(p.getAdjustedBandwidth())))*time.Nanosecond,
 	))
 }
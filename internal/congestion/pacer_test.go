@@ -25,14 +25,14 @@ var _ = Describe("Pacer", func() {
 	It("allows a burst at the beginning", func() {
 		t := time.Now()
 		Expect(p.TimeUntilSend()).To(BeZero())
-		Expect(p.Budget(t)).To(BeEquivalentTo(maxBurstSize))
+		Expect(p.Budget(t)).To(BeEquivalentTo(10 * maxDatagramSize))
 	})
 
 	It("allows a big burst for high pacing rates", func() {
 		t := time.Now()
 		bandwidth = uint64(10000 * packetsPerSecond * maxDatagramSize)
 		Expect(p.TimeUntilSend()).To(BeZero())
-		Expect(p.Budget(t)).To(BeNumerically(">", maxBurstSize))
+		Expect(p.Budget(t)).To(BeNumerically(">", 10*maxDatagramSize))
 	})
 
 	It("reduces the budget when sending packets", func() {
@@ -92,7 +92,7 @@ var _ = Describe("Pacer", func() {
 	It("never allows bursts larger than the maximum burst size", func() {
 		t := time.Now()
 		sendBurst(t)
-		Expect(p.Budget(t.Add(time.Hour))).To(BeEquivalentTo(maxBurstSize))
+		Expect(p.Budget(t.Add(time.Hour))).To(BeEquivalentTo(10 * maxDatagramSize))
 	})
 
 	It("changes the bandwidth", func() {
@@ -109,4 +109,13 @@ var _ = Describe("Pacer", func() {
 		Expect(p.TimeUntilSend()).To(Equal(t.Add(protocol.MinPacingDelay)))
 		Expect(p.Budget(t.Add(protocol.MinPacingDelay))).To(Equal(protocol.ByteCount(protocol.MinPacingDelay) * maxDatagramSize * 1e6 / 1e9))
 	})
+
+	It("uses the maximum datagram size", func() {
+		t := time.Now()
+		p.SetMaxDatagramSize(2 * maxDatagramSize)
+		Expect(p.Budget(t)).To(BeEquivalentTo(20 * maxDatagramSize))
+		sendBurst(t)
+		// 2 full-size packets are needed before the next packet can be sent
+		Expect(p.TimeUntilSend()).To(Equal(t.Add(2 * time.Second / packetsPerSecond)))
+	})
 })
@@ -20,11 +20,21 @@ type baseFlowController struct {
 	highestReceived      protocol.ByteCount
 	receiveWindow        protocol.ByteCount
 	receiveWindowSize    protocol.ByteCount
+	minReceiveWindowSize protocol.ByteCount
 	maxReceiveWindowSize protocol.ByteCount
 
-	epochStartTime   time.Time
-	epochStartOffset protocol.ByteCount
-	rttStats         *utils.RTTStats
+	// budget is the memory budget shared by all connections. It may be nil.
+	// Under memory pressure, the receive window is reduced instead of being increased.
+	budget *MemoryBudget
+	// reservesMemory is set if increases of the receive window are reserved from the budget.
+	reservesMemory bool
+
+	// tuneInitialWindow is set if the first auto-tuning step should size the receive window
+	// according to the bandwidth-delay product.
+	tuneInitialWindow bool
+	epochStartTime    time.Time
+	epochStartOffset  protocol.ByteCount
+	rttStats          *utils.RTTStats
 
 	logger utils.Logger
 }
@@ -86,13 +96,28 @@ func (c *baseFlowController) getWindowUpdate() protocol.ByteCount {
 	}
 
 	c.maybeAdjustWindowSize()
-	c.receiveWindow = c.bytesRead + c.receiveWindowSize
+	// The window size might have been reduced, but the advertised offset must never decrease.
+	offset := c.bytesRead + c.receiveWindowSize
+	if offset <= c.receiveWindow {
+		return 0
+	}
+	c.receiveWindow = offset
 	return c.receiveWindow
 }
 
 // maybeAdjustWindowSize increases the receiveWindowSize if we're sending updates too often.
 // For details about auto-tuning, see https://docs.google.com/document/d/1SExkMmGiz8VYzV3s9E35JQlJ73vhzCekKkDi85F1qCE/edit?usp=sharing.
+// When the memory budget is nearly exhausted, the receiveWindowSize is reduced instead.
 func (c *baseFlowController) maybeAdjustWindowSize() {
+	now := time.Now()
+	if c.budget != nil && c.budget.UnderPressure() {
+		if c.receiveWindowSize > c.minReceiveWindowSize {
+			c.setReceiveWindowSize(utils.MaxByteCount(c.receiveWindowSize/2, c.minReceiveWindowSize))
+		}
+		c.startNewAutoTuningEpoch(now)
+		return
+	}
+
 	bytesReadInEpoch := c.bytesRead - c.epochStartOffset
 	// don't do anything if less than half the window has been consumed
 	if bytesReadInEpoch <= c.receiveWindowSize/2 {
@@ -103,15 +128,41 @@ func (c *baseFlowController) maybeAdjustWindowSize() {
 		return
 	}
 
+	if c.tuneInitialWindow {
+		c.tuneInitialWindow = false
+		// Estimate the bandwidth from the rate at which data was read,
+		// and use twice the bandwidth-delay product as the window size.
+		if epoch := now.Sub(c.epochStartTime); epoch > 0 {
+			bdp := protocol.ByteCount(float64(bytesReadInEpoch) * float64(rtt) / float64(epoch))
+			if 2*bdp > c.receiveWindowSize {
+				c.setReceiveWindowSize(utils.MinByteCount(2*bdp, c.maxReceiveWindowSize))
+				c.startNewAutoTuningEpoch(now)
+				return
+			}
+		}
+	}
+
 	fraction := float64(bytesReadInEpoch) / float64(c.receiveWindowSize)
-	now := time.Now()
 	if now.Sub(c.epochStartTime) < time.Duration(4*fraction*float64(rtt)) {
 		// window is consumed too fast, try to increase the window size
-		c.receiveWindowSize = utils.MinByteCount(2*c.receiveWindowSize, c.maxReceiveWindowSize)
+		c.setReceiveWindowSize(utils.MinByteCount(2*c.receiveWindowSize, c.maxReceiveWindowSize))
 	}
 	c.startNewAutoTuningEpoch(now)
 }
 
+// setReceiveWindowSize sets the receiveWindowSize.
+// If memory is reserved from the budget, an increase of the window size is limited by the budget.
+func (c *baseFlowController) setReceiveWindowSize(size protocol.ByteCount) {
+	if c.budget != nil && c.reservesMemory {
+		if size > c.receiveWindowSize {
+			size = c.receiveWindowSize + c.budget.reserve(size-c.receiveWindowSize)
+		} else {
+			c.budget.release(c.receiveWindowSize - size)
+		}
+	}
+	c.receiveWindowSize = size
+}
+
 func (c *baseFlowController) startNewAutoTuningEpoch(now time.Time) {
 	c.epochStartTime = now
 	c.epochStartOffset = c.bytesRead
@@ -232,6 +232,84 @@ var _ = Describe("Base Flow controller", func() {
 				controller.maybeAdjustWindowSize()
 				Expect(controller.receiveWindowSize).To(Equal(controller.maxReceiveWindowSize)) // 5000
 			})
+
+			It("sizes the window according to the bandwidth-delay product when it is adjusted the first time", func() {
+				controller.tuneInitialWindow = true
+				rtt := scaleDuration(20 * time.Millisecond)
+				setRtt(rtt)
+				// read 600 bytes in half an RTT, i.e. the bandwidth-delay product is 1200 bytes
+				controller.epochStartOffset = controller.bytesRead
+				controller.epochStartTime = time.Now().Add(-rtt / 2)
+				controller.AddBytesRead(600)
+				controller.maybeAdjustWindowSize()
+				Expect(controller.receiveWindowSize).To(And(
+					BeNumerically(">", oldWindowSize),
+					BeNumerically("<=", 2*1200),
+				))
+				Expect(controller.tuneInitialWindow).To(BeFalse())
+			})
+
+			It("doesn't decrease the window size when the bandwidth-delay product is small", func() {
+				controller.tuneInitialWindow = true
+				rtt := scaleDuration(20 * time.Millisecond)
+				setRtt(rtt)
+				// read 600 bytes in 5 RTTs
+				controller.epochStartOffset = controller.bytesRead
+				controller.epochStartTime = time.Now().Add(-5 * rtt)
+				controller.AddBytesRead(600)
+				controller.maybeAdjustWindowSize()
+				Expect(controller.receiveWindowSize).To(Equal(oldWindowSize))
+				Expect(controller.tuneInitialWindow).To(BeFalse())
+			})
+
+			Context("using a memory budget", func() {
+				It("limits window size increases to the memory budget", func() {
+					controller.budget = NewMemoryBudget(1800)
+					controller.budget.forceReserve(oldWindowSize)
+					controller.reservesMemory = true
+					setRtt(scaleDuration(20 * time.Millisecond))
+					controller.epochStartTime = time.Now().Add(-time.Millisecond)
+					controller.epochStartOffset = controller.bytesRead
+					controller.AddBytesRead(oldWindowSize/2 + 1)
+					controller.maybeAdjustWindowSize()
+					Expect(controller.receiveWindowSize).To(Equal(protocol.ByteCount(1800)))
+					Expect(controller.budget.Used()).To(Equal(protocol.ByteCount(1800)))
+				})
+
+				It("reduces the window size under memory pressure", func() {
+					controller.budget = NewMemoryBudget(1000)
+					controller.budget.forceReserve(900)
+					controller.minReceiveWindowSize = 300
+					setRtt(scaleDuration(20 * time.Millisecond))
+					controller.maybeAdjustWindowSize()
+					Expect(controller.receiveWindowSize).To(Equal(oldWindowSize / 2))
+					controller.maybeAdjustWindowSize()
+					Expect(controller.receiveWindowSize).To(Equal(protocol.ByteCount(300)))
+					controller.maybeAdjustWindowSize()
+					Expect(controller.receiveWindowSize).To(Equal(protocol.ByteCount(300)))
+				})
+
+				It("releases memory when reducing the window size", func() {
+					controller.budget = NewMemoryBudget(1000)
+					controller.budget.forceReserve(900)
+					controller.reservesMemory = true
+					controller.maybeAdjustWindowSize()
+					Expect(controller.receiveWindowSize).To(Equal(oldWindowSize / 2))
+					Expect(controller.budget.Used()).To(Equal(900 - oldWindowSize/2))
+				})
+
+				It("never decreases the offset that was already advertised", func() {
+					controller.budget = NewMemoryBudget(1000)
+					controller.budget.forceReserve(900)
+					oldReceiveWindow := controller.receiveWindow
+					// consume a bit more than the window update threshold
+					controller.AddBytesRead(protocol.ByteCount(float64(oldWindowSize)*protocol.WindowUpdateThreshold) + 1)
+					Expect(controller.hasWindowUpdate()).To(BeTrue())
+					Expect(controller.getWindowUpdate()).To(BeZero())
+					Expect(controller.receiveWindowSize).To(Equal(oldWindowSize / 2))
+					Expect(controller.receiveWindow).To(Equal(oldReceiveWindow))
+				})
+			})
 		})
 	})
 })
@@ -7,34 +7,48 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/qerr"
 	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/logging"
 )
 
 type connectionFlowController struct {
 	baseFlowController
 
 	queueWindowUpdate func()
+
+	tracer logging.ConnectionTracer
 }
 
 var _ ConnectionFlowController = &connectionFlowController{}
 
 // NewConnectionFlowController gets a new flow controller for the connection
 // It is created before we receive the peer's transport paramenters, thus it starts with a sendWindow of 0.
+// If a memory budget is used, the receive window is reserved from the budget.
 func NewConnectionFlowController(
 	receiveWindow protocol.ByteCount,
 	maxReceiveWindow protocol.ByteCount,
 	queueWindowUpdate func(),
+	budget *MemoryBudget,
 	rttStats *utils.RTTStats,
+	tracer logging.ConnectionTracer,
 	logger utils.Logger,
 ) ConnectionFlowController {
+	if budget != nil {
+		budget.forceReserve(receiveWindow)
+	}
 	return &connectionFlowController{
 		baseFlowController: baseFlowController{
 			rttStats:             rttStats,
 			receiveWindow:        receiveWindow,
 			receiveWindowSize:    receiveWindow,
+			minReceiveWindowSize: receiveWindow,
 			maxReceiveWindowSize: maxReceiveWindow,
+			budget:               budget,
+			reservesMemory:       true,
+			tuneInitialWindow:    true,
 			logger:               logger,
 		},
 		queueWindowUpdate: queueWindowUpdate,
+		tracer:            tracer,
 	}
 }
 
@@ -42,6 +56,13 @@ func (c *connectionFlowController) SendWindowSize() protocol.ByteCount {
 	return c.baseFlowController.sendWindowSize()
 }
 
+func (c *connectionFlowController) ReceiveWindowSize() protocol.ByteCount {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.receiveWindowSize
+}
+
 // IncrementHighestReceived adds an increment to the highestReceived value
 func (c *connectionFlowController) IncrementHighestReceived(increment protocol.ByteCount) error {
 	c.mutex.Lock()
@@ -74,6 +95,13 @@ func (c *connectionFlowController) GetWindowUpdate() protocol.ByteCount {
 	offset := c.baseFlowController.getWindowUpdate()
 	if oldWindowSize < c.receiveWindowSize {
 		c.logger.Debugf("Increasing receive flow control window for the connection to %d kB", c.receiveWindowSize/(1<<10))
+	} else if oldWindowSize > c.receiveWindowSize {
+		c.logger.Debugf("Decreasing receive flow control window for the connection to %d kB, due to memory pressure", c.receiveWindowSize/(1<<10))
+	}
+	if oldWindowSize != c.receiveWindowSize {
+		if t, ok := c.tracer.(logging.ReceiveWindowTracer); ok {
+			t.UpdatedReceiveWindow(c.receiveWindowSize)
+		}
 	}
 	c.mutex.Unlock()
 	return offset
@@ -83,10 +111,26 @@ func (c *connectionFlowController) GetWindowUpdate() protocol.ByteCount {
 // it should make sure that the connection-level window is increased when a stream-level window grows
 func (c *connectionFlowController) EnsureMinimumWindowSize(inc protocol.ByteCount) {
 	c.mutex.Lock()
-	if inc > c.receiveWindowSize {
-		c.logger.Debugf("Increasing receive flow control window for the connection to %d kB, in response to stream flow control window increase", c.receiveWindowSize/(1<<10))
-		c.receiveWindowSize = utils.MinByteCount(inc, c.maxReceiveWindowSize)
-		c.startNewAutoTuningEpoch(time.Now())
+	if oldWindowSize := c.receiveWindowSize; inc > oldWindowSize {
+		c.setReceiveWindowSize(utils.MinByteCount(inc, c.maxReceiveWindowSize))
+		// the increase might have been prevented by the memory budget
+		if c.receiveWindowSize > oldWindowSize {
+			c.logger.Debugf("Increasing receive flow control window for the connection to %d kB, in response to stream flow control window increase", c.receiveWindowSize/(1<<10))
+			c.startNewAutoTuningEpoch(time.Now())
+			if t, ok := c.tracer.(logging.ReceiveWindowTracer); ok {
+				t.UpdatedReceiveWindow(c.receiveWindowSize)
+			}
+		}
+	}
+	c.mutex.Unlock()
+}
+
+// Close releases the memory reserved from the memory budget.
+func (c *connectionFlowController) Close() {
+	c.mutex.Lock()
+	if c.budget != nil {
+		c.budget.release(c.receiveWindowSize)
+		c.budget = nil
 	}
 	c.mutex.Unlock()
 }
@@ -5,10 +5,31 @@ import (
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/logging"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
 
+// receiveWindowTracer records the receive window updates.
+// All other methods of the logging.ConnectionTracer are not implemented.
+type receiveWindowTracer struct {
+	logging.ConnectionTracer
+
+	windows       []protocol.ByteCount
+	streamWindows map[protocol.StreamID][]protocol.ByteCount
+}
+
+func (t *receiveWindowTracer) UpdatedReceiveWindow(size protocol.ByteCount) {
+	t.windows = append(t.windows, size)
+}
+
+func (t *receiveWindowTracer) UpdatedStreamReceiveWindow(id protocol.StreamID, size protocol.ByteCount) {
+	if t.streamWindows == nil {
+		t.streamWindows = make(map[protocol.StreamID][]protocol.ByteCount)
+	}
+	t.streamWindows[id] = append(t.streamWindows[id], size)
+}
+
 var _ = Describe("Connection Flow controller", func() {
 	var (
 		controller         *connectionFlowController
@@ -36,7 +57,7 @@ var _ = Describe("Connection Flow controller", func() {
 			receiveWindow := protocol.ByteCount(2000)
 			maxReceiveWindow := protocol.ByteCount(3000)
 
-			fc := NewConnectionFlowController(receiveWindow, maxReceiveWindow, nil, rttStats, utils.DefaultLogger).(*connectionFlowController)
+			fc := NewConnectionFlowController(receiveWindow, maxReceiveWindow, nil, nil, rttStats, nil, utils.DefaultLogger).(*connectionFlowController)
 			Expect(fc.receiveWindow).To(Equal(receiveWindow))
 			Expect(fc.maxReceiveWindowSize).To(Equal(maxReceiveWindow))
 		})
@@ -90,6 +111,7 @@ var _ = Describe("Connection Flow controller", func() {
 				newWindowSize := controller.receiveWindowSize
 				Expect(newWindowSize).To(Equal(2 * oldWindowSize))
 				Expect(offset).To(Equal(oldOffset + dataRead + newWindowSize))
+				Expect(controller.ReceiveWindowSize()).To(Equal(newWindowSize))
 			})
 		})
 	})
@@ -129,4 +151,54 @@ var _ = Describe("Connection Flow controller", func() {
 			Expect(controller.epochStartTime).To(BeTemporally("~", time.Now(), 100*time.Millisecond))
 		})
 	})
+
+	Context("using a memory budget", func() {
+		It("reserves the receive window, and releases it when closed", func() {
+			budget := NewMemoryBudget(1 << 20)
+			fc := NewConnectionFlowController(1000, 5000, nil, budget, &utils.RTTStats{}, nil, utils.DefaultLogger).(*connectionFlowController)
+			Expect(budget.Used()).To(Equal(protocol.ByteCount(1000)))
+			fc.EnsureMinimumWindowSize(3000)
+			Expect(fc.ReceiveWindowSize()).To(Equal(protocol.ByteCount(3000)))
+			Expect(budget.Used()).To(Equal(protocol.ByteCount(3000)))
+			fc.Close()
+			Expect(budget.Used()).To(BeZero())
+			// closing multiple times doesn't release the memory again
+			fc.Close()
+			Expect(budget.Used()).To(BeZero())
+		})
+
+		It("limits increases of the window size to the budget", func() {
+			budget := NewMemoryBudget(2000)
+			fc := NewConnectionFlowController(1000, 5000, nil, budget, &utils.RTTStats{}, nil, utils.DefaultLogger).(*connectionFlowController)
+			fc.EnsureMinimumWindowSize(3000)
+			Expect(fc.ReceiveWindowSize()).To(Equal(protocol.ByteCount(2000)))
+			Expect(budget.Used()).To(Equal(protocol.ByteCount(2000)))
+			// the budget is exhausted
+			fc.EnsureMinimumWindowSize(4000)
+			Expect(fc.ReceiveWindowSize()).To(Equal(protocol.ByteCount(2000)))
+		})
+	})
+
+	Context("tracing", func() {
+		It("traces window size increases", func() {
+			tracer := &receiveWindowTracer{}
+			fc := NewConnectionFlowController(1000, 5000, nil, nil, &utils.RTTStats{}, tracer, utils.DefaultLogger).(*connectionFlowController)
+			fc.EnsureMinimumWindowSize(500)
+			Expect(tracer.windows).To(BeEmpty())
+			fc.EnsureMinimumWindowSize(3000)
+			Expect(tracer.windows).To(Equal([]protocol.ByteCount{3000}))
+		})
+
+		It("traces window size decreases", func() {
+			tracer := &receiveWindowTracer{}
+			budget := NewMemoryBudget(4000)
+			fc := NewConnectionFlowController(1000, 5000, func() {}, budget, &utils.RTTStats{}, tracer, utils.DefaultLogger).(*connectionFlowController)
+			fc.EnsureMinimumWindowSize(2000)
+			Expect(tracer.windows).To(Equal([]protocol.ByteCount{2000}))
+			budget.forceReserve(1500) // another connection uses the budget
+			fc.AddBytesRead(900)
+			Expect(fc.GetWindowUpdate()).To(Equal(protocol.ByteCount(900 + 1000)))
+			Expect(tracer.windows).To(Equal([]protocol.ByteCount{2000, 1000}))
+		})
+	})
 })
@@ -29,6 +29,12 @@ type StreamFlowController interface {
 // The ConnectionFlowController is the flow controller for the connection.
 type ConnectionFlowController interface {
 	flowController
+	// ReceiveWindowSize returns the current size of the receive window.
+	// It is increased by the window auto-tuning.
+	ReceiveWindowSize() protocol.ByteCount
+	// Close releases the memory reserved from the memory budget.
+	// It must be called when the connection is closed.
+	Close()
 }
 
 type connectionFlowControllerI interface {
@@ -0,0 +1,77 @@
+package flowcontrol
+
+import (
+	"sync"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+// memoryPressureThreshold is the fraction of the memory budget above which
+// receive windows are reduced instead of being increased.
+const memoryPressureThreshold = 0.75
+
+// A MemoryBudget limits the memory that can be committed to receive windows.
+// It can be shared between multiple connections.
+// Every connection reserves its connection-level receive window from the budget,
+// since this window limits the amount of data buffered for the connection.
+type MemoryBudget struct {
+	mutex sync.Mutex
+
+	limit protocol.ByteCount
+	used  protocol.ByteCount
+}
+
+// NewMemoryBudget creates a new memory budget.
+func NewMemoryBudget(limit protocol.ByteCount) *MemoryBudget {
+	return &MemoryBudget{limit: limit}
+}
+
+// Limit returns the size of the budget.
+func (b *MemoryBudget) Limit() protocol.ByteCount {
+	return b.limit
+}
+
+// Used returns the amount of memory currently reserved.
+func (b *MemoryBudget) Used() protocol.ByteCount {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.used
+}
+
+// UnderPressure says if the budget is nearly exhausted.
+func (b *MemoryBudget) UnderPressure() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return float64(b.used) > memoryPressureThreshold*float64(b.limit)
+}
+
+// reserve reserves up to n bytes.
+// It returns the number of bytes that were actually reserved.
+func (b *MemoryBudget) reserve(n protocol.ByteCount) protocol.ByteCount {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.used >= b.limit {
+		return 0
+	}
+	n = utils.MinByteCount(n, b.limit-b.used)
+	b.used += n
+	return n
+}
+
+// forceReserve reserves n bytes, even if this exceeds the budget.
+// It is used for the initial receive window, which is advertised in the transport parameters.
+func (b *MemoryBudget) forceReserve(n protocol.ByteCount) {
+	b.mutex.Lock()
+	b.used += n
+	b.mutex.Unlock()
+}
+
+func (b *MemoryBudget) release(n protocol.ByteCount) {
+	b.mutex.Lock()
+	b.used -= n
+	b.mutex.Unlock()
+}
@@ -0,0 +1,39 @@
+package flowcontrol
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Memory Budget", func() {
+	It("reserves memory up to the limit", func() {
+		b := NewMemoryBudget(1000)
+		Expect(b.Limit()).To(Equal(protocol.ByteCount(1000)))
+		Expect(b.reserve(600)).To(Equal(protocol.ByteCount(600)))
+		Expect(b.reserve(600)).To(Equal(protocol.ByteCount(400)))
+		Expect(b.reserve(1)).To(BeZero())
+		Expect(b.Used()).To(Equal(protocol.ByteCount(1000)))
+		b.release(500)
+		Expect(b.Used()).To(Equal(protocol.ByteCount(500)))
+		Expect(b.reserve(100)).To(Equal(protocol.ByteCount(100)))
+	})
+
+	It("force reserves memory", func() {
+		b := NewMemoryBudget(1000)
+		b.forceReserve(1500)
+		Expect(b.Used()).To(Equal(protocol.ByteCount(1500)))
+		Expect(b.reserve(100)).To(BeZero())
+	})
+
+	It("says when it is under pressure", func() {
+		b := NewMemoryBudget(1000)
+		b.forceReserve(750)
+		Expect(b.UnderPressure()).To(BeFalse())
+		b.forceReserve(1)
+		Expect(b.UnderPressure()).To(BeTrue())
+		b.release(1)
+		Expect(b.UnderPressure()).To(BeFalse())
+	})
+})
@@ -6,6 +6,7 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/qerr"
 	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/logging"
 )
 
 type streamFlowController struct {
@@ -18,11 +19,15 @@ type streamFlowController struct {
 	connection connectionFlowControllerI
 
 	receivedFinalOffset bool
+
+	tracer logging.ConnectionTracer
 }
 
 var _ StreamFlowController = &streamFlowController{}
 
 // NewStreamFlowController gets a new flow controller for a stream
+// The stream's receive window isn't reserved from the memory budget (the connection's window is),
+// but it is reduced under memory pressure.
 func NewStreamFlowController(
 	streamID protocol.StreamID,
 	cfc ConnectionFlowController,
@@ -30,7 +35,9 @@ func NewStreamFlowController(
 	maxReceiveWindow protocol.ByteCount,
 	initialSendWindow protocol.ByteCount,
 	queueWindowUpdate func(protocol.StreamID),
+	budget *MemoryBudget,
 	rttStats *utils.RTTStats,
+	tracer logging.ConnectionTracer,
 	logger utils.Logger,
 ) StreamFlowController {
 	return &streamFlowController{
@@ -41,10 +48,14 @@ func NewStreamFlowController(
 			rttStats:             rttStats,
 			receiveWindow:        receiveWindow,
 			receiveWindowSize:    receiveWindow,
+			minReceiveWindowSize: receiveWindow,
 			maxReceiveWindowSize: maxReceiveWindow,
+			budget:               budget,
+			tuneInitialWindow:    true,
 			sendWindow:           initialSendWindow,
 			logger:               logger,
 		},
+		tracer: tracer,
 	}
 }
 
@@ -132,6 +143,13 @@ func (c *streamFlowController) GetWindowUpdate() protocol.ByteCount {
 	if c.receiveWindowSize > oldWindowSize { // auto-tuning enlarged the window size
 		c.logger.Debugf("Increasing receive flow control window for stream %d to %d kB", c.streamID, c.receiveWindowSize/(1<<10))
 		c.connection.EnsureMinimumWindowSize(protocol.ByteCount(float64(c.receiveWindowSize) * protocol.ConnectionFlowControlMultiplier))
+	} else if c.receiveWindowSize < oldWindowSize {
+		c.logger.Debugf("Decreasing receive flow control window for stream %d to %d kB, due to memory pressure", c.streamID, c.receiveWindowSize/(1<<10))
+	}
+	if c.receiveWindowSize != oldWindowSize {
+		if t, ok := c.tracer.(logging.ReceiveWindowTracer); ok {
+			t.UpdatedStreamReceiveWindow(c.streamID, c.receiveWindowSize)
+		}
 	}
 	c.mutex.Unlock()
 	return offset
@@ -60,18 +60,27 @@ func (m messageType) String() string {
 
 const clientSessionStateRevision = 3
 
+// ConnWithVersion is the connection used in the tls.ClientHelloInfo.
+// It can be used to determine the QUIC version in use.
+type ConnWithVersion interface {
+	net.Conn
+	GetQUICVersion() protocol.VersionNumber
+}
+
 type conn struct {
 	localAddr, remoteAddr net.Addr
+	version               protocol.VersionNumber
 }
 
-func newConn(local, remote net.Addr) net.Conn {
+func newConn(local, remote net.Addr, version protocol.VersionNumber) ConnWithVersion {
 	return &conn{
 		localAddr:  local,
 		remoteAddr: remote,
+		version:    version,
 	}
 }
 
-var _ net.Conn = &conn{}
+var _ ConnWithVersion = &conn{}
 
 func (c *conn) Read([]byte) (int, error)         { return 0, nil }
 func (c *conn) Write([]byte) (int, error)        { return 0, nil }
@@ -82,6 +91,8 @@ func (c *conn) SetReadDeadline(time.Time) error  { return nil }
 func (c *conn) SetWriteDeadline(time.Time) error { return nil }
 func (c *conn) SetDeadline(time.Time) error      { return nil }
 
+func (c *conn) GetQUICVersion() protocol.VersionNumber { return c.version }
+
 type cryptoSetup struct {
 	tlsConf   *tls.Config
 	extraConf *qtls.ExtraConfig
@@ -92,6 +103,10 @@ type cryptoSetup struct {
 	ourParams  *wire.TransportParameters
 	peerParams *wire.TransportParameters
 	paramsChan <-chan []byte
+	extHandler tlsExtensionHandler
+
+	initialConnID protocol.ConnectionID // the connection ID used to derive the Initial keys
+	version       protocol.VersionNumber
 
 	runner handshakeRunner
 
@@ -134,6 +149,10 @@ type cryptoSetup struct {
 	initialStream io.Writer
 	initialOpener LongHeaderOpener
 	initialSealer LongHeaderSealer
+	// only set for the server, after switching to a compatible version:
+	// the client keeps using the original version until it receives our first Initial
+	origVersion       protocol.VersionNumber
+	origInitialOpener LongHeaderOpener
 
 	handshakeStream io.Writer
 	handshakeOpener LongHeaderOpener
@@ -158,9 +177,11 @@ func NewCryptoSetupClient(
 	runner handshakeRunner,
 	tlsConf *tls.Config,
 	enable0RTT bool,
+	keyUpdatePolicy KeyUpdatePolicy,
 	rttStats *utils.RTTStats,
 	tracer logging.ConnectionTracer,
 	logger utils.Logger,
+	version protocol.VersionNumber,
 ) (CryptoSetup, <-chan *wire.TransportParameters /* ClientHello written. Receive nil for non-0-RTT */) {
 	cs, clientHelloWritten := newCryptoSetup(
 		initialStream,
@@ -170,12 +191,14 @@ func NewCryptoSetupClient(
 		runner,
 		tlsConf,
 		enable0RTT,
+		keyUpdatePolicy,
 		rttStats,
 		tracer,
 		logger,
 		protocol.PerspectiveClient,
+		version,
 	)
-	cs.conn = qtls.Client(newConn(localAddr, remoteAddr), cs.tlsConf, cs.extraConf)
+	cs.conn = qtls.Client(newConn(localAddr, remoteAddr, version), cs.tlsConf, cs.extraConf)
 	return cs, clientHelloWritten
 }
 
@@ -190,9 +213,11 @@ func NewCryptoSetupServer(
 	runner handshakeRunner,
 	tlsConf *tls.Config,
 	enable0RTT bool,
+	keyUpdatePolicy KeyUpdatePolicy,
 	rttStats *utils.RTTStats,
 	tracer logging.ConnectionTracer,
 	logger utils.Logger,
+	version protocol.VersionNumber,
 ) CryptoSetup {
 	cs, _ := newCryptoSetup(
 		initialStream,
@@ -202,12 +227,14 @@ func NewCryptoSetupServer(
 		runner,
 		tlsConf,
 		enable0RTT,
+		keyUpdatePolicy,
 		rttStats,
 		tracer,
 		logger,
 		protocol.PerspectiveServer,
+		version,
 	)
-	cs.conn = qtls.Server(newConn(localAddr, remoteAddr), cs.tlsConf, cs.extraConf)
+	cs.conn = qtls.Server(newConn(localAddr, remoteAddr, version), cs.tlsConf, cs.extraConf)
 	return cs
 }
 
@@ -219,29 +246,40 @@ func newCryptoSetup(
 	runner handshakeRunner,
 	tlsConf *tls.Config,
 	enable0RTT bool,
+	keyUpdatePolicy KeyUpdatePolicy,
 	rttStats *utils.RTTStats,
 	tracer logging.ConnectionTracer,
 	logger utils.Logger,
 	perspective protocol.Perspective,
+	version protocol.VersionNumber,
 ) (*cryptoSetup, <-chan *wire.TransportParameters /* ClientHello written. Receive nil for non-0-RTT */) {
-	initialSealer, initialOpener := NewInitialAEAD(connID, perspective)
+	initialSealer, initialOpener := NewInitialAEAD(connID, perspective, version)
 	if tracer != nil {
 		tracer.UpdatedKeyFromTLS(protocol.EncryptionInitial, protocol.PerspectiveClient)
 		tracer.UpdatedKeyFromTLS(protocol.EncryptionInitial, protocol.PerspectiveServer)
 	}
-	extHandler := newExtensionHandler(tp.Marshal(perspective), perspective)
+	var ourParams []byte
+	if perspective == protocol.PerspectiveClient {
+		// The server's transport parameters depend on the negotiated version.
+		// They are marshaled after processing the client's transport parameters.
+		ourParams = tp.Marshal(perspective)
+	}
+	extHandler := newExtensionHandler(ourParams, perspective, version)
 	cs := &cryptoSetup{
 		tlsConf:                tlsConf,
 		initialStream:          initialStream,
 		initialSealer:          initialSealer,
 		initialOpener:          initialOpener,
 		handshakeStream:        handshakeStream,
-		aead:                   newUpdatableAEAD(rttStats, tracer, logger),
+		aead:                   newUpdatableAEAD(rttStats, keyUpdatePolicy, tracer, logger),
 		readEncLevel:           protocol.EncryptionInitial,
 		writeEncLevel:          protocol.EncryptionInitial,
 		runner:                 runner,
 		ourParams:              tp,
 		paramsChan:             extHandler.TransportParameters(),
+		extHandler:             extHandler,
+		initialConnID:          connID,
+		version:                version,
 		rttStats:               rttStats,
 		tracer:                 tracer,
 		logger:                 logger,
@@ -275,7 +313,8 @@ func newCryptoSetup(
 }
 
 func (h *cryptoSetup) ChangeConnectionID(id protocol.ConnectionID) {
-	initialSealer, initialOpener := NewInitialAEAD(id, h.perspective)
+	h.initialConnID = id
+	initialSealer, initialOpener := NewInitialAEAD(id, h.perspective, h.version)
 	h.initialSealer = initialSealer
 	h.initialOpener = initialOpener
 	if h.tracer != nil {
@@ -284,10 +323,38 @@ func (h *cryptoSetup) ChangeConnectionID(id protocol.ConnectionID) {
 	}
 }
 
+// SetVersion switches to a compatible version (see protocol.AreCompatibleVersions).
+// It derives new Initial keys, and for the server, it updates the chosen version
+// sent in the version_information transport parameter.
+// The server keeps the Initial opener of the original version, see GetInitialOpener.
+func (h *cryptoSetup) SetVersion(v protocol.VersionNumber) {
+	h.mutex.Lock()
+	if h.perspective == protocol.PerspectiveServer && h.origInitialOpener == nil && v != h.version {
+		h.origVersion = h.version
+		h.origInitialOpener = h.initialOpener
+	}
+	h.version = v
+	h.initialSealer, h.initialOpener = NewInitialAEAD(h.initialConnID, h.perspective, v)
+	h.mutex.Unlock()
+	if h.perspective == protocol.PerspectiveServer && h.ourParams.VersionInformation != nil {
+		h.ourParams.VersionInformation.ChosenVersion = v
+	}
+	if h.tracer != nil {
+		h.tracer.UpdatedKeyFromTLS(protocol.EncryptionInitial, protocol.PerspectiveClient)
+		h.tracer.UpdatedKeyFromTLS(protocol.EncryptionInitial, protocol.PerspectiveServer)
+	}
+}
+
 func (h *cryptoSetup) SetLargest1RTTAcked(pn protocol.PacketNumber) {
 	h.aead.SetLargestAcked(pn)
 }
 
+// InitiateKeyUpdate requests a key update of the 1-RTT keys.
+// It is safe to call it concurrently.
+func (h *cryptoSetup) InitiateKeyUpdate() {
+	h.aead.InitiateKeyUpdate()
+}
+
 func (h *cryptoSetup) RunHandshake() {
 	// Handle errors that might occur when HandleData() is called.
 	handshakeComplete := make(chan struct{})
@@ -394,6 +461,7 @@ func (h *cryptoSetup) handleMessageForServer(msgType messageType) bool {
 			return false
 		case data := <-h.paramsChan:
 			h.handleTransportParameters(data)
+			h.extHandler.SetTransportParameters(h.ourParams.Marshal(h.perspective))
 		case <-h.handshakeDone:
 			return false
 		}
@@ -740,6 +808,7 @@ func (h *cryptoSetup) dropInitialKeys() {
 	h.mutex.Lock()
 	h.initialOpener = nil
 	h.initialSealer = nil
+	h.origInitialOpener = nil
 	h.mutex.Unlock()
 	h.runner.DropKeys(protocol.EncryptionInitial)
 	h.logger.Debugf("Dropping Initial keys.")
@@ -803,13 +872,21 @@ func (h *cryptoSetup) Get1RTTSealer() (ShortHeaderSealer, error) {
 	return h.aead, nil
 }
 
-func (h *cryptoSetup) GetInitialOpener() (LongHeaderOpener, error) {
+// GetInitialOpener returns the opener for Initial packets of a QUIC version.
+// After switching to a compatible version, the server can still open Initial packets of the original version.
+func (h *cryptoSetup) GetInitialOpener(v protocol.VersionNumber) (LongHeaderOpener, error) {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
 
 	if h.initialOpener == nil {
 		return nil, ErrKeysDropped
 	}
+	if v != h.version {
+		if h.origInitialOpener == nil || v != h.origVersion {
+			return nil, fmt.Errorf("no Initial keys for %s", v)
+		}
+		return h.origInitialOpener, nil
+	}
 	return h.initialOpener, nil
 }
 
@@ -88,9 +88,11 @@ var _ = Describe("Crypto Setup TLS", func() {
 			runner,
 			testdata.GetTLSConfig(),
 			false,
+			KeyUpdatePolicy{},
 			&utils.RTTStats{},
 			nil,
 			utils.DefaultLogger.WithPrefix("server"),
+			protocol.VersionTLS,
 		)
 
 		done := make(chan struct{})
@@ -112,6 +114,41 @@ var _ = Describe("Crypto Setup TLS", func() {
 		Eventually(done).Should(BeClosed())
 	})
 
+	It("keeps the Initial opener of the original version when switching to a compatible version", func() {
+		_, sInitialStream, sHandshakeStream := initStreams()
+		connID := protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef}
+		var token protocol.StatelessResetToken
+		server := NewCryptoSetupServer(
+			sInitialStream,
+			sHandshakeStream,
+			connID,
+			nil,
+			nil,
+			&wire.TransportParameters{StatelessResetToken: &token},
+			NewMockHandshakeRunner(mockCtrl),
+			testdata.GetTLSConfig(),
+			false,
+			KeyUpdatePolicy{},
+			&utils.RTTStats{},
+			nil,
+			utils.DefaultLogger.WithPrefix("server"),
+			protocol.VersionDraft29,
+		)
+		server.SetVersion(protocol.Version1)
+
+		for _, v := range []protocol.VersionNumber{protocol.VersionDraft29, protocol.Version1} {
+			sealer, _ := NewInitialAEAD(connID, protocol.PerspectiveClient, v)
+			opener, err := server.GetInitialOpener(v)
+			Expect(err).ToNot(HaveOccurred())
+			sealed := sealer.Seal(nil, []byte("foobar"), 42, []byte("ad"))
+			opened, err := opener.Open(nil, sealed, 42, []byte("ad"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(opened).To(Equal([]byte("foobar")))
+		}
+		_, err := server.GetInitialOpener(protocol.VersionNumber(0x1234))
+		Expect(err).To(HaveOccurred())
+	})
+
 	It("errors when a message is received at the wrong encryption level", func() {
 		sErrChan := make(chan error, 1)
 		_, sInitialStream, sHandshakeStream := initStreams()
@@ -128,9 +165,11 @@ var _ = Describe("Crypto Setup TLS", func() {
 			runner,
 			testdata.GetTLSConfig(),
 			false,
+			KeyUpdatePolicy{},
 			&utils.RTTStats{},
 			nil,
 			utils.DefaultLogger.WithPrefix("server"),
+			protocol.VersionTLS,
 		)
 
 		done := make(chan struct{})
@@ -171,9 +210,11 @@ var _ = Describe("Crypto Setup TLS", func() {
 			runner,
 			serverConf,
 			false,
+			KeyUpdatePolicy{},
 			&utils.RTTStats{},
 			nil,
 			utils.DefaultLogger.WithPrefix("server"),
+			protocol.VersionTLS,
 		)
 
 		done := make(chan struct{})
@@ -207,9 +248,11 @@ var _ = Describe("Crypto Setup TLS", func() {
 			NewMockHandshakeRunner(mockCtrl),
 			serverConf,
 			false,
+			KeyUpdatePolicy{},
 			&utils.RTTStats{},
 			nil,
 			utils.DefaultLogger.WithPrefix("server"),
+			protocol.VersionTLS,
 		)
 
 		done := make(chan struct{})
@@ -305,9 +348,11 @@ var _ = Describe("Crypto Setup TLS", func() {
 				cRunner,
 				clientConf,
 				enable0RTT,
+				KeyUpdatePolicy{},
 				clientRTTStats,
 				nil,
 				utils.DefaultLogger.WithPrefix("client"),
+				protocol.VersionTLS,
 			)
 
 			var sHandshakeComplete bool
@@ -331,9 +376,11 @@ var _ = Describe("Crypto Setup TLS", func() {
 				sRunner,
 				serverConf,
 				enable0RTT,
+				KeyUpdatePolicy{},
 				serverRTTStats,
 				nil,
 				utils.DefaultLogger.WithPrefix("server"),
+				protocol.VersionTLS,
 			)
 
 			handshake(client, cChunkChan, server, sChunkChan)
@@ -400,9 +447,11 @@ var _ = Describe("Crypto Setup TLS", func() {
 				runner,
 				&tls.Config{InsecureSkipVerify: true},
 				false,
+				KeyUpdatePolicy{},
 				&utils.RTTStats{},
 				nil,
 				utils.DefaultLogger.WithPrefix("client"),
+				protocol.VersionTLS,
 			)
 
 			done := make(chan struct{})
@@ -442,9 +491,11 @@ var _ = Describe("Crypto Setup TLS", func() {
 				cRunner,
 				clientConf,
 				false,
+				KeyUpdatePolicy{},
 				&utils.RTTStats{},
 				nil,
 				utils.DefaultLogger.WithPrefix("client"),
+				protocol.VersionTLS,
 			)
 
 			sChunkChan, sInitialStream, sHandshakeStream := initStreams()
@@ -466,9 +517,11 @@ var _ = Describe("Crypto Setup TLS", func() {
 				sRunner,
 				serverConf,
 				false,
+				KeyUpdatePolicy{},
 				&utils.RTTStats{},
 				nil,
 				utils.DefaultLogger.WithPrefix("server"),
+				protocol.VersionTLS,
 			)
 
 			done := make(chan struct{})
@@ -499,9 +552,11 @@ var _ = Describe("Crypto Setup TLS", func() {
 					cRunner,
 					clientConf,
 					false,
+					KeyUpdatePolicy{},
 					&utils.RTTStats{},
 					nil,
 					utils.DefaultLogger.WithPrefix("client"),
+					protocol.VersionTLS,
 				)
 
 				sChunkChan, sInitialStream, sHandshakeStream := initStreams()
@@ -519,9 +574,11 @@ var _ = Describe("Crypto Setup TLS", func() {
 					sRunner,
 					serverConf,
 					false,
+					KeyUpdatePolicy{},
 					&utils.RTTStats{},
 					nil,
 					utils.DefaultLogger.WithPrefix("server"),
+					protocol.VersionTLS,
 				)
 
 				done := make(chan struct{})
@@ -559,9 +616,11 @@ var _ = Describe("Crypto Setup TLS", func() {
 					cRunner,
 					clientConf,
 					false,
+					KeyUpdatePolicy{},
 					&utils.RTTStats{},
 					nil,
 					utils.DefaultLogger.WithPrefix("client"),
+					protocol.VersionTLS,
 				)
 
 				sChunkChan, sInitialStream, sHandshakeStream := initStreams()
@@ -579,9 +638,11 @@ var _ = Describe("Crypto Setup TLS", func() {
 					sRunner,
 					serverConf,
 					false,
+					KeyUpdatePolicy{},
 					&utils.RTTStats{},
 					nil,
 					utils.DefaultLogger.WithPrefix("server"),
+					protocol.VersionTLS,
 				)
 
 				done := make(chan struct{})
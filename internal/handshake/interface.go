@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/qerr"
 	"github.com/lucas-clemente/quic-go/internal/qtls"
 	"github.com/lucas-clemente/quic-go/internal/wire"
 )
@@ -20,6 +21,8 @@ var (
 	ErrKeysDropped = errors.New("CryptoSetup: keys were already dropped")
 	// ErrDecryptionFailed is returned when the AEAD fails to open the packet.
 	ErrDecryptionFailed = errors.New("decryption failed")
+	// ErrAEADLimitReached is returned when more packets failed authentication than permitted by the integrity limit of the AEAD.
+	ErrAEADLimitReached = qerr.NewError(qerr.AEADLimitReached, "integrity limit exceeded")
 )
 
 // ConnectionState contains information about the state of the connection.
@@ -59,6 +62,9 @@ type tlsExtensionHandler interface {
 	GetExtensions(msgType uint8) []qtls.Extension
 	ReceivedExtensions(msgType uint8, exts []qtls.Extension)
 	TransportParameters() <-chan []byte
+	// SetTransportParameters sets the server's transport parameters.
+	// They are only sent after the client's transport parameters were processed.
+	SetTransportParameters([]byte)
 }
 
 type handshakeRunner interface {
@@ -73,14 +79,16 @@ type CryptoSetup interface {
 	RunHandshake()
 	io.Closer
 	ChangeConnectionID(protocol.ConnectionID)
+	SetVersion(protocol.VersionNumber)
 	GetSessionTicket() ([]byte, error)
 
 	HandleMessage([]byte, protocol.EncryptionLevel) bool
 	SetLargest1RTTAcked(protocol.PacketNumber)
+	InitiateKeyUpdate()
 	DropHandshakeKeys()
 	ConnectionState() ConnectionState
 
-	GetInitialOpener() (LongHeaderOpener, error)
+	GetInitialOpener(protocol.VersionNumber) (LongHeaderOpener, error)
 	GetHandshakeOpener() (LongHeaderOpener, error)
 	Get0RTTOpener() (LongHeaderOpener, error)
 	Get1RTTOpener() (ShortHeaderOpener, error)
@@ -5,23 +5,35 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/qtls"
 )
 
-const quicTLSExtensionType = 0xffa5
+const (
+	quicTLSExtensionTypeOldDrafts = 0xffa5
+	quicTLSExtensionType          = 0x39
+)
 
 type extensionHandler struct {
-	ourParams  []byte
-	paramsChan chan []byte
+	ourParams     []byte
+	paramsChan    chan []byte
+	ourParamsChan chan []byte // only used by the server
 
-	perspective protocol.Perspective
+	extensionType uint16
+	perspective   protocol.Perspective
 }
 
 var _ tlsExtensionHandler = &extensionHandler{}
 
-// newExtensionHandler creates a new extension handler
-func newExtensionHandler(params []byte, pers protocol.Perspective) tlsExtensionHandler {
+// newExtensionHandler creates a new extension handler.
+// The server's transport parameters are set using SetTransportParameters.
+func newExtensionHandler(params []byte, pers protocol.Perspective, v protocol.VersionNumber) tlsExtensionHandler {
+	et := uint16(quicTLSExtensionType)
+	if v == protocol.VersionDraft29 {
+		et = quicTLSExtensionTypeOldDrafts
+	}
 	return &extensionHandler{
-		ourParams:   params,
-		paramsChan:  make(chan []byte),
-		perspective: pers,
+		ourParams:     params,
+		paramsChan:    make(chan []byte),
+		ourParamsChan: make(chan []byte, 1),
+		extensionType: et,
+		perspective:   pers,
 	}
 }
 
@@ -31,7 +43,7 @@ func (h *extensionHandler) GetExtensions(msgType uint8) []qtls.Extension {
 		return nil
 	}
 	return []qtls.Extension{{
-		Type: quicTLSExtensionType,
+		Type: h.extensionType,
 		Data: h.ourParams,
 	}}
 }
@@ -44,13 +56,26 @@ func (h *extensionHandler) ReceivedExtensions(msgType uint8, exts []qtls.Extensi
 
 	var data []byte
 	for _, ext := range exts {
-		if ext.Type == quicTLSExtensionType {
+		if ext.Type == quicTLSExtensionType || ext.Type == quicTLSExtensionTypeOldDrafts {
+			// The server uses the same code point as the client.
+			// When the version is changed using compatible version negotiation,
+			// the client might have used the code point of the old version.
+			h.extensionType = ext.Type
 			data = ext.Data
 			break
 		}
 	}
 
 	h.paramsChan <- data
+	if h.perspective == protocol.PerspectiveServer {
+		// Wait until the client's transport parameters have been processed.
+		// Our transport parameters depend on the negotiated version.
+		h.ourParams = <-h.ourParamsChan
+	}
+}
+
+func (h *extensionHandler) SetTransportParameters(params []byte) {
+	h.ourParamsChan <- params
 }
 
 func (h *extensionHandler) TransportParameters() <-chan []byte {
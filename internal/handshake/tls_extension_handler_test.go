@@ -16,12 +16,14 @@ var _ = Describe("TLS Extension Handler, for the server", func() {
 
 	BeforeEach(func() {
 		handlerServer = newExtensionHandler(
-			[]byte("foobar"),
+			nil,
 			protocol.PerspectiveServer,
+			protocol.VersionTLS,
 		)
 		handlerClient = newExtensionHandler(
 			[]byte("raboof"),
 			protocol.PerspectiveClient,
+			protocol.VersionTLS,
 		)
 	})
 
@@ -33,12 +35,33 @@ var _ = Describe("TLS Extension Handler, for the server", func() {
 				Expect(handlerServer.GetExtensions(uint8(typeFinished))).To(BeEmpty())
 			})
 
-			It("adds TransportParameters to the EncryptedExtensions message", func() {
+			receiveClientHello := func(exts []qtls.Extension) {
+				done := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					handlerServer.ReceivedExtensions(uint8(typeClientHello), exts)
+					close(done)
+				}()
+				Eventually(handlerServer.TransportParameters()).Should(Receive())
+				Consistently(done).ShouldNot(BeClosed())
+				handlerServer.SetTransportParameters([]byte("foobar"))
+				Eventually(done).Should(BeClosed())
+			}
+
+			It("adds TransportParameters to the EncryptedExtensions message, after processing the client's", func() {
+				receiveClientHello(handlerClient.GetExtensions(uint8(typeClientHello)))
 				exts := handlerServer.GetExtensions(uint8(typeEncryptedExtensions))
 				Expect(exts).To(HaveLen(1))
 				Expect(exts[0].Type).To(BeEquivalentTo(quicTLSExtensionType))
 				Expect(exts[0].Data).To(Equal([]byte("foobar")))
 			})
+
+			It("uses the code point that the client used", func() {
+				receiveClientHello([]qtls.Extension{{Type: quicTLSExtensionTypeOldDrafts, Data: []byte("raboof")}})
+				exts := handlerServer.GetExtensions(uint8(typeEncryptedExtensions))
+				Expect(exts).To(HaveLen(1))
+				Expect(exts[0].Type).To(BeEquivalentTo(quicTLSExtensionTypeOldDrafts))
+			})
 		})
 
 		Context("receiving", func() {
@@ -111,14 +134,20 @@ var _ = Describe("TLS Extension Handler, for the server", func() {
 				Expect(exts[0].Type).To(BeEquivalentTo(quicTLSExtensionType))
 				Expect(exts[0].Data).To(Equal([]byte("raboof")))
 			})
+
+			It("uses the old code point for draft-29", func() {
+				handler := newExtensionHandler([]byte("raboof"), protocol.PerspectiveClient, protocol.VersionDraft29)
+				exts := handler.GetExtensions(uint8(typeClientHello))
+				Expect(exts).To(HaveLen(1))
+				Expect(exts[0].Type).To(BeEquivalentTo(quicTLSExtensionTypeOldDrafts))
+			})
 		})
 
 		Context("receiving", func() {
 			var chExts []qtls.Extension
 
 			BeforeEach(func() {
-				chExts = handlerServer.GetExtensions(uint8(typeEncryptedExtensions))
-				Expect(chExts).To(HaveLen(1))
+				chExts = []qtls.Extension{{Type: quicTLSExtensionType, Data: []byte("foobar")}}
 			})
 
 			It("sends the extension on the channel", func() {
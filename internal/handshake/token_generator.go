@@ -38,9 +38,10 @@ type TokenGenerator struct {
 	tokenProtector tokenProtector
 }
 
-// NewTokenGenerator initializes a new TookenGenerator
-func NewTokenGenerator() (*TokenGenerator, error) {
-	tokenProtector, err := newTokenProtector()
+// NewTokenGenerator initializes a new TookenGenerator.
+// If keys is nil, tokens are protected using a random key.
+func NewTokenGenerator(keys *TokenKeyRing) (*TokenGenerator, error) {
+	tokenProtector, err := newTokenProtector(keys)
 	if err != nil {
 		return nil, err
 	}
@@ -16,7 +16,7 @@ var _ = Describe("Token Generator", func() {
 
 	BeforeEach(func() {
 		var err error
-		tokenGen, err = NewTokenGenerator()
+		tokenGen, err = NewTokenGenerator(nil)
 		Expect(err).ToNot(HaveOccurred())
 	})
 
@@ -0,0 +1,117 @@
+package handshake
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TokenKeySize is the size of the secret of a TokenKey.
+const TokenKeySize = tokenSecretSize
+
+// A TokenKey is a key used to protect address validation tokens.
+type TokenKey struct {
+	// ID identifies the key. It is sent in every token protected with this key.
+	ID uint8
+	// Secret is the secret, TokenKeySize bytes long.
+	Secret []byte
+	// NotBefore is the time from which on the key is used to protect new tokens.
+	// This allows distributing a key to all servers in advance, and rotating to it at the same time.
+	// If zero, the key can be used right away.
+	NotBefore time.Time
+}
+
+// A TokenKeyRing holds the keys used to protect address validation tokens.
+// New tokens are protected using the current key: the key with the latest NotBefore time that is not in the future.
+// If two keys have the same NotBefore time, the key with the larger ID is used.
+// Tokens protected with any key in the ring are accepted.
+// It is safe for concurrent use.
+type TokenKeyRing struct {
+	mutex sync.RWMutex
+	keys  map[uint8]TokenKey
+}
+
+// NewTokenKeyRing creates a new key ring. At least one key is required.
+func NewTokenKeyRing(keys ...TokenKey) (*TokenKeyRing, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("a token key ring needs at least one key")
+	}
+	r := &TokenKeyRing{keys: make(map[uint8]TokenKey, len(keys))}
+	for _, key := range keys {
+		if err := r.AddKey(key); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// AddKey adds a key to the ring.
+// To rotate keys, add a new key and remove the old key once all tokens protected with it have expired.
+func (r *TokenKeyRing) AddKey(key TokenKey) error {
+	if len(key.Secret) != TokenKeySize {
+		return fmt.Errorf("invalid token key size: %d bytes (expected %d)", len(key.Secret), TokenKeySize)
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.keys[key.ID]; ok {
+		return fmt.Errorf("duplicate token key ID: %d", key.ID)
+	}
+	key.Secret = append([]byte(nil), key.Secret...)
+	r.keys[key.ID] = key
+	return nil
+}
+
+// RemoveKey removes a key from the ring.
+// Tokens protected with this key are not accepted any more.
+// The last key can't be removed.
+func (r *TokenKeyRing) RemoveKey(id uint8) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.keys[id]; !ok {
+		return fmt.Errorf("unknown token key ID: %d", id)
+	}
+	if len(r.keys) == 1 {
+		return errors.New("can't remove the last token key")
+	}
+	delete(r.keys, id)
+	return nil
+}
+
+// currentKey returns the key used to protect new tokens.
+// If all keys are scheduled for the future, the key with the earliest NotBefore time is used.
+func (r *TokenKeyRing) currentKey(now time.Time) TokenKey {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var current, earliest TokenKey
+	var haveCurrent, haveEarliest bool
+	for _, key := range r.keys {
+		if !key.NotBefore.After(now) {
+			if !haveCurrent || key.NotBefore.After(current.NotBefore) || (key.NotBefore.Equal(current.NotBefore) && key.ID > current.ID) {
+				current = key
+				haveCurrent = true
+			}
+			continue
+		}
+		if !haveEarliest || key.NotBefore.Before(earliest.NotBefore) || (key.NotBefore.Equal(earliest.NotBefore) && key.ID > earliest.ID) {
+			earliest = key
+			haveEarliest = true
+		}
+	}
+	if haveCurrent {
+		return current
+	}
+	return earliest
+}
+
+// secret returns the secret of the key with the given ID.
+func (r *TokenKeyRing) secret(id uint8) ([]byte, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	key, ok := r.keys[id]
+	return key.Secret, ok
+}
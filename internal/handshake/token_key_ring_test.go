@@ -0,0 +1,76 @@
+package handshake
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Token Key Ring", func() {
+	newKey := func(id uint8, notBefore time.Time) TokenKey {
+		return TokenKey{ID: id, Secret: make([]byte, TokenKeySize), NotBefore: notBefore}
+	}
+
+	It("requires at least one key", func() {
+		_, err := NewTokenKeyRing()
+		Expect(err).To(MatchError("a token key ring needs at least one key"))
+	})
+
+	It("rejects keys with the wrong size", func() {
+		_, err := NewTokenKeyRing(TokenKey{Secret: make([]byte, 16)})
+		Expect(err).To(MatchError("invalid token key size: 16 bytes (expected 32)"))
+	})
+
+	It("rejects duplicate key IDs", func() {
+		keys, err := NewTokenKeyRing(newKey(1, time.Time{}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(keys.AddKey(newKey(1, time.Time{}))).To(MatchError("duplicate token key ID: 1"))
+	})
+
+	It("copies the secret", func() {
+		key := newKey(1, time.Time{})
+		keys, err := NewTokenKeyRing(key)
+		Expect(err).ToNot(HaveOccurred())
+		key.Secret[0] = 42
+		secret, ok := keys.secret(1)
+		Expect(ok).To(BeTrue())
+		Expect(secret[0]).To(BeZero())
+	})
+
+	It("uses the latest key that is not scheduled for the future", func() {
+		now := time.Now()
+		keys, err := NewTokenKeyRing(
+			newKey(1, now.Add(-2*time.Hour)),
+			newKey(2, now.Add(-time.Hour)),
+			newKey(3, now.Add(time.Hour)),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(keys.currentKey(now).ID).To(BeEquivalentTo(2))
+		Expect(keys.currentKey(now.Add(time.Hour)).ID).To(BeEquivalentTo(3))
+	})
+
+	It("uses the key with the larger ID if two keys have the same NotBefore time", func() {
+		keys, err := NewTokenKeyRing(newKey(5, time.Time{}), newKey(3, time.Time{}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(keys.currentKey(time.Now()).ID).To(BeEquivalentTo(5))
+	})
+
+	It("uses the earliest key if all keys are scheduled for the future", func() {
+		now := time.Now()
+		keys, err := NewTokenKeyRing(newKey(1, now.Add(2*time.Hour)), newKey(2, now.Add(time.Hour)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(keys.currentKey(now).ID).To(BeEquivalentTo(2))
+	})
+
+	It("removes keys", func() {
+		keys, err := NewTokenKeyRing(newKey(1, time.Time{}), newKey(2, time.Time{}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(keys.RemoveKey(2)).To(Succeed())
+		Expect(keys.currentKey(time.Now()).ID).To(BeEquivalentTo(1))
+		_, ok := keys.secret(2)
+		Expect(ok).To(BeFalse())
+		Expect(keys.RemoveKey(2)).To(MatchError("unknown token key ID: 2"))
+		Expect(keys.RemoveKey(1)).To(MatchError("can't remove the last token key"))
+	})
+})
@@ -7,6 +7,7 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"io"
+	"time"
 
 	"golang.org/x/crypto/hkdf"
 )
@@ -26,46 +27,63 @@ const (
 
 // tokenProtector is used to create and verify a token
 type tokenProtectorImpl struct {
-	secret []byte
+	keys *TokenKeyRing
 }
 
-// newTokenProtector creates a source for source address tokens
-func newTokenProtector() (tokenProtector, error) {
-	secret := make([]byte, tokenSecretSize)
-	if _, err := rand.Read(secret); err != nil {
-		return nil, err
+// newTokenProtector creates a source for source address tokens.
+// If keys is nil, a random key is generated.
+func newTokenProtector(keys *TokenKeyRing) (tokenProtector, error) {
+	if keys == nil {
+		secret := make([]byte, tokenSecretSize)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		var err error
+		keys, err = NewTokenKeyRing(TokenKey{Secret: secret})
+		if err != nil {
+			return nil, err
+		}
 	}
-	return &tokenProtectorImpl{secret: secret}, nil
+	return &tokenProtectorImpl{keys: keys}, nil
 }
 
 // NewToken encodes data into a new token.
+// The token starts with the ID of the key that was used to protect it.
 func (s *tokenProtectorImpl) NewToken(data []byte) ([]byte, error) {
+	key := s.keys.currentKey(time.Now())
 	nonce := make([]byte, tokenNonceSize)
 	if _, err := rand.Read(nonce); err != nil {
 		return nil, err
 	}
-	aead, aeadNonce, err := s.createAEAD(nonce)
+	aead, aeadNonce, err := s.createAEAD(key.Secret, nonce)
 	if err != nil {
 		return nil, err
 	}
-	return append(nonce, aead.Seal(nil, aeadNonce, data, nil)...), nil
+	token := make([]byte, 0, 1+tokenNonceSize+len(data)+aead.Overhead())
+	token = append(token, key.ID)
+	token = append(token, nonce...)
+	return aead.Seal(token, aeadNonce, data, nil), nil
 }
 
 // DecodeToken decodes a token.
 func (s *tokenProtectorImpl) DecodeToken(p []byte) ([]byte, error) {
-	if len(p) < tokenNonceSize {
+	if len(p) < 1+tokenNonceSize {
 		return nil, fmt.Errorf("token too short: %d", len(p))
 	}
-	nonce := p[:tokenNonceSize]
-	aead, aeadNonce, err := s.createAEAD(nonce)
+	secret, ok := s.keys.secret(p[0])
+	if !ok {
+		return nil, fmt.Errorf("unknown token key ID: %d", p[0])
+	}
+	nonce := p[1 : 1+tokenNonceSize]
+	aead, aeadNonce, err := s.createAEAD(secret, nonce)
 	if err != nil {
 		return nil, err
 	}
-	return aead.Open(nil, aeadNonce, p[tokenNonceSize:], nil)
+	return aead.Open(nil, aeadNonce, p[1+tokenNonceSize:], nil)
 }
 
-func (s *tokenProtectorImpl) createAEAD(nonce []byte) (cipher.AEAD, []byte, error) {
-	h := hkdf.New(sha256.New, s.secret, nonce, []byte("quic-go token source"))
+func (s *tokenProtectorImpl) createAEAD(secret, nonce []byte) (cipher.AEAD, []byte, error) {
+	h := hkdf.New(sha256.New, secret, nonce, []byte("quic-go token source"))
 	key := make([]byte, 32) // use a 32 byte key, in order to select AES-256
 	if _, err := io.ReadFull(h, key); err != nil {
 		return nil, nil, err
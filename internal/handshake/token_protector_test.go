@@ -1,6 +1,8 @@
 package handshake
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -10,7 +12,7 @@ var _ = Describe("Token Protector", func() {
 
 	BeforeEach(func() {
 		var err error
-		tp, err = newTokenProtector()
+		tp, err = newTokenProtector(nil)
 		Expect(err).ToNot(HaveOccurred())
 	})
 
@@ -26,12 +28,65 @@ var _ = Describe("Token Protector", func() {
 	It("fails deconding invalid tokens", func() {
 		token, err := tp.NewToken([]byte("foobar"))
 		Expect(err).ToNot(HaveOccurred())
-		token = token[1:] // remove the first byte
+		token[len(token)-1]++ // modify the last byte
 		_, err = tp.DecodeToken(token)
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("message authentication failed"))
 	})
 
+	It("decodes tokens protected with a previous key", func() {
+		keys, err := NewTokenKeyRing(TokenKey{ID: 1, Secret: make([]byte, TokenKeySize)})
+		Expect(err).ToNot(HaveOccurred())
+		tp, err = newTokenProtector(keys)
+		Expect(err).ToNot(HaveOccurred())
+		token, err := tp.NewToken([]byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token[0]).To(Equal(uint8(1)))
+
+		secret := make([]byte, TokenKeySize)
+		secret[0] = 42
+		Expect(keys.AddKey(TokenKey{ID: 2, Secret: secret})).To(Succeed())
+		newToken, err := tp.NewToken([]byte("raboof"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(newToken[0]).To(Equal(uint8(2)))
+		decoded, err := tp.DecodeToken(token)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal([]byte("foobar")))
+		decoded, err = tp.DecodeToken(newToken)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal([]byte("raboof")))
+	})
+
+	It("decodes tokens created by a different protector using the same keys", func() {
+		keys, err := NewTokenKeyRing(TokenKey{ID: 7, Secret: make([]byte, TokenKeySize)})
+		Expect(err).ToNot(HaveOccurred())
+		tp1, err := newTokenProtector(keys)
+		Expect(err).ToNot(HaveOccurred())
+		tp2, err := newTokenProtector(keys)
+		Expect(err).ToNot(HaveOccurred())
+		token, err := tp1.NewToken([]byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		decoded, err := tp2.DecodeToken(token)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal([]byte("foobar")))
+	})
+
+	It("rejects tokens protected with a removed key", func() {
+		keys, err := NewTokenKeyRing(
+			TokenKey{ID: 1, Secret: make([]byte, TokenKeySize)},
+			TokenKey{ID: 2, Secret: make([]byte, TokenKeySize), NotBefore: time.Now().Add(-time.Hour)},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		tp, err = newTokenProtector(keys)
+		Expect(err).ToNot(HaveOccurred())
+		token, err := tp.NewToken([]byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token[0]).To(Equal(uint8(2)))
+		Expect(keys.RemoveKey(2)).To(Succeed())
+		_, err = tp.DecodeToken(token)
+		Expect(err).To(MatchError("unknown token key ID: 2"))
+	})
+
 	It("errors when decoding too short tokens", func() {
 		_, err := tp.DecodeToken([]byte("foobar"))
 		Expect(err).To(MatchError("token too short: 6"))
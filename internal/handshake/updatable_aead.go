@@ -3,10 +3,10 @@ package handshake
 import (
 	"crypto"
 	"crypto/cipher"
+	"crypto/tls"
 	"encoding/binary"
-	"fmt"
-	"os"
-	"strconv"
+	"math"
+	"sync/atomic"
 	"time"
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
@@ -16,29 +16,31 @@ import (
 	"github.com/lucas-clemente/quic-go/logging"
 )
 
-// By setting this environment variable, the key update interval can be adjusted.
-// This is not needed in production, but useful for integration and interop testing.
-// Note that no mattter what value is set, a key update is only initiated once it is
-// permitted (i.e. once an ACK for a packet sent at the current key phase has been received).
-const keyUpdateEnv = "QUIC_GO_KEY_UPDATE_INTERVAL"
-
-var keyUpdateInterval uint64
-
-func init() {
-	setKeyUpdateInterval()
+// KeyUpdatePolicy configures when keys are updated.
+type KeyUpdatePolicy struct {
+	// Interval is the number of packets sent or received with the current keys after which a key update is initiated.
+	// It is capped at the confidentiality limit of the cipher suite.
+	// If zero, protocol.KeyUpdateInterval is used.
+	Interval uint64
+	// Period is the time after which a key update is initiated.
+	// If zero, keys are not updated based on time.
+	Period time.Duration
 }
 
-func setKeyUpdateInterval() {
-	env := os.Getenv(keyUpdateEnv)
-	if env == "" {
-		keyUpdateInterval = protocol.KeyUpdateInterval
-		return
-	}
-	interval, err := strconv.ParseUint(env, 10, 64)
-	if err != nil {
-		panic(fmt.Sprintf("Cannot parse %s: %s", keyUpdateEnv, err))
+// The AEAD limits, as defined in RFC 9001, section 6.6.
+const (
+	aesGCMConfidentialityLimit = 1 << 23
+	aesGCMIntegrityLimit       = 1 << 52
+	chaChaIntegrityLimit       = 1 << 36
+)
+
+// aeadLimits returns the confidentiality and integrity limit of a cipher suite.
+func aeadLimits(suite *qtls.CipherSuiteTLS13) (confidentiality, integrity uint64) {
+	if suite.ID == tls.TLS_CHACHA20_POLY1305_SHA256 {
+		// The confidentiality limit of ChaCha20-Poly1305 is larger than the number of possible packets.
+		return math.MaxUint64, chaChaIntegrityLimit
 	}
-	keyUpdateInterval = interval
+	return aesGCMConfidentialityLimit, aesGCMIntegrityLimit
 }
 
 type updatableAEAD struct {
@@ -48,6 +50,13 @@ type updatableAEAD struct {
 	largestAcked      protocol.PacketNumber
 	firstPacketNumber protocol.PacketNumber
 	keyUpdateInterval uint64
+	keyUpdatePeriod   time.Duration
+	// set to 1 when a key update was requested by the application, to be used as an atomic
+	keyUpdateRequested int32
+
+	// the number of packets that failed authentication, with any key
+	numInvalidPackets uint64
+	integrityLimit    uint64
 
 	// Time when the keys should be dropped. Keys are dropped on the next call to Open().
 	prevRcvAEADExpiry time.Time
@@ -57,6 +66,7 @@ type updatableAEAD struct {
 	firstSentWithCurrentKey protocol.PacketNumber
 	numRcvdWithCurrentKey   uint64
 	numSentWithCurrentKey   uint64
+	currentKeyPhaseStart    time.Time
 	rcvAEAD                 cipher.AEAD
 	sendAEAD                cipher.AEAD
 	// caches cipher.AEAD.Overhead(). This speeds up calls to Overhead().
@@ -82,13 +92,19 @@ type updatableAEAD struct {
 var _ ShortHeaderOpener = &updatableAEAD{}
 var _ ShortHeaderSealer = &updatableAEAD{}
 
-func newUpdatableAEAD(rttStats *utils.RTTStats, tracer logging.ConnectionTracer, logger utils.Logger) *updatableAEAD {
+func newUpdatableAEAD(rttStats *utils.RTTStats, keyUpdatePolicy KeyUpdatePolicy, tracer logging.ConnectionTracer, logger utils.Logger) *updatableAEAD {
+	keyUpdateInterval := keyUpdatePolicy.Interval
+	if keyUpdateInterval == 0 {
+		keyUpdateInterval = protocol.KeyUpdateInterval
+	}
 	return &updatableAEAD{
 		firstPacketNumber:       protocol.InvalidPacketNumber,
 		largestAcked:            protocol.InvalidPacketNumber,
 		firstRcvdWithCurrentKey: protocol.InvalidPacketNumber,
 		firstSentWithCurrentKey: protocol.InvalidPacketNumber,
 		keyUpdateInterval:       keyUpdateInterval,
+		keyUpdatePeriod:         keyUpdatePolicy.Period,
+		integrityLimit:          math.MaxUint64,
 		rttStats:                rttStats,
 		tracer:                  tracer,
 		logger:                  logger,
@@ -101,6 +117,8 @@ func (a *updatableAEAD) rollKeys(now time.Time) {
 	a.firstSentWithCurrentKey = protocol.InvalidPacketNumber
 	a.numRcvdWithCurrentKey = 0
 	a.numSentWithCurrentKey = 0
+	a.currentKeyPhaseStart = now
+	atomic.StoreInt32(&a.keyUpdateRequested, 0)
 	a.prevRcvAEAD = a.rcvAEAD
 	a.prevRcvAEADExpiry = now.Add(3 * a.rttStats.PTO(true))
 	a.rcvAEAD = a.nextRcvAEAD
@@ -112,6 +130,16 @@ func (a *updatableAEAD) rollKeys(now time.Time) {
 	a.nextSendAEAD = createAEAD(a.suite, a.nextSendTrafficSecret)
 }
 
+func (a *updatableAEAD) setSuite(suite *qtls.CipherSuiteTLS13) {
+	a.suite = suite
+	a.currentKeyPhaseStart = time.Now()
+	confidentialityLimit, integrityLimit := aeadLimits(suite)
+	if a.keyUpdateInterval > confidentialityLimit {
+		a.keyUpdateInterval = confidentialityLimit
+	}
+	a.integrityLimit = integrityLimit
+}
+
 func (a *updatableAEAD) getNextTrafficSecret(hash crypto.Hash, ts []byte) []byte {
 	return hkdfExpandLabel(hash, ts, []byte{}, "quic ku", hash.Size())
 }
@@ -124,7 +152,7 @@ func (a *updatableAEAD) SetReadKey(suite *qtls.CipherSuiteTLS13, trafficSecret [
 	if a.suite == nil {
 		a.nonceBuf = make([]byte, a.rcvAEAD.NonceSize())
 		a.aeadOverhead = a.rcvAEAD.Overhead()
-		a.suite = suite
+		a.setSuite(suite)
 	}
 
 	a.nextRcvTrafficSecret = a.getNextTrafficSecret(suite.Hash, trafficSecret)
@@ -139,7 +167,7 @@ func (a *updatableAEAD) SetWriteKey(suite *qtls.CipherSuiteTLS13, trafficSecret
 	if a.suite == nil {
 		a.nonceBuf = make([]byte, a.sendAEAD.NonceSize())
 		a.aeadOverhead = a.sendAEAD.Overhead()
-		a.suite = suite
+		a.setSuite(suite)
 	}
 
 	a.nextSendTrafficSecret = a.getNextTrafficSecret(suite.Hash, trafficSecret)
@@ -166,14 +194,14 @@ func (a *updatableAEAD) Open(dst, src []byte, rcvTime time.Time, pn protocol.Pac
 			// we updated the key, but the peer hasn't updated yet
 			dec, err := a.prevRcvAEAD.Open(dst, a.nonceBuf, src, ad)
 			if err != nil {
-				err = ErrDecryptionFailed
+				err = a.decryptionFailed()
 			}
 			return dec, err
 		}
 		// try opening the packet with the next key phase
 		dec, err := a.nextRcvAEAD.Open(dst, a.nonceBuf, src, ad)
 		if err != nil {
-			return nil, ErrDecryptionFailed
+			return nil, a.decryptionFailed()
 		}
 		// Opening succeeded. Check if the peer was allowed to update.
 		if a.firstSentWithCurrentKey == protocol.InvalidPacketNumber {
@@ -191,7 +219,7 @@ func (a *updatableAEAD) Open(dst, src []byte, rcvTime time.Time, pn protocol.Pac
 	// It uses the nonce provided here and XOR it with the IV.
 	dec, err := a.rcvAEAD.Open(dst, a.nonceBuf, src, ad)
 	if err != nil {
-		err = ErrDecryptionFailed
+		err = a.decryptionFailed()
 	} else {
 		a.numRcvdWithCurrentKey++
 		if a.firstRcvdWithCurrentKey == protocol.InvalidPacketNumber {
@@ -201,6 +229,16 @@ func (a *updatableAEAD) Open(dst, src []byte, rcvTime time.Time, pn protocol.Pac
 	return dec, err
 }
 
+// decryptionFailed counts a packet that failed authentication.
+// Once the integrity limit is exceeded, the connection has to be closed.
+func (a *updatableAEAD) decryptionFailed() error {
+	a.numInvalidPackets++
+	if a.numInvalidPackets > a.integrityLimit {
+		return ErrAEADLimitReached
+	}
+	return ErrDecryptionFailed
+}
+
 func (a *updatableAEAD) Seal(dst, src []byte, pn protocol.PacketNumber, ad []byte) []byte {
 	if a.firstSentWithCurrentKey == protocol.InvalidPacketNumber {
 		a.firstSentWithCurrentKey = pn
@@ -225,7 +263,14 @@ func (a *updatableAEAD) updateAllowed() bool {
 		a.largestAcked >= a.firstSentWithCurrentKey
 }
 
-func (a *updatableAEAD) shouldInitiateKeyUpdate() bool {
+// InitiateKeyUpdate requests a key update.
+// The update is performed when the next packet is sent, as soon as a key update is permitted.
+// It is safe to call it concurrently.
+func (a *updatableAEAD) InitiateKeyUpdate() {
+	atomic.StoreInt32(&a.keyUpdateRequested, 1)
+}
+
+func (a *updatableAEAD) shouldInitiateKeyUpdate(now time.Time) bool {
 	if !a.updateAllowed() {
 		return false
 	}
@@ -237,15 +282,24 @@ func (a *updatableAEAD) shouldInitiateKeyUpdate() bool {
 		a.logger.Debugf("Sent %d packets with current key phase. Initiating key update to the next key phase: %s", a.numSentWithCurrentKey, a.keyPhase+1)
 		return true
 	}
+	if a.keyUpdatePeriod > 0 && now.Sub(a.currentKeyPhaseStart) >= a.keyUpdatePeriod {
+		a.logger.Debugf("Using current key phase for %s. Initiating key update to the next key phase: %s", now.Sub(a.currentKeyPhaseStart), a.keyPhase+1)
+		return true
+	}
+	if atomic.LoadInt32(&a.keyUpdateRequested) == 1 {
+		a.logger.Debugf("Key update requested. Initiating key update to the next key phase: %s", a.keyPhase+1)
+		return true
+	}
 	return false
 }
 
 func (a *updatableAEAD) KeyPhase() protocol.KeyPhaseBit {
-	if a.shouldInitiateKeyUpdate() {
+	now := time.Now()
+	if a.shouldInitiateKeyUpdate(now) {
 		if a.tracer != nil {
 			a.tracer.UpdatedKey(a.keyPhase, false)
 		}
-		a.rollKeys(time.Now())
+		a.rollKeys(now)
 	}
 	return a.keyPhase.Bit()
 }
@@ -4,7 +4,7 @@ import (
 	"crypto/rand"
 	"crypto/tls"
 	"fmt"
-	"os"
+	"math"
 	"time"
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
@@ -18,7 +18,7 @@ import (
 var _ = Describe("Updatable AEAD", func() {
 	It("ChaCha test vector from the draft", func() {
 		secret := splitHexString("9ac312a7f877468ebe69422748ad00a1 5443f18203a07d6060f688f30f21632b")
-		aead := newUpdatableAEAD(&utils.RTTStats{}, nil, nil)
+		aead := newUpdatableAEAD(&utils.RTTStats{}, KeyUpdatePolicy{}, nil, nil)
 		chacha := cipherSuites[2]
 		Expect(chacha.ID).To(Equal(tls.TLS_CHACHA20_POLY1305_SHA256))
 		aead.SetWriteKey(chacha, secret)
@@ -43,8 +43,8 @@ var _ = Describe("Updatable AEAD", func() {
 				rand.Read(trafficSecret1)
 				rand.Read(trafficSecret2)
 
-				client = newUpdatableAEAD(rttStats, nil, utils.DefaultLogger)
-				server = newUpdatableAEAD(rttStats, nil, utils.DefaultLogger)
+				client = newUpdatableAEAD(rttStats, KeyUpdatePolicy{}, nil, utils.DefaultLogger)
+				server = newUpdatableAEAD(rttStats, KeyUpdatePolicy{}, nil, utils.DefaultLogger)
 				client.SetReadKey(cs, trafficSecret2)
 				client.SetWriteKey(cs, trafficSecret1)
 				server.SetReadKey(cs, trafficSecret1)
@@ -252,26 +252,53 @@ var _ = Describe("Updatable AEAD", func() {
 						})
 					})
 
-					Context("reading the key update env", func() {
-						AfterEach(func() {
-							os.Setenv(keyUpdateEnv, "")
-							setKeyUpdateInterval()
+					Context("key update policy", func() {
+						It("uses the configured interval", func() {
+							aead := newUpdatableAEAD(rttStats, KeyUpdatePolicy{Interval: 1337}, nil, utils.DefaultLogger)
+							aead.SetWriteKey(cs, make([]byte, 16))
+							Expect(aead.keyUpdateInterval).To(BeEquivalentTo(1337))
 						})
 
-						It("uses the default value if the env is not set", func() {
-							setKeyUpdateInterval()
-							Expect(keyUpdateInterval).To(BeEquivalentTo(protocol.KeyUpdateInterval))
+						It("caps the interval at the confidentiality limit", func() {
+							aead := newUpdatableAEAD(rttStats, KeyUpdatePolicy{Interval: math.MaxUint64}, nil, utils.DefaultLogger)
+							aead.SetWriteKey(cs, make([]byte, 16))
+							confidentialityLimit, _ := aeadLimits(cs)
+							Expect(aead.keyUpdateInterval).To(Equal(confidentialityLimit))
 						})
 
-						It("uses the env", func() {
-							os.Setenv(keyUpdateEnv, "1337")
-							setKeyUpdateInterval()
-							Expect(keyUpdateInterval).To(BeEquivalentTo(1337))
+						It("initiates a key update after the key update period", func() {
+							server.keyUpdatePeriod = time.Hour
+							server.Seal(nil, msg, 1, ad)
+							server.SetLargestAcked(1)
+							Expect(server.KeyPhase()).To(Equal(protocol.KeyPhaseZero))
+							server.currentKeyPhaseStart = time.Now().Add(-time.Hour)
+							Expect(server.KeyPhase()).To(Equal(protocol.KeyPhaseOne))
+							// the period starts again with the new key phase
+							server.Seal(nil, msg, 2, ad)
+							server.SetLargestAcked(2)
+							Expect(server.KeyPhase()).To(Equal(protocol.KeyPhaseOne))
+						})
+
+						It("initiates a key update when requested", func() {
+							server.InitiateKeyUpdate()
+							// no update allowed before receiving an acknowledgement for the current key phase
+							Expect(server.KeyPhase()).To(Equal(protocol.KeyPhaseZero))
+							server.Seal(nil, msg, 1, ad)
+							server.SetLargestAcked(1)
+							Expect(server.KeyPhase()).To(Equal(protocol.KeyPhaseOne))
+							server.Seal(nil, msg, 2, ad)
+							server.SetLargestAcked(2)
+							Expect(server.KeyPhase()).To(Equal(protocol.KeyPhaseOne))
 						})
 
-						It("panics when it can't parse the env", func() {
-							os.Setenv(keyUpdateEnv, "foobar")
-							Expect(setKeyUpdateInterval).To(Panic())
+						It("errors when the integrity limit is exceeded", func() {
+							server.integrityLimit = 2
+							for i := 0; i < 2; i++ {
+								_, err := server.Open(nil, []byte("foobar"), time.Now(), protocol.PacketNumber(i), protocol.KeyPhaseZero, ad)
+								Expect(err).To(MatchError(ErrDecryptionFailed))
+							}
+							_, err := server.Open(nil, []byte("foobar"), time.Now(), 2, protocol.KeyPhaseZero, ad)
+							Expect(err).To(MatchError(ErrAEADLimitReached))
 						})
 					})
 				})
@@ -8,7 +8,7 @@ import (
 
 // ConvertFrame converts a wire.Frame into a logging.Frame.
 // This makes it possible for external packages to access the frames.
-// Furthermore, it removes the data slices from CRYPTO and STREAM frames.
+// Furthermore, it removes the data slices from CRYPTO, STREAM and DATAGRAM frames.
 func ConvertFrame(frame wire.Frame) logging.Frame {
 	switch f := frame.(type) {
 	case *wire.CryptoFrame:
@@ -23,6 +23,10 @@ func ConvertFrame(frame wire.Frame) logging.Frame {
 			Length:   f.DataLen(),
 			Fin:      f.Fin,
 		}
+	case *wire.DatagramFrame:
+		return &logging.DatagramFrame{
+			Length: protocol.ByteCount(len(f.Data)),
+		}
 	default:
 		return logging.Frame(frame)
 	}
@@ -90,6 +90,18 @@ func (mr *MockReceivedPacketHandlerMockRecorder) IsPotentiallyDuplicate(arg0, ar
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsPotentiallyDuplicate", reflect.TypeOf((*MockReceivedPacketHandler)(nil).IsPotentiallyDuplicate), arg0, arg1)
 }
 
+// QueueImmediateAck mocks base method
+func (m *MockReceivedPacketHandler) QueueImmediateAck() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "QueueImmediateAck")
+}
+
+// QueueImmediateAck indicates an expected call of QueueImmediateAck
+func (mr *MockReceivedPacketHandlerMockRecorder) QueueImmediateAck() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueueImmediateAck", reflect.TypeOf((*MockReceivedPacketHandler)(nil).QueueImmediateAck))
+}
+
 // ReceivedPacket mocks base method
 func (m *MockReceivedPacketHandler) ReceivedPacket(arg0 protocol.PacketNumber, arg1 protocol.EncryptionLevel, arg2 time.Time, arg3 bool) error {
 	m.ctrl.T.Helper()
@@ -103,3 +115,15 @@ func (mr *MockReceivedPacketHandlerMockRecorder) ReceivedPacket(arg0, arg1, arg2
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceivedPacket", reflect.TypeOf((*MockReceivedPacketHandler)(nil).ReceivedPacket), arg0, arg1, arg2, arg3)
 }
+
+// SetAckFrequency mocks base method
+func (m *MockReceivedPacketHandler) SetAckFrequency(arg0 *wire.AckFrequencyFrame) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetAckFrequency", arg0)
+}
+
+// SetAckFrequency indicates an expected call of SetAckFrequency
+func (mr *MockReceivedPacketHandlerMockRecorder) SetAckFrequency(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAckFrequency", reflect.TypeOf((*MockReceivedPacketHandler)(nil).SetAckFrequency), arg0)
+}
@@ -38,6 +38,22 @@ func (m *MockSentPacketHandler) EXPECT() *MockSentPacketHandlerMockRecorder {
 	return m.recorder
 }
 
+// AckStatsUpdate mocks base method
+func (m *MockSentPacketHandler) AckStatsUpdate() (int, int, int) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AckStatsUpdate")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(int)
+	return ret0, ret1, ret2
+}
+
+// AckStatsUpdate indicates an expected call of AckStatsUpdate
+func (mr *MockSentPacketHandlerMockRecorder) AckStatsUpdate() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AckStatsUpdate", reflect.TypeOf((*MockSentPacketHandler)(nil).AckStatsUpdate))
+}
+
 // AmplificationWindow mocks base method
 func (m *MockSentPacketHandler) AmplificationWindow() protocol.ByteCount {
 	m.ctrl.T.Helper()
@@ -52,6 +68,30 @@ func (mr *MockSentPacketHandlerMockRecorder) AmplificationWindow() *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AmplificationWindow", reflect.TypeOf((*MockSentPacketHandler)(nil).AmplificationWindow))
 }
 
+// CodingDisabled mocks base method
+func (m *MockSentPacketHandler) CodingDisabled() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CodingDisabled")
+}
+
+// CodingDisabled indicates an expected call of CodingDisabled
+func (mr *MockSentPacketHandlerMockRecorder) CodingDisabled() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CodingDisabled", reflect.TypeOf((*MockSentPacketHandler)(nil).CodingDisabled))
+}
+
+// CodingEnabled mocks base method
+func (m *MockSentPacketHandler) CodingEnabled() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CodingEnabled")
+}
+
+// CodingEnabled indicates an expected call of CodingEnabled
+func (mr *MockSentPacketHandlerMockRecorder) CodingEnabled() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CodingEnabled", reflect.TypeOf((*MockSentPacketHandler)(nil).CodingEnabled))
+}
+
 // DropPackets mocks base method
 func (m *MockSentPacketHandler) DropPackets(arg0 protocol.EncryptionLevel) {
 	m.ctrl.T.Helper()
@@ -64,6 +104,20 @@ func (mr *MockSentPacketHandlerMockRecorder) DropPackets(arg0 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropPackets", reflect.TypeOf((*MockSentPacketHandler)(nil).DropPackets), arg0)
 }
 
+// GetCongestionWindow mocks base method
+func (m *MockSentPacketHandler) GetCongestionWindow() protocol.ByteCount {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCongestionWindow")
+	ret0, _ := ret[0].(protocol.ByteCount)
+	return ret0
+}
+
+// GetCongestionWindow indicates an expected call of GetCongestionWindow
+func (mr *MockSentPacketHandlerMockRecorder) GetCongestionWindow() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCongestionWindow", reflect.TypeOf((*MockSentPacketHandler)(nil).GetCongestionWindow))
+}
+
 // GetLossDetectionTimeout mocks base method
 func (m *MockSentPacketHandler) GetLossDetectionTimeout() time.Time {
 	m.ctrl.T.Helper()
@@ -78,6 +132,20 @@ func (mr *MockSentPacketHandlerMockRecorder) GetLossDetectionTimeout() *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLossDetectionTimeout", reflect.TypeOf((*MockSentPacketHandler)(nil).GetLossDetectionTimeout))
 }
 
+// GetSentPacketStats mocks base method
+func (m *MockSentPacketHandler) GetSentPacketStats() ackhandler.SentPacketStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSentPacketStats")
+	ret0, _ := ret[0].(ackhandler.SentPacketStats)
+	return ret0
+}
+
+// GetSentPacketStats indicates an expected call of GetSentPacketStats
+func (mr *MockSentPacketHandlerMockRecorder) GetSentPacketStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSentPacketStats", reflect.TypeOf((*MockSentPacketHandler)(nil).GetSentPacketStats))
+}
+
 // GetStats mocks base method
 func (m *MockSentPacketHandler) GetStats() *quictrace.TransportState {
 	m.ctrl.T.Helper()
@@ -149,6 +217,30 @@ func (mr *MockSentPacketHandlerMockRecorder) PopPacketNumber(arg0 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PopPacketNumber", reflect.TypeOf((*MockSentPacketHandler)(nil).PopPacketNumber), arg0)
 }
 
+// ProcessingCoded mocks base method
+func (m *MockSentPacketHandler) ProcessingCoded() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ProcessingCoded")
+}
+
+// ProcessingCoded indicates an expected call of ProcessingCoded
+func (mr *MockSentPacketHandlerMockRecorder) ProcessingCoded() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessingCoded", reflect.TypeOf((*MockSentPacketHandler)(nil).ProcessingCoded))
+}
+
+// ProcessingCodedFinished mocks base method
+func (m *MockSentPacketHandler) ProcessingCodedFinished() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ProcessingCodedFinished")
+}
+
+// ProcessingCodedFinished indicates an expected call of ProcessingCodedFinished
+func (mr *MockSentPacketHandlerMockRecorder) ProcessingCodedFinished() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessingCodedFinished", reflect.TypeOf((*MockSentPacketHandler)(nil).ProcessingCodedFinished))
+}
+
 // QueueProbePacket mocks base method
 func (m *MockSentPacketHandler) QueueProbePacket(arg0 protocol.EncryptionLevel) bool {
 	m.ctrl.T.Helper()
@@ -241,6 +333,18 @@ func (mr *MockSentPacketHandlerMockRecorder) SetHandshakeConfirmed() *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHandshakeConfirmed", reflect.TypeOf((*MockSentPacketHandler)(nil).SetHandshakeConfirmed))
 }
 
+// SetMaxDatagramSize mocks base method
+func (m *MockSentPacketHandler) SetMaxDatagramSize(arg0 protocol.ByteCount) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetMaxDatagramSize", arg0)
+}
+
+// SetMaxDatagramSize indicates an expected call of SetMaxDatagramSize
+func (mr *MockSentPacketHandlerMockRecorder) SetMaxDatagramSize(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaxDatagramSize", reflect.TypeOf((*MockSentPacketHandler)(nil).SetMaxDatagramSize), arg0)
+}
+
 // TimeUntilSend mocks base method
 func (m *MockSentPacketHandler) TimeUntilSend() time.Time {
 	m.ctrl.T.Helper()
@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/lucas-clemente/quic-go/logging (interfaces: Tracer)
+// Source: github.com/lucas-clemente/quic-go/internal/mocks (interfaces: Tracer)
 
 // Package mocks is a generated GoMock package.
 package mocks
@@ -37,6 +37,18 @@ func (m *MockTracer) EXPECT() *MockTracerMockRecorder {
 	return m.recorder
 }
 
+// Draining mocks base method
+func (m *MockTracer) Draining(arg0 int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Draining", arg0)
+}
+
+// Draining indicates an expected call of Draining
+func (mr *MockTracerMockRecorder) Draining(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Draining", reflect.TypeOf((*MockTracer)(nil).Draining), arg0)
+}
+
 // DroppedPacket mocks base method
 func (m *MockTracer) DroppedPacket(arg0 net.Addr, arg1 protocol.PacketType, arg2 protocol.ByteCount, arg3 logging.PacketDropReason) {
 	m.ctrl.T.Helper()
@@ -49,6 +61,18 @@ func (mr *MockTracerMockRecorder) DroppedPacket(arg0, arg1, arg2, arg3 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DroppedPacket", reflect.TypeOf((*MockTracer)(nil).DroppedPacket), arg0, arg1, arg2, arg3)
 }
 
+// HandledConnectionAttempt mocks base method
+func (m *MockTracer) HandledConnectionAttempt(arg0 net.Addr, arg1 logging.AdmissionDecision) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandledConnectionAttempt", arg0, arg1)
+}
+
+// HandledConnectionAttempt indicates an expected call of HandledConnectionAttempt
+func (mr *MockTracerMockRecorder) HandledConnectionAttempt(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandledConnectionAttempt", reflect.TypeOf((*MockTracer)(nil).HandledConnectionAttempt), arg0, arg1)
+}
+
 // SentPacket mocks base method
 func (m *MockTracer) SentPacket(arg0 net.Addr, arg1 *wire.Header, arg2 protocol.ByteCount, arg3 []logging.Frame) {
 	m.ctrl.T.Helper()
@@ -8,6 +8,17 @@ const MaxPacketSizeIPv4 = 1252
 // MaxPacketSizeIPv6 is the maximum packet size that we use for sending IPv6 packets.
 const MaxPacketSizeIPv6 = 1232
 
+// MaxMTUProbes is the number of times a Path MTU Discovery probe packet of the same size
+// needs to be lost before we conclude that the path doesn't support this packet size.
+const MaxMTUProbes = 3
+
+// MTUProbeDelay is the time between two Path MTU Discovery probe packets, in multiples of the RTT.
+const MTUProbeDelay = 5
+
+// MaxBatchSize is the maximum number of packets that are read or written using a single syscall,
+// if the platform supports batched I/O.
+const MaxBatchSize = 16
+
 // MaxCongestionWindowPackets is the maximum congestion window in packet.
 const MaxCongestionWindowPackets = 10000
 
@@ -109,6 +120,19 @@ const MaxKeepAliveInterval = 20 * time.Second
 // after this time all information about the old connection will be deleted
 const RetiredConnectionIDDeleteTimeout = 5 * time.Second
 
+// MaxStatelessResetRate is the maximum number of stateless resets we send per second.
+const MaxStatelessResetRate = 1000
+
+// MaxStatelessResetRatePerSource is the maximum number of stateless resets we send per second to a single IP address.
+// Since stateless resets are sent in response to packets that could have been spoofed,
+// this prevents an attacker from using us to flood a victim.
+const MaxStatelessResetRatePerSource = 10
+
+// AmplificationFactor is the maximum factor by which the data we send in response to a peer
+// may exceed the data we received from that peer, as long as its address is not validated.
+// It also limits the retransmissions of the CONNECTION_CLOSE for closed sessions.
+const AmplificationFactor = 3
+
 // MinStreamFrameSize is the minimum size that has to be left in a packet, so that we add another STREAM frame.
 // This avoids splitting up STREAM frames into small pieces, which has 2 advantages:
 // 1. it reduces the framing overhead
@@ -163,6 +187,22 @@ const MaxAckDelay = 25 * time.Millisecond
 // This is the value that should be advertised to the peer.
 const MaxAckDelayInclGranularity = MaxAckDelay + TimerGranularity
 
+// MinAckDelay is the min_ack_delay we advertise to the peer (ACK frequency extension).
+// It is the smallest max_ack_delay the peer is allowed to request using an ACK_FREQUENCY frame.
+const MinAckDelay = TimerGranularity
+
+// MaxAckElicitingThreshold is the maximum number of ack-eliciting packets
+// we allow the peer to receive before sending an ACK, when requesting a lower ACK rate.
+const MaxAckElicitingThreshold = 64
+
+// MaxDatagramFrameSize is the maximum size of a DATAGRAM frame we advertise to the peer (RFC 9221).
+// The value is chosen such that it is always possible to fit a DATAGRAM frame into a packet.
+const MaxDatagramFrameSize ByteCount = 1220
+
+// DatagramRcvQueueLen is the length of the receive queue for DATAGRAM frames (RFC 9221).
+// Datagrams that arrive while the queue is full are dropped.
+const DatagramRcvQueueLen = 128
+
 // KeyUpdateInterval is the maximum number of packets we send or receive before initiating a key udpate.
 const KeyUpdateInterval = 100 * 1000
 
@@ -52,6 +52,11 @@ type StatelessResetToken [16]byte
 // Ethernet's max packet size is 1500 bytes,  1500 - 48 = 1452.
 const MaxReceivePacketSize ByteCount = 1452
 
+// MaxPacketBufferSize is the size of the buffers used for sending and receiving packets.
+// It is large enough to hold a packet sent on a link with a jumbo frame MTU of 9000 bytes,
+// minus the IPv6 and UDP headers, such that Path MTU Discovery can make use of these links.
+const MaxPacketBufferSize ByteCount = 9000 - 48
+
 // MinInitialPacketSize is the minimum size an Initial packet is required to have.
 const MinInitialPacketSize = 1200
 
@@ -0,0 +1,28 @@
+package protocol
+
+// MaxStreamUrgency is the lowest urgency a stream can have.
+// Urgencies range from 0 (most urgent) to MaxStreamUrgency.
+const MaxStreamUrgency = 7
+
+// StreamPriority is the scheduling priority of a stream.
+// It is modeled after the Extensible Prioritization Scheme for HTTP.
+type StreamPriority struct {
+	// Urgency is the urgency of the stream.
+	// Data from streams with a lower value is sent first.
+	Urgency uint8
+	// Incremental says if the stream shares the available bandwidth with other streams of the same urgency.
+	// Incremental streams are served round-robin, non-incremental streams are served one after the other.
+	Incremental bool
+}
+
+// DefaultStreamPriority is the priority of a stream that didn't set a priority.
+// Data of streams with the default priority is sent round-robin.
+var DefaultStreamPriority = StreamPriority{Urgency: 3, Incremental: true}
+
+// Normalize returns a priority with the urgency capped at MaxStreamUrgency.
+func (p StreamPriority) Normalize() StreamPriority {
+	if p.Urgency > MaxStreamUrgency {
+		p.Urgency = MaxStreamUrgency
+	}
+	return p
+}
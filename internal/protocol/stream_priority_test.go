@@ -0,0 +1,18 @@
+package protocol
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stream Priority", func() {
+	It("has a default urgency in the middle of the range", func() {
+		Expect(DefaultStreamPriority.Urgency).To(BeEquivalentTo(3))
+		Expect(DefaultStreamPriority.Incremental).To(BeTrue())
+	})
+
+	It("caps the urgency", func() {
+		Expect(StreamPriority{Urgency: 5}.Normalize()).To(Equal(StreamPriority{Urgency: 5}))
+		Expect(StreamPriority{Urgency: 42, Incremental: true}.Normalize()).To(Equal(StreamPriority{Urgency: MaxStreamUrgency, Incremental: true}))
+	})
+})
@@ -18,30 +18,46 @@ const (
 
 // The version numbers, making grepping easier
 const (
-	VersionTLS      VersionNumber = 0xff00001d // draft-29
-	VersionWhatever VersionNumber = 1          // for when the version doesn't matter
+	VersionTLS      VersionNumber = Version1
+	VersionWhatever VersionNumber = math.MaxUint32 - 1 // for when the version doesn't matter
 	VersionUnknown  VersionNumber = math.MaxUint32
 
-	VersionMilestone0_18 VersionNumber = 0xff00001d // QUIC WG draft-29
+	VersionDraft29 VersionNumber = 0xff00001d // QUIC WG draft-29
+	Version1       VersionNumber = 0x1        // RFC 9000
+
+	VersionMilestone0_18 = VersionDraft29
 )
 
 // SupportedVersions lists the versions that the server supports
-// must be in sorted descending order
-var SupportedVersions = []VersionNumber{VersionMilestone0_18}
+// must be in order of preference
+var SupportedVersions = []VersionNumber{Version1, VersionDraft29}
 
 // IsValidVersion says if the version is known to quic-go
 func IsValidVersion(v VersionNumber) bool {
 	return v == VersionTLS || IsSupportedVersion(SupportedVersions, v)
 }
 
+// AreCompatibleVersions says if a connection can be switched from one version to another
+// during the handshake, using compatible version negotiation.
+// Draft-29 and v1 use the same wire image. They only differ in the cryptographic constants,
+// which allows converting the first flight of one version into the other.
+func AreCompatibleVersions(from, to VersionNumber) bool {
+	if from == to {
+		return true
+	}
+	return (from == Version1 || from == VersionDraft29) && (to == Version1 || to == VersionDraft29)
+}
+
 func (vn VersionNumber) String() string {
 	switch vn {
 	case VersionWhatever:
 		return "whatever"
 	case VersionUnknown:
 		return "unknown"
-	case VersionMilestone0_18:
+	case VersionDraft29:
 		return "QUIC WG draft-29"
+	case Version1:
+		return "v1"
 	default:
 		if vn.isGQUIC() {
 			return fmt.Sprintf("gQUIC %d", vn.toGQUICVersion())
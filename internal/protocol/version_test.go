@@ -12,7 +12,8 @@ var _ = Describe("Version", func() {
 
 	It("says if a version is valid", func() {
 		Expect(IsValidVersion(VersionTLS)).To(BeTrue())
-		Expect(IsValidVersion(VersionMilestone0_18)).To(BeTrue())
+		Expect(IsValidVersion(Version1)).To(BeTrue())
+		Expect(IsValidVersion(VersionDraft29)).To(BeTrue())
 		Expect(IsValidVersion(VersionWhatever)).To(BeFalse())
 		Expect(IsValidVersion(VersionUnknown)).To(BeFalse())
 		Expect(IsValidVersion(1234)).To(BeFalse())
@@ -20,10 +21,13 @@ var _ = Describe("Version", func() {
 
 	It("versions don't have reserved version numbers", func() {
 		Expect(isReservedVersion(VersionTLS)).To(BeFalse())
+		Expect(isReservedVersion(Version1)).To(BeFalse())
+		Expect(isReservedVersion(VersionDraft29)).To(BeFalse())
 	})
 
 	It("has the right string representation", func() {
-		Expect(VersionMilestone0_18.String()).To(ContainSubstring("QUIC WG draft-29"))
+		Expect(VersionDraft29.String()).To(ContainSubstring("QUIC WG draft-29"))
+		Expect(Version1.String()).To(Equal("v1"))
 		Expect(VersionWhatever.String()).To(Equal("whatever"))
 		Expect(VersionUnknown.String()).To(Equal("unknown"))
 		// check with unsupported version numbers from the wiki
@@ -40,10 +44,16 @@ var _ = Describe("Version", func() {
 		Expect(IsSupportedVersion(SupportedVersions, SupportedVersions[len(SupportedVersions)-1])).To(BeTrue())
 	})
 
-	It("has supported versions in sorted order", func() {
-		for i := 0; i < len(SupportedVersions)-1; i++ {
-			Expect(SupportedVersions[i]).To(BeNumerically(">", SupportedVersions[i+1]))
-		}
+	It("prefers v1", func() {
+		Expect(SupportedVersions).To(Equal([]VersionNumber{Version1, VersionDraft29}))
+	})
+
+	It("says if versions are compatible", func() {
+		Expect(AreCompatibleVersions(Version1, VersionDraft29)).To(BeTrue())
+		Expect(AreCompatibleVersions(VersionDraft29, Version1)).To(BeTrue())
+		Expect(AreCompatibleVersions(Version1, Version1)).To(BeTrue())
+		Expect(AreCompatibleVersions(Version1, 0x1234)).To(BeFalse())
+		Expect(AreCompatibleVersions(0x1234, VersionDraft29)).To(BeFalse())
 	})
 
 	Context("highest supported version", func() {
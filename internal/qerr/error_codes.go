@@ -25,6 +25,8 @@ const (
 	InvalidToken            ErrorCode = 0xb
 	ApplicationError        ErrorCode = 0xc
 	CryptoBufferExceeded    ErrorCode = 0xd
+	AEADLimitReached        ErrorCode = 0xf
+	VersionNegotiationError ErrorCode = 0x11 // RFC 9368
 )
 
 func (e ErrorCode) isCryptoError() bool {
@@ -77,6 +79,10 @@ func (e ErrorCode) String() string {
 		return "APPLICATION_ERROR"
 	case CryptoBufferExceeded:
 		return "CRYPTO_BUFFER_EXCEEDED"
+	case AEADLimitReached:
+		return "AEAD_LIMIT_REACHED"
+	case VersionNegotiationError:
+		return "VERSION_NEGOTIATION_ERROR"
 	default:
 		if e.isCryptoError() {
 			return "CRYPTO_ERROR"
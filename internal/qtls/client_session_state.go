@@ -0,0 +1,162 @@
+package qtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"time"
+	"unsafe"
+)
+
+// clientSessionState has the same memory layout as the tls.ClientSessionState.
+// It gives access to the (unexported) fields, so that the session state can be serialized.
+type clientSessionState struct {
+	sessionTicket      []uint8
+	vers               uint16
+	cipherSuite        uint16
+	masterSecret       []byte
+	serverCertificates []*x509.Certificate
+	verifiedChains     [][]*x509.Certificate
+	receivedAt         time.Time
+	ocspResponse       []byte
+	scts               [][]byte
+
+	nonce  []byte // for TLS 1.3, the nonce also contains the quic-go app data (the transport parameters)
+	useBy  time.Time
+	ageAdd uint32
+}
+
+var clientSessionStateSerializable = structsEqual(&tls.ClientSessionState{}, &clientSessionState{})
+
+var errClientSessionStateNotSerializable = errors.New("tls.ClientSessionState can't be serialized with this Go version")
+
+// serializedClientSessionState is the struct that is used for ASN1 serialization and deserialization
+type serializedClientSessionState struct {
+	SessionTicket      []byte
+	Version            int
+	CipherSuite        int
+	MasterSecret       []byte
+	ServerCertificates [][]byte
+	VerifiedChains     []serializedCertificateChain
+	ReceivedAt         int64
+	OCSPResponse       []byte
+	SCTs               [][]byte
+	Nonce              []byte
+	UseBy              int64
+	AgeAdd             int64
+}
+
+type serializedCertificateChain struct {
+	Certificates [][]byte
+}
+
+// MarshalClientSessionState serializes a tls.ClientSessionState.
+func MarshalClientSessionState(cs *tls.ClientSessionState) ([]byte, error) {
+	if !clientSessionStateSerializable {
+		return nil, errClientSessionStateNotSerializable
+	}
+	s := (*clientSessionState)(unsafe.Pointer(cs))
+	chains := make([]serializedCertificateChain, len(s.verifiedChains))
+	for i, chain := range s.verifiedChains {
+		chains[i].Certificates = rawCertificates(chain)
+	}
+	return asn1.Marshal(serializedClientSessionState{
+		SessionTicket:      s.sessionTicket,
+		Version:            int(s.vers),
+		CipherSuite:        int(s.cipherSuite),
+		MasterSecret:       s.masterSecret,
+		ServerCertificates: rawCertificates(s.serverCertificates),
+		VerifiedChains:     chains,
+		ReceivedAt:         timeToUnixNano(s.receivedAt),
+		OCSPResponse:       s.ocspResponse,
+		SCTs:               s.scts,
+		Nonce:              s.nonce,
+		UseBy:              timeToUnixNano(s.useBy),
+		AgeAdd:             int64(s.ageAdd),
+	})
+}
+
+// UnmarshalClientSessionState parses a tls.ClientSessionState serialized by MarshalClientSessionState.
+func UnmarshalClientSessionState(data []byte) (*tls.ClientSessionState, error) {
+	if !clientSessionStateSerializable {
+		return nil, errClientSessionStateNotSerializable
+	}
+	var ss serializedClientSessionState
+	rest, err := asn1.Unmarshal(data, &ss)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("rest when unpacking session state")
+	}
+	serverCerts, err := parseCertificates(ss.ServerCertificates)
+	if err != nil {
+		return nil, err
+	}
+	chains := make([][]*x509.Certificate, len(ss.VerifiedChains))
+	for i, chain := range ss.VerifiedChains {
+		chains[i], err = parseCertificates(chain.Certificates)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s := &clientSessionState{
+		sessionTicket:      ss.SessionTicket,
+		vers:               uint16(ss.Version),
+		cipherSuite:        uint16(ss.CipherSuite),
+		masterSecret:       ss.MasterSecret,
+		serverCertificates: serverCerts,
+		verifiedChains:     chains,
+		receivedAt:         unixNanoToTime(ss.ReceivedAt),
+		ocspResponse:       ss.OCSPResponse,
+		scts:               ss.SCTs,
+		nonce:              ss.Nonce,
+		useBy:              unixNanoToTime(ss.UseBy),
+		ageAdd:             uint32(ss.AgeAdd),
+	}
+	return (*tls.ClientSessionState)(unsafe.Pointer(s)), nil
+}
+
+// ClientSessionStateExpiry returns the time when the session ticket expires.
+// The zero time is returned for session states that don't have an expiry time (i.e. TLS 1.2 session states).
+func ClientSessionStateExpiry(cs *tls.ClientSessionState) time.Time {
+	if !clientSessionStateSerializable {
+		return time.Time{}
+	}
+	return (*clientSessionState)(unsafe.Pointer(cs)).useBy
+}
+
+func timeToUnixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func unixNanoToTime(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+func rawCertificates(certs []*x509.Certificate) [][]byte {
+	raw := make([][]byte, len(certs))
+	for i, cert := range certs {
+		raw[i] = cert.Raw
+	}
+	return raw
+}
+
+func parseCertificates(raw [][]byte) ([]*x509.Certificate, error) {
+	certs := make([]*x509.Certificate, len(raw))
+	for i, r := range raw {
+		cert, err := x509.ParseCertificate(r)
+		if err != nil {
+			return nil, err
+		}
+		certs[i] = cert
+	}
+	return certs, nil
+}
@@ -0,0 +1,73 @@
+package qtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"time"
+	"unsafe"
+
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client Session State", func() {
+	getCertificate := func() *x509.Certificate {
+		cert, err := x509.ParseCertificate(testdata.GetTLSConfig().Certificates[0].Certificate[0])
+		Expect(err).ToNot(HaveOccurred())
+		return cert
+	}
+
+	It("serializes and parses a session state", func() {
+		cert := getCertificate()
+		now := time.Now()
+		s := &clientSessionState{
+			sessionTicket:      []byte("ticket"),
+			vers:               tls.VersionTLS13,
+			cipherSuite:        tls.TLS_CHACHA20_POLY1305_SHA256,
+			masterSecret:       []byte("secret"),
+			serverCertificates: []*x509.Certificate{cert},
+			verifiedChains:     [][]*x509.Certificate{{cert}, {cert, cert}},
+			receivedAt:         now,
+			ocspResponse:       []byte("ocsp"),
+			scts:               [][]byte{[]byte("foo"), []byte("bar")},
+			nonce:              []byte("nonce and app data"),
+			useBy:              now.Add(time.Hour),
+			ageAdd:             0xdeadbeef,
+		}
+		data, err := MarshalClientSessionState((*tls.ClientSessionState)(unsafe.Pointer(s)))
+		Expect(err).ToNot(HaveOccurred())
+		cs, err := UnmarshalClientSessionState(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ClientSessionStateExpiry(cs)).To(BeTemporally("==", now.Add(time.Hour)))
+		parsed := (*clientSessionState)(unsafe.Pointer(cs))
+		Expect(parsed.sessionTicket).To(Equal([]byte("ticket")))
+		Expect(parsed.vers).To(BeEquivalentTo(tls.VersionTLS13))
+		Expect(parsed.cipherSuite).To(Equal(tls.TLS_CHACHA20_POLY1305_SHA256))
+		Expect(parsed.masterSecret).To(Equal([]byte("secret")))
+		Expect(parsed.serverCertificates).To(HaveLen(1))
+		Expect(parsed.serverCertificates[0].Equal(cert)).To(BeTrue())
+		Expect(parsed.verifiedChains).To(HaveLen(2))
+		Expect(parsed.verifiedChains[0]).To(HaveLen(1))
+		Expect(parsed.verifiedChains[1]).To(HaveLen(2))
+		Expect(parsed.receivedAt).To(BeTemporally("==", now))
+		Expect(parsed.ocspResponse).To(Equal([]byte("ocsp")))
+		Expect(parsed.scts).To(Equal([][]byte{[]byte("foo"), []byte("bar")}))
+		Expect(parsed.nonce).To(Equal([]byte("nonce and app data")))
+		Expect(parsed.ageAdd).To(BeEquivalentTo(0xdeadbeef))
+	})
+
+	It("handles session states without an expiry time", func() {
+		data, err := MarshalClientSessionState((*tls.ClientSessionState)(unsafe.Pointer(&clientSessionState{vers: tls.VersionTLS12})))
+		Expect(err).ToNot(HaveOccurred())
+		cs, err := UnmarshalClientSessionState(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ClientSessionStateExpiry(cs).IsZero()).To(BeTrue())
+	})
+
+	It("errors on invalid data", func() {
+		_, err := UnmarshalClientSessionState([]byte("foobar"))
+		Expect(err).To(HaveOccurred())
+	})
+})
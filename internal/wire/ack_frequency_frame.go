@@ -0,0 +1,69 @@
+package wire
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+const ackFrequencyFrameType = 0xaf
+
+// An AckFrequencyFrame is an ACK_FREQUENCY frame.
+// It is defined in the ACK frequency extension (draft-ietf-quic-ack-frequency).
+type AckFrequencyFrame struct {
+	SequenceNumber        uint64
+	AckElicitingThreshold uint64
+	RequestMaxAckDelay    time.Duration
+	ReorderingThreshold   uint64
+}
+
+func parseAckFrequencyFrame(r *bytes.Reader, _ protocol.VersionNumber) (*AckFrequencyFrame, error) {
+	frameType, err := utils.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if frameType != ackFrequencyFrameType {
+		return nil, errUnknownFrameType
+	}
+
+	f := &AckFrequencyFrame{}
+	if f.SequenceNumber, err = utils.ReadVarInt(r); err != nil {
+		return nil, err
+	}
+	if f.AckElicitingThreshold, err = utils.ReadVarInt(r); err != nil {
+		return nil, err
+	}
+	delay, err := utils.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	// prevent an overflow when converting to a time.Duration
+	if delay > uint64(protocol.MaxMaxAckDelay/time.Microsecond) {
+		delay = uint64(protocol.MaxMaxAckDelay / time.Microsecond)
+	}
+	f.RequestMaxAckDelay = time.Duration(delay) * time.Microsecond
+	if f.ReorderingThreshold, err = utils.ReadVarInt(r); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *AckFrequencyFrame) Write(b *bytes.Buffer, _ protocol.VersionNumber) error {
+	utils.WriteVarInt(b, ackFrequencyFrameType)
+	utils.WriteVarInt(b, f.SequenceNumber)
+	utils.WriteVarInt(b, f.AckElicitingThreshold)
+	utils.WriteVarInt(b, uint64(f.RequestMaxAckDelay/time.Microsecond))
+	utils.WriteVarInt(b, f.ReorderingThreshold)
+	return nil
+}
+
+// Length of a written frame
+func (f *AckFrequencyFrame) Length(_ protocol.VersionNumber) protocol.ByteCount {
+	return utils.VarIntLen(ackFrequencyFrameType) +
+		utils.VarIntLen(f.SequenceNumber) +
+		utils.VarIntLen(f.AckElicitingThreshold) +
+		utils.VarIntLen(uint64(f.RequestMaxAckDelay/time.Microsecond)) +
+		utils.VarIntLen(f.ReorderingThreshold)
+}
@@ -0,0 +1,85 @@
+package wire
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ACK_FREQUENCY frame", func() {
+	Context("when parsing", func() {
+		It("accepts a sample frame", func() {
+			data := encodeVarInt(0xaf)
+			data = append(data, encodeVarInt(0xdeadbeef)...) // sequence number
+			data = append(data, encodeVarInt(10)...)         // ack-eliciting threshold
+			data = append(data, encodeVarInt(12345)...)      // request max ack delay
+			data = append(data, encodeVarInt(3)...)          // reordering threshold
+			b := bytes.NewReader(data)
+			frame, err := parseAckFrequencyFrame(b, versionIETFFrames)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame.SequenceNumber).To(Equal(uint64(0xdeadbeef)))
+			Expect(frame.AckElicitingThreshold).To(Equal(uint64(10)))
+			Expect(frame.RequestMaxAckDelay).To(Equal(12345 * time.Microsecond))
+			Expect(frame.ReorderingThreshold).To(Equal(uint64(3)))
+			Expect(b.Len()).To(BeZero())
+		})
+
+		It("errors on EOFs", func() {
+			data := encodeVarInt(0xaf)
+			data = append(data, encodeVarInt(0xdeadbeef)...)
+			data = append(data, encodeVarInt(10)...)
+			data = append(data, encodeVarInt(12345)...)
+			data = append(data, encodeVarInt(3)...)
+			_, err := parseAckFrequencyFrame(bytes.NewReader(data), versionIETFFrames)
+			Expect(err).NotTo(HaveOccurred())
+			for i := range data {
+				_, err := parseAckFrequencyFrame(bytes.NewReader(data[0:i]), versionIETFFrames)
+				Expect(err).To(MatchError(io.EOF))
+			}
+		})
+
+		It("rejects other two-byte frame types", func() {
+			data := encodeVarInt(0xae)
+			data = append(data, make([]byte, 4)...)
+			_, err := parseAckFrequencyFrame(bytes.NewReader(data), versionIETFFrames)
+			Expect(err).To(MatchError("unknown frame type"))
+		})
+	})
+
+	Context("when writing", func() {
+		It("writes a sample frame", func() {
+			frame := &AckFrequencyFrame{
+				SequenceNumber:        0xdecafbad,
+				AckElicitingThreshold: 0xcafe,
+				RequestMaxAckDelay:    1337 * time.Millisecond,
+				ReorderingThreshold:   0,
+			}
+			b := &bytes.Buffer{}
+			Expect(frame.Write(b, versionIETFFrames)).To(Succeed())
+			expected := encodeVarInt(0xaf)
+			expected = append(expected, encodeVarInt(0xdecafbad)...)
+			expected = append(expected, encodeVarInt(0xcafe)...)
+			expected = append(expected, encodeVarInt(1337000)...)
+			expected = append(expected, encodeVarInt(0)...)
+			Expect(b.Bytes()).To(Equal(expected))
+		})
+
+		It("has the correct length", func() {
+			frame := &AckFrequencyFrame{
+				SequenceNumber:        0xdecafbad,
+				AckElicitingThreshold: 0xcafe,
+				RequestMaxAckDelay:    1337 * time.Millisecond,
+				ReorderingThreshold:   3,
+			}
+			b := &bytes.Buffer{}
+			Expect(frame.Write(b, versionIETFFrames)).To(Succeed())
+			Expect(frame.Length(versionIETFFrames)).To(BeEquivalentTo(b.Len()))
+			Expect(frame.Length(versionIETFFrames)).To(Equal(2 + utils.VarIntLen(0xdecafbad) + utils.VarIntLen(0xcafe) + utils.VarIntLen(1337000) + 1))
+		})
+	})
+})
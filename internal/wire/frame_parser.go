@@ -10,6 +10,8 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/qerr"
 )
 
+var errUnknownFrameType = errors.New("unknown frame type")
+
 type frameParser struct {
 	ackDelayExponent uint8
 
@@ -87,8 +89,14 @@ func (p *frameParser) parseFrame(r *bytes.Reader, typeByte byte, encLevel protoc
 			frame, err = parseConnectionCloseFrame(r, p.version)
 		case 0x1e:
 			frame, err = parseHandshakeDoneFrame(r, p.version)
+		case 0x1f:
+			frame, err = parseImmediateAckFrame(r, p.version)
+		case 0x30, 0x31:
+			frame, err = parseDatagramFrame(r, p.version)
+		case 0x40: // the first byte of a two-byte frame type
+			frame, err = parseAckFrequencyFrame(r, p.version)
 		default:
-			err = errors.New("unknown frame type")
+			err = errUnknownFrameType
 		}
 	}
 	if err != nil {
@@ -79,11 +79,24 @@ var _ = Describe("STREAM frame", func() {
 			Expect(err).To(MatchError("FRAME_ENCODING_ERROR: stream data overflows maximum offset"))
 		})
 
+		It("parses frames that are larger than the default packet size", func() {
+			// Path MTU Discovery allows packets larger than protocol.MaxReceivePacketSize.
+			data := []byte{0x8 ^ 0x2}
+			data = append(data, encodeVarInt(0x12345)...) // stream ID
+			data = append(data, encodeVarInt(3000)...)    // data length
+			data = append(data, bytes.Repeat([]byte{'f'}, 3000)...)
+			r := bytes.NewReader(data)
+			frame, err := parseStreamFrame(r, versionIETFFrames)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame.Data).To(Equal(bytes.Repeat([]byte{'f'}, 3000)))
+			Expect(r.Len()).To(BeZero())
+		})
+
 		It("rejects frames that claim to be longer than the packet size", func() {
 			data := []byte{0x8 ^ 0x2}
-			data = append(data, encodeVarInt(0x12345)...)                                 // stream ID
-			data = append(data, encodeVarInt(uint64(protocol.MaxReceivePacketSize)+1)...) // data length
-			data = append(data, make([]byte, protocol.MaxReceivePacketSize+1)...)
+			data = append(data, encodeVarInt(0x12345)...)                                // stream ID
+			data = append(data, encodeVarInt(uint64(protocol.MaxPacketBufferSize)+1)...) // data length
+			data = append(data, make([]byte, protocol.MaxPacketBufferSize+1)...)
 			r := bytes.NewReader(data)
 			_, err := parseStreamFrame(r, versionIETFFrames)
 			Expect(err).To(Equal(io.EOF))
@@ -86,7 +86,9 @@ var _ = Describe("Transport Parameters", func() {
 			RetrySourceConnectionID:         &protocol.ConnectionID{0xde, 0xad, 0xc0, 0xde},
 			AckDelayExponent:                13,
 			MaxAckDelay:                     42 * time.Millisecond,
+			MinAckDelay:                     1337 * time.Microsecond,
 			ActiveConnectionIDLimit:         getRandomValue(),
+			MaxDatagramFrameSize:            protocol.ByteCount(getRandomValue()),
 		}
 		data := params.Marshal(protocol.PerspectiveServer)
 
@@ -106,7 +108,24 @@ var _ = Describe("Transport Parameters", func() {
 		Expect(p.RetrySourceConnectionID).To(Equal(&protocol.ConnectionID{0xde, 0xad, 0xc0, 0xde}))
 		Expect(p.AckDelayExponent).To(Equal(uint8(13)))
 		Expect(p.MaxAckDelay).To(Equal(42 * time.Millisecond))
+		Expect(p.MinAckDelay).To(Equal(1337 * time.Microsecond))
 		Expect(p.ActiveConnectionIDLimit).To(Equal(params.ActiveConnectionIDLimit))
+		Expect(p.MaxDatagramFrameSize).To(Equal(params.MaxDatagramFrameSize))
+	})
+
+	It("doesn't send the max_datagram_frame_size, if the DATAGRAM extension is not supported", func() {
+		data := (&TransportParameters{
+			MaxAckDelay:         protocol.DefaultMaxAckDelay,
+			StatelessResetToken: &protocol.StatelessResetToken{},
+		}).Marshal(protocol.PerspectiveServer)
+		p := &TransportParameters{}
+		Expect(p.Unmarshal(data, protocol.PerspectiveServer)).To(Succeed())
+		Expect(p.MaxDatagramFrameSize).To(BeZero())
+	})
+
+	It("has a string representation, if the DATAGRAM extension is supported", func() {
+		p := &TransportParameters{MaxDatagramFrameSize: 1200}
+		Expect(p.String()).To(ContainSubstring("MaxDatagramFrameSize: 1200"))
 	})
 
 	It("doesn't marshal a retry_source_connection_id, if no Retry was performed", func() {
@@ -129,6 +148,45 @@ var _ = Describe("Transport Parameters", func() {
 		Expect(p.RetrySourceConnectionID.Len()).To(BeZero())
 	})
 
+	It("marshals and unmarshals the version_information", func() {
+		data := (&TransportParameters{
+			VersionInformation: &VersionInformation{
+				ChosenVersion:     protocol.Version1,
+				AvailableVersions: []protocol.VersionNumber{protocol.Version1, protocol.VersionDraft29},
+			},
+		}).Marshal(protocol.PerspectiveClient)
+		p := &TransportParameters{}
+		Expect(p.Unmarshal(data, protocol.PerspectiveClient)).To(Succeed())
+		Expect(p.VersionInformation).ToNot(BeNil())
+		Expect(p.VersionInformation.ChosenVersion).To(Equal(protocol.Version1))
+		Expect(p.VersionInformation.AvailableVersions).To(Equal([]protocol.VersionNumber{protocol.Version1, protocol.VersionDraft29}))
+	})
+
+	It("doesn't marshal the version_information, if it's not set", func() {
+		data := (&TransportParameters{}).Marshal(protocol.PerspectiveClient)
+		p := &TransportParameters{}
+		Expect(p.Unmarshal(data, protocol.PerspectiveClient)).To(Succeed())
+		Expect(p.VersionInformation).To(BeNil())
+	})
+
+	It("errors when the version_information has the wrong length", func() {
+		b := &bytes.Buffer{}
+		utils.WriteVarInt(b, uint64(versionInformationParameterID))
+		utils.WriteVarInt(b, 6)
+		b.Write([]byte("foobar"))
+		addInitialSourceConnectionID(b)
+		Expect((&TransportParameters{}).Unmarshal(b.Bytes(), protocol.PerspectiveClient)).To(MatchError("TRANSPORT_PARAMETER_ERROR: invalid length for version_information: 6"))
+	})
+
+	It("errors when the chosen version is 0", func() {
+		b := &bytes.Buffer{}
+		utils.WriteVarInt(b, uint64(versionInformationParameterID))
+		utils.WriteVarInt(b, 4)
+		b.Write([]byte{0, 0, 0, 0})
+		addInitialSourceConnectionID(b)
+		Expect((&TransportParameters{}).Unmarshal(b.Bytes(), protocol.PerspectiveClient)).To(MatchError("TRANSPORT_PARAMETER_ERROR: version_information: chosen version must not be 0"))
+	})
+
 	It("errors when the stateless_reset_token has the wrong length", func() {
 		b := &bytes.Buffer{}
 		utils.WriteVarInt(b, uint64(statelessResetTokenParameterID))
@@ -175,6 +233,26 @@ var _ = Describe("Transport Parameters", func() {
 		Expect(p.Unmarshal(data, protocol.PerspectiveServer)).To(MatchError("TRANSPORT_PARAMETER_ERROR: invalid value for max_ack_delay: 16384ms (maximum 16383ms)"))
 	})
 
+	It("doesn't send the min_ack_delay, if the ACK frequency extension is not supported", func() {
+		data := (&TransportParameters{
+			MaxAckDelay:         protocol.DefaultMaxAckDelay,
+			StatelessResetToken: &protocol.StatelessResetToken{},
+		}).Marshal(protocol.PerspectiveServer)
+		p := &TransportParameters{}
+		Expect(p.Unmarshal(data, protocol.PerspectiveServer)).To(Succeed())
+		Expect(p.MinAckDelay).To(BeZero())
+	})
+
+	It("errors when the min_ack_delay is larger than the max_ack_delay", func() {
+		data := (&TransportParameters{
+			MaxAckDelay:         10 * time.Millisecond,
+			MinAckDelay:         11 * time.Millisecond,
+			StatelessResetToken: &protocol.StatelessResetToken{},
+		}).Marshal(protocol.PerspectiveServer)
+		p := &TransportParameters{}
+		Expect(p.Unmarshal(data, protocol.PerspectiveServer)).To(MatchError("TRANSPORT_PARAMETER_ERROR: min_ack_delay (11ms) larger than max_ack_delay (10ms)"))
+	})
+
 	It("doesn't send the max_ack_delay, if it has the default value", func() {
 		const num = 1000
 		var defaultLen, dataLen int
@@ -42,6 +42,12 @@ const (
 	activeConnectionIDLimitParameterID         transportParameterID = 0xe
 	initialSourceConnectionIDParameterID       transportParameterID = 0xf
 	retrySourceConnectionIDParameterID         transportParameterID = 0x10
+	// RFC 9368
+	versionInformationParameterID transportParameterID = 0x11
+	// RFC 9221
+	maxDatagramFrameSizeParameterID transportParameterID = 0x20
+	// https://datatracker.ietf.org/doc/draft-ietf-quic-ack-frequency/
+	minAckDelayParameterID transportParameterID = 0xff04de1b
 )
 
 // PreferredAddress is the value encoding in the preferred_address transport parameter
@@ -54,6 +60,13 @@ type PreferredAddress struct {
 	StatelessResetToken protocol.StatelessResetToken
 }
 
+// VersionInformation is the value encoded in the version_information transport parameter.
+// It is used to authenticate the version negotiation.
+type VersionInformation struct {
+	ChosenVersion     protocol.VersionNumber
+	AvailableVersions []protocol.VersionNumber
+}
+
 // TransportParameters are parameters sent to the peer during the handshake
 type TransportParameters struct {
 	InitialMaxStreamDataBidiLocal  protocol.ByteCount
@@ -63,6 +76,7 @@ type TransportParameters struct {
 
 	MaxAckDelay      time.Duration
 	AckDelayExponent uint8
+	MinAckDelay      time.Duration // 0 if the peer doesn't support the ACK frequency extension
 
 	DisableActiveMigration bool
 
@@ -81,6 +95,10 @@ type TransportParameters struct {
 
 	StatelessResetToken     *protocol.StatelessResetToken
 	ActiveConnectionIDLimit uint64
+
+	VersionInformation *VersionInformation // nil if the peer didn't send the version_information transport parameter
+
+	MaxDatagramFrameSize protocol.ByteCount // 0 if the peer doesn't support the DATAGRAM extension
 }
 
 // Unmarshal the transport parameters
@@ -132,7 +150,9 @@ func (p *TransportParameters) unmarshal(r *bytes.Reader, sentBy protocol.Perspec
 			initialMaxStreamsUniParameterID,
 			maxIdleTimeoutParameterID,
 			maxUDPPayloadSizeParameterID,
-			activeConnectionIDLimitParameterID:
+			activeConnectionIDLimitParameterID,
+			minAckDelayParameterID,
+			maxDatagramFrameSizeParameterID:
 			if err := p.readNumericTransportParameter(r, paramID, int(paramLen)); err != nil {
 				return err
 			}
@@ -178,6 +198,10 @@ func (p *TransportParameters) unmarshal(r *bytes.Reader, sentBy protocol.Perspec
 				}
 				connID, _ := protocol.ReadConnectionID(r, int(paramLen))
 				p.RetrySourceConnectionID = &connID
+			case versionInformationParameterID:
+				if err := p.readVersionInformation(r, int(paramLen)); err != nil {
+					return err
+				}
 			default:
 				r.Seek(int64(paramLen), io.SeekCurrent)
 			}
@@ -200,6 +224,9 @@ func (p *TransportParameters) unmarshal(r *bytes.Reader, sentBy protocol.Perspec
 		if !readInitialSourceConnectionID {
 			return errors.New("missing initial_source_connection_id")
 		}
+		if p.MinAckDelay > p.MaxAckDelay {
+			return fmt.Errorf("min_ack_delay (%s) larger than max_ack_delay (%s)", p.MinAckDelay, p.MaxAckDelay)
+		}
 	}
 
 	// check that every transport parameter was sent at most once
@@ -258,6 +285,23 @@ func (p *TransportParameters) readPreferredAddress(r *bytes.Reader, expectedLen
 	return nil
 }
 
+func (p *TransportParameters) readVersionInformation(r *bytes.Reader, l int) error {
+	if l < 4 || l%4 != 0 {
+		return fmt.Errorf("invalid length for version_information: %d", l)
+	}
+	chosen, _ := utils.BigEndian.ReadUint32(r)
+	if chosen == 0 {
+		return errors.New("version_information: chosen version must not be 0")
+	}
+	vi := &VersionInformation{ChosenVersion: protocol.VersionNumber(chosen)}
+	for i := 4; i < l; i += 4 {
+		v, _ := utils.BigEndian.ReadUint32(r)
+		vi.AvailableVersions = append(vi.AvailableVersions, protocol.VersionNumber(v))
+	}
+	p.VersionInformation = vi
+	return nil
+}
+
 func (p *TransportParameters) readNumericTransportParameter(
 	r *bytes.Reader,
 	paramID transportParameterID,
@@ -307,6 +351,14 @@ func (p *TransportParameters) readNumericTransportParameter(
 		p.MaxAckDelay = maxAckDelay
 	case activeConnectionIDLimitParameterID:
 		p.ActiveConnectionIDLimit = val
+	case minAckDelayParameterID:
+		minAckDelay := time.Duration(val) * time.Microsecond
+		if minAckDelay >= protocol.MaxMaxAckDelay {
+			return fmt.Errorf("invalid value for min_ack_delay: %dus", val)
+		}
+		p.MinAckDelay = minAckDelay
+	case maxDatagramFrameSizeParameterID:
+		p.MaxDatagramFrameSize = protocol.ByteCount(val)
 	default:
 		return fmt.Errorf("TransportParameter BUG: transport parameter %d not found", paramID)
 	}
@@ -340,7 +392,7 @@ func (p *TransportParameters) Marshal(pers protocol.Perspective) []byte {
 	// idle_timeout
 	p.marshalVarintParam(b, maxIdleTimeoutParameterID, uint64(p.MaxIdleTimeout/time.Millisecond))
 	// max_packet_size
-	p.marshalVarintParam(b, maxUDPPayloadSizeParameterID, uint64(protocol.MaxReceivePacketSize))
+	p.marshalVarintParam(b, maxUDPPayloadSizeParameterID, uint64(protocol.MaxPacketBufferSize))
 	// max_ack_delay
 	// Only send it if is different from the default value.
 	if p.MaxAckDelay != protocol.DefaultMaxAckDelay {
@@ -351,6 +403,14 @@ func (p *TransportParameters) Marshal(pers protocol.Perspective) []byte {
 	if p.AckDelayExponent != protocol.DefaultAckDelayExponent {
 		p.marshalVarintParam(b, ackDelayExponentParameterID, uint64(p.AckDelayExponent))
 	}
+	// min_ack_delay
+	if p.MinAckDelay > 0 {
+		p.marshalVarintParam(b, minAckDelayParameterID, uint64(p.MinAckDelay/time.Microsecond))
+	}
+	// max_datagram_frame_size
+	if p.MaxDatagramFrameSize > 0 {
+		p.marshalVarintParam(b, maxDatagramFrameSizeParameterID, uint64(p.MaxDatagramFrameSize))
+	}
 	// disable_active_migration
 	if p.DisableActiveMigration {
 		utils.WriteVarInt(b, uint64(disableActiveMigrationParameterID))
@@ -393,6 +453,15 @@ func (p *TransportParameters) Marshal(pers protocol.Perspective) []byte {
 		utils.WriteVarInt(b, uint64(p.RetrySourceConnectionID.Len()))
 		b.Write(p.RetrySourceConnectionID.Bytes())
 	}
+	// version_information
+	if p.VersionInformation != nil {
+		utils.WriteVarInt(b, uint64(versionInformationParameterID))
+		utils.WriteVarInt(b, 4+4*uint64(len(p.VersionInformation.AvailableVersions)))
+		utils.BigEndian.WriteUint32(b, uint32(p.VersionInformation.ChosenVersion))
+		for _, v := range p.VersionInformation.AvailableVersions {
+			utils.BigEndian.WriteUint32(b, uint32(v))
+		}
+	}
 	return b.Bytes()
 }
 
@@ -465,6 +534,18 @@ func (p *TransportParameters) String() string {
 		logString += ", StatelessResetToken: %#x"
 		logParams = append(logParams, *p.StatelessResetToken)
 	}
+	if p.MinAckDelay > 0 {
+		logString += ", MinAckDelay: %s"
+		logParams = append(logParams, p.MinAckDelay)
+	}
+	if p.MaxDatagramFrameSize > 0 {
+		logString += ", MaxDatagramFrameSize: %d"
+		logParams = append(logParams, p.MaxDatagramFrameSize)
+	}
+	if p.VersionInformation != nil {
+		logString += ", ChosenVersion: %s, AvailableVersions: %s"
+		logParams = append(logParams, p.VersionInformation.ChosenVersion, p.VersionInformation.AvailableVersions)
+	}
 	logString += "}"
 	return fmt.Sprintf(logString, logParams...)
 }
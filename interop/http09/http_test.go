@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 
 	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/testdata"
 
 	. "github.com/onsi/ginkgo"
@@ -67,6 +68,34 @@ var _ = Describe("HTTP 0.9 integration tests", func() {
 		Expect(data).To(Equal([]byte("Hello World!")))
 	})
 
+	It("performs requests over QUIC draft-29", func() {
+		rt := &RoundTripper{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			QuicConfig:      &quic.Config{Versions: []quic.VersionNumber{protocol.VersionDraft29}},
+		}
+		defer rt.Close()
+		req := httptest.NewRequest(
+			http.MethodGet,
+			fmt.Sprintf("https://%s/helloworld", saddr),
+			nil,
+		)
+		rsp, err := rt.RoundTrip(req)
+		Expect(err).ToNot(HaveOccurred())
+		data, err := ioutil.ReadAll(rsp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal([]byte("Hello World!")))
+	})
+
+	It("uses the application protocols of the QUIC versions", func() {
+		Expect(nextProtos(nil)).To(Equal([]string{NextProto, NextProtoDraft29}))
+		Expect(nextProtos(&quic.Config{Versions: []quic.VersionNumber{protocol.VersionDraft29}})).To(Equal([]string{NextProtoDraft29}))
+	})
+
+	It("errors when serving without http.Server", func() {
+		Expect((&Server{}).ListenAndServe()).To(MatchError("use of http09.Server without http.Server"))
+		Expect((&Server{}).ServeListener(nil)).To(MatchError("use of http09.Server without http.Server"))
+	})
+
 	It("allows setting of headers", func() {
 		http.HandleFunc("/headers", func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Add("foo", "bar")
@@ -14,9 +14,34 @@ import (
 	"sync"
 
 	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
 )
 
-const h09alpn = "hq-29"
+const (
+	// NextProto is the application protocol (ALPN) of HTTP/0.9 over QUIC version 1.
+	NextProto = "hq-interop"
+	// NextProtoDraft29 is the application protocol (ALPN) of HTTP/0.9 over QUIC draft-29.
+	NextProtoDraft29 = "hq-29"
+)
+
+// nextProtos returns the HTTP/0.9 application protocols for the QUIC versions of a quic.Config,
+// in the order of preference of the versions.
+func nextProtos(conf *quic.Config) []string {
+	versions := protocol.SupportedVersions
+	if conf != nil && len(conf.Versions) > 0 {
+		versions = conf.Versions
+	}
+	protos := make([]string, 0, len(versions))
+	for _, v := range versions {
+		switch v {
+		case protocol.Version1:
+			protos = append(protos, NextProto)
+		case protocol.VersionDraft29:
+			protos = append(protos, NextProtoDraft29)
+		}
+	}
+	return protos
+}
 
 type responseWriter struct {
 	io.Writer
@@ -55,7 +80,7 @@ func (s *Server) Close() error {
 // ListenAndServe listens and serves HTTP/0.9 over QUIC.
 func (s *Server) ListenAndServe() error {
 	if s.Server == nil {
-		return errors.New("use of http3.Server without http.Server")
+		return errors.New("use of http09.Server without http.Server")
 	}
 
 	udpAddr, err := net.ResolveUDPAddr("udp", s.Addr)
@@ -68,11 +93,21 @@ func (s *Server) ListenAndServe() error {
 	}
 
 	tlsConf := s.TLSConfig.Clone()
-	tlsConf.NextProtos = []string{h09alpn}
+	tlsConf.NextProtos = nextProtos(s.QuicConfig)
 	ln, err := quic.ListenEarly(conn, tlsConf, s.QuicConfig)
 	if err != nil {
 		return err
 	}
+	return s.ServeListener(ln)
+}
+
+// ServeListener serves HTTP/0.9 on the sessions accepted by an existing listener,
+// e.g. the listener of a quic.Route.
+// The listener must negotiate one of the HTTP/0.9 application protocols, see NextProto and NextProtoDraft29.
+func (s *Server) ServeListener(ln quic.EarlyListener) error {
+	if s.Server == nil {
+		return errors.New("use of http09.Server without http.Server")
+	}
 	s.mutex.Lock()
 	s.listener = ln
 	s.mutex.Unlock()
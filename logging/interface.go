@@ -92,6 +92,21 @@ type Tracer interface {
 	DroppedPacket(net.Addr, PacketType, ByteCount, PacketDropReason)
 }
 
+// An AdmissionTracer traces how a server handles connection attempts.
+// A Tracer can optionally implement this interface.
+type AdmissionTracer interface {
+	// HandledConnectionAttempt is called when the server decides how to handle an Initial packet
+	// that would create a new connection, see AdmissionDecision.
+	HandledConnectionAttempt(net.Addr, AdmissionDecision)
+}
+
+// A DrainingTracer traces the draining of a server.
+// A Tracer can optionally implement this interface.
+type DrainingTracer interface {
+	// Draining is called when a server is drained, every time the number of remaining sessions changes.
+	Draining(remainingSessions int)
+}
+
 // A ConnectionTracer records events.
 type ConnectionTracer interface {
 	StartedConnection(local, remote net.Addr, version VersionNumber, srcConnID, destConnID ConnectionID)
@@ -117,3 +132,13 @@ type ConnectionTracer interface {
 	// Close is called when the connection is closed.
 	Close()
 }
+
+// A ReceiveWindowTracer traces the size of the flow control receive windows of a connection.
+// A ConnectionTracer can optionally implement this interface.
+type ReceiveWindowTracer interface {
+	// UpdatedReceiveWindow is called when the size of the connection-level receive window changes,
+	// either due to auto-tuning or due to memory pressure.
+	UpdatedReceiveWindow(size ByteCount)
+	// UpdatedStreamReceiveWindow is called when the size of a stream's receive window changes.
+	UpdatedStreamReceiveWindow(id StreamID, size ByteCount)
+}
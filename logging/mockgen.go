@@ -2,3 +2,6 @@ package logging
 
 //go:generate sh -c "mockgen -package logging -self_package github.com/lucas-clemente/quic-go/logging -destination mock_connection_tracer_test.go github.com/lucas-clemente/quic-go/logging ConnectionTracer && goimports -w mock_connection_tracer_test.go"
 //go:generate sh -c "mockgen -package logging -self_package github.com/lucas-clemente/quic-go/logging -destination mock_tracer_test.go github.com/lucas-clemente/quic-go/logging Tracer && goimports -w mock_tracer_test.go"
+//go:generate sh -c "mockgen -package logging -self_package github.com/lucas-clemente/quic-go/logging -destination mock_admission_tracer_test.go github.com/lucas-clemente/quic-go/logging AdmissionTracer && goimports -w mock_admission_tracer_test.go"
+//go:generate sh -c "mockgen -package logging -self_package github.com/lucas-clemente/quic-go/logging -destination mock_draining_tracer_test.go github.com/lucas-clemente/quic-go/logging DrainingTracer && goimports -w mock_draining_tracer_test.go"
+//go:generate sh -c "mockgen -package logging -self_package github.com/lucas-clemente/quic-go/logging -destination mock_receive_window_tracer_test.go github.com/lucas-clemente/quic-go/logging ReceiveWindowTracer && goimports -w mock_receive_window_tracer_test.go"
@@ -9,7 +9,11 @@ type tracerMultiplexer struct {
 	tracers []Tracer
 }
 
-var _ Tracer = &tracerMultiplexer{}
+var (
+	_ Tracer          = &tracerMultiplexer{}
+	_ AdmissionTracer = &tracerMultiplexer{}
+	_ DrainingTracer  = &tracerMultiplexer{}
+)
 
 // NewMultiplexedTracer creates a new tracer that multiplexes all events to multiple tracers.
 func NewMultiplexedTracer(tracers ...Tracer) Tracer {
@@ -38,17 +42,36 @@ func (m *tracerMultiplexer) SentPacket(remote net.Addr, hdr *Header, size ByteCo
 	}
 }
 
+func (m *tracerMultiplexer) Draining(remainingSessions int) {
+	for _, t := range m.tracers {
+		if dt, ok := t.(DrainingTracer); ok {
+			dt.Draining(remainingSessions)
+		}
+	}
+}
+
 func (m *tracerMultiplexer) DroppedPacket(remote net.Addr, typ PacketType, size ByteCount, reason PacketDropReason) {
 	for _, t := range m.tracers {
 		t.DroppedPacket(remote, typ, size, reason)
 	}
 }
 
+func (m *tracerMultiplexer) HandledConnectionAttempt(remote net.Addr, decision AdmissionDecision) {
+	for _, t := range m.tracers {
+		if at, ok := t.(AdmissionTracer); ok {
+			at.HandledConnectionAttempt(remote, decision)
+		}
+	}
+}
+
 type connTracerMultiplexer struct {
 	tracers []ConnectionTracer
 }
 
-var _ ConnectionTracer = &connTracerMultiplexer{}
+var (
+	_ ConnectionTracer    = &connTracerMultiplexer{}
+	_ ReceiveWindowTracer = &connTracerMultiplexer{}
+)
 
 func newConnectionMultiplexer(tracers ...ConnectionTracer) ConnectionTracer {
 	if len(tracers) == 0 {
@@ -144,6 +167,22 @@ func (m *connTracerMultiplexer) UpdatedPTOCount(value uint32) {
 	}
 }
 
+func (m *connTracerMultiplexer) UpdatedReceiveWindow(size ByteCount) {
+	for _, t := range m.tracers {
+		if rt, ok := t.(ReceiveWindowTracer); ok {
+			rt.UpdatedReceiveWindow(size)
+		}
+	}
+}
+
+func (m *connTracerMultiplexer) UpdatedStreamReceiveWindow(id StreamID, size ByteCount) {
+	for _, t := range m.tracers {
+		if rt, ok := t.(ReceiveWindowTracer); ok {
+			rt.UpdatedStreamReceiveWindow(id, size)
+		}
+	}
+}
+
 func (m *connTracerMultiplexer) UpdatedKeyFromTLS(encLevel EncryptionLevel, perspective Perspective) {
 	for _, t := range m.tracers {
 		t.UpdatedKeyFromTLS(encLevel, perspective)
@@ -81,6 +81,28 @@ var _ = Describe("Tracing", func() {
 				tr2.EXPECT().DroppedPacket(remote, PacketTypeRetry, ByteCount(1024), PacketDropDuplicate)
 				tracer.DroppedPacket(remote, PacketTypeRetry, 1024, PacketDropDuplicate)
 			})
+
+			It("traces the HandledConnectionAttempt event, for tracers that implement the AdmissionTracer", func() {
+				remote := &net.UDPAddr{IP: net.IPv4(4, 3, 2, 1)}
+				at := NewMockAdmissionTracer(mockCtrl)
+				tracer = NewMultiplexedTracer(tr1, &struct {
+					Tracer
+					AdmissionTracer
+				}{tr2, at})
+				Expect(tracer).To(BeAssignableToTypeOf(&tracerMultiplexer{}))
+				at.EXPECT().HandledConnectionAttempt(remote, AdmissionRetry)
+				tracer.(AdmissionTracer).HandledConnectionAttempt(remote, AdmissionRetry)
+			})
+
+			It("traces the Draining event, for tracers that implement the DrainingTracer", func() {
+				dt := NewMockDrainingTracer(mockCtrl)
+				tracer = NewMultiplexedTracer(&struct {
+					Tracer
+					DrainingTracer
+				}{tr1, dt}, tr2)
+				dt.EXPECT().Draining(42)
+				tracer.(DrainingTracer).Draining(42)
+			})
 		})
 	})
 
@@ -195,6 +217,26 @@ var _ = Describe("Tracing", func() {
 			tracer.UpdatedPTOCount(88)
 		})
 
+		It("traces the UpdatedReceiveWindow event, for tracers that implement the ReceiveWindowTracer", func() {
+			rt := NewMockReceiveWindowTracer(mockCtrl)
+			tracer = newConnectionMultiplexer(tr1, &struct {
+				ConnectionTracer
+				ReceiveWindowTracer
+			}{tr2, rt})
+			rt.EXPECT().UpdatedReceiveWindow(ByteCount(1337))
+			tracer.(ReceiveWindowTracer).UpdatedReceiveWindow(1337)
+		})
+
+		It("traces the UpdatedStreamReceiveWindow event, for tracers that implement the ReceiveWindowTracer", func() {
+			rt := NewMockReceiveWindowTracer(mockCtrl)
+			tracer = newConnectionMultiplexer(&struct {
+				ConnectionTracer
+				ReceiveWindowTracer
+			}{tr1, rt}, tr2)
+			rt.EXPECT().UpdatedStreamReceiveWindow(StreamID(4), ByteCount(1337))
+			tracer.(ReceiveWindowTracer).UpdatedStreamReceiveWindow(4, 1337)
+		})
+
 		It("traces the UpdatedKeyFromTLS event", func() {
 			tr1.EXPECT().UpdatedKeyFromTLS(EncryptionHandshake, PerspectiveClient)
 			tr2.EXPECT().UpdatedKeyFromTLS(EncryptionHandshake, PerspectiveClient)
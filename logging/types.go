@@ -60,6 +60,26 @@ const (
 	PacketDropDuplicate
 )
 
+// An AdmissionDecision is the decision the server takes for a connection attempt, i.e. an Initial packet that would create a new connection.
+type AdmissionDecision uint8
+
+const (
+	// AdmissionAccepted is used when a new connection is created
+	AdmissionAccepted AdmissionDecision = iota
+	// AdmissionRetry is used when a Retry packet is sent, requiring the client to validate its address
+	AdmissionRetry
+	// AdmissionInvalidToken is used when the connection attempt is rejected with an INVALID_TOKEN error
+	AdmissionInvalidToken
+	// AdmissionRateLimited is used when the packet is dropped because the source exceeded its rate limit
+	AdmissionRateLimited
+	// AdmissionRefusedHandshakeLimit is used when the connection is refused because too many handshakes are in progress
+	AdmissionRefusedHandshakeLimit
+	// AdmissionRefusedQueueFull is used when the connection is refused because the accept queue is full
+	AdmissionRefusedQueueFull
+	// AdmissionRefusedDraining is used when the connection is refused because the server is draining
+	AdmissionRefusedDraining
+)
+
 // TimerType is the type of the loss detection timer
 type TimerType uint8
 
@@ -21,6 +21,9 @@ var (
 	sentPackets = stats.Int64("quic-go/sent-packets", "number of packets sent", stats.UnitDimensionless)
 	ptos        = stats.Int64("quic-go/ptos", "number of times the PTO timer fired", stats.UnitDimensionless)
 	closes      = stats.Int64("quic-go/close", "number of connections closed", stats.UnitDimensionless)
+	attempts    = stats.Int64("quic-go/connection-attempts", "number of connection attempts handled by the server", stats.UnitDimensionless)
+	keyUpdates  = stats.Int64("quic-go/key-updates", "number of key updates", stats.UnitDimensionless)
+	draining    = stats.Int64("quic-go/draining-sessions", "number of sessions a draining server is waiting for", stats.UnitDimensionless)
 )
 
 // Tags
@@ -34,6 +37,8 @@ var (
 	keyCloseRemote, _      = tag.NewKey("close_remote")
 	keyErrorCode, _        = tag.NewKey("error_code")
 	keyHandshakePhase, _   = tag.NewKey("handshake_phase")
+	keyAdmission, _        = tag.NewKey("admission")
+	keyKeyUpdateRemote, _  = tag.NewKey("key_update_remote")
 )
 
 // Views
@@ -63,6 +68,20 @@ var (
 		TagKeys:     []tag.Key{keyCloseReason, keyErrorCode},
 		Aggregation: view.Count(),
 	}
+	ConnectionAttemptsView = &view.View{
+		Measure:     attempts,
+		TagKeys:     []tag.Key{keyAdmission},
+		Aggregation: view.Count(),
+	}
+	KeyUpdatesView = &view.View{
+		Measure:     keyUpdates,
+		TagKeys:     []tag.Key{keyKeyUpdateRemote},
+		Aggregation: view.Count(),
+	}
+	DrainingSessionsView = &view.View{
+		Measure:     draining,
+		Aggregation: view.LastValue(),
+	}
 )
 
 // DefaultViews collects all OpenCensus views for metric gathering purposes
@@ -71,11 +90,18 @@ var DefaultViews = []*view.View{
 	LostPacketsView,
 	SentPacketsView,
 	CloseView,
+	ConnectionAttemptsView,
+	KeyUpdatesView,
+	DrainingSessionsView,
 }
 
 type tracer struct{}
 
-var _ logging.Tracer = &tracer{}
+var (
+	_ logging.Tracer          = &tracer{}
+	_ logging.AdmissionTracer = &tracer{}
+	_ logging.DrainingTracer  = &tracer{}
+)
 
 // NewTracer creates a new metrics tracer.
 func NewTracer() logging.Tracer { return &tracer{} }
@@ -97,6 +123,20 @@ func (t *tracer) SentPacket(_ net.Addr, hdr *logging.Header, _ protocol.ByteCoun
 func (t *tracer) DroppedPacket(net.Addr, logging.PacketType, logging.ByteCount, logging.PacketDropReason) {
 }
 
+func (t *tracer) HandledConnectionAttempt(_ net.Addr, decision logging.AdmissionDecision) {
+	stats.RecordWithTags(
+		context.Background(),
+		[]tag.Mutator{
+			tag.Upsert(keyAdmission, admissionDecision(decision).String()),
+		},
+		attempts.M(1),
+	)
+}
+
+func (t *tracer) Draining(remainingSessions int) {
+	stats.Record(context.Background(), draining.M(int64(remainingSessions)))
+}
+
 type connTracer struct {
 	perspective logging.Perspective
 	tracer      logging.Tracer
@@ -211,8 +251,14 @@ func (t *connTracer) UpdatedPTOCount(value uint32) {
 		ptos.M(1),
 	)
 }
-func (t *connTracer) UpdatedKeyFromTLS(logging.EncryptionLevel, logging.Perspective)     {}
-func (t *connTracer) UpdatedKey(logging.KeyPhase, bool)                                  {}
+func (t *connTracer) UpdatedKeyFromTLS(logging.EncryptionLevel, logging.Perspective) {}
+func (t *connTracer) UpdatedKey(_ logging.KeyPhase, remote bool) {
+	stats.RecordWithTags(
+		context.Background(),
+		[]tag.Mutator{tag.Upsert(keyKeyUpdateRemote, fmt.Sprintf("%t", remote))},
+		keyUpdates.M(1),
+	)
+}
 func (t *connTracer) DroppedEncryptionLevel(logging.EncryptionLevel)                     {}
 func (t *connTracer) SetLossTimer(logging.TimerType, logging.EncryptionLevel, time.Time) {}
 func (t *connTracer) LossTimerExpired(logging.TimerType, logging.EncryptionLevel)        {}
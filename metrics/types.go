@@ -78,3 +78,26 @@ func (r timeoutReason) String() string {
 		panic("unknown timeout reason")
 	}
 }
+
+type admissionDecision logging.AdmissionDecision
+
+func (d admissionDecision) String() string {
+	switch logging.AdmissionDecision(d) {
+	case logging.AdmissionAccepted:
+		return "accepted"
+	case logging.AdmissionRetry:
+		return "retry"
+	case logging.AdmissionInvalidToken:
+		return "invalid_token"
+	case logging.AdmissionRateLimited:
+		return "rate_limited"
+	case logging.AdmissionRefusedHandshakeLimit:
+		return "refused_handshake_limit"
+	case logging.AdmissionRefusedQueueFull:
+		return "refused_queue_full"
+	case logging.AdmissionRefusedDraining:
+		return "refused_draining"
+	default:
+		panic("unknown admission decision")
+	}
+}
@@ -63,6 +63,20 @@ func (mr *MockReceiveStreamIMockRecorder) Read(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Read", reflect.TypeOf((*MockReceiveStreamI)(nil).Read), arg0)
 }
 
+// ReceivedEarlyData mocks base method
+func (m *MockReceiveStreamI) ReceivedEarlyData() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReceivedEarlyData")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// ReceivedEarlyData indicates an expected call of ReceivedEarlyData
+func (mr *MockReceiveStreamIMockRecorder) ReceivedEarlyData() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceivedEarlyData", reflect.TypeOf((*MockReceiveStreamI)(nil).ReceivedEarlyData))
+}
+
 // SetReadDeadline mocks base method
 func (m *MockReceiveStreamI) SetReadDeadline(arg0 time.Time) error {
 	m.ctrl.T.Helper()
@@ -144,3 +158,15 @@ func (mr *MockReceiveStreamIMockRecorder) handleStreamFrame(arg0 interface{}) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "handleStreamFrame", reflect.TypeOf((*MockReceiveStreamI)(nil).handleStreamFrame), arg0)
 }
+
+// markReceivedEarlyData mocks base method
+func (m *MockReceiveStreamI) markReceivedEarlyData() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "markReceivedEarlyData")
+}
+
+// markReceivedEarlyData indicates an expected call of markReceivedEarlyData
+func (mr *MockReceiveStreamIMockRecorder) markReceivedEarlyData() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "markReceivedEarlyData", reflect.TypeOf((*MockReceiveStreamI)(nil).markReceivedEarlyData))
+}
@@ -48,6 +48,20 @@ func (mr *MockSendConnMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockSendConn)(nil).Close))
 }
 
+// DontFragment mocks base method
+func (m *MockSendConn) DontFragment() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DontFragment")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// DontFragment indicates an expected call of DontFragment
+func (mr *MockSendConnMockRecorder) DontFragment() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DontFragment", reflect.TypeOf((*MockSendConn)(nil).DontFragment))
+}
+
 // LocalAddr mocks base method
 func (m *MockSendConn) LocalAddr() net.Addr {
 	m.ctrl.T.Helper()
@@ -89,3 +103,17 @@ func (mr *MockSendConnMockRecorder) Write(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Write", reflect.TypeOf((*MockSendConn)(nil).Write), arg0)
 }
+
+// WriteBatch mocks base method
+func (m *MockSendConn) WriteBatch(arg0 [][]byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteBatch", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteBatch indicates an expected call of WriteBatch
+func (mr *MockSendConnMockRecorder) WriteBatch(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteBatch", reflect.TypeOf((*MockSendConn)(nil).WriteBatch), arg0)
+}
@@ -136,6 +136,21 @@ func (mr *MockStreamManagerMockRecorder) HandleMaxStreamsFrame(arg0 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleMaxStreamsFrame", reflect.TypeOf((*MockStreamManager)(nil).HandleMaxStreamsFrame), arg0)
 }
 
+// NumStreams mocks base method
+func (m *MockStreamManager) NumStreams() (int, int) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NumStreams")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(int)
+	return ret0, ret1
+}
+
+// NumStreams indicates an expected call of NumStreams
+func (mr *MockStreamManagerMockRecorder) NumStreams() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NumStreams", reflect.TypeOf((*MockStreamManager)(nil).NumStreams))
+}
+
 // OpenStream mocks base method
 func (m *MockStreamManager) OpenStream() (Stream, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,87 @@
+package quic
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/ackhandler"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+// The mtuDiscoverer implements Datagram Packetization Layer Path MTU Discovery (RFC 8899).
+type mtuDiscoverer interface {
+	ShouldSendProbe(now time.Time) bool
+	GetPing() (ping ackhandler.Frame, datagramSize protocol.ByteCount)
+	CurrentSize() protocol.ByteCount
+}
+
+const (
+	// At some point, we have to stop searching for a higher MTU.
+	// We're happy to send a packet that's up to 20 bytes smaller than the actual MTU.
+	maxMTUDiff = 20
+)
+
+type mtuFinder struct {
+	lastProbeTime time.Time
+	probeInFlight bool
+	// number of probe packets of the current probe size that were lost
+	numProbesLost int
+	mtuIncreased  func(protocol.ByteCount)
+
+	rttStats *utils.RTTStats
+	current  protocol.ByteCount
+	max      protocol.ByteCount // the maximum value, as advertised by the peer (or our maximum size buffer)
+}
+
+var _ mtuDiscoverer = &mtuFinder{}
+
+func newMTUDiscoverer(rttStats *utils.RTTStats, start, max protocol.ByteCount, mtuIncreased func(protocol.ByteCount)) *mtuFinder {
+	return &mtuFinder{
+		current:       start,
+		rttStats:      rttStats,
+		lastProbeTime: time.Now(), // to make sure the first probe packet is not sent immediately
+		mtuIncreased:  mtuIncreased,
+		max:           max,
+	}
+}
+
+func (f *mtuFinder) done() bool {
+	return f.max-f.current <= maxMTUDiff+1
+}
+
+func (f *mtuFinder) ShouldSendProbe(now time.Time) bool {
+	if f.probeInFlight || f.done() {
+		return false
+	}
+	return !now.Before(f.lastProbeTime.Add(protocol.MTUProbeDelay * f.rttStats.SmoothedRTT()))
+}
+
+func (f *mtuFinder) GetPing() (ackhandler.Frame, protocol.ByteCount) {
+	size := (f.max + f.current) / 2
+	f.lastProbeTime = time.Now()
+	f.probeInFlight = true
+	return ackhandler.Frame{
+		Frame: &wire.PingFrame{},
+		OnLost: func(wire.Frame) {
+			f.probeInFlight = false
+			f.numProbesLost++
+			// A single lost probe might have been lost due to congestion.
+			// Only conclude that the path doesn't support this size after losing multiple probes.
+			if f.numProbesLost >= protocol.MaxMTUProbes {
+				f.max = size
+				f.numProbesLost = 0
+			}
+		},
+		OnAcked: func(wire.Frame) {
+			f.probeInFlight = false
+			f.numProbesLost = 0
+			f.current = size
+			f.mtuIncreased(size)
+		},
+	}, size
+}
+
+func (f *mtuFinder) CurrentSize() protocol.ByteCount {
+	return f.current
+}
@@ -0,0 +1,88 @@
+package quic
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MTU Discoverer", func() {
+	const (
+		rtt                         = 100 * time.Millisecond
+		startMTU protocol.ByteCount = 1000
+		maxMTU   protocol.ByteCount = 2000
+	)
+
+	var (
+		d             *mtuFinder
+		rttStats      *utils.RTTStats
+		discoveredMTU protocol.ByteCount
+	)
+
+	BeforeEach(func() {
+		rttStats = &utils.RTTStats{}
+		rttStats.SetInitialRTT(rtt)
+		Expect(rttStats.SmoothedRTT()).To(Equal(rtt))
+		d = newMTUDiscoverer(rttStats, startMTU, maxMTU, func(s protocol.ByteCount) { discoveredMTU = s })
+	})
+
+	It("only allows a probe 5 RTTs after the handshake completes", func() {
+		now := time.Now()
+		Expect(d.ShouldSendProbe(now)).To(BeFalse())
+		Expect(d.ShouldSendProbe(now.Add(rtt * 9 / 2))).To(BeFalse())
+		Expect(d.ShouldSendProbe(now.Add(rtt * 5))).To(BeTrue())
+	})
+
+	It("doesn't allow a probe if another probe is still in flight", func() {
+		ping, _ := d.GetPing()
+		Expect(d.ShouldSendProbe(time.Now().Add(10 * rtt))).To(BeFalse())
+		ping.OnLost(ping.Frame)
+		Expect(d.ShouldSendProbe(time.Now().Add(10 * rtt))).To(BeTrue())
+	})
+
+	It("tries a lower size when a probe is lost repeatedly", func() {
+		_, size := d.GetPing()
+		Expect(size).To(Equal(protocol.ByteCount(1500)))
+		for i := 0; i < protocol.MaxMTUProbes; i++ {
+			ping, s := d.GetPing()
+			Expect(s).To(Equal(size))
+			ping.OnLost(ping.Frame)
+		}
+		_, size = d.GetPing()
+		Expect(size).To(Equal(protocol.ByteCount(1250)))
+	})
+
+	It("doesn't lower the size after a single lost probe", func() {
+		ping, size := d.GetPing()
+		ping.OnLost(ping.Frame)
+		_, s := d.GetPing()
+		Expect(s).To(Equal(size))
+	})
+
+	It("tries a higher size and calls the callback when a probe is acknowledged", func() {
+		ping, size := d.GetPing()
+		Expect(size).To(Equal(protocol.ByteCount(1500)))
+		ping.OnAcked(ping.Frame)
+		Expect(d.CurrentSize()).To(Equal(protocol.ByteCount(1500)))
+		Expect(discoveredMTU).To(Equal(protocol.ByteCount(1500)))
+		_, size = d.GetPing()
+		Expect(size).To(Equal(protocol.ByteCount(1750)))
+	})
+
+	It("stops discovery after getting close enough to the MTU", func() {
+		var sizes []protocol.ByteCount
+		t := time.Now().Add(5 * rtt)
+		for d.ShouldSendProbe(t) {
+			ping, size := d.GetPing()
+			ping.OnAcked(ping.Frame)
+			sizes = append(sizes, size)
+			t = t.Add(5 * rtt)
+		}
+		Expect(sizes).To(Equal([]protocol.ByteCount{1500, 1750, 1875, 1937, 1968, 1984}))
+		Expect(d.CurrentSize()).To(BeNumerically(">=", maxMTU-maxMTUDiff-1))
+	})
+})
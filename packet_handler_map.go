@@ -14,6 +14,8 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/internal/wire"
 	"github.com/lucas-clemente/quic-go/logging"
+
+	"golang.org/x/net/ipv4"
 )
 
 type statelessResetErr struct {
@@ -46,6 +48,7 @@ type packetHandlerMap struct {
 	statelessResetEnabled bool
 	statelessResetMutex   sync.Mutex
 	statelessResetHasher  hash.Hash
+	statelessResetLimiter *statelessResetLimiter
 
 	tracer logging.Tracer
 	logger utils.Logger
@@ -69,6 +72,7 @@ func newPacketHandlerMap(
 		deleteRetiredSessionsAfter: protocol.RetiredConnectionIDDeleteTimeout,
 		statelessResetEnabled:      len(statelessResetKey) > 0,
 		statelessResetHasher:       hmac.New(sha256.New, statelessResetKey),
+		statelessResetLimiter:      newStatelessResetLimiter(),
 		tracer:                     tracer,
 		logger:                     logger,
 	}
@@ -252,10 +256,14 @@ func (h *packetHandlerMap) close(e error) error {
 
 func (h *packetHandlerMap) listen() {
 	defer close(h.listening)
+	if bc := newBatchConn(h.conn); bc != nil {
+		h.listenBatch(bc)
+		return
+	}
 	for {
 		buffer := getPacketBuffer()
-		data := buffer.Data[:protocol.MaxReceivePacketSize]
-		// The packet size should not exceed protocol.MaxReceivePacketSize bytes
+		data := buffer.Data[:protocol.MaxPacketBufferSize]
+		// The packet size should not exceed protocol.MaxPacketBufferSize bytes
 		// If it does, we only read a truncated packet, which will then end up undecryptable
 		n, addr, err := h.conn.ReadFrom(data)
 		if err != nil {
@@ -266,6 +274,36 @@ func (h *packetHandlerMap) listen() {
 	}
 }
 
+// listenBatch reads up to protocol.MaxBatchSize packets using a single syscall.
+func (h *packetHandlerMap) listenBatch(conn *batchConn) {
+	buffers := make([]*packetBuffer, protocol.MaxBatchSize)
+	msgs := make([]ipv4.Message, protocol.MaxBatchSize)
+	for i := range msgs {
+		buffers[i] = getPacketBuffer()
+		msgs[i].Buffers = [][]byte{buffers[i].Data[:protocol.MaxPacketBufferSize]}
+	}
+	defer func() {
+		for _, b := range buffers {
+			b.Release()
+		}
+	}()
+
+	for {
+		n, err := conn.ReadBatch(msgs)
+		if err != nil {
+			h.close(err)
+			return
+		}
+		for i := 0; i < n; i++ {
+			msg := &msgs[i]
+			h.handlePacket(msg.Addr, buffers[i], msg.Buffers[0][:msg.N])
+			// The buffer now belongs to the session (or was released). Get a new one.
+			buffers[i] = getPacketBuffer()
+			msg.Buffers[0] = buffers[i].Data[:protocol.MaxPacketBufferSize]
+		}
+	}
+}
+
 func (h *packetHandlerMap) handlePacket(
 	addr net.Addr,
 	buffer *packetBuffer,
@@ -331,6 +369,10 @@ func (h *packetHandlerMap) maybeHandleStatelessReset(data []byte) bool {
 	return false
 }
 
+// GetStatelessResetToken returns the stateless reset token for a connection ID.
+// If a stateless reset key is configured, the token only depends on the key and the connection ID.
+// Every endpoint using the same key, e.g. every server in a fleet or a restarted server,
+// is therefore able to reset connections it doesn't have any state for.
 func (h *packetHandlerMap) GetStatelessResetToken(connID protocol.ConnectionID) protocol.StatelessResetToken {
 	var token protocol.StatelessResetToken
 	if !h.statelessResetEnabled {
@@ -358,6 +400,10 @@ func (h *packetHandlerMap) maybeSendStatelessReset(p *receivedPacket, connID pro
 	if len(p.data) <= protocol.MinStatelessResetSize {
 		return
 	}
+	if !h.statelessResetLimiter.Allow(p.remoteAddr, p.rcvTime) {
+		h.logger.Debugf("Not sending stateless reset to %s (connection ID: %s), since the rate limit was reached.", p.remoteAddr, connID)
+		return
+	}
 	token := h.GetStatelessResetToken(connID)
 	h.logger.Debugf("Sending stateless reset to %s (connection ID: %s). Token: %#x", p.remoteAddr, connID, token)
 	data := make([]byte, protocol.MinStatelessResetSize-16, protocol.MinStatelessResetSize)
@@ -368,3 +414,45 @@ func (h *packetHandlerMap) maybeSendStatelessReset(p *receivedPacket, connID pro
 		h.logger.Debugf("Error sending Stateless Reset: %s", err)
 	}
 }
+
+// statelessResetLimiter limits the rate of stateless resets we send, both in total and per source address.
+// Stateless resets are sent in response to packets for unknown connection IDs,
+// so without a limit, spoofed packets could be used to make us flood a victim.
+type statelessResetLimiter struct {
+	mutex sync.Mutex
+	total tokenBucket
+
+	rate      float64
+	burst     float64
+	perSource *sourceRateLimiter
+}
+
+func newStatelessResetLimiter() *statelessResetLimiter {
+	return &statelessResetLimiter{
+		rate:  protocol.MaxStatelessResetRate,
+		burst: protocol.MaxStatelessResetRate,
+		total: tokenBucket{tokens: protocol.MaxStatelessResetRate},
+		perSource: newSourceRateLimiterWithPrefixes(
+			protocol.MaxStatelessResetRatePerSource,
+			protocol.MaxStatelessResetRatePerSource,
+			defaultAdmissionIPv4PrefixLen,
+			defaultAdmissionIPv6PrefixLen,
+		),
+	}
+}
+
+// Allow says if a stateless reset may be sent to addr.
+func (l *statelessResetLimiter) Allow(addr net.Addr, now time.Time) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.total.refill(now, l.rate, l.burst)
+	if l.total.tokens < 1 {
+		return false
+	}
+	if !l.perSource.Allow(addr, now) {
+		return false
+	}
+	l.total.tokens--
+	return true
+}
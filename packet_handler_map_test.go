@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/rand"
 	"errors"
+	"fmt"
 	"net"
 	"time"
 
@@ -19,6 +20,37 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+var _ = Describe("Stateless Reset Limiter", func() {
+	var now time.Time
+
+	BeforeEach(func() {
+		now = time.Now()
+	})
+
+	udpAddr := func(ip string) net.Addr {
+		return &net.UDPAddr{IP: net.ParseIP(ip), Port: 1337}
+	}
+
+	It("limits the rate per source address", func() {
+		l := newStatelessResetLimiter()
+		for i := 0; i < protocol.MaxStatelessResetRatePerSource; i++ {
+			Expect(l.Allow(udpAddr("192.168.0.1"), now)).To(BeTrue())
+		}
+		Expect(l.Allow(udpAddr("192.168.0.1"), now)).To(BeFalse())
+		Expect(l.Allow(udpAddr("192.168.0.2"), now)).To(BeTrue())
+		Expect(l.Allow(udpAddr("192.168.0.1"), now.Add(time.Second))).To(BeTrue())
+	})
+
+	It("limits the total rate", func() {
+		l := newStatelessResetLimiter()
+		for i := 0; i < protocol.MaxStatelessResetRate; i++ {
+			Expect(l.Allow(udpAddr(fmt.Sprintf("10.0.%d.%d", i/256, i%256)), now)).To(BeTrue())
+		}
+		Expect(l.Allow(udpAddr("192.168.0.1"), now)).To(BeFalse())
+		Expect(l.Allow(udpAddr("192.168.0.1"), now.Add(10*time.Millisecond))).To(BeTrue())
+	})
+})
+
 var _ = Describe("Packet Handler Map", func() {
 	var (
 		handler *packetHandlerMap
@@ -340,6 +372,27 @@ var _ = Describe("Packet Handler Map", func() {
 				Expect(reset.data).To(HaveLen(protocol.MinStatelessResetSize))
 			})
 
+			It("generates the same stateless reset tokens when using the same key", func() {
+				connID := []byte{0xde, 0xad, 0xbe, 0xef}
+				h := newPacketHandlerMap(newMockPacketConn(), connIDLen, statelessResetKey, tracer, utils.DefaultLogger).(*packetHandlerMap)
+				defer h.Destroy()
+				Expect(h.GetStatelessResetToken(connID)).To(Equal(handler.GetStatelessResetToken(connID)))
+			})
+
+			It("rate limits stateless resets", func() {
+				addr := &net.UDPAddr{IP: net.IPv4(192, 168, 0, 1), Port: 1337}
+				for i := 0; i < protocol.MaxStatelessResetRatePerSource; i++ {
+					handler.handlePacket(addr, getPacketBuffer(), append([]byte{40}, make([]byte, 100)...))
+					Eventually(conn.dataWritten).Should(Receive())
+				}
+				handler.handlePacket(addr, getPacketBuffer(), append([]byte{40}, make([]byte, 100)...))
+				Consistently(conn.dataWritten, 50*time.Millisecond).ShouldNot(Receive())
+				// stateless resets are still sent to other addresses
+				otherAddr := &net.UDPAddr{IP: net.IPv4(192, 168, 0, 2), Port: 1337}
+				handler.handlePacket(otherAddr, getPacketBuffer(), append([]byte{40}, make([]byte, 100)...))
+				Eventually(conn.dataWritten).Should(Receive())
+			})
+
 			It("doesn't send stateless resets for small packets", func() {
 				addr := &net.UDPAddr{IP: net.IPv4(192, 168, 0, 1), Port: 1337}
 				p := append([]byte{40}, make([]byte, protocol.MinStatelessResetSize-2)...)
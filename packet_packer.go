@@ -26,9 +26,12 @@ type packer interface {
 	MaybePackProbePacket(protocol.EncryptionLevel) (*packedPacket, error)
 	MaybePackAckPacket(handshakeConfirmed bool) (*packedPacket, error)
 	PackConnectionClose(*qerr.QuicError) (*coalescedPacket, error)
+	PackMTUProbePacket(ping ackhandler.Frame, size protocol.ByteCount) (*packedPacket, error)
 
 	HandleTransportParameters(*wire.TransportParameters)
 	SetToken([]byte)
+	SetMaxPacketSize(protocol.ByteCount)
+	SetVersion(protocol.VersionNumber)
 	// rQUIC {
 	SetFecEncoder(*encoder)
 	CodingEnabled()
@@ -57,6 +60,8 @@ type packetContents struct {
 	frames []ackhandler.Frame
 
 	length protocol.ByteCount
+
+	isMTUProbePacket bool
 }
 
 type coalescedPacket struct {
@@ -105,12 +110,13 @@ func (p *packetContents) ToAckHandlerPacket(now time.Time, q *retransmissionQueu
 		}
 	}
 	return &ackhandler.Packet{
-		PacketNumber:    p.header.PacketNumber,
-		LargestAcked:    largestAcked,
-		Frames:          p.frames,
-		Length:          p.length,
-		EncryptionLevel: encLevel,
-		SendTime:        now,
+		PacketNumber:         p.header.PacketNumber,
+		LargestAcked:         largestAcked,
+		Frames:               p.frames,
+		Length:               p.length,
+		EncryptionLevel:      encLevel,
+		SendTime:             now,
+		IsPathMTUProbePacket: p.isMTUProbePacket,
 	}
 }
 
@@ -169,6 +175,7 @@ type packetPacker struct {
 	pnManager           packetNumberManager
 	framer              frameSource
 	acks                ackFrameSource
+	datagramQueue       *datagramQueue
 	retransmissionQueue *retransmissionQueue
 
 	maxPacketSize          protocol.ByteCount
@@ -193,6 +200,7 @@ func newPacketPacker(
 	cryptoSetup sealingManager,
 	framer frameSource,
 	acks ackFrameSource,
+	datagramQueue *datagramQueue,
 	perspective protocol.Perspective,
 	version protocol.VersionNumber,
 ) *packetPacker {
@@ -207,6 +215,7 @@ func newPacketPacker(
 		version:             version,
 		framer:              framer,
 		acks:                acks,
+		datagramQueue:       datagramQueue,
 		pnManager:           packetNumberManager,
 		maxPacketSize:       getMaxPacketSize(remoteAddr),
 	}
@@ -293,7 +302,7 @@ func (p *packetPacker) PackConnectionClose(quicErr *qerr.QuicError) (*coalescedP
 		} else {
 			hdr = p.getLongHeader(encLevel)
 		}
-		c, err := p.appendPacket(buffer, hdr, payload, encLevel, sealer)
+		c, err := p.appendPacket(buffer, hdr, payload, 0, encLevel, sealer, false)
 		if err != nil {
 			return nil, err
 		}
@@ -505,7 +514,7 @@ func (p *packetPacker) maybeAppendCryptoPacket(buffer *packetBuffer, maxPacketSi
 		payload.frames = []ackhandler.Frame{{Frame: cf}}
 		payload.length += cf.Length(p.version)
 	}
-	return p.appendPacket(buffer, hdr, payload, encLevel, sealer)
+	return p.appendPacket(buffer, hdr, payload, 0, encLevel, sealer, false)
 }
 
 func (p *packetPacker) maybeAppendAppDataPacket(buffer *packetBuffer, maxPacketSize protocol.ByteCount) (*packetContents, error) {
@@ -563,11 +572,29 @@ func (p *packetPacker) maybeAppendAppDataPacket(buffer *packetBuffer, maxPacketS
 		p.numNonAckElicitingAcks = 0
 	}
 
-	return p.appendPacket(buffer, header, payload, encLevel, sealer)
+	return p.appendPacket(buffer, header, payload, 0, encLevel, sealer, false)
 }
 
 func (p *packetPacker) composeNextPacket(maxFrameSize protocol.ByteCount, ackAllowed bool) payload {
 	var payload payload
+	var hasDatagram bool
+	if p.datagramQueue != nil {
+		if datagram := p.datagramQueue.Get(); datagram != nil {
+			// The size of a DATAGRAM frame is only limited by the max_datagram_frame_size.
+			// It might not fit into a packet, in that case we drop it.
+			if datagram.Length(p.version) <= maxFrameSize {
+				payload.frames = append(payload.frames, ackhandler.Frame{
+					Frame: datagram,
+					// DATAGRAM frames are not retransmitted.
+					// Set a no-op callback, such that ToAckHandlerPacket doesn't set the default callback.
+					OnLost: func(wire.Frame) {},
+				})
+				payload.length += datagram.Length(p.version)
+				hasDatagram = true
+			}
+		}
+	}
+
 	var ack *wire.AckFrame
 	hasData := p.framer.HasData()
 	hasRetransmission := p.retransmissionQueue.HasAppData()
@@ -579,7 +606,7 @@ func (p *packetPacker) composeNextPacket(maxFrameSize protocol.ByteCount, ackAll
 		}
 	}
 
-	if ack == nil && !hasData && !hasRetransmission {
+	if ack == nil && !hasData && !hasRetransmission && !hasDatagram {
 		return payload
 	}
 
@@ -635,6 +662,39 @@ func (p *packetPacker) MaybePackProbePacket(encLevel protocol.EncryptionLevel) (
 	}, nil
 }
 
+// PackMTUProbePacket packs a Path MTU Discovery probe packet.
+// The packet only contains the PING frame and is padded to the requested size.
+func (p *packetPacker) PackMTUProbePacket(ping ackhandler.Frame, size protocol.ByteCount) (*packedPacket, error) {
+	payload := payload{
+		frames: []ackhandler.Frame{ping},
+		length: ping.Length(p.version),
+	}
+	sealer, err := p.cryptoSetup.Get1RTTSealer()
+	if err != nil {
+		return nil, err
+	}
+	buffer := getPacketBuffer()
+	header := p.getShortHeader(sealer.KeyPhase())
+	padding := size - header.GetLength(p.version) - protocol.ByteCount(sealer.Overhead()) - payload.length
+	// rQUIC {
+	// Probe packets are never protected by coded packets.
+	// Otherwise, coded packets would exceed the currently validated packet size.
+	if p.coding {
+		padding -= protocol.ByteCount(rquic.FieldSizeType)
+	}
+	// } rQUIC
+	contents, err := p.appendPacket(buffer, header, payload, padding, protocol.Encryption1RTT, sealer, true)
+	if err != nil {
+		return nil, err
+	}
+	contents.isMTUProbePacket = true
+	buffer.isMTUProbePacket = true
+	return &packedPacket{
+		buffer:         buffer,
+		packetContents: contents,
+	}, nil
+}
+
 func (p *packetPacker) getSealerAndHeader(encLevel protocol.EncryptionLevel) (sealer, *wire.ExtendedHeader, error) {
 	switch encLevel {
 	case protocol.EncryptionInitial:
@@ -716,7 +776,7 @@ func (p *packetPacker) writeSinglePacket(
 	sealer sealer,
 ) (*packedPacket, error) {
 	buffer := getPacketBuffer()
-	contents, err := p.appendPacket(buffer, header, payload, encLevel, sealer)
+	contents, err := p.appendPacket(buffer, header, payload, 0, encLevel, sealer, false)
 	if err != nil {
 		return nil, err
 	}
@@ -730,14 +790,17 @@ func (p *packetPacker) appendPacket(
 	buffer *packetBuffer,
 	header *wire.ExtendedHeader,
 	payload payload,
+	padding protocol.ByteCount,
 	encLevel protocol.EncryptionLevel,
 	sealer sealer,
+	isMTUProbePacket bool,
 ) (*packetContents, error) {
 	var paddingLen protocol.ByteCount
 	pnLen := protocol.ByteCount(header.PacketNumberLen)
 	if payload.length < 4-pnLen {
 		paddingLen = 4 - pnLen - payload.length
 	}
+	paddingLen += padding
 	if header.IsLongHeader {
 		header.Length = pnLen + protocol.ByteCount(sealer.Overhead()) + payload.length + paddingLen
 	}
@@ -747,7 +810,8 @@ func (p *packetPacker) appendPacket(
 	// rQUIC {
 	var rquicOv int
 	if p.coding && encLevel == protocol.Encryption1RTT {
-		if p.codingActive && ackhandler.HasAckElicitingFrames(payload.frames) {
+		protect := !isMTUProbePacket && ackhandler.HasAckElicitingFrames(payload.frames)
+		if p.codingActive && protect {
 			rquicOv = rquic.SrcHeaderSize
 		} else {
 			rquicOv = rquic.FieldSizeType
@@ -759,7 +823,7 @@ func (p *packetPacker) appendPacket(
 			p.encoder.process(
 				buffer.Data[hdrOffset:],
 				header.DestConnectionID.Bytes(),
-				ackhandler.HasAckElicitingFrames(payload.frames),
+				protect,
 			)
 		}()
 	}
@@ -786,8 +850,10 @@ func (p *packetPacker) appendPacket(
 	if payloadSize := protocol.ByteCount(buf.Len()-payloadOffset) - paddingLen; payloadSize != payload.length {
 		return nil, fmt.Errorf("PacketPacker BUG: payload size inconsistent (expected %d, got %d bytes)", payload.length, payloadSize)
 	}
-	if size := protocol.ByteCount(buf.Len() + sealer.Overhead()); size > p.maxPacketSize {
-		return nil, fmt.Errorf("PacketPacker BUG: packet too large (%d bytes, allowed %d bytes)", size, p.maxPacketSize)
+	if !isMTUProbePacket {
+		if size := protocol.ByteCount(buf.Len() + sealer.Overhead()); size > p.maxPacketSize {
+			return nil, fmt.Errorf("PacketPacker BUG: packet too large (%d bytes, allowed %d bytes)", size, p.maxPacketSize)
+		}
 	}
 
 	raw := buffer.Data
@@ -820,6 +886,18 @@ func (p *packetPacker) SetToken(token []byte) {
 	p.token = token
 }
 
+// SetVersion sets the version used in long header packets,
+// after the version was changed using compatible version negotiation.
+func (p *packetPacker) SetVersion(v protocol.VersionNumber) {
+	p.version = v
+}
+
+// SetMaxPacketSize sets the maximum packet size, after Path MTU Discovery found a larger MTU.
+// The size of rQUIC packets follows, since the rQUIC overhead is subtracted from this size.
+func (p *packetPacker) SetMaxPacketSize(s protocol.ByteCount) {
+	p.maxPacketSize = s
+}
+
 func (p *packetPacker) HandleTransportParameters(params *wire.TransportParameters) {
 	if params.MaxUDPPayloadSize != 0 {
 		p.maxPacketSize = utils.MinByteCount(p.maxPacketSize, params.MaxUDPPayloadSize)
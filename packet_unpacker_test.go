@@ -69,7 +69,7 @@ var _ = Describe("Packet Unpacker", func() {
 		}
 		hdr, hdrRaw := getHeader(extHdr)
 		opener := mocks.NewMockLongHeaderOpener(mockCtrl)
-		cs.EXPECT().GetInitialOpener().Return(opener, nil)
+		cs.EXPECT().GetInitialOpener(hdr.Version).Return(opener, nil)
 		opener.EXPECT().DecryptHeader(gomock.Any(), gomock.Any(), gomock.Any())
 		opener.EXPECT().Open(gomock.Any(), payload, extHdr.PacketNumber, hdrRaw).Return([]byte("decrypted"), nil)
 		packet, err := unpacker.Unpack(hdr, time.Now(), append(hdrRaw, payload...))
@@ -277,6 +277,22 @@ func (e eventUpdatedPTO) MarshalJSONObject(enc *gojay.Encoder) {
 	enc.Uint32Key("pto_count", e.Value)
 }
 
+type eventReceiveWindowUpdated struct {
+	StreamID *protocol.StreamID // nil for the connection-level window
+	Size     protocol.ByteCount
+}
+
+func (e eventReceiveWindowUpdated) Category() category { return categoryTransport }
+func (e eventReceiveWindowUpdated) Name() string       { return "receive_window_updated" }
+func (e eventReceiveWindowUpdated) IsNil() bool        { return false }
+
+func (e eventReceiveWindowUpdated) MarshalJSONObject(enc *gojay.Encoder) {
+	if e.StreamID != nil {
+		enc.Int64Key("stream_id", int64(*e.StreamID))
+	}
+	enc.Int64Key("window_size", int64(e.Size))
+}
+
 type eventPacketLost struct {
 	PacketType   packetType
 	PacketNumber protocol.PacketNumber
@@ -338,7 +354,9 @@ type eventTransportParameters struct {
 	MaxUDPPayloadSize       protocol.ByteCount
 	AckDelayExponent        uint8
 	MaxAckDelay             time.Duration
+	MinAckDelay             time.Duration
 	ActiveConnectionIDLimit uint64
+	MaxDatagramFrameSize    protocol.ByteCount
 
 	InitialMaxData                 protocol.ByteCount
 	InitialMaxStreamDataBidiLocal  protocol.ByteCount
@@ -371,7 +389,9 @@ func (e eventTransportParameters) MarshalJSONObject(enc *gojay.Encoder) {
 	enc.Uint64KeyNullEmpty("max_udp_payload_size", uint64(e.MaxUDPPayloadSize))
 	enc.Uint8KeyOmitEmpty("ack_delay_exponent", e.AckDelayExponent)
 	enc.FloatKeyOmitEmpty("max_ack_delay", milliseconds(e.MaxAckDelay))
+	enc.FloatKeyOmitEmpty("min_ack_delay", milliseconds(e.MinAckDelay))
 	enc.Uint64KeyOmitEmpty("active_connection_id_limit", e.ActiveConnectionIDLimit)
+	enc.Int64KeyOmitEmpty("max_datagram_frame_size", int64(e.MaxDatagramFrameSize))
 
 	enc.Int64KeyOmitEmpty("initial_max_data", int64(e.InitialMaxData))
 	enc.Int64KeyOmitEmpty("initial_max_stream_data_bidi_local", int64(e.InitialMaxStreamDataBidiLocal))
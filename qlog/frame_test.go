@@ -347,4 +347,41 @@ var _ = Describe("Frames", func() {
 			},
 		)
 	})
+
+	It("marshals ACK_FREQUENCY frames", func() {
+		check(
+			&logging.AckFrequencyFrame{
+				SequenceNumber:        42,
+				AckElicitingThreshold: 10,
+				RequestMaxAckDelay:    1500 * time.Microsecond,
+				ReorderingThreshold:   3,
+			},
+			map[string]interface{}{
+				"frame_type":              "ack_frequency",
+				"sequence_number":         42,
+				"ack_eliciting_threshold": 10,
+				"request_max_ack_delay":   1.5,
+				"reordering_threshold":    3,
+			},
+		)
+	})
+
+	It("marshals IMMEDIATE_ACK frames", func() {
+		check(
+			&logging.ImmediateAckFrame{},
+			map[string]interface{}{
+				"frame_type": "immediate_ack",
+			},
+		)
+	})
+
+	It("marshals DATAGRAM frames", func() {
+		check(
+			&logging.DatagramFrame{Length: 1337},
+			map[string]interface{}{
+				"frame_type": "datagram",
+				"length":     1337,
+			},
+		)
+	})
 })
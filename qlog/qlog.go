@@ -58,7 +58,10 @@ type connectionTracer struct {
 	lastMetrics *metrics
 }
 
-var _ logging.ConnectionTracer = &connectionTracer{}
+var (
+	_ logging.ConnectionTracer    = &connectionTracer{}
+	_ logging.ReceiveWindowTracer = &connectionTracer{}
+)
 
 // newTracer creates a new connectionTracer to record a qlog.
 func newConnectionTracer(w io.WriteCloser, p protocol.Perspective, odcid protocol.ConnectionID) logging.ConnectionTracer {
@@ -200,7 +203,9 @@ func (t *connectionTracer) recordTransportParameters(sentBy protocol.Perspective
 		MaxUDPPayloadSize:               tp.MaxUDPPayloadSize,
 		AckDelayExponent:                tp.AckDelayExponent,
 		MaxAckDelay:                     tp.MaxAckDelay,
+		MinAckDelay:                     tp.MinAckDelay,
 		ActiveConnectionIDLimit:         tp.ActiveConnectionIDLimit,
+		MaxDatagramFrameSize:            tp.MaxDatagramFrameSize,
 		InitialMaxData:                  tp.InitialMaxData,
 		InitialMaxStreamDataBidiLocal:   tp.InitialMaxStreamDataBidiLocal,
 		InitialMaxStreamDataBidiRemote:  tp.InitialMaxStreamDataBidiRemote,
@@ -328,6 +333,18 @@ func (t *connectionTracer) UpdatedPTOCount(value uint32) {
 	t.mutex.Unlock()
 }
 
+func (t *connectionTracer) UpdatedReceiveWindow(size protocol.ByteCount) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventReceiveWindowUpdated{Size: size})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) UpdatedStreamReceiveWindow(id protocol.StreamID, size protocol.ByteCount) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventReceiveWindowUpdated{StreamID: &id, Size: size})
+	t.mutex.Unlock()
+}
+
 func (t *connectionTracer) UpdatedKeyFromTLS(encLevel protocol.EncryptionLevel, pers protocol.Perspective) {
 	t.mutex.Lock()
 	t.recordEvent(time.Now(), &eventKeyUpdated{
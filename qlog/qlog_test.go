@@ -202,6 +202,7 @@ var _ = Describe("Tracing", func() {
 					MaxBidiStreamNum:                10,
 					MaxUniStreamNum:                 20,
 					MaxAckDelay:                     123 * time.Millisecond,
+					MinAckDelay:                     1500 * time.Microsecond,
 					AckDelayExponent:                12,
 					DisableActiveMigration:          true,
 					MaxUDPPayloadSize:               1234,
@@ -211,6 +212,7 @@ var _ = Describe("Tracing", func() {
 					InitialSourceConnectionID:       protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef},
 					RetrySourceConnectionID:         &protocol.ConnectionID{0xde, 0xca, 0xfb, 0xad},
 					ActiveConnectionIDLimit:         7,
+					MaxDatagramFrameSize:            1337,
 				})
 				entry := exportAndParseSingle()
 				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
@@ -225,7 +227,9 @@ var _ = Describe("Tracing", func() {
 				Expect(ev).To(HaveKeyWithValue("max_idle_timeout", float64(321)))
 				Expect(ev).To(HaveKeyWithValue("max_udp_payload_size", float64(1234)))
 				Expect(ev).To(HaveKeyWithValue("ack_delay_exponent", float64(12)))
+				Expect(ev).To(HaveKeyWithValue("min_ack_delay", 1.5))
 				Expect(ev).To(HaveKeyWithValue("active_connection_id_limit", float64(7)))
+				Expect(ev).To(HaveKeyWithValue("max_datagram_frame_size", float64(1337)))
 				Expect(ev).To(HaveKeyWithValue("initial_max_data", float64(4000)))
 				Expect(ev).To(HaveKeyWithValue("initial_max_stream_data_bidi_local", float64(1000)))
 				Expect(ev).To(HaveKeyWithValue("initial_max_stream_data_bidi_remote", float64(2000)))
@@ -546,6 +550,25 @@ var _ = Describe("Tracing", func() {
 				Expect(entry.Event).To(HaveKeyWithValue("pto_count", float64(42)))
 			})
 
+			It("records updates of the connection-level receive window", func() {
+				tracer.(logging.ReceiveWindowTracer).UpdatedReceiveWindow(1337)
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Category).To(Equal("transport"))
+				Expect(entry.Name).To(Equal("receive_window_updated"))
+				Expect(entry.Event).To(HaveKeyWithValue("window_size", float64(1337)))
+				Expect(entry.Event).ToNot(HaveKey("stream_id"))
+			})
+
+			It("records updates of a stream's receive window", func() {
+				tracer.(logging.ReceiveWindowTracer).UpdatedStreamReceiveWindow(8, 1337)
+				entry := exportAndParseSingle()
+				Expect(entry.Category).To(Equal("transport"))
+				Expect(entry.Name).To(Equal("receive_window_updated"))
+				Expect(entry.Event).To(HaveKeyWithValue("stream_id", float64(8)))
+				Expect(entry.Event).To(HaveKeyWithValue("window_size", float64(1337)))
+			})
+
 			It("records TLS key updates", func() {
 				tracer.UpdatedKeyFromTLS(protocol.EncryptionHandshake, protocol.PerspectiveClient)
 				entry := exportAndParseSingle()
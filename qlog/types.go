@@ -209,6 +209,10 @@ func (e transportError) String() string {
 		return "application_error"
 	case qerr.CryptoBufferExceeded:
 		return "crypto_buffer_exceeded"
+	case qerr.AEADLimitReached:
+		return "aead_limit_reached"
+	case qerr.VersionNegotiationError:
+		return "version_negotiation_error"
 	default:
 		return ""
 	}
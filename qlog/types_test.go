@@ -121,6 +121,8 @@ var _ = Describe("Types", func() {
 			Expect(transportError(qerr.InvalidToken).String()).To(Equal("invalid_token"))
 			Expect(transportError(qerr.ApplicationError).String()).To(Equal("application_error"))
 			Expect(transportError(qerr.CryptoBufferExceeded).String()).To(Equal("crypto_buffer_exceeded"))
+			Expect(transportError(qerr.AEADLimitReached).String()).To(Equal("aead_limit_reached"))
+			Expect(transportError(qerr.VersionNegotiationError).String()).To(Equal("version_negotiation_error"))
 			Expect(transportError(1337).String()).To(BeEmpty())
 		})
 	})
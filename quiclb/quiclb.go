@@ -0,0 +1,197 @@
+// Package quiclb implements connection ID encodings for QUIC-LB (draft-ietf-quic-load-balancers),
+// allowing a load balancer to statelessly route QUIC packets to the server that generated the connection ID.
+//
+// A Generator is used by the server, by setting it as the quic.Config.ConnectionIDGenerator.
+// A Decoder is used by the load balancer, to extract the server ID from a connection ID.
+// Both have to be created using the same Config.
+//
+// Connection IDs start with a first octet, whose two most significant bits contain the config ID,
+// allowing for configuration rotation. The remaining bits of the first octet are random.
+// Two encodings are supported: With the plaintext encoding, the server ID follows the first octet,
+// followed by a random nonce. With the stream cipher encoding, a random nonce follows the first octet,
+// followed by the server ID. Nonce and server ID are encrypted using a three-pass AES-ECB based construction.
+package quiclb
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+// UnroutableConfigID is the config ID that marks connection IDs that can't be routed by the load balancer.
+const UnroutableConfigID = 3
+
+// KeySize is the size of the key used for the stream cipher encoding (AES-128).
+const KeySize = 16
+
+const (
+	minPlaintextNonceLen    = 4
+	minStreamCipherNonceLen = 8
+	maxStreamCipherNonceLen = 16
+	maxServerIDLen          = 16
+)
+
+// A Config configures the encoding of connection IDs.
+// The load balancer and all servers need to use the same Config (with the exception of the server ID).
+type Config struct {
+	// ConfigID identifies the configuration. It is encoded into every connection ID.
+	// It must be 0, 1 or 2.
+	ConfigID uint8
+	// ServerIDLen is the length of the server ID, in bytes.
+	ServerIDLen int
+	// NonceLen is the length of the nonce, in bytes.
+	// For the plaintext encoding, it must be at least 4 bytes.
+	// For the stream cipher encoding, it must be between 8 and 16 bytes.
+	NonceLen int
+	// Key is the key for the stream cipher encoding.
+	// If nil, the plaintext encoding is used.
+	Key []byte
+}
+
+// ConnectionIDLen returns the length of the connection IDs.
+func (c *Config) ConnectionIDLen() int {
+	return 1 + c.ServerIDLen + c.NonceLen
+}
+
+func (c *Config) validate() error {
+	if c.ConfigID >= UnroutableConfigID {
+		return fmt.Errorf("quiclb: invalid config ID: %d", c.ConfigID)
+	}
+	if c.ServerIDLen < 1 || c.ServerIDLen > maxServerIDLen {
+		return fmt.Errorf("quiclb: invalid server ID length: %d", c.ServerIDLen)
+	}
+	if c.Key != nil {
+		if len(c.Key) != KeySize {
+			return fmt.Errorf("quiclb: invalid key length: %d", len(c.Key))
+		}
+		if c.NonceLen < minStreamCipherNonceLen || c.NonceLen > maxStreamCipherNonceLen {
+			return fmt.Errorf("quiclb: invalid nonce length: %d", c.NonceLen)
+		}
+	} else if c.NonceLen < minPlaintextNonceLen {
+		// The nonce makes sure that connection IDs can't be linked to each other.
+		return fmt.Errorf("quiclb: invalid nonce length: %d", c.NonceLen)
+	}
+	if l := c.ConnectionIDLen(); l < 4 || l > protocol.MaxConnIDLen {
+		return fmt.Errorf("quiclb: invalid connection ID length: %d", l)
+	}
+	return nil
+}
+
+func (c *Config) newCipher() (cipher.Block, error) {
+	if c.Key == nil {
+		return nil, nil
+	}
+	return aes.NewCipher(c.Key)
+}
+
+// A Generator generates connection IDs that encode the server ID.
+// It implements the quic.ConnectionIDGenerator interface.
+type Generator struct {
+	config   Config
+	serverID []byte
+	block    cipher.Block // nil for the plaintext encoding
+}
+
+// NewGenerator creates a new Generator for the server with the given server ID.
+func NewGenerator(config *Config, serverID []byte) (*Generator, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	if len(serverID) != config.ServerIDLen {
+		return nil, fmt.Errorf("quiclb: invalid server ID length: %d (expected %d)", len(serverID), config.ServerIDLen)
+	}
+	block, err := config.newCipher()
+	if err != nil {
+		return nil, err
+	}
+	return &Generator{
+		config:   *config,
+		serverID: append([]byte(nil), serverID...),
+		block:    block,
+	}, nil
+}
+
+// GenerateConnectionID generates a new connection ID.
+func (g *Generator) GenerateConnectionID() (protocol.ConnectionID, error) {
+	connID := make([]byte, g.config.ConnectionIDLen())
+	// Fill the first octet and the nonce with random bytes.
+	if _, err := rand.Read(connID); err != nil {
+		return nil, err
+	}
+	connID[0] = g.config.ConfigID<<6 | connID[0]&0x3f
+	if g.block == nil {
+		copy(connID[1:], g.serverID)
+		return connID, nil
+	}
+	nonce := connID[1 : 1+g.config.NonceLen]
+	serverID := connID[1+g.config.NonceLen:]
+	copy(serverID, g.serverID)
+	// Three passes: encrypt the server ID, then the nonce, then the server ID again.
+	xorPad(g.block, serverID, nonce)
+	xorPad(g.block, nonce, serverID)
+	xorPad(g.block, serverID, nonce)
+	return connID, nil
+}
+
+// ConnectionIDLen returns the length of the connection IDs.
+func (g *Generator) ConnectionIDLen() int {
+	return g.config.ConnectionIDLen()
+}
+
+// xorPad XORs dst with AES-ECB(key, src padded with zeros to the block size).
+func xorPad(block cipher.Block, dst, src []byte) {
+	var pad [aes.BlockSize]byte
+	copy(pad[:], src)
+	block.Encrypt(pad[:], pad[:])
+	for i := range dst {
+		dst[i] ^= pad[i]
+	}
+}
+
+// A Decoder decodes the server ID from connection IDs.
+// It is safe for concurrent use.
+type Decoder struct {
+	config Config
+	block  cipher.Block // nil for the plaintext encoding
+}
+
+// ErrUnroutable is returned by Decoder.ServerID for connection IDs that don't use the Decoder's config ID.
+// These are usually connection IDs chosen by the client, or connection IDs generated using a different configuration.
+var ErrUnroutable = errors.New("quiclb: unroutable connection ID")
+
+// NewDecoder creates a new Decoder.
+func NewDecoder(config *Config) (*Decoder, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	block, err := config.newCipher()
+	if err != nil {
+		return nil, err
+	}
+	return &Decoder{config: *config, block: block}, nil
+}
+
+// ServerID returns the server ID encoded in the connection ID.
+// connID may be longer than the connection ID length, e.g. when passing the remainder of a short header packet.
+func (d *Decoder) ServerID(connID []byte) ([]byte, error) {
+	if len(connID) < d.config.ConnectionIDLen() {
+		return nil, fmt.Errorf("quiclb: connection ID too short: %d bytes", len(connID))
+	}
+	if connID[0]>>6 != d.config.ConfigID {
+		return nil, ErrUnroutable
+	}
+	if d.block == nil {
+		return append([]byte(nil), connID[1:1+d.config.ServerIDLen]...), nil
+	}
+	nonce := append([]byte(nil), connID[1:1+d.config.NonceLen]...)
+	serverID := append([]byte(nil), connID[1+d.config.NonceLen:d.config.ConnectionIDLen()]...)
+	// Reverse the three passes applied by the Generator.
+	xorPad(d.block, serverID, nonce)
+	xorPad(d.block, nonce, serverID)
+	xorPad(d.block, serverID, nonce)
+	return serverID, nil
+}
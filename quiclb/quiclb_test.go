@@ -0,0 +1,144 @@
+package quiclb
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("QUIC-LB", func() {
+	serverID := []byte{0xde, 0xad, 0xbe, 0xef}
+
+	Context("validating the config", func() {
+		It("rejects invalid config IDs", func() {
+			_, err := NewGenerator(&Config{ConfigID: 3, ServerIDLen: 4, NonceLen: 4}, serverID)
+			Expect(err).To(MatchError("quiclb: invalid config ID: 3"))
+		})
+
+		It("rejects invalid server ID lengths", func() {
+			_, err := NewDecoder(&Config{ServerIDLen: 0, NonceLen: 8})
+			Expect(err).To(MatchError("quiclb: invalid server ID length: 0"))
+			_, err = NewGenerator(&Config{ServerIDLen: 3, NonceLen: 4}, serverID)
+			Expect(err).To(MatchError("quiclb: invalid server ID length: 4 (expected 3)"))
+		})
+
+		It("rejects invalid keys", func() {
+			_, err := NewDecoder(&Config{ServerIDLen: 4, NonceLen: 8, Key: make([]byte, 15)})
+			Expect(err).To(MatchError("quiclb: invalid key length: 15"))
+		})
+
+		It("rejects invalid nonce lengths for the stream cipher encoding", func() {
+			_, err := NewDecoder(&Config{ServerIDLen: 4, NonceLen: 7, Key: make([]byte, KeySize)})
+			Expect(err).To(MatchError("quiclb: invalid nonce length: 7"))
+			_, err = NewDecoder(&Config{ServerIDLen: 2, NonceLen: 17, Key: make([]byte, KeySize)})
+			Expect(err).To(MatchError("quiclb: invalid nonce length: 17"))
+		})
+
+		It("rejects invalid nonce lengths for the plaintext encoding", func() {
+			_, err := NewDecoder(&Config{ServerIDLen: 4})
+			Expect(err).To(MatchError("quiclb: invalid nonce length: 0"))
+			_, err = NewDecoder(&Config{ServerIDLen: 4, NonceLen: 3})
+			Expect(err).To(MatchError("quiclb: invalid nonce length: 3"))
+			_, err = NewDecoder(&Config{ServerIDLen: 4, NonceLen: 4})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects invalid connection ID lengths", func() {
+			_, err := NewDecoder(&Config{ServerIDLen: 8, NonceLen: 12})
+			Expect(err).To(MatchError("quiclb: invalid connection ID length: 21"))
+		})
+	})
+
+	for _, enc := range []string{"plaintext", "stream cipher"} {
+		encoding := enc
+
+		Context(encoding, func() {
+			var conf *Config
+
+			BeforeEach(func() {
+				conf = &Config{ConfigID: 1, ServerIDLen: len(serverID), NonceLen: 8}
+				if encoding == "stream cipher" {
+					conf.Key = []byte("0123456789abcdef")
+				}
+			})
+
+			It("generates connection IDs that encode the server ID", func() {
+				g, err := NewGenerator(conf, serverID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(g.ConnectionIDLen()).To(Equal(13))
+				d, err := NewDecoder(conf)
+				Expect(err).ToNot(HaveOccurred())
+				seen := make(map[string]struct{})
+				for i := 0; i < 100; i++ {
+					c, err := g.GenerateConnectionID()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(c.Len()).To(Equal(13))
+					Expect(c[0] >> 6).To(BeEquivalentTo(1))
+					Expect(seen).ToNot(HaveKey(string(c)))
+					seen[string(c)] = struct{}{}
+					id, err := d.ServerID(c)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(id).To(Equal(serverID))
+				}
+			})
+
+			It("decodes the server ID from a short header packet", func() {
+				g, err := NewGenerator(conf, serverID)
+				Expect(err).ToNot(HaveOccurred())
+				d, err := NewDecoder(conf)
+				Expect(err).ToNot(HaveOccurred())
+				c, err := g.GenerateConnectionID()
+				Expect(err).ToNot(HaveOccurred())
+				id, err := d.ServerID(append(c, []byte("packet payload")...))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(id).To(Equal(serverID))
+			})
+
+			It("rejects connection IDs with a different config ID", func() {
+				g, err := NewGenerator(conf, serverID)
+				Expect(err).ToNot(HaveOccurred())
+				c, err := g.GenerateConnectionID()
+				Expect(err).ToNot(HaveOccurred())
+				conf.ConfigID = 2
+				d, err := NewDecoder(conf)
+				Expect(err).ToNot(HaveOccurred())
+				_, err = d.ServerID(c)
+				Expect(err).To(MatchError(ErrUnroutable))
+			})
+
+			It("rejects too short connection IDs", func() {
+				d, err := NewDecoder(conf)
+				Expect(err).ToNot(HaveOccurred())
+				_, err = d.ServerID(make([]byte, 12))
+				Expect(err).To(MatchError("quiclb: connection ID too short: 12 bytes"))
+			})
+		})
+	}
+
+	It("doesn't reveal the server ID when using the stream cipher encoding", func() {
+		conf := &Config{ServerIDLen: len(serverID), NonceLen: 8, Key: []byte("0123456789abcdef")}
+		g, err := NewGenerator(conf, serverID)
+		Expect(err).ToNot(HaveOccurred())
+		c1, err := g.GenerateConnectionID()
+		Expect(err).ToNot(HaveOccurred())
+		c2, err := g.GenerateConnectionID()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bytes.Contains(c1, serverID)).To(BeFalse())
+		Expect(c1[len(c1)-len(serverID):]).ToNot(Equal(c2[len(c2)-len(serverID):]))
+	})
+
+	It("can't decode the server ID with a different key", func() {
+		conf := &Config{ServerIDLen: len(serverID), NonceLen: 8, Key: []byte("0123456789abcdef")}
+		g, err := NewGenerator(conf, serverID)
+		Expect(err).ToNot(HaveOccurred())
+		c, err := g.GenerateConnectionID()
+		Expect(err).ToNot(HaveOccurred())
+		conf.Key = []byte("fedcba9876543210")
+		d, err := NewDecoder(conf)
+		Expect(err).ToNot(HaveOccurred())
+		id, err := d.ServerID(c)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(id).ToNot(Equal(serverID))
+	})
+})
@@ -17,6 +17,7 @@ type receiveStreamI interface {
 
 	handleStreamFrame(*wire.StreamFrame) error
 	handleResetStreamFrame(*wire.ResetStreamFrame) error
+	markReceivedEarlyData()
 	closeForShutdown(error)
 	getWindowUpdate() protocol.ByteCount
 }
@@ -44,6 +45,7 @@ type receiveStream struct {
 	finRead           bool // set once we read a frame with a Fin
 	canceledRead      bool // set when CancelRead() is called
 	resetRemotely     bool // set when HandleResetStreamFrame() is called
+	receivedEarlyData bool // set when a STREAM frame is received in a 0-RTT packet
 
 	readChan chan struct{}
 	deadline time.Time
@@ -291,6 +293,20 @@ func (s *receiveStream) CloseRemote(offset protocol.ByteCount) {
 	s.handleStreamFrame(&wire.StreamFrame{Fin: true, Offset: offset})
 }
 
+// markReceivedEarlyData is called when a STREAM frame for this stream is received in a 0-RTT packet.
+// It must be called before the frame is handled, such that the data can't be read before the stream was marked.
+func (s *receiveStream) markReceivedEarlyData() {
+	s.mutex.Lock()
+	s.receivedEarlyData = true
+	s.mutex.Unlock()
+}
+
+func (s *receiveStream) ReceivedEarlyData() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.receivedEarlyData
+}
+
 func (s *receiveStream) SetReadDeadline(t time.Time) error {
 	s.mutex.Lock()
 	s.deadline = t
@@ -56,6 +56,18 @@ var _ = Describe("Receive Stream", func() {
 			Expect(b).To(Equal([]byte{0xDE, 0xAD, 0xBE, 0xEF}))
 		})
 
+		It("says if data was received in 0-RTT", func() {
+			mockFC.EXPECT().UpdateHighestReceived(protocol.ByteCount(4), false)
+			mockFC.EXPECT().AddBytesRead(protocol.ByteCount(4))
+			Expect(str.ReceivedEarlyData()).To(BeFalse())
+			str.markReceivedEarlyData()
+			Expect(str.handleStreamFrame(&wire.StreamFrame{Data: []byte{0xDE, 0xAD, 0xBE, 0xEF}})).To(Succeed())
+			b := make([]byte, 4)
+			_, err := strWithTimeout.Read(b)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(str.ReceivedEarlyData()).To(BeTrue())
+		})
+
 		It("reads a single STREAM frame in multiple goes", func() {
 			mockFC.EXPECT().UpdateHighestReceived(protocol.ByteCount(4), false)
 			mockFC.EXPECT().AddBytesRead(protocol.ByteCount(2))
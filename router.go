@@ -0,0 +1,386 @@
+package quic
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+var errRouteClosed = errors.New("route closed")
+
+// A Route describes which sessions a Router dispatches to the listener of the route,
+// and how these sessions are configured.
+type Route struct {
+	// NextProtos are the application protocols (ALPN) of this route, in order of preference.
+	// If empty, the route matches any application protocol.
+	NextProtos []string
+	// ServerNames are the server names (SNI) of this route.
+	// If empty, the route matches any server name.
+	ServerNames []string
+	// Config is used for the sessions of this route, instead of the Config of the Router.
+	// The settings that apply to the listener as a whole are always taken from the Router's Config:
+	// Versions, ConnectionIDLength, ConnectionIDGenerator, AcceptToken, StatelessResetKey,
+	// TokenKeyRing, AdmissionControl and Tracer.
+	// If nil, the Config of the Router is used.
+	Config *Config
+}
+
+// A Router accepts QUIC connections on a single net.PacketConn,
+// and dispatches the sessions to the listeners of its routes,
+// depending on the application protocol (ALPN) and the server name (SNI) of the session.
+// Routes are matched in order, and every session is dispatched to the first route that matches.
+// Sessions that don't match any route are closed.
+//
+// The configuration of a session is selected when the session is created,
+// based on the ClientHello contained in the client's first Initial packet.
+// If the ClientHello doesn't fit into a single packet, the Router's Config is used.
+// Such a session is closed if it is matched to a route that uses a different Config.
+//
+// The application protocol and the server name are only known once the handshake completes.
+// Sessions are therefore dispatched after completion of the handshake,
+// and the listeners of the routes only return sessions that completed the handshake.
+type Router struct {
+	server *baseServer
+
+	tlsConf *tls.Config // used for sessions that can't be matched to a route when they are created
+	routes  []*routeListener
+
+	logger utils.Logger
+}
+
+// ListenAddrRouter creates a Router listening on a given address.
+// See ListenRouter for details.
+func ListenAddrRouter(addr string, tlsConf *tls.Config, config *Config, routes ...*Route) (*Router, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return nil, err
+	}
+	r, err := ListenRouter(conn, tlsConf, config, routes...)
+	if err != nil {
+		return nil, err
+	}
+	r.server.createdPacketConn = true
+	return r, nil
+}
+
+// ListenRouter creates a Router listening for QUIC connections on a given net.PacketConn.
+// The tls.Config and the Config are used like for Listen.
+// The application protocols of the tls.Config are replaced by the application protocols of the routes.
+func ListenRouter(conn net.PacketConn, tlsConf *tls.Config, config *Config, routes ...*Route) (*Router, error) {
+	if len(routes) == 0 {
+		return nil, errors.New("quic: no routes")
+	}
+	for _, route := range routes {
+		if err := validateConfig(route.Config); err != nil {
+			return nil, err
+		}
+	}
+	s, err := newServer(conn, tlsConf, config, true)
+	if err != nil {
+		return nil, err
+	}
+	r := &Router{
+		server: s,
+		logger: utils.DefaultLogger.WithPrefix("router"),
+	}
+	var nextProtos []string
+	for _, route := range routes {
+		r.routes = append(r.routes, newRouteListener(r, route, s.config, tlsConf))
+		nextProtos = appendNextProtos(nextProtos, route.NextProtos...)
+	}
+	r.tlsConf = tlsConfigWithNextProtos(tlsConf, appendNextProtos(nextProtos, tlsConf.NextProtos...))
+	s.sessionConfig = r.sessionConfig
+	s.start()
+	go r.run()
+	return r, nil
+}
+
+// Listener returns the listener of a route.
+// It returns nil if the route is not one of the Router's routes.
+func (r *Router) Listener(route *Route) EarlyListener {
+	for _, l := range r.routes {
+		if l.route == route {
+			return l
+		}
+	}
+	return nil
+}
+
+// Addr returns the local network address that the Router is listening on.
+func (r *Router) Addr() net.Addr {
+	return r.server.Addr()
+}
+
+// Close closes the Router. All active sessions will be closed.
+func (r *Router) Close() error {
+	return r.server.Close()
+}
+
+// Drain stops accepting new connections, and closes the Router once all sessions are closed.
+// See Listener.Drain for details.
+func (r *Router) Drain(ctx context.Context) error {
+	return r.server.Drain(ctx)
+}
+
+// sessionConfig selects the configuration of a new session, based on the ClientHello.
+func (r *Router) sessionConfig(hdr *wire.Header, data []byte) (*Config, *tls.Config) {
+	chi, err := peekClientHello(hdr, data)
+	if err != nil {
+		r.logger.Debugf("Couldn't parse the ClientHello (%s). Using the default configuration.", err)
+		return r.server.config, r.tlsConf
+	}
+	if l := r.match(chi.ServerName, chi.NextProtos); l != nil {
+		return l.config, l.tlsConf
+	}
+	r.logger.Debugf("No route for server name %q and application protocols %q. Using the default configuration.", chi.ServerName, chi.NextProtos)
+	return r.server.config, r.tlsConf
+}
+
+func (r *Router) run() {
+	for {
+		sess, err := r.server.accept(context.Background())
+		if err != nil {
+			return
+		}
+		// Dispatch sessions concurrently, such that sessions whose handshake takes a long time
+		// don't hold up the other sessions.
+		go r.dispatch(sess)
+	}
+}
+
+// dispatch waits for the handshake of a session to complete,
+// and passes the session to the listener of the route matching the negotiated application protocol and server name.
+func (r *Router) dispatch(sess quicSession) {
+	// ConnectionState blocks until the handshake completes.
+	select {
+	case <-sess.HandshakeComplete().Done():
+	case <-sess.Context().Done():
+		return
+	}
+	state := sess.ConnectionState()
+	var nextProtos []string
+	if state.NegotiatedProtocol != "" {
+		nextProtos = []string{state.NegotiatedProtocol}
+	}
+	l := r.match(state.ServerName, nextProtos)
+	if l == nil {
+		r.logger.Debugf("No route for server name %q and application protocol %q. Closing session.", state.ServerName, state.NegotiatedProtocol)
+		sess.CloseWithError(0, "no route")
+		return
+	}
+	// The session was created before its route was known, and therefore didn't use the route's Config.
+	if sess.getConfig() != l.config {
+		r.logger.Debugf("Session for application protocol %q wasn't created with the Config of its route. Closing session.", state.NegotiatedProtocol)
+		sess.CloseWithError(0, "configuration mismatch")
+		return
+	}
+	if !l.enqueue(sess) {
+		r.logger.Debugf("Accept queue of route for application protocol %q full. Closing session.", state.NegotiatedProtocol)
+		sess.CloseWithError(0, "server busy")
+	}
+}
+
+func (r *Router) match(serverName string, nextProtos []string) *routeListener {
+	for _, l := range r.routes {
+		if l.matches(serverName, nextProtos) {
+			return l
+		}
+	}
+	return nil
+}
+
+// A routeListener is the listener of a Route.
+type routeListener struct {
+	router *Router
+	route  *Route
+
+	serverNames []string
+	nextProtos  []string
+	config      *Config
+	tlsConf     *tls.Config
+
+	mutex        sync.Mutex
+	closed       bool
+	closeChan    chan struct{}
+	sessionQueue chan quicSession
+}
+
+var _ EarlyListener = &routeListener{}
+
+func newRouteListener(r *Router, route *Route, config *Config, tlsConf *tls.Config) *routeListener {
+	l := &routeListener{
+		router:       r,
+		route:        route,
+		serverNames:  append([]string(nil), route.ServerNames...),
+		nextProtos:   append([]string(nil), route.NextProtos...),
+		config:       populateRouteConfig(config, route.Config),
+		tlsConf:      tlsConf,
+		closeChan:    make(chan struct{}),
+		sessionQueue: make(chan quicSession, protocol.MaxAcceptQueueSize),
+	}
+	if len(l.nextProtos) > 0 {
+		l.tlsConf = tlsConfigWithNextProtos(tlsConf, l.nextProtos)
+	}
+	return l
+}
+
+func (l *routeListener) matches(serverName string, nextProtos []string) bool {
+	l.mutex.Lock()
+	closed := l.closed
+	l.mutex.Unlock()
+	if closed {
+		return false
+	}
+
+	if len(l.serverNames) > 0 {
+		var found bool
+		for _, name := range l.serverNames {
+			if strings.EqualFold(name, serverName) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(l.nextProtos) == 0 {
+		return true
+	}
+	for _, proto := range l.nextProtos {
+		for _, p := range nextProtos {
+			if proto == p {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// enqueue queues a session until it is accepted.
+// It returns false if the listener was closed, or if the queue is full.
+func (l *routeListener) enqueue(sess quicSession) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.closed {
+		return false
+	}
+	select {
+	case l.sessionQueue <- sess:
+		return true
+	default:
+		return false
+	}
+}
+
+// Accept returns new sessions of this route.
+func (l *routeListener) Accept(ctx context.Context) (EarlySession, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case sess := <-l.sessionQueue:
+		return sess, nil
+	case <-l.closeChan:
+		return nil, errRouteClosed
+	case <-l.router.server.errorChan:
+		return nil, l.router.server.serverError
+	}
+}
+
+// Addr returns the local network address that the Router is listening on.
+func (l *routeListener) Addr() net.Addr {
+	return l.router.Addr()
+}
+
+// Close closes the listener of this route.
+// Sessions that were not accepted yet are closed, and new sessions are no longer dispatched to this route.
+// Sessions that were accepted before are not closed.
+func (l *routeListener) Close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	close(l.closeChan)
+	for {
+		select {
+		case sess := <-l.sessionQueue:
+			sess.CloseWithError(0, "server closed")
+		default:
+			return nil
+		}
+	}
+}
+
+// Drain drains the Router, see Router.Drain.
+func (l *routeListener) Drain(ctx context.Context) error {
+	return l.router.Drain(ctx)
+}
+
+// populateRouteConfig creates the Config for the sessions of a route.
+// The settings that apply to the listener as a whole are taken from the listener's (populated) Config.
+func populateRouteConfig(config, routeConfig *Config) *Config {
+	if routeConfig == nil {
+		return config
+	}
+	c := populateServerConfig(routeConfig)
+	c.Versions = config.Versions
+	c.ConnectionIDLength = config.ConnectionIDLength
+	c.ConnectionIDGenerator = config.ConnectionIDGenerator
+	c.AcceptToken = config.AcceptToken
+	c.StatelessResetKey = config.StatelessResetKey
+	c.TokenKeyRing = config.TokenKeyRing
+	c.AdmissionControl = config.AdmissionControl
+	c.Tracer = config.Tracer
+	return c
+}
+
+// tlsConfigWithNextProtos returns a copy of the tls.Config, using the given application protocols.
+func tlsConfigWithNextProtos(tlsConf *tls.Config, nextProtos []string) *tls.Config {
+	conf := tlsConf.Clone()
+	conf.NextProtos = nextProtos
+	if conf.GetConfigForClient != nil {
+		getConfigForClient := conf.GetConfigForClient
+		conf.GetConfigForClient = func(ch *tls.ClientHelloInfo) (*tls.Config, error) {
+			c, err := getConfigForClient(ch)
+			if err != nil || c == nil {
+				return c, err
+			}
+			c = c.Clone()
+			c.NextProtos = nextProtos
+			return c, nil
+		}
+	}
+	return conf
+}
+
+// appendNextProtos appends the application protocols that are not yet contained in the list.
+func appendNextProtos(list []string, nextProtos ...string) []string {
+	for _, proto := range nextProtos {
+		var found bool
+		for _, p := range list {
+			if p == proto {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, proto)
+		}
+	}
+	return list
+}
@@ -0,0 +1,287 @@
+package quic
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Router", func() {
+	var (
+		serv   *baseServer
+		router *Router
+		h3     *Route
+		hq     *Route
+	)
+
+	getConnectionState := func(nextProto, serverName string) ConnectionState {
+		var state ConnectionState
+		state.NegotiatedProtocol = nextProto
+		state.ServerName = serverName
+		return state
+	}
+
+	// newSession creates a session that completed the handshake
+	newSession := func(nextProto, serverName string) *MockQuicSession {
+		sess := NewMockQuicSession(mockCtrl)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		sess.EXPECT().HandshakeComplete().Return(ctx)
+		sess.EXPECT().Context().Return(context.Background()).AnyTimes()
+		sess.EXPECT().ConnectionState().Return(getConnectionState(nextProto, serverName))
+		return sess
+	}
+
+	newRouter := func(routes ...*Route) *Router {
+		r := &Router{server: serv, logger: utils.DefaultLogger}
+		for _, route := range routes {
+			r.routes = append(r.routes, newRouteListener(r, route, serv.config, &tls.Config{}))
+		}
+		return r
+	}
+
+	BeforeEach(func() {
+		serv = &baseServer{
+			config:    populateServerConfig(&Config{}),
+			errorChan: make(chan struct{}),
+		}
+		h3 = &Route{NextProtos: []string{"h3-29"}}
+		hq = &Route{
+			NextProtos:  []string{"hq-29"},
+			ServerNames: []string{"quic.clemente.io"},
+			Config:      &Config{MaxIdleTimeout: 42 * time.Second},
+		}
+		router = newRouter(h3, hq)
+	})
+
+	It("errors when no routes are given", func() {
+		_, err := ListenRouter(nil, &tls.Config{}, nil)
+		Expect(err).To(MatchError("quic: no routes"))
+	})
+
+	It("returns the listener of a route", func() {
+		Expect(router.Listener(h3)).To(Equal(router.routes[0]))
+		Expect(router.Listener(hq)).To(Equal(router.routes[1]))
+		Expect(router.Listener(&Route{})).To(BeNil())
+	})
+
+	Context("matching", func() {
+		It("matches the application protocol", func() {
+			Expect(router.match("", []string{"h3-29"})).To(Equal(router.routes[0]))
+			Expect(router.match("", []string{"foo", "h3-29"})).To(Equal(router.routes[0]))
+			Expect(router.match("", []string{"foo"})).To(BeNil())
+			Expect(router.match("", nil)).To(BeNil())
+		})
+
+		It("matches the server name", func() {
+			Expect(router.match("quic.clemente.io", []string{"hq-29"})).To(Equal(router.routes[1]))
+			Expect(router.match("QUIC.clemente.io", []string{"hq-29"})).To(Equal(router.routes[1]))
+			Expect(router.match("example.com", []string{"hq-29"})).To(BeNil())
+		})
+
+		It("matches any application protocol if the route doesn't specify any", func() {
+			router = newRouter(h3, &Route{})
+			Expect(router.match("", []string{"foo"})).To(Equal(router.routes[1]))
+			Expect(router.match("", nil)).To(Equal(router.routes[1]))
+		})
+
+		It("uses the first route that matches", func() {
+			router = newRouter(h3, &Route{NextProtos: []string{"h3-29", "hq-29"}})
+			Expect(router.match("", []string{"h3-29"})).To(Equal(router.routes[0]))
+			Expect(router.match("", []string{"hq-29"})).To(Equal(router.routes[1]))
+		})
+
+		It("doesn't match closed routes", func() {
+			Expect(router.routes[0].Close()).To(Succeed())
+			Expect(router.match("", []string{"h3-29"})).To(BeNil())
+		})
+	})
+
+	Context("dispatching sessions", func() {
+		It("dispatches sessions to the route of the negotiated application protocol", func() {
+			sess := newSession("hq-29", "quic.clemente.io")
+			sess.EXPECT().getConfig().Return(router.routes[1].config)
+			router.dispatch(sess)
+			s, err := router.Listener(hq).Accept(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s).To(Equal(sess))
+		})
+
+		It("closes sessions that don't match any route", func() {
+			sess := newSession("foo", "")
+			sess.EXPECT().CloseWithError(ErrorCode(0), "no route")
+			router.dispatch(sess)
+		})
+
+		It("closes sessions that weren't created with the config of their route", func() {
+			// This happens if the ClientHello didn't fit into the first Initial packet.
+			sess := newSession("hq-29", "quic.clemente.io")
+			sess.EXPECT().getConfig().Return(serv.config)
+			sess.EXPECT().CloseWithError(ErrorCode(0), "configuration mismatch")
+			router.dispatch(sess)
+			Expect(router.routes[1].sessionQueue).To(BeEmpty())
+		})
+
+		It("dispatches sessions created with the router's config to routes without a config", func() {
+			sess := newSession("h3-29", "")
+			sess.EXPECT().getConfig().Return(serv.config)
+			router.dispatch(sess)
+			Expect(router.routes[0].sessionQueue).To(HaveLen(1))
+		})
+
+		It("closes sessions if the accept queue of the route is full", func() {
+			for i := 0; i < protocol.MaxAcceptQueueSize; i++ {
+				sess := newSession("h3-29", "")
+				sess.EXPECT().getConfig().Return(serv.config)
+				router.dispatch(sess)
+			}
+			sess := newSession("h3-29", "")
+			sess.EXPECT().getConfig().Return(serv.config)
+			sess.EXPECT().CloseWithError(ErrorCode(0), "server busy")
+			router.dispatch(sess)
+		})
+
+		It("closes queued sessions when the route is closed", func() {
+			sess := newSession("h3-29", "")
+			sess.EXPECT().getConfig().Return(serv.config)
+			router.dispatch(sess)
+			sess.EXPECT().CloseWithError(ErrorCode(0), "server closed")
+			l := router.Listener(h3)
+			Expect(l.Close()).To(Succeed())
+			_, err := l.Accept(context.Background())
+			Expect(err).To(MatchError(errRouteClosed))
+		})
+
+		It("waits for the handshake to complete", func() {
+			sess := NewMockQuicSession(mockCtrl)
+			handshakeCtx, handshakeComplete := context.WithCancel(context.Background())
+			sess.EXPECT().HandshakeComplete().Return(handshakeCtx)
+			sess.EXPECT().Context().Return(context.Background()).AnyTimes()
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				router.dispatch(sess)
+				close(done)
+			}()
+			Consistently(done).ShouldNot(BeClosed())
+			sess.EXPECT().ConnectionState().Return(getConnectionState("h3-29", ""))
+			sess.EXPECT().getConfig().Return(serv.config)
+			handshakeComplete()
+			Eventually(done).Should(BeClosed())
+			Expect(router.routes[0].sessionQueue).To(HaveLen(1))
+		})
+
+		It("doesn't dispatch sessions that are closed before the handshake completes", func() {
+			sess := NewMockQuicSession(mockCtrl)
+			sess.EXPECT().HandshakeComplete().Return(context.Background())
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			sess.EXPECT().Context().Return(ctx)
+			router.dispatch(sess)
+			Expect(router.routes[0].sessionQueue).To(BeEmpty())
+		})
+
+		It("doesn't block other sessions while waiting for the handshake of a session", func() {
+			serv.sessionQueue = make(chan quicSession, 2)
+			stalled := NewMockQuicSession(mockCtrl)
+			stalledCtx, closeStalled := context.WithCancel(context.Background())
+			defer closeStalled()
+			stalled.EXPECT().HandshakeComplete().Return(context.Background()).AnyTimes()
+			stalled.EXPECT().Context().Return(stalledCtx).AnyTimes()
+			serv.sessionQueue <- stalled
+			sess := newSession("h3-29", "")
+			sess.EXPECT().getConfig().Return(serv.config)
+			serv.sessionQueue <- sess
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				router.run()
+				close(done)
+			}()
+			s, err := router.Listener(h3).Accept(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s).To(Equal(sess))
+			serv.serverError = errors.New("test err")
+			close(serv.errorChan)
+			Eventually(done).Should(BeClosed())
+		})
+	})
+
+	Context("accepting sessions", func() {
+		It("returns when the context is canceled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				_, err := router.Listener(h3).Accept(ctx)
+				Expect(err).To(MatchError(context.Canceled))
+				close(done)
+			}()
+			Consistently(done).ShouldNot(BeClosed())
+			cancel()
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("returns the error of the server", func() {
+			serv.serverError = errors.New("test err")
+			close(serv.errorChan)
+			_, err := router.Listener(h3).Accept(context.Background())
+			Expect(err).To(MatchError("test err"))
+		})
+	})
+
+	Context("configuration", func() {
+		It("uses the server's config for routes without a config", func() {
+			Expect(router.routes[0].config).To(Equal(serv.config))
+		})
+
+		It("takes the listener settings from the server's config", func() {
+			key := []byte("foobar")
+			config := populateServerConfig(&Config{
+				StatelessResetKey:  key,
+				ConnectionIDLength: 6,
+				MaxIdleTimeout:     time.Minute,
+			})
+			c := populateRouteConfig(config, &Config{
+				StatelessResetKey:  []byte("raboof"),
+				ConnectionIDLength: 10,
+				MaxIdleTimeout:     42 * time.Second,
+			})
+			Expect(c.MaxIdleTimeout).To(Equal(42 * time.Second))
+			Expect(c.StatelessResetKey).To(Equal(key))
+			Expect(c.ConnectionIDLength).To(Equal(6))
+			Expect(c.Versions).To(Equal(config.Versions))
+		})
+
+		It("restricts the application protocols of a route", func() {
+			Expect(router.routes[0].tlsConf.NextProtos).To(Equal([]string{"h3-29"}))
+			Expect(router.routes[1].tlsConf.NextProtos).To(Equal([]string{"hq-29"}))
+		})
+
+		It("restricts the application protocols of configs returned by GetConfigForClient", func() {
+			conf := &tls.Config{
+				NextProtos: []string{"foo"},
+				GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+					return &tls.Config{NextProtos: []string{"bar"}}, nil
+				},
+			}
+			c := tlsConfigWithNextProtos(conf, []string{"h3-29"})
+			Expect(c.NextProtos).To(Equal([]string{"h3-29"}))
+			Expect(conf.NextProtos).To(Equal([]string{"foo"}))
+			cc, err := c.GetConfigForClient(&tls.ClientHelloInfo{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cc.NextProtos).To(Equal([]string{"h3-29"}))
+		})
+
+		It("appends application protocols without duplicates", func() {
+			Expect(appendNextProtos([]string{"h3-29"}, "hq-29", "h3-29", "hq-29")).To(Equal([]string{"h3-29", "hq-29"}))
+		})
+	})
+})
@@ -49,6 +49,8 @@ type encoder struct {
 
 	encodingPaused  bool
 	ratioWasDynamic bool
+
+	numCodedPackets uint64 // number of coded packets handed out for sending
 }
 
 func (e *encoder) offset() int { return 1 /*1st byte*/ + e.lenDCID }
@@ -307,6 +309,7 @@ func (e *encoder) retrieveCodedPackets() []*packetBuffer {
 
 	if cods := len(e.newCodedPackets); cods > 0 {
 		rLogger.MaybeIncreaseTxCodN(cods)
+		e.numCodedPackets += uint64(cods)
 	}
 
 	defer func() { e.newCodedPackets = []*packetBuffer{} }()
@@ -348,7 +351,7 @@ func MakeEncoder(conf *rquic.CConf) *encoder {
 		overlap:          byte(conf.Overlap),
 		overlapInt:       conf.Overlap,
 		reduns:           conf.Reduns,
-		srcForCoding:     make([]byte, protocol.MaxPacketSizeIPv4),
+		srcForCoding:     make([]byte, protocol.MaxPacketBufferSize),
 		encodingPaused:   true, // encodingNotPaused will do the necessary initializations
 		localMaxAckDelay: protocol.DefaultMaxAckDelay,
 	}
@@ -7,21 +7,34 @@ import (
 // A sendConn allows sending using a simple Write() on a non-connected packet conn.
 type sendConn interface {
 	Write([]byte) error
+	// WriteBatch sends multiple packets, using a single syscall if possible.
+	// Packets that are too large for the interface are skipped, and a message size error is returned
+	// after all other packets were sent.
+	WriteBatch([][]byte) error
 	Close() error
 	LocalAddr() net.Addr
 	RemoteAddr() net.Addr
+	// DontFragment says if the DF bit is set on packets sent on this connection.
+	DontFragment() bool
 }
 
 type conn struct {
 	net.PacketConn
 
-	remoteAddr net.Addr
+	remoteAddr   net.Addr
+	dontFragment bool
+	batch        *batchConn // nil if batched I/O is not supported
 }
 
 var _ sendConn = &conn{}
 
 func newSendConn(c net.PacketConn, remote net.Addr) sendConn {
-	return &conn{PacketConn: c, remoteAddr: remote}
+	return &conn{
+		PacketConn:   c,
+		remoteAddr:   remote,
+		dontFragment: setDF(c),
+		batch:        newBatchConn(c),
+	}
 }
 
 func (c *conn) Write(p []byte) error {
@@ -29,6 +42,26 @@ func (c *conn) Write(p []byte) error {
 	return err
 }
 
+func (c *conn) WriteBatch(packets [][]byte) error {
+	if c.batch != nil {
+		return c.batch.WriteTo(packets, c.remoteAddr)
+	}
+	var msgSizeErr error
+	for _, p := range packets {
+		if err := c.Write(p); err != nil {
+			if !isMsgSizeErr(err) {
+				return err
+			}
+			msgSizeErr = err
+		}
+	}
+	return msgSizeErr
+}
+
 func (c *conn) RemoteAddr() net.Addr {
 	return c.remoteAddr
 }
+
+func (c *conn) DontFragment() bool {
+	return c.dontFragment
+}
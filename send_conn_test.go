@@ -87,6 +87,15 @@ var _ = Describe("Send-Connection", func() {
 		Expect(write.data).To(Equal([]byte("foobar")))
 	})
 
+	It("writes batches", func() {
+		Expect(c.WriteBatch([][]byte{[]byte("foo"), []byte("bar")})).To(Succeed())
+		var write mockPacketConnWrite
+		Expect(packetConn.dataWritten).To(Receive(&write))
+		Expect(write.data).To(Equal([]byte("foo")))
+		Expect(packetConn.dataWritten).To(Receive(&write))
+		Expect(write.data).To(Equal([]byte("bar")))
+	})
+
 	It("gets the remote address", func() {
 		Expect(c.RemoteAddr().String()).To(Equal("192.168.100.200:1337"))
 	})
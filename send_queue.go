@@ -1,7 +1,11 @@
 package quic
 
+import "github.com/lucas-clemente/quic-go/internal/protocol"
+
 type sendQueue struct {
 	queue       chan *packetBuffer
+	packets     []*packetBuffer // packets that are sent in the current batch
+	data        [][]byte
 	closeCalled chan struct{} // runStopped when Close() is called
 	runStopped  chan struct{} // runStopped when the run loop returns
 	conn        sendConn
@@ -12,7 +16,9 @@ func newSendQueue(conn sendConn) *sendQueue {
 		conn:        conn,
 		runStopped:  make(chan struct{}),
 		closeCalled: make(chan struct{}),
-		queue:       make(chan *packetBuffer, 1),
+		queue:       make(chan *packetBuffer, protocol.MaxBatchSize),
+		packets:     make([]*packetBuffer, 0, protocol.MaxBatchSize),
+		data:        make([][]byte, 0, protocol.MaxBatchSize),
 	}
 	return s
 }
@@ -37,14 +43,71 @@ func (h *sendQueue) Run() error {
 			// make sure that all queued packets are actually sent out
 			shouldClose = true
 		case p := <-h.queue:
-			if err := h.conn.Write(p.Data); err != nil {
-				return err
+			if err := h.send(p); err != nil {
+				// Path MTU Discovery probe packets might be too large for the interface.
+				// This is expected, and the probe packet will be declared lost.
+				if !isMsgSizeErr(err) {
+					return err
+				}
 			}
-			p.Release()
 		}
 	}
 }
 
+// send sends p, together with all other packets that are already queued.
+func (h *sendQueue) send(p *packetBuffer) error {
+	packets := append(h.packets[:0], p)
+loop:
+	for len(packets) < protocol.MaxBatchSize {
+		select {
+		case p := <-h.queue:
+			packets = append(packets, p)
+		default:
+			break loop
+		}
+	}
+	var msgSizeErr error
+	write := func(data [][]byte) error {
+		var err error
+		switch len(data) {
+		case 0:
+			return nil
+		case 1:
+			err = h.conn.Write(data[0])
+		default:
+			err = h.conn.WriteBatch(data)
+		}
+		if isMsgSizeErr(err) {
+			msgSizeErr = err
+			return nil
+		}
+		return err
+	}
+	data := h.data[:0]
+	for _, p := range packets {
+		if !p.isMTUProbePacket {
+			data = append(data, p.Data)
+			continue
+		}
+		// A probe packet might be too large for the path.
+		// Send it on its own, so that the other packets are not dropped together with it.
+		if err := write(data); err != nil {
+			return err
+		}
+		if err := write(append(data[:0], p.Data)); err != nil {
+			return err
+		}
+		data = data[:0]
+	}
+	if err := write(data); err != nil {
+		return err
+	}
+	for _, p := range packets {
+		p.Release()
+	}
+	return msgSizeErr
+}
+
 func (h *sendQueue) Close() {
 	close(h.closeCalled)
 	// wait until the run loop returned
@@ -2,8 +2,13 @@ package quic
 
 import (
 	"errors"
+	"net"
+	"os"
+	"runtime"
+	"syscall"
 
 	"github.com/golang/mock/gomock"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -43,10 +48,13 @@ var _ = Describe("Send Queue", func() {
 	})
 
 	It("blocks sending when too many packets are queued", func() {
-		q.Send(getPacket([]byte("foobar")))
+		for i := 0; i < protocol.MaxBatchSize; i++ {
+			q.Send(getPacket([]byte("foobar")))
+		}
 
-		written := make(chan []byte, 2)
-		c.EXPECT().Write(gomock.Any()).Do(func(p []byte) { written <- p }).Times(2)
+		written := make(chan [][]byte, 2)
+		c.EXPECT().WriteBatch(gomock.Any()).Do(func(p [][]byte) { written <- p })
+		c.EXPECT().Write(gomock.Any()).Do(func(p []byte) { written <- [][]byte{p} })
 
 		sent := make(chan struct{})
 		go func() {
@@ -64,8 +72,113 @@ var _ = Describe("Send Queue", func() {
 			close(done)
 		}()
 
+		var batch [][]byte
+		Eventually(written).Should(Receive(&batch))
+		Expect(batch).To(HaveLen(protocol.MaxBatchSize))
+		for _, p := range batch {
+			Expect(p).To(Equal([]byte("foobar")))
+		}
+		Eventually(written).Should(Receive(Equal([][]byte{[]byte("raboof")})))
+		q.Close()
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("sends queued packets in a single batch", func() {
+		q.Send(getPacket([]byte("foo")))
+		q.Send(getPacket([]byte("bar")))
+
+		written := make(chan struct{})
+		c.EXPECT().WriteBatch([][]byte{[]byte("foo"), []byte("bar")}).Do(func([][]byte) { close(written) })
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			q.Run()
+			close(done)
+		}()
+
+		Eventually(written).Should(BeClosed())
+		q.Close()
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("sends MTU probe packets in their own message", func() {
+		q.Send(getPacket([]byte("foo")))
+		q.Send(getPacket([]byte("bar")))
+		probe := getPacket([]byte("probe"))
+		probe.isMTUProbePacket = true
+		q.Send(probe)
+		q.Send(getPacket([]byte("baz")))
+
+		written := make(chan struct{})
+		gomock.InOrder(
+			c.EXPECT().WriteBatch([][]byte{[]byte("foo"), []byte("bar")}),
+			c.EXPECT().Write([]byte("probe")),
+			c.EXPECT().Write([]byte("baz")).Do(func([]byte) { close(written) }),
+		)
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			q.Run()
+			close(done)
+		}()
+
+		Eventually(written).Should(BeClosed())
+		q.Close()
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("sends the other packets of a batch when an MTU probe packet is too large", func() {
+		if runtime.GOOS != "linux" {
+			Skip("message size errors are only detected on Linux")
+		}
+		probe := getPacket([]byte("probe"))
+		probe.isMTUProbePacket = true
+		q.Send(probe)
+		q.Send(getPacket([]byte("foo")))
+		q.Send(getPacket([]byte("bar")))
+
+		msgSizeErr := &net.OpError{Op: "write", Err: os.NewSyscallError("sendto", syscall.EMSGSIZE)}
+		written := make(chan struct{})
+		gomock.InOrder(
+			c.EXPECT().Write([]byte("probe")).Return(msgSizeErr),
+			c.EXPECT().WriteBatch([][]byte{[]byte("foo"), []byte("bar")}).Do(func([][]byte) { close(written) }),
+		)
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			q.Run()
+			close(done)
+		}()
+
+		Eventually(written).Should(BeClosed())
+		Expect(done).ToNot(BeClosed())
+		q.Close()
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("continues running when a packet is too large", func() {
+		if runtime.GOOS != "linux" {
+			Skip("message size errors are only detected on Linux")
+		}
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			q.Run()
+			close(done)
+		}()
+
+		msgSizeErr := &net.OpError{Op: "write", Err: os.NewSyscallError("sendto", syscall.EMSGSIZE)}
+		written := make(chan []byte, 2)
+		c.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) error {
+			written <- p
+			return msgSizeErr
+		})
+		q.Send(getPacket([]byte("foobar")))
 		Eventually(written).Should(Receive(Equal([]byte("foobar"))))
+		c.EXPECT().Write(gomock.Any()).Do(func(p []byte) { written <- p })
+		q.Send(getPacket([]byte("raboof")))
 		Eventually(written).Should(Receive(Equal([]byte("raboof"))))
+		Expect(done).ToNot(BeClosed())
 		q.Close()
 		Eventually(done).Should(BeClosed())
 	})
@@ -52,6 +52,8 @@ type sendStream struct {
 	writeChan chan struct{}
 	deadline  time.Time
 
+	priority protocol.StreamPriority
+
 	flowController flowcontrol.StreamFlowController
 
 	version protocol.VersionNumber
@@ -71,6 +73,7 @@ func newSendStream(
 		sender:         sender,
 		flowController: flowController,
 		writeChan:      make(chan struct{}, 1),
+		priority:       protocol.DefaultStreamPriority,
 		version:        version,
 	}
 	s.ctx, s.ctxCancel = context.WithCancel(context.Background())
@@ -189,7 +192,7 @@ func (s *sendStream) canBufferStreamFrame() bool {
 	if s.nextFrame != nil {
 		l = s.nextFrame.DataLen()
 	}
-	return l+protocol.ByteCount(len(s.dataForWriting)) <= protocol.MaxReceivePacketSize
+	return l+protocol.ByteCount(len(s.dataForWriting)) <= protocol.MaxPacketBufferSize
 }
 
 // popStreamFrame returns the next STREAM frame that is supposed to be sent on this stream
@@ -456,6 +459,23 @@ func (s *sendStream) SetWriteDeadline(t time.Time) error {
 	return nil
 }
 
+func (s *sendStream) SetPriority(p protocol.StreamPriority) {
+	p = p.Normalize()
+	s.mutex.Lock()
+	changed := s.priority != p
+	s.priority = p
+	s.mutex.Unlock()
+	if changed {
+		s.sender.onStreamPriorityChanged(s.streamID, p) // must be called without holding the mutex
+	}
+}
+
+func (s *sendStream) Priority() protocol.StreamPriority {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.priority
+}
+
 // CloseForShutdown closes a stream abruptly.
 // It makes Write unblock (and return the error) immediately.
 // The peer will NOT be informed about this: the stream is closed without sending a FIN or RST.
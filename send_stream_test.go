@@ -125,6 +125,35 @@ var _ = Describe("Send Stream", func() {
 			Eventually(done).Should(BeClosed())
 		})
 
+		It("gets STREAM frames larger than the default packet size", func() {
+			// Path MTU Discovery can increase the packet size up to protocol.MaxPacketBufferSize.
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				mockSender.EXPECT().onHasStreamData(streamID)
+				_, err := strWithTimeout.Write(getData(3 * protocol.MaxPacketBufferSize))
+				Expect(err).ToNot(HaveOccurred())
+			}()
+			waitForWrite()
+			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).AnyTimes()
+			mockFC.EXPECT().AddBytesSent(gomock.Any()).AnyTimes()
+			var offset protocol.ByteCount
+			for offset < 3*protocol.MaxPacketBufferSize {
+				frame, _ := str.popStreamFrame(protocol.MaxPacketBufferSize)
+				Expect(frame).ToNot(BeNil())
+				f := frame.Frame.(*wire.StreamFrame)
+				Expect(f.Offset).To(Equal(offset))
+				if offset+f.DataLen() < 3*protocol.MaxPacketBufferSize {
+					Expect(f.DataLen()).To(BeNumerically(">", protocol.MaxReceivePacketSize))
+				}
+				Expect(f.Length(protocol.VersionWhatever)).To(BeNumerically("<=", protocol.MaxPacketBufferSize))
+				Expect(f.Data).To(Equal(getDataAtOffset(f.Offset, f.DataLen())))
+				offset += f.DataLen()
+			}
+			Eventually(done).Should(BeClosed())
+		})
+
 		It("bundles small writes", func() {
 			done := make(chan struct{})
 			go func() {
@@ -180,7 +209,7 @@ var _ = Describe("Send Stream", func() {
 				defer GinkgoRecover()
 				defer close(done)
 				mockSender.EXPECT().onHasStreamData(streamID)
-				_, err := strWithTimeout.Write(getData(protocol.MaxReceivePacketSize + 3))
+				_, err := strWithTimeout.Write(getData(protocol.MaxPacketBufferSize + 3))
 				Expect(err).ToNot(HaveOccurred())
 			}()
 			waitForWrite()
@@ -208,7 +237,7 @@ var _ = Describe("Send Stream", func() {
 				defer GinkgoRecover()
 				defer close(done)
 				mockSender.EXPECT().onHasStreamData(streamID)
-				_, err := str.Write(getData(protocol.MaxReceivePacketSize))
+				_, err := str.Write(getData(protocol.MaxPacketBufferSize))
 				Expect(err).ToNot(HaveOccurred())
 			}()
 			waitForWrite()
@@ -649,6 +678,27 @@ var _ = Describe("Send Stream", func() {
 		})
 	})
 
+	Context("priorities", func() {
+		It("uses the default priority", func() {
+			Expect(str.Priority()).To(Equal(protocol.DefaultStreamPriority))
+		})
+
+		It("tells the sender when the priority changes", func() {
+			p := protocol.StreamPriority{Urgency: 1}
+			mockSender.EXPECT().onStreamPriorityChanged(streamID, p)
+			str.SetPriority(p)
+			Expect(str.Priority()).To(Equal(p))
+			// setting the same priority again is a no-op
+			str.SetPriority(p)
+		})
+
+		It("caps the urgency", func() {
+			mockSender.EXPECT().onStreamPriorityChanged(streamID, protocol.StreamPriority{Urgency: protocol.MaxStreamUrgency})
+			str.SetPriority(protocol.StreamPriority{Urgency: 100})
+			Expect(str.Priority().Urgency).To(BeEquivalentTo(protocol.MaxStreamUrgency))
+		})
+	})
+
 	Context("handling MAX_STREAM_DATA frames", func() {
 		It("informs the flow controller", func() {
 			mockFC.EXPECT().UpdateSendWindow(protocol.ByteCount(0x1337))
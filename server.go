@@ -45,6 +45,7 @@ type quicSession interface {
 	earlySessionReady() <-chan struct{}
 	handlePacket(*receivedPacket)
 	GetVersion() protocol.VersionNumber
+	getConfig() *Config
 	getPerspective() protocol.Perspective
 	run() error
 	destroy(error)
@@ -99,6 +100,17 @@ type baseServer struct {
 	sessionQueue    chan quicSession
 	sessionQueueLen int32 // to be used as an atomic
 
+	rateLimiter          *sourceRateLimiter // nil if connection attempts are not rate limited
+	handshakesInProgress int32              // to be used as an atomic
+
+	draining      int32         // to be used as an atomic
+	numSessions   int32         // to be used as an atomic
+	sessionClosed chan struct{} // signaled when a session is closed
+
+	// sessionConfig is set when the server is used by a Router.
+	// It selects the configuration of a new session, based on the first Initial packet.
+	sessionConfig func(hdr *wire.Header, data []byte) (*Config, *tls.Config)
+
 	logger utils.Logger
 }
 
@@ -168,6 +180,17 @@ func ListenEarly(conn net.PacketConn, tlsConf *tls.Config, config *Config) (Earl
 }
 
 func listen(conn net.PacketConn, tlsConf *tls.Config, config *Config, acceptEarly bool) (*baseServer, error) {
+	s, err := newServer(conn, tlsConf, config, acceptEarly)
+	if err != nil {
+		return nil, err
+	}
+	s.start()
+	return s, nil
+}
+
+// newServer creates a new server.
+// It doesn't handle any packets until start is called.
+func newServer(conn net.PacketConn, tlsConf *tls.Config, config *Config, acceptEarly bool) (*baseServer, error) {
 	if tlsConf == nil {
 		return nil, errors.New("quic: tls.Config not set")
 	}
@@ -185,7 +208,7 @@ func listen(conn net.PacketConn, tlsConf *tls.Config, config *Config, acceptEarl
 	if err != nil {
 		return nil, err
 	}
-	tokenGenerator, err := handshake.NewTokenGenerator()
+	tokenGenerator, err := handshake.NewTokenGenerator(config.TokenKeyRing)
 	if err != nil {
 		return nil, err
 	}
@@ -199,17 +222,24 @@ func listen(conn net.PacketConn, tlsConf *tls.Config, config *Config, acceptEarl
 		sessionQueue:        make(chan quicSession),
 		errorChan:           make(chan struct{}),
 		running:             make(chan struct{}),
+		sessionClosed:       make(chan struct{}, 1),
 		receivedPackets:     make(chan *receivedPacket, protocol.MaxServerUnprocessedPackets),
 		newSession:          newSession,
 		logger:              utils.DefaultLogger.WithPrefix("server"),
 		acceptEarlySessions: acceptEarly,
 	}
-	go s.run()
-	sessionHandler.SetServer(s)
-	s.logger.Debugf("Listening for %s connections on %s", conn.LocalAddr().Network(), conn.LocalAddr().String())
+	if config.AdmissionControl != nil && config.AdmissionControl.SourceRate > 0 {
+		s.rateLimiter = newSourceRateLimiter(config.AdmissionControl)
+	}
 	return s, nil
 }
 
+func (s *baseServer) start() {
+	go s.run()
+	s.sessionHandler.SetServer(s)
+	s.logger.Debugf("Listening for %s connections on %s", s.conn.LocalAddr().Network(), s.conn.LocalAddr().String())
+}
+
 func (s *baseServer) run() {
 	defer close(s.running)
 	for {
@@ -393,6 +423,24 @@ func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) erro
 		return errors.New("too short connection ID")
 	}
 
+	// Drop packets from sources that exceed their rate limit before doing any work, and without sending a response.
+	// Initials carrying a valid Retry token are not rate limited: The client is retrying a connection attempt
+	// that we already charged to its rate limit when sending the Retry.
+	// The token is only decoded to check for this exemption if the packet carries one.
+	rateLimited := s.rateLimiter != nil && !s.rateLimiter.Allow(p.remoteAddr, time.Now())
+	dropRateLimited := func() {
+		p.buffer.Release()
+		s.logger.Debugf("Dropping Initial packet from %s. Rate limit exceeded.", p.remoteAddr)
+		s.traceConnectionAttempt(p.remoteAddr, logging.AdmissionRateLimited)
+		if s.config.Tracer != nil {
+			s.config.Tracer.DroppedPacket(p.remoteAddr, logging.PacketTypeInitial, p.Size(), logging.PacketDropDOSPrevention)
+		}
+	}
+	if rateLimited && len(hdr.Token) == 0 {
+		dropRateLimited()
+		return nil
+	}
+
 	var (
 		token                *Token
 		retrySrcConnectionID *protocol.ConnectionID
@@ -412,7 +460,22 @@ func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) erro
 			}
 		}
 	}
-	if !s.config.AcceptToken(p.remoteAddr, token) {
+	acceptToken := s.config.AcceptToken(p.remoteAddr, token)
+	if rateLimited && !(acceptToken && token != nil && token.IsRetryToken) {
+		dropRateLimited()
+		return nil
+	}
+
+	if atomic.LoadInt32(&s.draining) == 1 {
+		s.rejectWhileDraining(p, hdr, token)
+		return nil
+	}
+	if !acceptToken {
+		if token != nil && token.IsRetryToken {
+			s.traceConnectionAttempt(p.remoteAddr, logging.AdmissionInvalidToken)
+		} else {
+			s.traceConnectionAttempt(p.remoteAddr, logging.AdmissionRetry)
+		}
 		go func() {
 			defer p.buffer.Release()
 			if token != nil && token.IsRetryToken {
@@ -428,8 +491,36 @@ func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) erro
 		return nil
 	}
 
+	if conf := s.config.AdmissionControl; conf != nil {
+		handshakes := int(atomic.LoadInt32(&s.handshakesInProgress))
+		// Under load, require clients to validate their address, unless they already did so.
+		if token == nil && conf.RetryThreshold > 0 && handshakes >= conf.RetryThreshold {
+			s.logger.Debugf("Requiring address validation. Handshakes in progress: %d (Retry threshold %d)", handshakes, conf.RetryThreshold)
+			s.traceConnectionAttempt(p.remoteAddr, logging.AdmissionRetry)
+			go func() {
+				defer p.buffer.Release()
+				if err := s.sendRetry(p.remoteAddr, hdr); err != nil {
+					s.logger.Debugf("Error sending Retry: %s", err)
+				}
+			}()
+			return nil
+		}
+		if conf.MaxConcurrentHandshakes > 0 && handshakes >= conf.MaxConcurrentHandshakes {
+			s.logger.Debugf("Rejecting new connection. Handshakes in progress: %d (max %d)", handshakes, conf.MaxConcurrentHandshakes)
+			s.traceConnectionAttempt(p.remoteAddr, logging.AdmissionRefusedHandshakeLimit)
+			go func() {
+				defer p.buffer.Release()
+				if err := s.sendConnectionRefused(p.remoteAddr, hdr); err != nil {
+					s.logger.Debugf("Error rejecting connection: %s", err)
+				}
+			}()
+			return nil
+		}
+	}
+
 	if queueLen := atomic.LoadInt32(&s.sessionQueueLen); queueLen >= protocol.MaxAcceptQueueSize {
 		s.logger.Debugf("Rejecting new connection. Server currently busy. Accept queue length: %d (max %d)", queueLen, protocol.MaxAcceptQueueSize)
+		s.traceConnectionAttempt(p.remoteAddr, logging.AdmissionRefusedQueueFull)
 		go func() {
 			if err := s.sendConnectionRefused(p.remoteAddr, hdr); err != nil {
 				s.logger.Debugf("Error rejecting connection: %s", err)
@@ -438,11 +529,15 @@ func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) erro
 		return nil
 	}
 
-	connID, err := protocol.GenerateConnectionID(s.config.ConnectionIDLength)
+	connID, err := s.generateConnectionID()
 	if err != nil {
 		return err
 	}
 	s.logger.Debugf("Changing connection ID to %s.", connID)
+	config, tlsConf := s.config, s.tlsConf
+	if s.sessionConfig != nil {
+		config, tlsConf = s.sessionConfig(hdr, p.data)
+	}
 	sess := s.createNewSession(
 		p.remoteAddr,
 		origDestConnectionID,
@@ -451,11 +546,18 @@ func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) erro
 		hdr.SrcConnectionID,
 		connID,
 		hdr.Version,
+		config,
+		tlsConf,
 	)
 	if sess == nil {
 		p.buffer.Release()
 		return nil
 	}
+	s.traceConnectionAttempt(p.remoteAddr, logging.AdmissionAccepted)
+	if s.config.AdmissionControl != nil {
+		atomic.AddInt32(&s.handshakesInProgress, 1)
+		go s.trackHandshake(sess)
+	}
 	sess.handlePacket(p)
 	for {
 		p := s.zeroRTTQueue.Dequeue(hdr.DestConnectionID)
@@ -467,6 +569,15 @@ func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) erro
 	return nil
 }
 
+// generateConnectionID generates a connection ID for a new connection,
+// using the ConnectionIDGenerator, if one is configured.
+func (s *baseServer) generateConnectionID() (protocol.ConnectionID, error) {
+	if s.config.ConnectionIDGenerator != nil {
+		return s.config.ConnectionIDGenerator.GenerateConnectionID()
+	}
+	return protocol.GenerateConnectionID(s.config.ConnectionIDLength)
+}
+
 func (s *baseServer) createNewSession(
 	remoteAddr net.Addr,
 	origDestConnID protocol.ConnectionID,
@@ -475,6 +586,8 @@ func (s *baseServer) createNewSession(
 	destConnID protocol.ConnectionID,
 	srcConnID protocol.ConnectionID,
 	version protocol.VersionNumber,
+	config *Config,
+	tlsConf *tls.Config,
 ) quicSession {
 	var sess quicSession
 	if added := s.sessionHandler.AddWithConnID(clientDestConnID, srcConnID, func() packetHandler {
@@ -496,8 +609,8 @@ func (s *baseServer) createNewSession(
 			destConnID,
 			srcConnID,
 			s.sessionHandler.GetStatelessResetToken(srcConnID),
-			s.config,
-			s.tlsConf,
+			config,
+			tlsConf,
 			s.tokenGenerator,
 			s.acceptEarlySessions,
 			tracer,
@@ -508,6 +621,7 @@ func (s *baseServer) createNewSession(
 	}); !added {
 		return nil
 	}
+	atomic.AddInt32(&s.numSessions, 1)
 	go sess.run()
 	go s.handleNewSession(sess)
 	return sess
@@ -515,6 +629,7 @@ func (s *baseServer) createNewSession(
 
 func (s *baseServer) handleNewSession(sess quicSession) {
 	sessCtx := sess.Context()
+	go s.trackSession(sessCtx)
 	if s.acceptEarlySessions {
 		// wait until the early session is ready (or the handshake fails)
 		select {
@@ -545,7 +660,7 @@ func (s *baseServer) sendRetry(remoteAddr net.Addr, hdr *wire.Header) error {
 	// Log the Initial packet now.
 	// If no Retry is sent, the packet will be logged by the session.
 	(&wire.ExtendedHeader{Header: *hdr}).Log(s.logger)
-	srcConnID, err := protocol.GenerateConnectionID(s.config.ConnectionIDLength)
+	srcConnID, err := s.generateConnectionID()
 	if err != nil {
 		return err
 	}
@@ -568,7 +683,7 @@ func (s *baseServer) sendRetry(remoteAddr net.Addr, hdr *wire.Header) error {
 		return err
 	}
 	// append the Retry integrity tag
-	tag := handshake.GetRetryIntegrityTag(buf.Bytes(), hdr.DestConnectionID)
+	tag := handshake.GetRetryIntegrityTag(buf.Bytes(), hdr.DestConnectionID, hdr.Version)
 	buf.Write(tag[:])
 	if s.config.Tracer != nil {
 		s.config.Tracer.SentPacket(remoteAddr, &replyHdr.Header, protocol.ByteCount(buf.Len()), nil)
@@ -580,7 +695,7 @@ func (s *baseServer) sendRetry(remoteAddr net.Addr, hdr *wire.Header) error {
 func (s *baseServer) maybeSendInvalidToken(p *receivedPacket, hdr *wire.Header) error {
 	// Only send INVALID_TOKEN if we can unprotect the packet.
 	// This makes sure that we won't send it for packets that were corrupted.
-	sealer, opener := handshake.NewInitialAEAD(hdr.DestConnectionID, protocol.PerspectiveServer)
+	sealer, opener := handshake.NewInitialAEAD(hdr.DestConnectionID, protocol.PerspectiveServer, hdr.Version)
 	data := p.data[:hdr.ParsedLen()+hdr.Length]
 	extHdr, err := unpackHeader(opener, hdr, data, hdr.Version)
 	if err != nil {
@@ -605,7 +720,7 @@ func (s *baseServer) maybeSendInvalidToken(p *receivedPacket, hdr *wire.Header)
 }
 
 func (s *baseServer) sendConnectionRefused(remoteAddr net.Addr, hdr *wire.Header) error {
-	sealer, _ := handshake.NewInitialAEAD(hdr.DestConnectionID, protocol.PerspectiveServer)
+	sealer, _ := handshake.NewInitialAEAD(hdr.DestConnectionID, protocol.PerspectiveServer, hdr.Version)
 	return s.sendError(remoteAddr, hdr, sealer, qerr.ConnectionRefused)
 }
 
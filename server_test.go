@@ -57,7 +57,7 @@ var _ = Describe("Server", func() {
 		n := buf.Len()
 		buf.Write(p)
 		data := buffer.Data[:buf.Len()]
-		sealer, _ := handshake.NewInitialAEAD(hdr.DestConnectionID, protocol.PerspectiveClient)
+		sealer, _ := handshake.NewInitialAEAD(hdr.DestConnectionID, protocol.PerspectiveClient, hdr.Version)
 		_ = sealer.Seal(data[n:n], data[n:], 0x42, data[:n])
 		data = data[:len(data)+16]
 		sealer.EncryptHeader(data[n:n+16], &data[0], data[n-4:n])
@@ -191,6 +191,7 @@ var _ = Describe("Server", func() {
 
 		BeforeEach(func() {
 			tracer = mocks.NewMockTracer(mockCtrl)
+			tracer.EXPECT().HandledConnectionAttempt(gomock.Any(), gomock.Any()).AnyTimes()
 			ln, err := Listen(conn, tlsConf, &Config{Tracer: tracer})
 			Expect(err).ToNot(HaveOccurred())
 			serv = ln.(*baseServer)
@@ -471,7 +472,7 @@ var _ = Describe("Server", func() {
 				Expect(replyHdr.SrcConnectionID).ToNot(Equal(hdr.DestConnectionID))
 				Expect(replyHdr.DestConnectionID).To(Equal(hdr.SrcConnectionID))
 				Expect(replyHdr.Token).ToNot(BeEmpty())
-				Expect(write.data[len(write.data)-16:]).To(Equal(handshake.GetRetryIntegrityTag(write.data[:len(write.data)-16], hdr.DestConnectionID)[:]))
+				Expect(write.data[len(write.data)-16:]).To(Equal(handshake.GetRetryIntegrityTag(write.data[:len(write.data)-16], hdr.DestConnectionID, hdr.Version)[:]))
 			})
 
 			It("sends an INVALID_TOKEN error, if an invalid retry token is received", func() {
@@ -507,7 +508,7 @@ var _ = Describe("Server", func() {
 				Expect(replyHdr.Type).To(Equal(protocol.PacketTypeInitial))
 				Expect(replyHdr.SrcConnectionID).To(Equal(hdr.DestConnectionID))
 				Expect(replyHdr.DestConnectionID).To(Equal(hdr.SrcConnectionID))
-				_, opener := handshake.NewInitialAEAD(hdr.DestConnectionID, protocol.PerspectiveClient)
+				_, opener := handshake.NewInitialAEAD(hdr.DestConnectionID, protocol.PerspectiveClient, hdr.Version)
 				extHdr, err := unpackHeader(opener, replyHdr, write.data, hdr.Version)
 				Expect(err).ToNot(HaveOccurred())
 				data, err := opener.Open(nil, write.data[extHdr.ParsedLen():], extHdr.PacketNumber, write.data[:extHdr.ParsedLen()])
@@ -966,7 +967,7 @@ var _ = Describe("Server", func() {
 					return true
 				})
 				tracer.EXPECT().TracerForConnection(protocol.PerspectiveServer, gomock.Any())
-				serv.createNewSession(&net.UDPAddr{}, nil, nil, nil, nil, nil, protocol.VersionWhatever)
+				serv.createNewSession(&net.UDPAddr{}, nil, nil, nil, nil, nil, protocol.VersionWhatever, serv.config, serv.tlsConf)
 				Consistently(done).ShouldNot(BeClosed())
 				cancel() // complete the handshake
 				Eventually(done).Should(BeClosed())
@@ -974,6 +975,378 @@ var _ = Describe("Server", func() {
 		})
 	})
 
+	Context("admission control", func() {
+		var (
+			serv   *baseServer
+			phm    *MockPacketHandlerManager
+			tracer *mocks.MockTracer
+		)
+
+		BeforeEach(func() {
+			tracer = mocks.NewMockTracer(mockCtrl)
+			ln, err := Listen(conn, tlsConf, &Config{
+				Tracer: tracer,
+				AdmissionControl: &AdmissionControl{
+					MaxConcurrentHandshakes: 4,
+					RetryThreshold:          2,
+					SourceRate:              1,
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			serv = ln.(*baseServer)
+			phm = NewMockPacketHandlerManager(mockCtrl)
+			serv.sessionHandler = phm
+		})
+
+		AfterEach(func() {
+			phm.EXPECT().CloseServer().MaxTimes(1)
+			serv.Close()
+		})
+
+		It("drops connection attempts exceeding the rate limit", func() {
+			var acceptTokenCalls int32
+			serv.config.AcceptToken = func(net.Addr, *Token) bool {
+				atomic.AddInt32(&acceptTokenCalls, 1)
+				return false
+			}
+			p := getInitialWithRandomDestConnID()
+			tracer.EXPECT().HandledConnectionAttempt(p.remoteAddr, logging.AdmissionRetry)
+			tracer.EXPECT().SentPacket(p.remoteAddr, gomock.Any(), gomock.Any(), nil)
+			serv.handlePacket(p)
+			Eventually(conn.dataWritten).Should(Receive())
+
+			p = getInitialWithRandomDestConnID()
+			done := make(chan struct{})
+			tracer.EXPECT().HandledConnectionAttempt(p.remoteAddr, logging.AdmissionRateLimited)
+			tracer.EXPECT().DroppedPacket(p.remoteAddr, logging.PacketTypeInitial, p.Size(), logging.PacketDropDOSPrevention).Do(func(net.Addr, logging.PacketType, protocol.ByteCount, logging.PacketDropReason) {
+				close(done)
+			})
+			serv.handlePacket(p)
+			Eventually(done).Should(BeClosed())
+			Consistently(conn.dataWritten).ShouldNot(Receive())
+			// the rate limiter is checked before processing the token
+			Expect(atomic.LoadInt32(&acceptTokenCalls)).To(BeEquivalentTo(1))
+		})
+
+		It("sends a Retry if too many handshakes are in progress", func() {
+			serv.config.AcceptToken = func(net.Addr, *Token) bool { return true }
+			atomic.StoreInt32(&serv.handshakesInProgress, 2)
+			p := getInitialWithRandomDestConnID()
+			hdr, _, _, err := wire.ParsePacket(p.data, 0)
+			Expect(err).ToNot(HaveOccurred())
+			tracer.EXPECT().HandledConnectionAttempt(p.remoteAddr, logging.AdmissionRetry)
+			tracer.EXPECT().SentPacket(p.remoteAddr, gomock.Any(), gomock.Any(), nil)
+			serv.handlePacket(p)
+			var write mockPacketConnWrite
+			Eventually(conn.dataWritten).Should(Receive(&write))
+			Expect(write.to).To(Equal(p.remoteAddr))
+			replyHdr := parseHeader(write.data)
+			Expect(replyHdr.Type).To(Equal(protocol.PacketTypeRetry))
+			Expect(replyHdr.DestConnectionID).To(Equal(hdr.SrcConnectionID))
+		})
+
+		It("doesn't rate limit Initials carrying a valid Retry token", func() {
+			serv.config.AcceptToken = func(addr net.Addr, token *Token) bool {
+				return token == nil || defaultAcceptToken(addr, token)
+			}
+			atomic.StoreInt32(&serv.handshakesInProgress, 2)
+			p := getInitialWithRandomDestConnID()
+			tracer.EXPECT().HandledConnectionAttempt(p.remoteAddr, logging.AdmissionRetry)
+			tracer.EXPECT().SentPacket(p.remoteAddr, gomock.Any(), gomock.Any(), nil)
+			serv.handlePacket(p)
+			var write mockPacketConnWrite
+			Eventually(conn.dataWritten).Should(Receive(&write))
+			retryHdr := parseHeader(write.data)
+			Expect(retryHdr.Type).To(Equal(protocol.PacketTypeRetry))
+
+			// The source already used up its burst of 1 for the first Initial.
+			// The retried Initial is accepted nevertheless.
+			handshakeCtx, handshakeComplete := context.WithCancel(context.Background())
+			defer handshakeComplete()
+			sess := NewMockQuicSession(mockCtrl)
+			serv.newSession = func(
+				_ sendConn,
+				_ sessionRunner,
+				_ protocol.ConnectionID,
+				retrySrcConnID *protocol.ConnectionID,
+				_ protocol.ConnectionID,
+				_ protocol.ConnectionID,
+				_ protocol.ConnectionID,
+				_ protocol.StatelessResetToken,
+				_ *Config,
+				_ *tls.Config,
+				_ *handshake.TokenGenerator,
+				_ bool,
+				_ logging.ConnectionTracer,
+				_ utils.Logger,
+				_ protocol.VersionNumber,
+			) quicSession {
+				Expect(*retrySrcConnID).To(Equal(retryHdr.SrcConnectionID))
+				sess.EXPECT().handlePacket(gomock.Any())
+				sess.EXPECT().run()
+				sess.EXPECT().Context().Return(context.Background()).AnyTimes()
+				sess.EXPECT().HandshakeComplete().Return(handshakeCtx).AnyTimes()
+				return sess
+			}
+			phm.EXPECT().AddWithConnID(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_, _ protocol.ConnectionID, fn func() packetHandler) bool {
+				phm.EXPECT().GetStatelessResetToken(gomock.Any())
+				fn()
+				return true
+			})
+			p = getPacket(&wire.Header{
+				IsLongHeader:     true,
+				Type:             protocol.PacketTypeInitial,
+				SrcConnectionID:  retryHdr.DestConnectionID,
+				DestConnectionID: retryHdr.SrcConnectionID,
+				Token:            retryHdr.Token,
+				Version:          protocol.VersionTLS,
+			}, make([]byte, protocol.MinInitialPacketSize))
+			tracer.EXPECT().HandledConnectionAttempt(p.remoteAddr, logging.AdmissionAccepted)
+			tracer.EXPECT().TracerForConnection(protocol.PerspectiveServer, gomock.Any())
+			serv.handlePacket(p)
+			Eventually(func() int32 { return atomic.LoadInt32(&serv.handshakesInProgress) }).Should(BeEquivalentTo(3))
+			// make the go routine passing the session to Accept return
+			phm.EXPECT().CloseServer()
+			sess.EXPECT().getPerspective().AnyTimes()
+			Expect(serv.Close()).To(Succeed())
+		})
+
+		It("refuses connection attempts if the handshake limit is reached", func() {
+			serv.config.AcceptToken = func(net.Addr, *Token) bool { return true }
+			atomic.StoreInt32(&serv.handshakesInProgress, 4)
+			token, err := serv.tokenGenerator.NewRetryToken(&net.UDPAddr{}, nil, nil)
+			Expect(err).ToNot(HaveOccurred())
+			p := getPacket(&wire.Header{
+				IsLongHeader:     true,
+				Type:             protocol.PacketTypeInitial,
+				SrcConnectionID:  protocol.ConnectionID{5, 4, 3, 2, 1},
+				DestConnectionID: protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8},
+				Token:            token,
+				Version:          protocol.VersionTLS,
+			}, make([]byte, protocol.MinInitialPacketSize))
+			tracer.EXPECT().HandledConnectionAttempt(p.remoteAddr, logging.AdmissionRefusedHandshakeLimit)
+			tracer.EXPECT().SentPacket(p.remoteAddr, gomock.Any(), gomock.Any(), gomock.Any())
+			serv.handlePacket(p)
+			var write mockPacketConnWrite
+			Eventually(conn.dataWritten).Should(Receive(&write))
+			Expect(write.to).To(Equal(p.remoteAddr))
+			replyHdr := parseHeader(write.data)
+			Expect(replyHdr.Type).To(Equal(protocol.PacketTypeInitial))
+			Expect(replyHdr.DestConnectionID).To(Equal(protocol.ConnectionID{5, 4, 3, 2, 1}))
+		})
+
+		It("counts the handshakes in progress", func() {
+			serv.config.AcceptToken = func(net.Addr, *Token) bool { return true }
+			handshakeCtx, handshakeComplete := context.WithCancel(context.Background())
+			sess := NewMockQuicSession(mockCtrl)
+			serv.newSession = func(
+				_ sendConn,
+				_ sessionRunner,
+				_ protocol.ConnectionID,
+				_ *protocol.ConnectionID,
+				_ protocol.ConnectionID,
+				_ protocol.ConnectionID,
+				_ protocol.ConnectionID,
+				_ protocol.StatelessResetToken,
+				_ *Config,
+				_ *tls.Config,
+				_ *handshake.TokenGenerator,
+				_ bool,
+				_ logging.ConnectionTracer,
+				_ utils.Logger,
+				_ protocol.VersionNumber,
+			) quicSession {
+				sess.EXPECT().handlePacket(gomock.Any())
+				sess.EXPECT().run()
+				sess.EXPECT().Context().Return(context.Background()).Times(2)
+				sess.EXPECT().HandshakeComplete().Return(handshakeCtx).Times(2)
+				return sess
+			}
+			phm.EXPECT().AddWithConnID(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_, _ protocol.ConnectionID, fn func() packetHandler) bool {
+				phm.EXPECT().GetStatelessResetToken(gomock.Any())
+				fn()
+				return true
+			})
+			p := getInitialWithRandomDestConnID()
+			tracer.EXPECT().HandledConnectionAttempt(p.remoteAddr, logging.AdmissionAccepted)
+			tracer.EXPECT().TracerForConnection(protocol.PerspectiveServer, gomock.Any())
+			serv.handlePacket(p)
+			Eventually(func() int32 { return atomic.LoadInt32(&serv.handshakesInProgress) }).Should(BeEquivalentTo(1))
+			handshakeComplete()
+			Eventually(func() int32 { return atomic.LoadInt32(&serv.handshakesInProgress) }).Should(BeZero())
+			// make the go routine passing the session to Accept return
+			phm.EXPECT().CloseServer()
+			sess.EXPECT().getPerspective().AnyTimes()
+			Expect(serv.Close()).To(Succeed())
+		})
+	})
+
+	Context("draining", func() {
+		var (
+			serv   *baseServer
+			phm    *MockPacketHandlerManager
+			tracer *mocks.MockTracer
+		)
+
+		BeforeEach(func() {
+			tracer = mocks.NewMockTracer(mockCtrl)
+			ln, err := Listen(conn, tlsConf, &Config{
+				Tracer:           tracer,
+				AdmissionControl: &AdmissionControl{},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			serv = ln.(*baseServer)
+			phm = NewMockPacketHandlerManager(mockCtrl)
+			serv.sessionHandler = phm
+		})
+
+		AfterEach(func() {
+			phm.EXPECT().CloseServer().MaxTimes(1)
+			serv.Close()
+		})
+
+		// startDraining starts draining the server, pretending that a session is still open
+		startDraining := func() <-chan error {
+			atomic.StoreInt32(&serv.numSessions, 1)
+			tracer.EXPECT().Draining(1)
+			errChan := make(chan error, 1)
+			go func() {
+				defer GinkgoRecover()
+				errChan <- serv.Drain(context.Background())
+			}()
+			Eventually(func() int32 { return atomic.LoadInt32(&serv.draining) }).Should(BeEquivalentTo(1))
+			return errChan
+		}
+
+		It("closes the server right away if there are no sessions", func() {
+			tracer.EXPECT().Draining(0)
+			phm.EXPECT().CloseServer()
+			Expect(serv.Drain(context.Background())).To(Succeed())
+			_, err := serv.Accept(context.Background())
+			Expect(err).To(MatchError("server closed"))
+		})
+
+		It("refuses connection attempts", func() {
+			errChan := startDraining()
+			serv.config.AcceptToken = func(net.Addr, *Token) bool { return true }
+			p := getInitialWithRandomDestConnID()
+			tracer.EXPECT().HandledConnectionAttempt(p.remoteAddr, logging.AdmissionRefusedDraining)
+			tracer.EXPECT().SentPacket(p.remoteAddr, gomock.Any(), gomock.Any(), gomock.Any())
+			serv.handlePacket(p)
+			var write mockPacketConnWrite
+			Eventually(conn.dataWritten).Should(Receive(&write))
+			Expect(write.to).To(Equal(p.remoteAddr))
+			replyHdr := parseHeader(write.data)
+			Expect(replyHdr.Type).To(Equal(protocol.PacketTypeInitial))
+			Expect(replyHdr.DestConnectionID).To(Equal(protocol.ConnectionID{5, 4, 3, 2, 1}))
+			Consistently(errChan).ShouldNot(Receive())
+		})
+
+		It("sends a Retry, if configured", func() {
+			serv.config.AdmissionControl.DrainWithRetry = true
+			startDraining()
+			serv.config.AcceptToken = func(net.Addr, *Token) bool { return true }
+			p := getInitialWithRandomDestConnID()
+			tracer.EXPECT().HandledConnectionAttempt(p.remoteAddr, logging.AdmissionRetry)
+			tracer.EXPECT().SentPacket(p.remoteAddr, gomock.Any(), gomock.Any(), nil)
+			serv.handlePacket(p)
+			var write mockPacketConnWrite
+			Eventually(conn.dataWritten).Should(Receive(&write))
+			Expect(write.to).To(Equal(p.remoteAddr))
+			Expect(parseHeader(write.data).Type).To(Equal(protocol.PacketTypeRetry))
+		})
+
+		It("refuses connection attempts with a Retry token, even if Retries are configured", func() {
+			serv.config.AdmissionControl.DrainWithRetry = true
+			startDraining()
+			serv.config.AcceptToken = func(net.Addr, *Token) bool { return true }
+			token, err := serv.tokenGenerator.NewRetryToken(&net.UDPAddr{}, nil, nil)
+			Expect(err).ToNot(HaveOccurred())
+			p := getPacket(&wire.Header{
+				IsLongHeader:     true,
+				Type:             protocol.PacketTypeInitial,
+				SrcConnectionID:  protocol.ConnectionID{5, 4, 3, 2, 1},
+				DestConnectionID: protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8},
+				Token:            token,
+				Version:          protocol.VersionTLS,
+			}, make([]byte, protocol.MinInitialPacketSize))
+			tracer.EXPECT().HandledConnectionAttempt(p.remoteAddr, logging.AdmissionRefusedDraining)
+			tracer.EXPECT().SentPacket(p.remoteAddr, gomock.Any(), gomock.Any(), gomock.Any())
+			serv.handlePacket(p)
+			var write mockPacketConnWrite
+			Eventually(conn.dataWritten).Should(Receive(&write))
+			Expect(parseHeader(write.data).Type).To(Equal(protocol.PacketTypeInitial))
+		})
+
+		It("waits for sessions to close", func() {
+			serv.config.AcceptToken = func(net.Addr, *Token) bool { return true }
+			sessCtx, closeSession := context.WithCancel(context.Background())
+			sess := NewMockQuicSession(mockCtrl)
+			serv.newSession = func(
+				_ sendConn,
+				_ sessionRunner,
+				_ protocol.ConnectionID,
+				_ *protocol.ConnectionID,
+				_ protocol.ConnectionID,
+				_ protocol.ConnectionID,
+				_ protocol.ConnectionID,
+				_ protocol.StatelessResetToken,
+				_ *Config,
+				_ *tls.Config,
+				_ *handshake.TokenGenerator,
+				_ bool,
+				_ logging.ConnectionTracer,
+				_ utils.Logger,
+				_ protocol.VersionNumber,
+			) quicSession {
+				sess.EXPECT().handlePacket(gomock.Any())
+				sess.EXPECT().run()
+				sess.EXPECT().Context().Return(sessCtx).Times(2)
+				sess.EXPECT().HandshakeComplete().Return(context.Background()).Times(2)
+				return sess
+			}
+			phm.EXPECT().AddWithConnID(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_, _ protocol.ConnectionID, fn func() packetHandler) bool {
+				phm.EXPECT().GetStatelessResetToken(gomock.Any())
+				fn()
+				return true
+			})
+			p := getInitialWithRandomDestConnID()
+			tracer.EXPECT().HandledConnectionAttempt(p.remoteAddr, logging.AdmissionAccepted)
+			tracer.EXPECT().TracerForConnection(protocol.PerspectiveServer, gomock.Any())
+			serv.handlePacket(p)
+			Eventually(func() int32 { return atomic.LoadInt32(&serv.numSessions) }).Should(BeEquivalentTo(1))
+
+			tracer.EXPECT().Draining(1)
+			errChan := make(chan error, 1)
+			go func() {
+				defer GinkgoRecover()
+				errChan <- serv.Drain(context.Background())
+			}()
+			Consistently(errChan).ShouldNot(Receive())
+			tracer.EXPECT().Draining(0)
+			phm.EXPECT().CloseServer()
+			closeSession()
+			Eventually(errChan).Should(Receive(BeNil()))
+		})
+
+		It("closes the server when the context is canceled", func() {
+			atomic.StoreInt32(&serv.numSessions, 1)
+			tracer.EXPECT().Draining(1)
+			phm.EXPECT().CloseServer()
+			ctx, cancel := context.WithCancel(context.Background())
+			errChan := make(chan error, 1)
+			go func() {
+				defer GinkgoRecover()
+				errChan <- serv.Drain(ctx)
+			}()
+			Consistently(errChan).ShouldNot(Receive())
+			cancel()
+			Eventually(errChan).Should(Receive(MatchError(context.Canceled)))
+			_, err := serv.Accept(context.Background())
+			Expect(err).To(MatchError("server closed"))
+		})
+	})
+
 	Context("server accepting sessions that haven't completed the handshake", func() {
 		var (
 			serv *earlyServer
@@ -1034,7 +1407,7 @@ var _ = Describe("Server", func() {
 				fn()
 				return true
 			})
-			serv.createNewSession(&net.UDPAddr{}, nil, nil, nil, nil, nil, protocol.VersionWhatever)
+			serv.createNewSession(&net.UDPAddr{}, nil, nil, nil, nil, nil, protocol.VersionWhatever, serv.config, serv.tlsConf)
 			Consistently(done).ShouldNot(BeClosed())
 			close(ready)
 			Eventually(done).Should(BeClosed())
@@ -10,6 +10,7 @@ import (
 	"net"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/lucas-clemente/quic-go/internal/ackhandler"
@@ -48,6 +49,7 @@ type streamManager interface {
 	AcceptStream(context.Context) (Stream, error)
 	AcceptUniStream(context.Context) (ReceiveStream, error)
 	DeleteStream(protocol.StreamID) error
+	NumStreams() (bidi, uni int)
 	UpdateLimits(*wire.TransportParameters) error
 	HandleMaxStreamsFrame(*wire.MaxStreamsFrame) error
 	CloseWithError(error)
@@ -56,7 +58,9 @@ type streamManager interface {
 type cryptoStreamHandler interface {
 	RunHandshake()
 	ChangeConnectionID(protocol.ConnectionID)
+	SetVersion(protocol.VersionNumber)
 	SetLargest1RTTAcked(protocol.PacketNumber)
+	InitiateKeyUpdate()
 	DropHandshakeKeys()
 	GetSessionTicket() ([]byte, error)
 	io.Closer
@@ -155,6 +159,9 @@ type session struct {
 	receivedPacketHandler ackhandler.ReceivedPacketHandler
 	retransmissionQueue   *retransmissionQueue
 	framer                framer
+	datagramQueue         *datagramQueue // nil if the DATAGRAM extension is disabled
+	// the peer's max_datagram_frame_size, accessed atomically, since it is used by SendMessage
+	peerMaxDatagramFrameSize uint64
 	windowUpdateQueue     *windowUpdateQueue
 	connFlowController    flowcontrol.ConnectionFlowController
 	tokenStoreKey         string                    // only set for the client
@@ -173,6 +180,8 @@ type session struct {
 	closeOnce sync.Once
 	// closeChan is used to notify the run loop that it should terminate
 	closeChan chan closeError
+	// statsRequests is used to request a statistics snapshot from the run loop
+	statsRequests chan chan ConnectionStats
 
 	ctx                context.Context
 	ctxCancel          context.CancelFunc
@@ -190,6 +199,14 @@ type session struct {
 	receivedRetry       bool
 	versionNegotiated   bool
 	receivedFirstPacket bool
+	// Only used by the server, after switching to a compatible version.
+	// The client keeps sending Initial packets of the original version, until it receives our first Initial.
+	origVersion       protocol.VersionNumber
+	acceptOrigVersion bool
+
+	// ACK frequency extension
+	nextAckFrequencySeqNum         uint64
+	requestedAckElicitingThreshold uint64 // 0 if we never sent an ACK_FREQUENCY frame
 
 	idleTimeout         time.Duration
 	sessionCreationTime time.Time
@@ -202,6 +219,8 @@ type session struct {
 
 	peerParams *wire.TransportParameters
 
+	mtuDiscoverer mtuDiscoverer // initialized when the handshake completes
+
 	timer *utils.Timer
 	// keepAlivePingSent stores whether a keep alive PING is in flight.
 	// It is reset as soon as we receive a packet from the peer.
@@ -219,6 +238,8 @@ type session struct {
 	encoderEnabled     bool
 	decoderEnabled     bool
 	rQuicBuffer        *rQuicReceivedPacketList
+	// numRecoveredPackets counts the packets recovered by the decoder
+	numRecoveredPackets uint64
 	// } rQUIC
 }
 
@@ -271,6 +292,7 @@ var newSession = func(
 	s.connIDGenerator = newConnIDGenerator(
 		srcConnID,
 		clientDestConnID,
+		s.config.ConnectionIDGenerator,
 		func(connID protocol.ConnectionID) { runner.Add(connID, s) },
 		runner.GetStatelessResetToken,
 		runner.Remove,
@@ -300,12 +322,20 @@ var newSession = func(
 		MaxUniStreamNum:                 protocol.StreamNum(s.config.MaxIncomingUniStreams),
 		MaxAckDelay:                     protocol.MaxAckDelayInclGranularity,
 		AckDelayExponent:                protocol.AckDelayExponent,
+		MinAckDelay:                     protocol.MinAckDelay,
 		DisableActiveMigration:          true,
 		StatelessResetToken:             &statelessResetToken,
 		OriginalDestinationConnectionID: origDestConnID,
 		ActiveConnectionIDLimit:         protocol.MaxActiveConnectionIDs,
 		InitialSourceConnectionID:       srcConnID,
 		RetrySourceConnectionID:         retrySrcConnID,
+		VersionInformation: &wire.VersionInformation{
+			ChosenVersion:     s.version,
+			AvailableVersions: s.config.Versions,
+		},
+	}
+	if s.config.EnableDatagrams {
+		params.MaxDatagramFrameSize = protocol.MaxDatagramFrameSize
 	}
 	if s.tracer != nil {
 		s.tracer.SentTransportParameters(params)
@@ -328,9 +358,11 @@ var newSession = func(
 		},
 		tlsConf,
 		enable0RTT,
+		handshake.KeyUpdatePolicy{Interval: s.config.KeyUpdateInterval, Period: s.config.KeyUpdatePeriod},
 		s.rttStats,
 		tracer,
 		logger,
+		s.version,
 	)
 	s.cryptoStreamHandler = cs
 	s.packer = newPacketPacker(
@@ -344,6 +376,7 @@ var newSession = func(
 		cs,
 		s.framer,
 		s.receivedPacketHandler,
+		s.datagramQueue,
 		s.perspective,
 		s.version,
 	)
@@ -396,6 +429,7 @@ var newClientSession = func(
 	s.connIDGenerator = newConnIDGenerator(
 		srcConnID,
 		nil,
+		s.config.ConnectionIDGenerator,
 		func(connID protocol.ConnectionID) { runner.Add(connID, s) },
 		runner.GetStatelessResetToken,
 		runner.Remove,
@@ -425,9 +459,17 @@ var newClientSession = func(
 		MaxUniStreamNum:                protocol.StreamNum(s.config.MaxIncomingUniStreams),
 		MaxAckDelay:                    protocol.MaxAckDelayInclGranularity,
 		AckDelayExponent:               protocol.AckDelayExponent,
+		MinAckDelay:                    protocol.MinAckDelay,
 		DisableActiveMigration:         true,
 		ActiveConnectionIDLimit:        protocol.MaxActiveConnectionIDs,
 		InitialSourceConnectionID:      srcConnID,
+		VersionInformation: &wire.VersionInformation{
+			ChosenVersion:     s.version,
+			AvailableVersions: s.config.Versions,
+		},
+	}
+	if s.config.EnableDatagrams {
+		params.MaxDatagramFrameSize = protocol.MaxDatagramFrameSize
 	}
 	if s.tracer != nil {
 		s.tracer.SentTransportParameters(params)
@@ -447,9 +489,11 @@ var newClientSession = func(
 		},
 		tlsConf,
 		enable0RTT,
+		handshake.KeyUpdatePolicy{Interval: s.config.KeyUpdateInterval, Period: s.config.KeyUpdatePeriod},
 		s.rttStats,
 		tracer,
 		logger,
+		s.version,
 	)
 	s.clientHelloWritten = clientHelloWritten
 	s.cryptoStreamHandler = cs
@@ -466,6 +510,7 @@ var newClientSession = func(
 		cs,
 		s.framer,
 		s.receivedPacketHandler,
+		s.datagramQueue,
 		s.perspective,
 		s.version,
 	)
@@ -494,7 +539,9 @@ func (s *session) preSetup() {
 		protocol.InitialMaxData,
 		protocol.ByteCount(s.config.MaxReceiveConnectionFlowControlWindow),
 		s.onHasConnectionWindowUpdate,
+		s.config.MemoryBudget,
 		s.rttStats,
+		s.tracer,
 		s.logger,
 	)
 	s.earlySessionReadyChan = make(chan struct{})
@@ -507,9 +554,13 @@ func (s *session) preSetup() {
 		s.version,
 	)
 	s.framer = newFramer(s.streamsMap, s.version)
+	if s.config.EnableDatagrams {
+		s.datagramQueue = newDatagramQueue(s.scheduleSending, s.logger)
+	}
 	s.receivedPackets = make(chan *receivedPacket, protocol.MaxSessionUnprocessedPackets)
 	s.closeChan = make(chan closeError, 1)
 	s.sendingScheduled = make(chan struct{}, 1)
+	s.statsRequests = make(chan chan ConnectionStats)
 	s.undecryptablePackets = make([]*receivedPacket, 0, protocol.MaxUndecryptablePackets)
 	s.ctx, s.ctxCancel = context.WithCancel(context.Background())
 	s.handshakeCtx, s.handshakeCtxCancel = context.WithCancel(context.Background())
@@ -641,6 +692,9 @@ runLoop:
 			}
 		case <-s.handshakeCompleteChan:
 			s.handleHandshakeComplete()
+		case req := <-s.statsRequests:
+			req <- s.collectStats()
+			continue
 		}
 
 		now := time.Now()
@@ -709,10 +763,39 @@ func (s *session) Context() context.Context {
 	return s.ctx
 }
 
+// SendMessage sends a message as a DATAGRAM frame.
+// It blocks until the frame has been packed into a packet.
+func (s *session) SendMessage(p []byte) error {
+	maxDatagramFrameSize := protocol.ByteCount(atomic.LoadUint64(&s.peerMaxDatagramFrameSize))
+	if !s.config.EnableDatagrams || maxDatagramFrameSize == 0 {
+		return errors.New("datagram support disabled")
+	}
+	f := &wire.DatagramFrame{DataLenPresent: true}
+	if protocol.ByteCount(len(p)) > f.MaxDataLen(maxDatagramFrameSize, s.version) {
+		return errors.New("message too large")
+	}
+	f.Data = make([]byte, len(p))
+	copy(f.Data, p)
+	return s.datagramQueue.AddAndWait(f)
+}
+
+// ReceiveMessage returns the payload of the next DATAGRAM frame received.
+func (s *session) ReceiveMessage() ([]byte, error) {
+	if !s.config.EnableDatagrams {
+		return nil, errors.New("datagram support disabled")
+	}
+	return s.datagramQueue.Receive()
+}
+
 func (s *session) ConnectionState() ConnectionState {
 	return s.cryptoStreamHandler.ConnectionState()
 }
 
+func (s *session) InitiateKeyUpdate() {
+	s.cryptoStreamHandler.InitiateKeyUpdate()
+	s.scheduleSending()
+}
+
 // Time when the next keep-alive packet should be sent.
 // It returns a zero time if no keep-alive should be sent.
 func (s *session) nextKeepAliveTime() time.Time {
@@ -819,7 +902,7 @@ func (s *session) handlePacketImpl(rp *receivedPacket) bool {
 			break
 		}
 
-		if hdr.IsLongHeader && hdr.Version != s.version {
+		if hdr.IsLongHeader && hdr.Version != s.version && !s.isOrigVersionInitial(hdr) && !s.maybeSwitchToCompatibleVersion(hdr) {
 			if s.tracer != nil {
 				s.tracer.DroppedPacket(logging.PacketTypeFromHeader(hdr), protocol.ByteCount(len(data)), logging.PacketDropUnexpectedVersion)
 			}
@@ -1027,6 +1110,9 @@ func (s *session) rQuicBufferFwd(e *rQuicReceivedPacket) {
 	rp := e.removeRQuicHeader()
 	e.doNotFwd = true
 	e.delivered = s.handleSinglePacketFinish(rp, e.hdr)
+	if e.delivered && e.wasCoded() {
+		s.numRecoveredPackets++
+	}
 }
 
 func (s *session) handleSinglePacketFinish(p *receivedPacket, hdr *wire.Header) bool {
@@ -1057,6 +1143,8 @@ func (s *session) handleSinglePacketFinish(p *receivedPacket, hdr *wire.Header)
 			s.tryQueueingUndecryptablePacket(p, hdr)
 		case wire.ErrInvalidReservedBits:
 			s.closeLocal(qerr.NewError(qerr.ProtocolViolation, err.Error()))
+		case handshake.ErrAEADLimitReached:
+			s.closeLocal(err)
 		default:
 			// This might be a packet injected by an attacker.
 			// Drop it.
@@ -1071,6 +1159,11 @@ func (s *session) handleSinglePacketFinish(p *receivedPacket, hdr *wire.Header)
 		return false
 	}
 
+	if s.acceptOrigVersion && hdr.IsLongHeader && hdr.Version == s.version {
+		// The client switched to the compatible version.
+		s.acceptOrigVersion = false
+	}
+
 	if s.logger.Debug() {
 		s.logger.Debugf("<- Reading packet %d (%d bytes) for connection %s, %s", packet.packetNumber, p.Size(), hdr.DestConnectionID, packet.encryptionLevel)
 		packet.hdr.Log(s.logger)
@@ -1128,7 +1221,7 @@ func (s *session) handleRetryPacket(hdr *wire.Header, data []byte) bool /* was t
 		return false
 	}
 
-	tag := handshake.GetRetryIntegrityTag(data[:len(data)-16], destConnID)
+	tag := handshake.GetRetryIntegrityTag(data[:len(data)-16], destConnID, hdr.Version)
 	if !bytes.Equal(data[len(data)-16:], tag[:]) {
 		if s.tracer != nil {
 			s.tracer.DroppedPacket(logging.PacketTypeRetry, protocol.ByteCount(len(data)), logging.PacketDropPayloadDecryptError)
@@ -1315,7 +1408,7 @@ func (s *session) handleFrame(f wire.Frame, encLevel protocol.EncryptionLevel, d
 	case *wire.CryptoFrame:
 		err = s.handleCryptoFrame(frame, encLevel)
 	case *wire.StreamFrame:
-		err = s.handleStreamFrame(frame)
+		err = s.handleStreamFrame(frame, encLevel)
 	case *wire.AckFrame:
 		err = s.handleAckFrame(frame, encLevel)
 	case *wire.ConnectionCloseFrame:
@@ -1347,6 +1440,12 @@ func (s *session) handleFrame(f wire.Frame, encLevel protocol.EncryptionLevel, d
 		err = s.handleRetireConnectionIDFrame(frame, destConnID)
 	case *wire.HandshakeDoneFrame:
 		err = s.handleHandshakeDoneFrame()
+	case *wire.AckFrequencyFrame:
+		err = s.handleAckFrequencyFrame(frame)
+	case *wire.ImmediateAckFrame:
+		s.receivedPacketHandler.QueueImmediateAck()
+	case *wire.DatagramFrame:
+		err = s.handleDatagramFrame(frame)
 	default:
 		err = fmt.Errorf("unexpected frame type: %s", reflect.ValueOf(&frame).Elem().Type().Name())
 	}
@@ -1384,7 +1483,7 @@ func (s *session) handleCryptoFrame(frame *wire.CryptoFrame, encLevel protocol.E
 	return nil
 }
 
-func (s *session) handleStreamFrame(frame *wire.StreamFrame) error {
+func (s *session) handleStreamFrame(frame *wire.StreamFrame, encLevel protocol.EncryptionLevel) error {
 	str, err := s.streamsMap.GetOrOpenReceiveStream(frame.StreamID)
 	if err != nil {
 		return err
@@ -1394,6 +1493,9 @@ func (s *session) handleStreamFrame(frame *wire.StreamFrame) error {
 		// ignore this StreamFrame
 		return nil
 	}
+	if encLevel == protocol.Encryption0RTT {
+		str.markReceivedEarlyData()
+	}
 	return str.handleStreamFrame(frame)
 }
 
@@ -1473,6 +1575,25 @@ func (s *session) handleHandshakeDoneFrame() error {
 	return nil
 }
 
+func (s *session) handleAckFrequencyFrame(frame *wire.AckFrequencyFrame) error {
+	if frame.RequestMaxAckDelay < protocol.MinAckDelay {
+		return qerr.NewError(qerr.ProtocolViolation, fmt.Sprintf("requested max_ack_delay (%s) smaller than min_ack_delay (%s)", frame.RequestMaxAckDelay, protocol.MinAckDelay))
+	}
+	s.receivedPacketHandler.SetAckFrequency(frame)
+	return nil
+}
+
+func (s *session) handleDatagramFrame(f *wire.DatagramFrame) error {
+	if !s.config.EnableDatagrams {
+		return qerr.NewError(qerr.ProtocolViolation, "received a DATAGRAM frame, but the DATAGRAM extension is disabled")
+	}
+	if f.Length(s.version) > protocol.MaxDatagramFrameSize {
+		return qerr.NewError(qerr.ProtocolViolation, "DATAGRAM frame too large")
+	}
+	s.datagramQueue.HandleDatagramFrame(f)
+	return nil
+}
+
 func (s *session) handleAckFrame(frame *wire.AckFrame, encLevel protocol.EncryptionLevel) error {
 	if err := s.sentPacketHandler.ReceivedAck(frame, encLevel, s.lastPacketReceivedTime); err != nil {
 		return err
@@ -1484,10 +1605,47 @@ func (s *session) handleAckFrame(frame *wire.AckFrame, encLevel protocol.Encrypt
 		}
 		// } rQUIC
 		s.cryptoStreamHandler.SetLargest1RTTAcked(frame.LargestAcked())
+		s.maybeQueueAckFrequencyFrame()
 	}
 	return nil
 }
 
+func (s *session) peerSupportsAckFrequency() bool {
+	return s.peerParams != nil && s.peerParams.MinAckDelay > 0
+}
+
+// maybeQueueAckFrequencyFrame asks the peer to adjust its ACK rate to the congestion window.
+// To avoid sending too many ACK_FREQUENCY frames while the congestion window is growing,
+// a new frame is only sent if the threshold changed by at least 25%.
+func (s *session) maybeQueueAckFrequencyFrame() {
+	if !s.handshakeConfirmed || !s.peerSupportsAckFrequency() {
+		return
+	}
+	threshold := ackhandler.AckElicitingThreshold(s.sentPacketHandler.GetCongestionWindow(), protocol.MaxPacketSizeIPv4)
+	requested := s.requestedAckElicitingThreshold
+	if requested == 0 {
+		requested = 1 // the default value, if no ACK_FREQUENCY frame was sent
+	}
+	var diff uint64
+	if threshold > requested {
+		diff = threshold - requested
+	} else {
+		diff = requested - threshold
+	}
+	if diff == 0 || 4*diff < requested {
+		return
+	}
+	s.requestedAckElicitingThreshold = threshold
+	s.queueControlFrame(&wire.AckFrequencyFrame{
+		SequenceNumber:        s.nextAckFrequencySeqNum,
+		AckElicitingThreshold: threshold,
+		// Don't change the max_ack_delay, so that we don't need to adjust the PTO calculation.
+		RequestMaxAckDelay:  s.peerParams.MaxAckDelay,
+		ReorderingThreshold: ackhandler.ReorderingThreshold,
+	})
+	s.nextAckFrequencySeqNum++
+}
+
 // closeLocal closes the session and send a CONNECTION_CLOSE containing the error
 func (s *session) closeLocal(e error) {
 	s.closeOnce.Do(func() {
@@ -1550,6 +1708,10 @@ func (s *session) handleCloseError(closeErr closeError) {
 
 	s.streamsMap.CloseWithError(quicErr)
 	s.connIDManager.Close()
+	s.connFlowController.Close()
+	if s.datagramQueue != nil {
+		s.datagramQueue.CloseWithError(quicErr)
+	}
 
 	if s.tracer != nil {
 		// timeout errors are logged as soon as they occur (to distinguish between handshake and idle timeouts)
@@ -1586,6 +1748,7 @@ func (s *session) dropEncryptionLevel(encLevel protocol.EncryptionLevel) {
 	if encLevel == protocol.EncryptionHandshake {
 		s.handshakeConfirmed = true
 		s.sentPacketHandler.SetHandshakeConfirmed()
+		s.maybeStartMTUDiscovery()
 	}
 	s.sentPacketHandler.DropPackets(encLevel)
 	s.receivedPacketHandler.DropPackets(encLevel)
@@ -1594,6 +1757,22 @@ func (s *session) dropEncryptionLevel(encLevel protocol.EncryptionLevel) {
 	}
 }
 
+func (s *session) maybeStartMTUDiscovery() {
+	if s.config.DisablePathMTUDiscovery || !s.conn.DontFragment() || s.peerParams == nil {
+		return
+	}
+	maxPacketSize := protocol.ByteCount(protocol.MaxPacketBufferSize)
+	if s.peerParams.MaxUDPPayloadSize != 0 {
+		maxPacketSize = utils.MinByteCount(maxPacketSize, s.peerParams.MaxUDPPayloadSize)
+	}
+	startSize := utils.MinByteCount(getMaxPacketSize(s.conn.RemoteAddr()), maxPacketSize)
+	s.mtuDiscoverer = newMTUDiscoverer(s.rttStats, startSize, maxPacketSize, func(size protocol.ByteCount) {
+		s.logger.Debugf("Path MTU Discovery: increasing the packet size to %d bytes", size)
+		s.sentPacketHandler.SetMaxDatagramSize(size)
+		s.packer.SetMaxPacketSize(size)
+	})
+}
+
 // is called for the client, when restoring transport parameters saved for 0-RTT
 func (s *session) restoreTransportParameters(params *wire.TransportParameters) {
 	if s.logger.Debug() {
@@ -1601,6 +1780,7 @@ func (s *session) restoreTransportParameters(params *wire.TransportParameters) {
 	}
 
 	s.peerParams = params
+	atomic.StoreUint64(&s.peerMaxDatagramFrameSize, uint64(params.MaxDatagramFrameSize))
 	s.connIDGenerator.SetMaxActiveConnIDs(params.ActiveConnectionIDLimit)
 	s.connFlowController.UpdateSendWindow(params.InitialMaxData)
 	if err := s.streamsMap.UpdateLimits(params); err != nil {
@@ -1609,6 +1789,71 @@ func (s *session) restoreTransportParameters(params *wire.TransportParameters) {
 	}
 }
 
+// processVersionInformation authenticates the version negotiation (RFC 9368).
+// The server uses the versions offered by the client to perform compatible version negotiation.
+func (s *session) processVersionInformation(vi *wire.VersionInformation) error {
+	if vi == nil { // the peer doesn't support authenticating the version negotiation
+		return nil
+	}
+	if s.perspective == protocol.PerspectiveServer {
+		if vi.ChosenVersion != s.version {
+			return qerr.NewError(qerr.VersionNegotiationError, fmt.Sprintf("client chose %s, but used %s", vi.ChosenVersion, s.version))
+		}
+		for _, v := range s.config.Versions {
+			if v == s.version {
+				break
+			}
+			if protocol.AreCompatibleVersions(s.version, v) && protocol.IsSupportedVersion(vi.AvailableVersions, v) {
+				s.switchVersion(v)
+				break
+			}
+		}
+		return nil
+	}
+	if vi.ChosenVersion != s.version {
+		return qerr.NewError(qerr.VersionNegotiationError, fmt.Sprintf("server chose %s, but we're using %s", vi.ChosenVersion, s.version))
+	}
+	if s.versionNegotiated {
+		// Make sure that the Version Negotiation packet wasn't forged:
+		// Given the versions the server actually supports, we would have chosen the same version.
+		if v, ok := protocol.ChooseSupportedVersion(s.config.Versions, vi.AvailableVersions); !ok || v != s.version {
+			return qerr.NewError(qerr.VersionNegotiationError, fmt.Sprintf("server supports %s, but we negotiated %s", vi.AvailableVersions, s.version))
+		}
+	}
+	return nil
+}
+
+// maybeSwitchToCompatibleVersion is called by the client when it receives a long header packet
+// with an unexpected version. Servers use compatible version negotiation by replying to our first
+// Initial using a different (but compatible) version that we offered.
+func (s *session) maybeSwitchToCompatibleVersion(hdr *wire.Header) bool {
+	if s.perspective == protocol.PerspectiveServer || s.receivedFirstPacket || hdr.Type != protocol.PacketTypeInitial {
+		return false
+	}
+	if !protocol.IsSupportedVersion(s.config.Versions, hdr.Version) || !protocol.AreCompatibleVersions(s.version, hdr.Version) {
+		return false
+	}
+	s.switchVersion(hdr.Version)
+	return true
+}
+
+// isOrigVersionInitial says if a packet is an Initial packet that the client sent using the original version,
+// before it noticed that the server switched to a compatible version.
+func (s *session) isOrigVersionInitial(hdr *wire.Header) bool {
+	return s.acceptOrigVersion && hdr.Type == protocol.PacketTypeInitial && hdr.Version == s.origVersion
+}
+
+func (s *session) switchVersion(v protocol.VersionNumber) {
+	s.logger.Infof("Switching to compatible QUIC version %s.", v)
+	if s.perspective == protocol.PerspectiveServer {
+		s.origVersion = s.version
+		s.acceptOrigVersion = true
+	}
+	s.version = v
+	s.cryptoStreamHandler.SetVersion(v)
+	s.packer.SetVersion(v)
+}
+
 func (s *session) processTransportParameters(params *wire.TransportParameters) {
 	if err := s.processTransportParametersImpl(params); err != nil {
 		s.closeLocal(err)
@@ -1645,7 +1890,12 @@ func (s *session) processTransportParametersImpl(params *wire.TransportParameter
 		}
 	}
 
+	if err := s.processVersionInformation(params.VersionInformation); err != nil {
+		return err
+	}
+
 	s.peerParams = params
+	atomic.StoreUint64(&s.peerMaxDatagramFrameSize, uint64(params.MaxDatagramFrameSize))
 	// Our local idle timeout will always be > 0.
 	s.idleTimeout = utils.MinNonZeroDuration(s.config.MaxIdleTimeout, params.MaxIdleTimeout)
 	s.keepAliveInterval = utils.MinDuration(s.idleTimeout/2, protocol.MaxKeepAliveInterval)
@@ -1739,6 +1989,11 @@ func (s *session) maybeSendAckOnlyPacket() error {
 }
 
 func (s *session) sendProbePacket(encLevel protocol.EncryptionLevel) error {
+	if encLevel == protocol.Encryption1RTT && s.peerSupportsAckFrequency() {
+		// Make sure the peer acknowledges the probe packet immediately,
+		// even if we requested a lower ACK rate.
+		s.retransmissionQueue.AddAppData(&wire.ImmediateAckFrame{})
+	}
 	// Queue probe packets until we actually send out a packet,
 	// or until there are no more packets to queue.
 	var packet *packedPacket
@@ -1802,6 +2057,15 @@ func (s *session) sendPacket() (bool, error) {
 		s.sendQueue.Send(packet.buffer)
 		return true, nil
 	}
+	if s.mtuDiscoverer != nil && s.mtuDiscoverer.ShouldSendProbe(time.Now()) {
+		ping, size := s.mtuDiscoverer.GetPing()
+		packet, err := s.packer.PackMTUProbePacket(ping, size)
+		if err != nil {
+			return false, err
+		}
+		s.sendPackedPacket(packet)
+		return true, nil
+	}
 	packet, err := s.packer.PackPacket()
 	if err != nil || packet == nil {
 		return false, err
@@ -1949,7 +2213,9 @@ func (s *session) newFlowController(id protocol.StreamID) flowcontrol.StreamFlow
 		protocol.ByteCount(s.config.MaxReceiveStreamFlowControlWindow),
 		initialSendWindow,
 		s.onHasStreamWindowUpdate,
+		s.config.MemoryBudget,
 		s.rttStats,
+		s.tracer,
 		s.logger,
 	)
 }
@@ -2004,7 +2270,12 @@ func (s *session) onHasStreamData(id protocol.StreamID) {
 	s.scheduleSending()
 }
 
+func (s *session) onStreamPriorityChanged(id protocol.StreamID, p protocol.StreamPriority) {
+	s.framer.SetStreamPriority(id, p)
+}
+
 func (s *session) onStreamCompleted(id protocol.StreamID) {
+	s.framer.RemoveStream(id)
 	if err := s.streamsMap.DeleteStream(id); err != nil {
 		s.closeLocal(err)
 	}
@@ -2018,6 +2289,10 @@ func (s *session) RemoteAddr() net.Addr {
 	return s.conn.RemoteAddr()
 }
 
+func (s *session) getConfig() *Config {
+	return s.config
+}
+
 func (s *session) getPerspective() protocol.Perspective {
 	return s.perspective
 }
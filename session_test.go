@@ -11,6 +11,7 @@ import (
 	"net"
 	"runtime/pprof"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/lucas-clemente/quic-go/internal/ackhandler"
@@ -86,7 +87,8 @@ var _ = Describe("Session", func() {
 		mconn = NewMockSendConn(mockCtrl)
 		mconn.EXPECT().RemoteAddr().Return(remoteAddr).AnyTimes()
 		mconn.EXPECT().LocalAddr().Return(localAddr).AnyTimes()
-		tokenGenerator, err := handshake.NewTokenGenerator()
+		mconn.EXPECT().DontFragment().AnyTimes()
+		tokenGenerator, err := handshake.NewTokenGenerator(nil)
 		Expect(err).ToNot(HaveOccurred())
 		tracer = mocks.NewMockConnectionTracer(mockCtrl)
 		tracer.EXPECT().SentTransportParameters(gomock.Any())
@@ -133,7 +135,21 @@ var _ = Describe("Session", func() {
 				str := NewMockReceiveStreamI(mockCtrl)
 				str.EXPECT().handleStreamFrame(f)
 				streamManager.EXPECT().GetOrOpenReceiveStream(protocol.StreamID(5)).Return(str, nil)
-				Expect(sess.handleStreamFrame(f)).To(Succeed())
+				Expect(sess.handleStreamFrame(f, protocol.Encryption1RTT)).To(Succeed())
+			})
+
+			It("marks streams that receive STREAM frames in 0-RTT, before passing the frame to the stream", func() {
+				f := &wire.StreamFrame{
+					StreamID: 4,
+					Data:     []byte("foobar"),
+				}
+				str := NewMockReceiveStreamI(mockCtrl)
+				gomock.InOrder(
+					str.EXPECT().markReceivedEarlyData(),
+					str.EXPECT().handleStreamFrame(f),
+				)
+				streamManager.EXPECT().GetOrOpenReceiveStream(protocol.StreamID(4)).Return(str, nil)
+				Expect(sess.handleStreamFrame(f, protocol.Encryption0RTT)).To(Succeed())
 			})
 
 			It("returns errors", func() {
@@ -145,7 +161,7 @@ var _ = Describe("Session", func() {
 				str := NewMockReceiveStreamI(mockCtrl)
 				str.EXPECT().handleStreamFrame(f).Return(testErr)
 				streamManager.EXPECT().GetOrOpenReceiveStream(protocol.StreamID(5)).Return(str, nil)
-				Expect(sess.handleStreamFrame(f)).To(MatchError(testErr))
+				Expect(sess.handleStreamFrame(f, protocol.Encryption1RTT)).To(MatchError(testErr))
 			})
 
 			It("ignores STREAM frames for closed streams", func() {
@@ -153,7 +169,7 @@ var _ = Describe("Session", func() {
 				Expect(sess.handleStreamFrame(&wire.StreamFrame{
 					StreamID: 5,
 					Data:     []byte("foobar"),
-				})).To(Succeed())
+				}, protocol.Encryption0RTT)).To(Succeed())
 			})
 		})
 
@@ -166,6 +182,70 @@ var _ = Describe("Session", func() {
 				err := sess.handleAckFrame(f, protocol.EncryptionHandshake)
 				Expect(err).ToNot(HaveOccurred())
 			})
+
+			It("requests a lower ACK rate when the congestion window grows", func() {
+				sess.handshakeConfirmed = true
+				sess.peerParams = &wire.TransportParameters{MaxAckDelay: 20 * time.Millisecond, MinAckDelay: time.Millisecond}
+				f := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 2, Largest: 3}}}
+				sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+				sph.EXPECT().ReceivedAck(f, protocol.Encryption1RTT, gomock.Any()).Times(3)
+				sess.sentPacketHandler = sph
+				cryptoSetup.EXPECT().SetLargest1RTTAcked(protocol.PacketNumber(3)).Times(3)
+				sph.EXPECT().GetCongestionWindow().Return(40 * protocol.MaxPacketSizeIPv4)
+				Expect(sess.handleAckFrame(f, protocol.Encryption1RTT)).To(Succeed())
+				frames, _ := sess.framer.AppendControlFrames(nil, protocol.MaxByteCount)
+				Expect(frames).To(HaveLen(1))
+				Expect(frames[0].Frame).To(Equal(&wire.AckFrequencyFrame{
+					SequenceNumber:        0,
+					AckElicitingThreshold: 10,
+					RequestMaxAckDelay:    20 * time.Millisecond,
+					ReorderingThreshold:   ackhandler.ReorderingThreshold,
+				}))
+				// a small change of the congestion window doesn't trigger a new ACK_FREQUENCY frame
+				sph.EXPECT().GetCongestionWindow().Return(44 * protocol.MaxPacketSizeIPv4)
+				Expect(sess.handleAckFrame(f, protocol.Encryption1RTT)).To(Succeed())
+				Expect(sess.framer.HasData()).To(BeFalse())
+				sph.EXPECT().GetCongestionWindow().Return(80 * protocol.MaxPacketSizeIPv4)
+				Expect(sess.handleAckFrame(f, protocol.Encryption1RTT)).To(Succeed())
+				frames, _ = sess.framer.AppendControlFrames(nil, protocol.MaxByteCount)
+				Expect(frames).To(HaveLen(1))
+				Expect(frames[0].Frame.(*wire.AckFrequencyFrame).SequenceNumber).To(BeEquivalentTo(1))
+				Expect(frames[0].Frame.(*wire.AckFrequencyFrame).AckElicitingThreshold).To(BeEquivalentTo(20))
+			})
+
+			It("doesn't send ACK_FREQUENCY frames if the peer doesn't support the extension", func() {
+				sess.handshakeConfirmed = true
+				sess.peerParams = &wire.TransportParameters{MaxAckDelay: 20 * time.Millisecond}
+				f := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 2, Largest: 3}}}
+				sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+				sph.EXPECT().ReceivedAck(f, protocol.Encryption1RTT, gomock.Any())
+				sess.sentPacketHandler = sph
+				cryptoSetup.EXPECT().SetLargest1RTTAcked(protocol.PacketNumber(3))
+				Expect(sess.handleAckFrame(f, protocol.Encryption1RTT)).To(Succeed())
+				Expect(sess.framer.HasData()).To(BeFalse())
+			})
+		})
+
+		Context("handling ACK_FREQUENCY frames", func() {
+			It("passes the frame to the ReceivedPacketHandler", func() {
+				rph := mockackhandler.NewMockReceivedPacketHandler(mockCtrl)
+				sess.receivedPacketHandler = rph
+				f := &wire.AckFrequencyFrame{AckElicitingThreshold: 10, RequestMaxAckDelay: 10 * time.Millisecond}
+				rph.EXPECT().SetAckFrequency(f)
+				Expect(sess.handleFrame(f, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+			})
+
+			It("errors if the requested max_ack_delay is smaller than the min_ack_delay", func() {
+				f := &wire.AckFrequencyFrame{AckElicitingThreshold: 10, RequestMaxAckDelay: protocol.MinAckDelay - 1}
+				Expect(sess.handleFrame(f, protocol.Encryption1RTT, protocol.ConnectionID{})).To(MatchError(ContainSubstring("PROTOCOL_VIOLATION: requested max_ack_delay")))
+			})
+
+			It("queues an ACK when receiving an IMMEDIATE_ACK frame", func() {
+				rph := mockackhandler.NewMockReceivedPacketHandler(mockCtrl)
+				sess.receivedPacketHandler = rph
+				rph.EXPECT().QueueImmediateAck()
+				Expect(sess.handleFrame(&wire.ImmediateAckFrame{}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+			})
 		})
 
 		Context("handling RESET_STREAM frames", func() {
@@ -329,6 +409,42 @@ var _ = Describe("Session", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		It("rejects DATAGRAM frames if the extension is disabled", func() {
+			err := sess.handleFrame(&wire.DatagramFrame{Data: []byte("foobar")}, protocol.Encryption1RTT, protocol.ConnectionID{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*qerr.QuicError).ErrorCode).To(Equal(qerr.ProtocolViolation))
+		})
+
+		Context("DATAGRAM frames", func() {
+			BeforeEach(func() {
+				sess.config.EnableDatagrams = true
+				sess.datagramQueue = newDatagramQueue(func() {}, utils.DefaultLogger)
+			})
+
+			It("queues received DATAGRAM frames", func() {
+				Expect(sess.handleFrame(&wire.DatagramFrame{Data: []byte("foobar")}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+				data, err := sess.ReceiveMessage()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).To(Equal([]byte("foobar")))
+			})
+
+			It("rejects DATAGRAM frames that are too large", func() {
+				f := &wire.DatagramFrame{Data: make([]byte, protocol.MaxDatagramFrameSize)}
+				err := sess.handleFrame(f, protocol.Encryption1RTT, protocol.ConnectionID{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.(*qerr.QuicError).ErrorCode).To(Equal(qerr.ProtocolViolation))
+			})
+
+			It("refuses to send DATAGRAMs if the peer doesn't support them", func() {
+				Expect(sess.SendMessage([]byte("foobar"))).To(MatchError("datagram support disabled"))
+			})
+
+			It("refuses to send DATAGRAMs that are larger than the peer allows", func() {
+				atomic.StoreUint64(&sess.peerMaxDatagramFrameSize, 100)
+				Expect(sess.SendMessage(make([]byte, 100))).To(MatchError("message too large"))
+			})
+		})
+
 		It("handles CONNECTION_CLOSE frames, with a transport error code", func() {
 			testErr := qerr.NewError(qerr.StreamLimitError, "foobar")
 			streamManager.EXPECT().CloseWithError(testErr)
@@ -405,6 +521,27 @@ var _ = Describe("Session", func() {
 		Expect(sess.GetVersion()).To(Equal(protocol.VersionNumber(4242)))
 	})
 
+	It("initiates key updates", func() {
+		cryptoSetup.EXPECT().InitiateKeyUpdate()
+		sess.InitiateKeyUpdate()
+		Expect(sess.sendingScheduled).To(Receive())
+	})
+
+	It("returns the connection statistics after the session was closed", func() {
+		sess.rttStats.UpdateRTT(100*time.Millisecond, 0, time.Now())
+		sess.numRecoveredPackets = 3
+		streamManager.EXPECT().NumStreams().Return(4, 2)
+		sess.ctxCancel()
+		stats := sess.Stats()
+		Expect(stats.SmoothedRTT).To(Equal(100 * time.Millisecond))
+		Expect(stats.MinRTT).To(Equal(100 * time.Millisecond))
+		Expect(stats.CongestionWindow).ToNot(BeZero())
+		Expect(stats.ReceiveWindow).To(BeEquivalentTo(protocol.InitialMaxData))
+		Expect(stats.OpenBidiStreams).To(Equal(4))
+		Expect(stats.OpenUniStreams).To(Equal(2))
+		Expect(stats.PacketsRecovered).To(BeEquivalentTo(3))
+	})
+
 	Context("closing", func() {
 		var (
 			runErr         chan error
@@ -476,6 +613,23 @@ var _ = Describe("Session", func() {
 			Expect(sess.Context().Done()).To(BeClosed())
 		})
 
+		It("returns the connection statistics from the run loop", func() {
+			runSession()
+			streamManager.EXPECT().NumStreams().Return(1, 0)
+			stats := sess.Stats()
+			Expect(stats.OpenBidiStreams).To(Equal(1))
+			Expect(stats.OpenUniStreams).To(BeZero())
+			streamManager.EXPECT().CloseWithError(gomock.Any())
+			expectReplaceWithClosed()
+			cryptoSetup.EXPECT().Close()
+			packer.EXPECT().PackConnectionClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
+			mconn.EXPECT().Write(gomock.Any())
+			tracer.EXPECT().ClosedConnection(gomock.Any())
+			tracer.EXPECT().Close()
+			sess.shutdown()
+			Eventually(areSessionsRunning).Should(BeFalse())
+		})
+
 		It("closes with an error", func() {
 			runSession()
 			streamManager.EXPECT().CloseWithError(qerr.NewApplicationError(0x1337, "test error"))
@@ -748,6 +902,64 @@ var _ = Describe("Session", func() {
 			Expect(sess.handlePacketImpl(p)).To(BeFalse())
 		})
 
+		It("accepts Initial packets of the original version, until the client switched to the compatible version", func() {
+			origSupportedVersions := make([]protocol.VersionNumber, len(protocol.SupportedVersions))
+			copy(origSupportedVersions, protocol.SupportedVersions)
+			defer func() {
+				protocol.SupportedVersions = origSupportedVersions
+			}()
+
+			origVersion := sess.version + 1
+			protocol.SupportedVersions = append(protocol.SupportedVersions, origVersion)
+			sess.origVersion = origVersion
+			sess.acceptOrigVersion = true
+			rph := mockackhandler.NewMockReceivedPacketHandler(mockCtrl)
+			rph.EXPECT().IsPotentiallyDuplicate(gomock.Any(), gomock.Any()).AnyTimes()
+			rph.EXPECT().ReceivedPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			sess.receivedPacketHandler = rph
+			getInitial := func(v protocol.VersionNumber, pn protocol.PacketNumber) (*receivedPacket, *wire.ExtendedHeader) {
+				hdr := &wire.ExtendedHeader{
+					Header: wire.Header{
+						IsLongHeader:     true,
+						Type:             protocol.PacketTypeInitial,
+						DestConnectionID: srcConnID,
+						SrcConnectionID:  destConnID,
+						Version:          v,
+						Length:           1,
+					},
+					PacketNumber:    pn,
+					PacketNumberLen: protocol.PacketNumberLen2,
+				}
+				return getPacket(hdr, nil), hdr
+			}
+			unpackInitial := func(hdr *wire.ExtendedHeader) {
+				unpacker.EXPECT().Unpack(gomock.Any(), gomock.Any(), gomock.Any()).Return(&unpackedPacket{
+					packetNumber:    hdr.PacketNumber,
+					encryptionLevel: protocol.EncryptionInitial,
+					hdr:             hdr,
+					data:            []byte{0}, // one PADDING frame
+				}, nil)
+			}
+
+			// an Initial packet sent by the client before it switched versions
+			p, hdr := getInitial(origVersion, 1)
+			unpackInitial(hdr)
+			tracer.EXPECT().StartedConnection(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			tracer.EXPECT().ReceivedPacket(gomock.Any(), gomock.Any(), gomock.Any())
+			Expect(sess.handlePacketImpl(p)).To(BeTrue())
+			Expect(sess.acceptOrigVersion).To(BeTrue())
+			// the client switched to the compatible version
+			p, hdr = getInitial(sess.version, 2)
+			unpackInitial(hdr)
+			tracer.EXPECT().ReceivedPacket(gomock.Any(), gomock.Any(), gomock.Any())
+			Expect(sess.handlePacketImpl(p)).To(BeTrue())
+			Expect(sess.acceptOrigVersion).To(BeFalse())
+			// Initial packets of the original version are now dropped
+			p, _ = getInitial(origVersion, 3)
+			tracer.EXPECT().DroppedPacket(logging.PacketTypeInitial, p.Size(), logging.PacketDropUnexpectedVersion)
+			Expect(sess.handlePacketImpl(p)).To(BeFalse())
+		})
+
 		It("informs the ReceivedPacketHandler about non-ack-eliciting packets", func() {
 			hdr := &wire.ExtendedHeader{
 				Header:          wire.Header{DestConnectionID: srcConnID},
@@ -881,6 +1093,32 @@ var _ = Describe("Session", func() {
 			Eventually(sess.Context().Done()).Should(BeClosed())
 		})
 
+		It("closes the session when the AEAD integrity limit is exceeded", func() {
+			unpacker.EXPECT().Unpack(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, handshake.ErrAEADLimitReached)
+			streamManager.EXPECT().CloseWithError(gomock.Any())
+			cryptoSetup.EXPECT().Close()
+			packer.EXPECT().PackConnectionClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
+				err := sess.run()
+				Expect(err).To(HaveOccurred())
+				Expect(err.(*qerr.QuicError).ErrorCode).To(Equal(qerr.AEADLimitReached))
+				close(done)
+			}()
+			expectReplaceWithClosed()
+			mconn.EXPECT().Write(gomock.Any())
+			packet := getPacket(&wire.ExtendedHeader{
+				Header:          wire.Header{DestConnectionID: srcConnID},
+				PacketNumberLen: protocol.PacketNumberLen1,
+			}, nil)
+			tracer.EXPECT().ClosedConnection(gomock.Any())
+			tracer.EXPECT().Close()
+			sess.handlePacket(packet)
+			Eventually(sess.Context().Done()).Should(BeClosed())
+		})
+
 		It("ignores packets when unpacking fails for any other reason", func() {
 			testErr := errors.New("test err")
 			unpacker.EXPECT().Unpack(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, testErr)
@@ -1216,6 +1454,7 @@ var _ = Describe("Session", func() {
 			fc := mocks.NewMockConnectionFlowController(mockCtrl)
 			fc.EXPECT().IsNewlyBlocked().Return(true, protocol.ByteCount(1337))
 			fc.EXPECT().IsNewlyBlocked()
+			fc.EXPECT().Close()
 			p := getPacket(1)
 			packer.EXPECT().PackPacket().Return(p, nil)
 			packer.EXPECT().PackPacket().Return(nil, nil).AnyTimes()
@@ -1802,6 +2041,65 @@ var _ = Describe("Session", func() {
 			sess.processTransportParameters(params)
 			Expect(sess.earlySessionReady()).To(BeClosed())
 		})
+
+		It("switches to a compatible version that both endpoints prefer", func() {
+			sess.version = protocol.VersionDraft29
+			sess.config.Versions = []protocol.VersionNumber{protocol.Version1, protocol.VersionDraft29}
+			params := &wire.TransportParameters{
+				InitialSourceConnectionID: destConnID,
+				VersionInformation: &wire.VersionInformation{
+					ChosenVersion:     protocol.VersionDraft29,
+					AvailableVersions: []protocol.VersionNumber{protocol.VersionDraft29, protocol.Version1},
+				},
+			}
+			streamManager.EXPECT().UpdateLimits(params)
+			packer.EXPECT().HandleTransportParameters(params)
+			sessionRunner.EXPECT().GetStatelessResetToken(gomock.Any()).AnyTimes()
+			sessionRunner.EXPECT().Add(gomock.Any(), sess).AnyTimes()
+			tracer.EXPECT().ReceivedTransportParameters(params)
+			cryptoSetup.EXPECT().SetVersion(protocol.Version1)
+			packer.EXPECT().SetVersion(protocol.Version1)
+			sess.processTransportParameters(params)
+			Expect(sess.version).To(Equal(protocol.Version1))
+			// accept the client's Initial packets sent before it switched
+			Expect(sess.origVersion).To(Equal(protocol.VersionDraft29))
+			Expect(sess.acceptOrigVersion).To(BeTrue())
+		})
+
+		It("doesn't switch versions if the client doesn't support the preferred version", func() {
+			sess.version = protocol.VersionDraft29
+			sess.config.Versions = []protocol.VersionNumber{protocol.Version1, protocol.VersionDraft29}
+			params := &wire.TransportParameters{
+				InitialSourceConnectionID: destConnID,
+				VersionInformation: &wire.VersionInformation{
+					ChosenVersion:     protocol.VersionDraft29,
+					AvailableVersions: []protocol.VersionNumber{protocol.VersionDraft29},
+				},
+			}
+			streamManager.EXPECT().UpdateLimits(params)
+			packer.EXPECT().HandleTransportParameters(params)
+			sessionRunner.EXPECT().GetStatelessResetToken(gomock.Any()).AnyTimes()
+			sessionRunner.EXPECT().Add(gomock.Any(), sess).AnyTimes()
+			tracer.EXPECT().ReceivedTransportParameters(params)
+			sess.processTransportParameters(params)
+			Expect(sess.version).To(Equal(protocol.VersionDraft29))
+		})
+
+		It("errors if the client's chosen version doesn't match the version it used", func() {
+			sess.version = protocol.VersionDraft29
+			params := &wire.TransportParameters{
+				InitialSourceConnectionID: destConnID,
+				VersionInformation: &wire.VersionInformation{
+					ChosenVersion:     protocol.Version1,
+					AvailableVersions: []protocol.VersionNumber{protocol.Version1},
+				},
+			}
+			tracer.EXPECT().ReceivedTransportParameters(params)
+			sess.processTransportParameters(params)
+			var closeErr closeError
+			Expect(sess.closeChan).To(Receive(&closeErr))
+			Expect(closeErr.err).To(MatchError("VERSION_NEGOTIATION_ERROR: client chose v1, but used QUIC WG draft-29"))
+		})
 	})
 
 	Context("keep-alives", func() {
@@ -2142,6 +2440,7 @@ var _ = Describe("Client Session", func() {
 
 		mconn = NewMockSendConn(mockCtrl)
 		mconn.EXPECT().RemoteAddr().Return(&net.UDPAddr{}).Times(2)
+		mconn.EXPECT().DontFragment().AnyTimes()
 		mconn.EXPECT().LocalAddr().Return(&net.UDPAddr{})
 		if tlsConf == nil {
 			mconn.EXPECT().RemoteAddr().Return(&net.UDPAddr{})
@@ -2360,7 +2659,7 @@ var _ = Describe("Client Session", func() {
 		getRetryTag := func(hdr *wire.ExtendedHeader) []byte {
 			buf := &bytes.Buffer{}
 			hdr.Write(buf, sess.version)
-			return handshake.GetRetryIntegrityTag(buf.Bytes(), origDestConnID)[:]
+			return handshake.GetRetryIntegrityTag(buf.Bytes(), origDestConnID, hdr.Version)[:]
 		}
 
 		It("handles Retry packets", func() {
@@ -2545,6 +2844,40 @@ var _ = Describe("Client Session", func() {
 			sess.processTransportParameters(params)
 			Eventually(errChan).Should(Receive(MatchError("TRANSPORT_PARAMETER_ERROR: expected original_destination_connection_id to equal 0xdeadbeef, is 0xdecafbad")))
 		})
+
+		It("errors if the server's chosen version doesn't match the version in use", func() {
+			sess.version = protocol.Version1
+			params := &wire.TransportParameters{
+				OriginalDestinationConnectionID: destConnID,
+				InitialSourceConnectionID:       destConnID,
+				VersionInformation: &wire.VersionInformation{
+					ChosenVersion:     protocol.VersionDraft29,
+					AvailableVersions: []protocol.VersionNumber{protocol.VersionDraft29},
+				},
+			}
+			expectClose()
+			tracer.EXPECT().ReceivedTransportParameters(params)
+			sess.processTransportParameters(params)
+			Eventually(errChan).Should(Receive(MatchError("VERSION_NEGOTIATION_ERROR: server chose QUIC WG draft-29, but we're using v1")))
+		})
+
+		It("errors if the Version Negotiation packet was forged", func() {
+			sess.version = protocol.VersionDraft29
+			sess.versionNegotiated = true
+			sess.config.Versions = []protocol.VersionNumber{protocol.Version1, protocol.VersionDraft29}
+			params := &wire.TransportParameters{
+				OriginalDestinationConnectionID: destConnID,
+				InitialSourceConnectionID:       destConnID,
+				VersionInformation: &wire.VersionInformation{
+					ChosenVersion:     protocol.VersionDraft29,
+					AvailableVersions: []protocol.VersionNumber{protocol.VersionDraft29, protocol.Version1},
+				},
+			}
+			expectClose()
+			tracer.EXPECT().ReceivedTransportParameters(params)
+			sess.processTransportParameters(params)
+			Eventually(errChan).Should(Receive(MatchError("VERSION_NEGOTIATION_ERROR: server supports [QUIC WG draft-29 v1], but we negotiated QUIC WG draft-29")))
+		})
 	})
 
 	Context("handling potentially injected packets", func() {
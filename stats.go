@@ -0,0 +1,74 @@
+package quic
+
+import (
+	"time"
+)
+
+// ConnectionStats is a snapshot of the statistics of a QUIC connection.
+type ConnectionStats struct {
+	MinRTT        time.Duration
+	LatestRTT     time.Duration
+	SmoothedRTT   time.Duration
+	MeanDeviation time.Duration
+
+	// CongestionWindow and BytesInFlight are counted in bytes.
+	CongestionWindow uint64
+	BytesInFlight    uint64
+
+	PacketsSent          uint64
+	PacketsLost          uint64
+	PacketsRetransmitted uint64
+
+	// SendWindow is the number of bytes that the connection-level flow control allows us to send.
+	SendWindow uint64
+	// ReceiveWindow is the size of the connection-level flow control window advertised to the peer.
+	ReceiveWindow uint64
+
+	OpenBidiStreams int
+	OpenUniStreams  int
+
+	// CodedPacketsSent is the number of rQUIC coded packets sent.
+	CodedPacketsSent uint64
+	// PacketsRecovered is the number of packets recovered by the rQUIC decoder.
+	PacketsRecovered uint64
+}
+
+// Stats returns a snapshot of the connection statistics.
+// While the session is running, the snapshot is taken by the run loop.
+func (s *session) Stats() ConnectionStats {
+	req := make(chan ConnectionStats, 1)
+	select {
+	case s.statsRequests <- req:
+		return <-req
+	case <-s.ctx.Done():
+		// The run loop has returned, so the state can't change any more.
+		return s.collectStats()
+	}
+}
+
+func (s *session) collectStats() ConnectionStats {
+	sentStats := s.sentPacketHandler.GetSentPacketStats()
+	bidi, uni := s.streamsMap.NumStreams()
+	stats := ConnectionStats{
+		MinRTT:               s.rttStats.MinRTT(),
+		LatestRTT:            s.rttStats.LatestRTT(),
+		SmoothedRTT:          s.rttStats.SmoothedRTT(),
+		MeanDeviation:        s.rttStats.MeanDeviation(),
+		CongestionWindow:     uint64(sentStats.CongestionWindow),
+		BytesInFlight:        uint64(sentStats.BytesInFlight),
+		PacketsSent:          sentStats.PacketsSent,
+		PacketsLost:          sentStats.PacketsLost,
+		PacketsRetransmitted: sentStats.PacketsRetransmitted,
+		SendWindow:           uint64(s.connFlowController.SendWindowSize()),
+		ReceiveWindow:        uint64(s.connFlowController.ReceiveWindowSize()),
+		OpenBidiStreams:      bidi,
+		OpenUniStreams:       uni,
+	}
+	// rQUIC {
+	if s.encoderEnabled {
+		stats.CodedPacketsSent = s.encoder.numCodedPackets
+	}
+	stats.PacketsRecovered = s.numRecoveredPackets
+	// } rQUIC
+	return stats
+}
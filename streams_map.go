@@ -133,6 +133,13 @@ func (m *streamsMap) AcceptUniStream(ctx context.Context) (ReceiveStream, error)
 	return str, convertStreamError(err, protocol.StreamTypeUni, m.perspective.Opposite())
 }
 
+// NumStreams returns the number of open bidirectional and unidirectional streams.
+func (m *streamsMap) NumStreams() (bidi, uni int) {
+	bidi = m.outgoingBidiStreams.NumStreams() + m.incomingBidiStreams.NumStreams()
+	uni = m.outgoingUniStreams.NumStreams() + m.incomingUniStreams.NumStreams()
+	return
+}
+
 func (m *streamsMap) DeleteStream(id protocol.StreamID) error {
 	num := id.StreamNum()
 	switch id.Type() {
@@ -190,6 +190,13 @@ func (m *outgoingBidiStreamsMap) unblockOpenSync() {
 	}
 }
 
+// NumStreams returns the number of open streams.
+func (m *outgoingBidiStreamsMap) NumStreams() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return len(m.streams)
+}
+
 func (m *outgoingBidiStreamsMap) CloseWithError(err error) {
 	m.mutex.Lock()
 	m.closeErr = err
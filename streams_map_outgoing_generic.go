@@ -188,6 +188,13 @@ func (m *outgoingItemsMap) unblockOpenSync() {
 	}
 }
 
+// NumStreams returns the number of open streams.
+func (m *outgoingItemsMap) NumStreams() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return len(m.streams)
+}
+
 func (m *outgoingItemsMap) CloseWithError(err error) {
 	m.mutex.Lock()
 	m.closeErr = err
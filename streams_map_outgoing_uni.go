@@ -190,6 +190,13 @@ func (m *outgoingUniStreamsMap) unblockOpenSync() {
 	}
 }
 
+// NumStreams returns the number of open streams.
+func (m *outgoingUniStreamsMap) NumStreams() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return len(m.streams)
+}
+
 func (m *outgoingUniStreamsMap) CloseWithError(err error) {
 	m.mutex.Lock()
 	m.closeErr = err
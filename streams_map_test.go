@@ -228,6 +228,22 @@ var _ = Describe("Streams Map", func() {
 					id := ids.firstOutgoingBidiStream + 4
 					Expect(m.DeleteStream(id)).To(MatchError(fmt.Sprintf("Tried to delete unknown outgoing stream %d", id)))
 				})
+
+				It("counts the open streams", func() {
+					_, err := m.OpenStream()
+					Expect(err).ToNot(HaveOccurred())
+					_, err = m.GetOrOpenReceiveStream(ids.firstIncomingBidiStream)
+					Expect(err).ToNot(HaveOccurred())
+					_, err = m.OpenUniStream()
+					Expect(err).ToNot(HaveOccurred())
+					bidi, uni := m.NumStreams()
+					Expect(bidi).To(Equal(2))
+					Expect(uni).To(Equal(1))
+					Expect(m.DeleteStream(ids.firstOutgoingBidiStream)).To(Succeed())
+					bidi, uni = m.NumStreams()
+					Expect(bidi).To(Equal(1))
+					Expect(uni).To(Equal(1))
+				})
 			})
 
 			Context("getting streams", func() {
@@ -0,0 +1,11 @@
+// +build !linux
+
+package quic
+
+import "net"
+
+func newBatchPacketConn(net.PacketConn) (batchPacketConn, bool, bool) { return nil, false, false }
+
+func appendUDPSegmentSizeMsg(b []byte, _ uint16) []byte { return b }
+
+func isGSOErr(error) bool { return false }
@@ -0,0 +1,64 @@
+// +build linux
+
+package quic
+
+import (
+	"errors"
+	"net"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// UDP_SEGMENT, as defined in linux/udp.h.
+// It is not (yet) exported by the syscall package.
+const udpSegment = 103
+
+func newBatchPacketConn(c net.PacketConn) (conn batchPacketConn, gso bool, ok bool) {
+	udpConn, ok := c.(*net.UDPConn)
+	if !ok {
+		return nil, false, false
+	}
+	if addr, ok := udpConn.LocalAddr().(*net.UDPAddr); ok && addr.IP.To4() != nil {
+		conn = ipv4.NewPacketConn(udpConn)
+	} else {
+		conn = ipv6.NewPacketConn(udpConn)
+	}
+	return conn, isGSOSupported(udpConn), true
+}
+
+func isGSOSupported(c *net.UDPConn) bool {
+	rawConn, err := c.SyscallConn()
+	if err != nil {
+		return false
+	}
+	var supported bool
+	if err := rawConn.Control(func(fd uintptr) {
+		_, err := syscall.GetsockoptInt(int(fd), syscall.IPPROTO_UDP, udpSegment)
+		supported = err == nil
+	}); err != nil {
+		return false
+	}
+	return supported
+}
+
+func appendUDPSegmentSizeMsg(b []byte, size uint16) []byte {
+	startLen := len(b)
+	const dataLen = 2 // payload is a uint16
+	b = append(b, make([]byte, syscall.CmsgSpace(dataLen))...)
+	h := (*syscall.Cmsghdr)(unsafe.Pointer(&b[startLen]))
+	h.Level = syscall.IPPROTO_UDP
+	h.Type = udpSegment
+	h.SetLen(syscall.CmsgLen(dataLen))
+
+	offset := startLen + syscall.CmsgSpace(0)
+	*(*uint16)(unsafe.Pointer(&b[offset])) = size
+	return b
+}
+
+// isGSOErr says if sending failed because GSO is not supported by the NIC.
+func isGSOErr(err error) bool {
+	return errors.Is(err, syscall.EIO)
+}
@@ -0,0 +1,15 @@
+// +build !linux
+
+package quic
+
+import "net"
+
+// setDF sets the DF (Don't Fragment) bit on packets sent on this connection.
+// This is not implemented on this platform, so Path MTU Discovery is disabled.
+func setDF(net.PacketConn) bool {
+	return false
+}
+
+func isMsgSizeErr(error) bool {
+	return false
+}
@@ -0,0 +1,36 @@
+// +build linux
+
+package quic
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+// setDF sets the DF (Don't Fragment) bit on packets sent on this connection.
+// It returns true if the DF bit could be set for at least one of IPv4 and IPv6.
+func setDF(c net.PacketConn) bool {
+	sc, ok := c.(syscall.Conn)
+	if !ok {
+		return false
+	}
+	rawConn, err := sc.SyscallConn()
+	if err != nil {
+		return false
+	}
+	var errDFIPv4, errDFIPv6 error
+	if err := rawConn.Control(func(fd uintptr) {
+		errDFIPv4 = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_MTU_DISCOVER, syscall.IP_PMTUDISC_DO)
+		errDFIPv6 = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_MTU_DISCOVER, syscall.IPV6_PMTUDISC_DO)
+	}); err != nil {
+		return false
+	}
+	return errDFIPv4 == nil || errDFIPv6 == nil
+}
+
+// isMsgSizeErr says if sending a packet failed because it was larger than the MTU of the interface.
+// This happens when sending Path MTU Discovery probe packets with the DF bit set.
+func isMsgSizeErr(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE)
+}
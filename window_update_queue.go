@@ -48,7 +48,10 @@ func (q *windowUpdateQueue) QueueAll() {
 	q.mutex.Lock()
 	// queue a connection-level window update
 	if q.queuedConn {
-		q.callback(&wire.MaxDataFrame{MaximumData: q.connFlowController.GetWindowUpdate()})
+		// GetWindowUpdate returns 0 if no window update is needed anymore
+		if offset := q.connFlowController.GetWindowUpdate(); offset > 0 {
+			q.callback(&wire.MaxDataFrame{MaximumData: offset})
+		}
 		q.queuedConn = false
 	}
 	// queue all stream-level window updates
@@ -98,6 +98,13 @@ var _ = Describe("Window Update Queue", func() {
 		}))
 	})
 
+	It("doesn't queue a MAX_DATA frame if the connection doesn't need a window update", func() {
+		connFC.EXPECT().GetWindowUpdate()
+		q.AddConnection()
+		q.QueueAll()
+		Expect(queuedFrames).To(BeEmpty())
+	})
+
 	It("deduplicates", func() {
 		stream10 := NewMockStreamI(mockCtrl)
 		stream10.EXPECT().getWindowUpdate().Return(protocol.ByteCount(200))